@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"text/tabwriter"
+	"time"
+
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// newBenchCmd creates the `bench` subcommand, which exports a whole project
+// to a scratch file and reports where the time went.
+func newBenchCmd(root *string, ef *extractFlags) *cobra.Command {
+	var cpuProfile string
+	var memProfile string
+
+	benchCmd := &cobra.Command{
+		Use:   "bench [path]",
+		Short: "Time the extraction pipeline on a project",
+		Long: `Runs a full export of the project (every file selected, default
+excludes) into a temporary file and prints a per-stage timing breakdown.
+Attach the output, and optionally a pprof profile, to performance reports.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := *root
+			if len(args) > 0 {
+				target = args[0]
+			}
+			if target == "" {
+				return fmt.Errorf("bench requires a root directory (via --root or argument)")
+			}
+			absRoot, err := filepath.Abs(target)
+			if err != nil {
+				return err
+			}
+
+			opts, err := ef.options()
+			if err != nil {
+				return err
+			}
+			opts.Timings = &core.Timings{}
+
+			scratch, err := os.CreateTemp("", "pandabrew-bench-*.txt")
+			if err != nil {
+				return err
+			}
+			scratch.Close()
+			defer os.Remove(scratch.Name())
+
+			space := &core.DirectorySpace{
+				RootPath:       absRoot,
+				OutputFilePath: scratch.Name(),
+				Config:         core.DefaultExtractionConfig(),
+			}
+			space.Config.ManualSelections = []string{absRoot}
+
+			if cpuProfile != "" {
+				f, err := os.Create(cpuProfile)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				if err := pprof.StartCPUProfile(f); err != nil {
+					return err
+				}
+			}
+			meta, err := core.RunExtractionWithOptions(space, opts)
+			if cpuProfile != "" {
+				pprof.StopCPUProfile()
+			}
+			if err != nil {
+				return err
+			}
+			if memProfile != "" {
+				if err := writeHeapProfile(memProfile); err != nil {
+					return err
+				}
+			}
+
+			var outSize int64
+			if info, err := os.Stat(scratch.Name()); err == nil {
+				outSize = info.Size()
+			}
+
+			jobs := opts.Jobs
+			if jobs < 1 {
+				jobs = runtime.NumCPU()
+			}
+			t := opts.Timings
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Benchmark of %s\n", absRoot)
+			fmt.Fprintf(out, "Files: %d  Tokens: ~%d  Output: %d bytes  Jobs: %d\n\n", meta.TotalFiles, meta.TotalTokens, outSize, jobs)
+
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "STAGE\tTIME\tSHARE")
+			for _, row := range []struct {
+				name string
+				d    time.Duration
+			}{
+				{"walk", t.Walk},
+				{"filter", t.Filter},
+				{"read", t.Read},
+				{"write", t.Write},
+				{"tokenize", t.Tokenize},
+			} {
+				fmt.Fprintf(tw, "%s\t%s\t%.1f%%\n", row.name, row.d.Round(time.Microsecond), share(row.d, t.Total))
+			}
+			fmt.Fprintf(tw, "total\t%s\t\n", t.Total.Round(time.Microsecond))
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+			if jobs > 1 {
+				fmt.Fprintln(out, "\nread is summed across workers and can exceed total.")
+			}
+			return nil
+		},
+	}
+
+	benchCmd.Flags().StringVar(&cpuProfile, "cpuprofile", "", "Write a pprof CPU profile to this file")
+	benchCmd.Flags().StringVar(&memProfile, "memprofile", "", "Write a pprof heap profile to this file")
+
+	return benchCmd
+}
+
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	runtime.GC() // Up-to-date allocation statistics
+	return pprof.WriteHeapProfile(f)
+}
+
+func share(d, total time.Duration) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return float64(d) / float64(total) * 100
+}
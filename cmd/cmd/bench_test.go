@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBench(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "lib/lib.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	profile := filepath.Join(t.TempDir(), "mem.pprof")
+
+	out, err := execute(t, "bench", root, "--jobs", "2", "--memprofile", profile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(out, "\n")
+	if len(lines) < 4 || lines[0] != "Benchmark of "+root {
+		t.Fatalf("output:\n%s", out)
+	}
+	if !regexp.MustCompile(`^Files: 2  Tokens: ~\d+  Output: [1-9]\d* bytes  Jobs: 2$`).MatchString(lines[1]) {
+		t.Errorf("summary = %q", lines[1])
+	}
+	if !regexp.MustCompile(`^STAGE +TIME +SHARE$`).MatchString(lines[3]) {
+		t.Errorf("table header = %q", lines[3])
+	}
+	for _, stage := range []string{"walk", "filter", "read", "write", "tokenize"} {
+		if !regexp.MustCompile(`(?m)^` + stage + ` +\S+ +\d+\.\d%$`).MatchString(out) {
+			t.Errorf("no %s row in:\n%s", stage, out)
+		}
+	}
+	if !regexp.MustCompile(`(?m)^total +\S+`).MatchString(out) || !strings.Contains(out, "read is summed across workers") {
+		t.Errorf("no total or workers note in:\n%s", out)
+	}
+	if info, err := os.Stat(profile); err != nil || info.Size() == 0 {
+		t.Errorf("heap profile not written: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// defaultProjectConfig is the config file `ci` looks for without --config.
+const defaultProjectConfig = "pandabrew.project.yaml"
+
+// newCICmd creates the `ci` subcommand: an export driven by a committed
+// project config that reports to GitHub Actions.
+func newCICmd(sf *spaceFlags, ef *extractFlags) *cobra.Command {
+	ciCmd := &cobra.Command{
+		Use:   "ci",
+		Short: "Export from a project config in CI and report to GitHub Actions",
+		Long: `Exports the project described by a project config (--config, default
+./pandabrew.project.yaml) without touching any session, then:
+
+  - prints a ::notice annotation with the file and token counts
+  - appends path, files and tokens to $GITHUB_OUTPUT when it is set
+  - fails with an ::error annotation if --fail-over-tokens is exceeded
+
+With --read-only nothing is written: the notice says so, and dry_run=true
+replaces the path output.
+
+The report is written even when the budget is exceeded, so it can be
+uploaded for inspection.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if sf.configPath == "" {
+				sf.configPath = defaultProjectConfig
+			}
+			project, err := sf.loadProject()
+			if err != nil {
+				return err
+			}
+			root, err := filepath.Abs(filepath.Dir(sf.configPath))
+			if err != nil {
+				return err
+			}
+			space := &core.DirectorySpace{RootPath: root, OutputFilePath: core.DefaultOutputPath(root), Config: core.DefaultExtractionConfig()}
+			sf.apply(cmd, space, project)
+
+			opts, err := ef.options()
+			if err != nil {
+				return err
+			}
+			if !ef.force {
+				for _, path := range core.FormatOutputPaths(space.OutputFilePath, core.OutputFormats(opts, space.Config)) {
+					if err := core.CheckOutputPath(path); err != nil {
+						return fmt.Errorf("%w: %s (use --force to overwrite)", err, path)
+					}
+				}
+			}
+			out := cmd.OutOrStdout()
+			meta, err := core.RunExtractionWithOptions(space, opts)
+			if err != nil {
+				workflowCommand(out, "error", "PandaBrew", err.Error())
+				return err
+			}
+
+			if err := reportCIExport(out, space.OutputFilePath, meta, opts.DryRun); err != nil {
+				return err
+			}
+
+			if err := ef.checkBudget(meta); err != nil {
+				workflowCommand(out, "error", "PandaBrew", err.Error())
+				return err
+			}
+			return nil
+		},
+	}
+
+	return ciCmd
+}
+
+// reportCIExport announces the export of meta to output as a workflow
+// notice and step outputs. A dry run says it wrote nothing and sets
+// dry_run=true instead of the path output.
+func reportCIExport(w io.Writer, output string, meta core.ReportMetadata, dryRun bool) error {
+	outputs := []string{fmt.Sprintf("files=%d", meta.TotalFiles), fmt.Sprintf("tokens=%d", meta.TotalTokens)}
+	if dryRun {
+		workflowCommand(w, "notice", "PandaBrew", fmt.Sprintf("Read-only: would export %d files (~%s tokens) to %s; nothing was written",
+			meta.TotalFiles, core.FormatTokens(meta.TotalTokens), output))
+		outputs = append(outputs, "dry_run=true")
+	} else {
+		workflowCommand(w, "notice", "PandaBrew", fmt.Sprintf("Exported %d files (~%s tokens) to %s",
+			meta.TotalFiles, core.FormatTokens(meta.TotalTokens), output))
+		outputs = append([]string{"path=" + output}, outputs...)
+	}
+	return writeGitHubOutputs(outputs...)
+}
+
+// Escapes for the message and the properties of workflow commands, so that
+// a newline or percent sign in a path or error cannot end the command or
+// start another one.
+var (
+	workflowDataEscaper     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	workflowPropertyEscaper = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+// workflowCommand writes a GitHub Actions workflow command such as ::notice,
+// with its title and message escaped.
+func workflowCommand(w io.Writer, command, title, message string) {
+	fmt.Fprintf(w, "::%s title=%s::%s\n", command, workflowPropertyEscaper.Replace(title), workflowDataEscaper.Replace(message))
+}
+
+// writeGitHubOutputs appends name=value step outputs to the file named by
+// $GITHUB_OUTPUT. Outside GitHub Actions it does nothing.
+func writeGitHubOutputs(outputs ...string) error {
+	path := os.Getenv("GITHUB_OUTPUT")
+	if path == "" {
+		return nil
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	for _, output := range outputs {
+		if _, err := fmt.Fprintln(f, output); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
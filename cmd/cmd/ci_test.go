@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestReportCIExport(t *testing.T) {
+	meta := core.ReportMetadata{TotalFiles: 3, TotalTokens: 1200}
+	tests := []struct {
+		name           string
+		dryRun         bool
+		notice, output string
+	}{
+		{name: "written", notice: "Exported 3 files", output: "path=/r/out.txt\nfiles=3\ntokens=1200\n"},
+		{name: "read-only", dryRun: true, notice: "Read-only: would export 3 files", output: "files=3\ntokens=1200\ndry_run=true\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outputs := filepath.Join(t.TempDir(), "outputs")
+			t.Setenv("GITHUB_OUTPUT", outputs)
+			var buf bytes.Buffer
+			if err := reportCIExport(&buf, "/r/out.txt", meta, tt.dryRun); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(buf.String(), tt.notice) {
+				t.Errorf("notice %q, want %q", buf.String(), tt.notice)
+			}
+			if data, _ := os.ReadFile(outputs); string(data) != tt.output {
+				t.Errorf("outputs %q, want %q", data, tt.output)
+			}
+		})
+	}
+}
+
+func TestWorkflowCommand(t *testing.T) {
+	tests := []struct {
+		name           string
+		title, message string
+		want           string
+	}{
+		{name: "plain", title: "PandaBrew", message: "Exported 3 files", want: "::notice title=PandaBrew::Exported 3 files\n"},
+		{name: "newlines in the message", title: "PandaBrew", message: "bad\r\n::error::forged", want: "::notice title=PandaBrew::bad%0D%0A::error::forged\n"},
+		{name: "percent signs", title: "100%", message: "50% done", want: "::notice title=100%25::50%25 done\n"},
+		{name: "separators in the title", title: "a:b,c\nd", message: "x:y,z", want: "::notice title=a%3Ab%2Cc%0Ad::x:y,z\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			workflowCommand(&buf, "notice", tt.title, tt.message)
+			if buf.String() != tt.want {
+				t.Errorf("got %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// newConfigCmd creates the `config` command group for project config files.
+func newConfigCmd(root, sessionName *string) *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Work with shareable project config files",
+	}
+
+	exportCmd := &cobra.Command{
+		Use:   "export [path]",
+		Short: "Print a workspace's settings as a project config",
+		Long: `Prints the filters, selections and options of the saved workspace for path
+(or the active workspace) as YAML, with every path relative to the project
+root. Commit the file and reproduce the workspace with:
+
+  pandabrew config export > pandabrew.project.yaml
+  pandabrew --config pandabrew.project.yaml --headless`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			space, err := resolveSpace(*root, *sessionName, args)
+			if err != nil {
+				return err
+			}
+			return core.WriteProjectConfig(cmd.OutOrStdout(), core.NewProjectConfig(space))
+		},
+	}
+
+	configCmd.AddCommand(exportCmd)
+	return configCmd
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// newDaemonCmd creates the `daemon` subcommand, which keeps the reports of
+// saved workspaces fresh by re-exporting them on an interval.
+func newDaemonCmd(sessionName *string, ef *extractFlags) *cobra.Command {
+	var every time.Duration
+
+	daemonCmd := &cobra.Command{
+		Use:   "daemon [path...]",
+		Short: "Re-export saved workspaces periodically",
+		Long: `Exports the saved workspaces of the session right away and then again every
+interval, until interrupted. With paths, only the workspaces for those roots
+are exported. The session is re-read on every run, so selection changes made
+in the TUI are picked up.
+
+A workspace is skipped when neither its settings nor any file it exports
+has changed since its last export (judged by size and modification time).
+
+  pandabrew daemon --every 15m`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if every <= 0 {
+				return fmt.Errorf("--every must be positive")
+			}
+			opts, err := ef.options()
+			if err != nil {
+				return err
+			}
+			sm, err := openSessionManager(cmd, *sessionName)
+			if err != nil {
+				return err
+			}
+			var roots []string
+			for _, arg := range args {
+				absRoot, err := filepath.Abs(arg)
+				if err != nil {
+					return err
+				}
+				roots = append(roots, absRoot)
+			}
+
+			d := &daemon{sm: sm, roots: roots, opts: opts, force: ef.force, out: cmd.OutOrStdout(), last: make(map[string]string)}
+			if err := d.run(); err != nil {
+				return err
+			}
+
+			ticker := time.NewTicker(every)
+			defer ticker.Stop()
+			stop := make(chan os.Signal, 1)
+			signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+			for {
+				select {
+				case <-ticker.C:
+					if err := d.run(); err != nil {
+						return err
+					}
+				case <-stop:
+					return nil
+				}
+			}
+		},
+	}
+
+	daemonCmd.Flags().DurationVar(&every, "every", 15*time.Minute, "Interval between exports, e.g. 30s, 15m or 1h")
+
+	return daemonCmd
+}
+
+// daemon remembers the fingerprint of every workspace it exported.
+type daemon struct {
+	sm    *core.SessionManager
+	roots []string // Workspaces to export; all of them when empty
+	opts  core.ExtractOptions
+	force bool
+	out   io.Writer
+	last  map[string]string // Space ID -> fingerprint of its last export
+}
+
+// run exports every workspace that changed. Failures of one workspace are
+// reported and don't stop the others.
+func (d *daemon) run() error {
+	session, err := d.sm.Load()
+	if err != nil {
+		return err
+	}
+
+	var spaces []*core.DirectorySpace
+	if len(d.roots) == 0 {
+		spaces = session.Spaces
+	}
+	for _, root := range d.roots {
+		space := session.FindSpaceByRoot(root)
+		if space == nil {
+			d.logf("%s: no saved workspace", root)
+			continue
+		}
+		spaces = append(spaces, space)
+	}
+	if len(spaces) == 0 && len(d.roots) == 0 {
+		d.logf("no saved workspaces")
+	}
+
+	for _, space := range spaces {
+		fingerprint, err := core.Fingerprint(space, d.opts)
+		if err != nil {
+			d.logf("%s: %v", space.RootPath, err)
+			continue
+		}
+		paths := core.FormatOutputPaths(space.OutputFilePath, core.OutputFormats(d.opts, space.Config))
+		if d.last[space.ID] == fingerprint && (d.opts.DryRun || core.IsReportFile(paths[0])) {
+			continue
+		}
+		if !d.force && !d.opts.DryRun && !d.outputsWritable(space, paths) {
+			continue
+		}
+		meta, err := core.RunExtractionWithOptions(space, d.opts)
+		if err != nil {
+			d.logf("%s: %v", space.RootPath, err)
+			continue
+		}
+		d.last[space.ID] = fingerprint
+		verb := "exported"
+		if d.opts.DryRun {
+			verb = "would export"
+		}
+		d.logf("%s: %s %d files (~%s tokens) to %s", space.RootPath, verb, meta.TotalFiles, core.FormatTokens(meta.TotalTokens), strings.Join(paths, ", "))
+	}
+	return nil
+}
+
+// outputsWritable logs and returns false when one of the output paths of
+// space holds a file PandaBrew did not write.
+func (d *daemon) outputsWritable(space *core.DirectorySpace, paths []string) bool {
+	for _, path := range paths {
+		if err := core.CheckOutputPath(path); err != nil {
+			d.logf("%s: %v: %s (use --force to overwrite)", space.RootPath, err, path)
+			return false
+		}
+	}
+	return true
+}
+
+func (d *daemon) logf(format string, args ...any) {
+	fmt.Fprintf(d.out, "%s %s\n", time.Now().Format("15:04:05"), fmt.Sprintf(format, args...))
+}
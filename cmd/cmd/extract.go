@@ -0,0 +1,156 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// newExtractCmd creates the `extract` subcommand: a headless export that
+// leaves the session untouched, suited to scripts and pipelines.
+func newExtractCmd(root, sessionName *string, sf *spaceFlags, ef *extractFlags) *cobra.Command {
+	var filesFrom string
+
+	extractCmd := &cobra.Command{
+		Use:   "extract [path...] [[--] glob...]",
+		Short: "Export a project without opening the TUI",
+		Long: `Exports the project at path (default: the current directory) using its saved
+workspace settings, or everything outside the default excludes if it has
+none. The session is not modified.
+
+Several paths are merged into one report with a labeled section per
+project, and require --output:
+
+  pandabrew extract ./backend ./frontend --output combined.txt
+
+Glob patterns, relative to each project root, replace the saved selection.
+They follow the paths, and start at the first argument after a path that is
+not a directory; "--" marks where they start explicitly, as is needed when
+no path is given:
+
+  pandabrew extract . 'internal/**/*.go' README.md
+  pandabrew extract -- '*.go'
+
+With --files-from, exactly the listed files are exported (one per line,
+relative to the project root) and the directory walk is skipped:
+
+  git diff --name-only | pandabrew extract --files-from -`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := sf.loadProject()
+			if err != nil {
+				return err
+			}
+			targets, globs := splitExtractArgs(args, cmd.ArgsLenAtDash(), isDir)
+			if len(targets) == 0 {
+				switch {
+				case *root != "":
+					targets = []string{*root}
+				case project != nil:
+					targets = []string{filepath.Dir(sf.configPath)}
+				default:
+					targets = []string{"."}
+				}
+			}
+			if len(targets) > 1 && sf.output == "" {
+				return fmt.Errorf("--output is required when extracting several projects")
+			}
+
+			var spaces []*core.DirectorySpace
+			for _, target := range targets {
+				space, err := resolveSpace("", *sessionName, []string{target})
+				if err != nil {
+					return err
+				}
+				if info, err := os.Stat(space.RootPath); err != nil || !info.IsDir() {
+					if cmd.ArgsLenAtDash() < 0 {
+						return fmt.Errorf("not a directory: %s (put glob patterns after --)", target)
+					}
+					return fmt.Errorf("not a directory: %s", target)
+				}
+				sf.apply(cmd, space, project)
+				if len(globs) > 0 {
+					selectGlobs(space, globs)
+				}
+				spaces = append(spaces, space)
+			}
+
+			opts, err := ef.options()
+			if err != nil {
+				return err
+			}
+			if filesFrom != "" {
+				if opts.Files, err = readFileList(cmd.InOrStdin(), filesFrom); err != nil {
+					return err
+				}
+				if len(opts.Files) == 0 {
+					return fmt.Errorf("--files-from: no files listed")
+				}
+			}
+			return runHeadless(cmd, spaces, spaces[0].OutputFilePath, opts, ef)
+		},
+	}
+
+	extractCmd.Flags().StringVar(&filesFrom, "files-from", "", "Export only the files listed in this file, one per line (- for stdin)")
+
+	return extractCmd
+}
+
+// splitExtractArgs splits args into the project directories and the glob
+// patterns given after "--", found at index dash of args (-1 without one).
+// Without "--" the patterns start at the first argument, past the first,
+// that isDir rejects; a lone first argument is always a directory, so a
+// mistyped one is reported rather than taken for a pattern.
+func splitExtractArgs(args []string, dash int, isDir func(string) bool) (roots, globs []string) {
+	if dash >= 0 {
+		return args[:dash], args[dash:]
+	}
+	for i := 1; i < len(args); i++ {
+		if !isDir(args[i]) {
+			return args[:i], args[i:]
+		}
+	}
+	return args, nil
+}
+
+// isDir reports whether path is a directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
+
+// selectGlobs makes the files matching globs the whole selection of space.
+func selectGlobs(space *core.DirectorySpace, globs []string) {
+	space.Config.IncludeMode = true
+	space.Config.IncludePatterns = globs
+	space.Config.ManualSelections = []string{}
+}
+
+// readFileList reads one path per line from source, or from stdin if source
+// is "-". Blank lines are ignored.
+func readFileList(stdin io.Reader, source string) ([]string, error) {
+	r := stdin
+	if source != "-" {
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var files []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			files = append(files, line)
+		}
+	}
+	return files, scanner.Err()
+}
@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// useTempConfig points the user config directory, and with it the saved
+// sessions, at a temporary directory for the rest of the test.
+func useTempConfig(t *testing.T) {
+	t.Helper()
+	config := t.TempDir()
+	t.Setenv("HOME", config)
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv("AppData", config)
+}
+
+// execute runs the command line args, returning its standard output.
+func execute(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	cmd := NewRootCmd("test")
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	cmd.SetArgs(append(args, "--progress", "none"))
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func TestSplitExtractArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		dash         int
+		roots, globs []string
+	}{
+		{name: "none", dash: -1},
+		{name: "paths only", args: []string{"a", "b"}, dash: -1, roots: []string{"a", "b"}},
+		{name: "globs after the dash", args: []string{".", "*.go", "README.md"}, dash: 1, roots: []string{"."}, globs: []string{"*.go", "README.md"}},
+		{name: "globs only", args: []string{"*.go"}, dash: 0, globs: []string{"*.go"}},
+		{name: "globs without the dash", args: []string{".", "*.go", "a"}, dash: -1, roots: []string{"."}, globs: []string{"*.go", "a"}},
+		{name: "globs after several paths", args: []string{"a", "b", "README.md"}, dash: -1, roots: []string{"a", "b"}, globs: []string{"README.md"}},
+		{name: "a lone argument is a path", args: []string{"*.go"}, dash: -1, roots: []string{"*.go"}},
+		{name: "dash with a directory after it", args: []string{".", "a"}, dash: 1, roots: []string{"."}, globs: []string{"a"}},
+	}
+	dirs := map[string]bool{".": true, "a": true, "b": true}
+	isDir := func(path string) bool { return dirs[path] }
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots, globs := splitExtractArgs(tt.args, tt.dash, isDir)
+			if !slices.Equal(roots, tt.roots) || !slices.Equal(globs, tt.globs) {
+				t.Errorf("got %v, %v; want %v, %v", roots, globs, tt.roots, tt.globs)
+			}
+		})
+	}
+}
+
+func TestExtractConfine(t *testing.T) {
+	useTempConfig(t)
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The default output is next to the root, so --confine asks for another
+	if _, err := execute(t, "extract", "--confine", root); err == nil || !strings.Contains(err.Error(), "--output") {
+		t.Errorf("default output: err = %v, want one asking for --output", err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), filepath.Base(root)+".txt")); err == nil {
+		t.Error("report written outside the root")
+	}
+
+	// A dry run writes nothing, so its output is not checked
+	if out, err := execute(t, "extract", "--confine", "--read-only", root); err != nil || !strings.Contains(out, "Read-only") {
+		t.Errorf("read-only: %v\n%s", err, out)
+	}
+
+	output := filepath.Join(root, "out", "report.txt")
+	if _, err := execute(t, "extract", "--confine", "--output", output, root); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(output); err != nil || !strings.Contains(string(data), "package main") {
+		t.Errorf("report inside the root: %v\n%s", err, data)
+	}
+}
+
+func TestExtractGlobsWithoutDash(t *testing.T) {
+	useTempConfig(t)
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "notes.md"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(name+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "report.txt")
+	if _, err := execute(t, "extract", root, "*.go", "--output", output); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "--- file: main.go ---") || strings.Contains(string(data), "--- file: notes.md ---") {
+		t.Errorf("report does not hold just the *.go files:\n%s", data)
+	}
+}
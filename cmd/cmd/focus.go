@@ -0,0 +1,153 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// newFocusCmd creates the `focus` subcommand: it proposes the files that
+// answer a question and exports them once accepted, leaving the session
+// untouched.
+func newFocusCmd(root, sessionName *string, sf *spaceFlags, ef *extractFlags) *cobra.Command {
+	var (
+		limit    int
+		yes      bool
+		embedCmd string
+	)
+
+	focusCmd := &cobra.Command{
+		Use:   "focus <question>",
+		Short: "Propose and export the files that answer a question",
+		Long: `Ranks the files of the project (--root, default: the current directory) by
+how well their names and contents match the words of a question, lists the
+best ones and asks before exporting them as the whole selection:
+
+  pandabrew focus "how does authentication work?"
+
+Answer y (or just Enter) to export, n to stop, or the numbers of files to
+leave out, such as "2 5". With --yes the proposal is exported unasked.
+
+--embed-cmd reranks the best matches by meaning rather than wording. The
+command reads a JSON array of texts on stdin, the question first, and
+prints a JSON array of one embedding vector per text. Each text is a path
+and the start of the file, with values masked as --mask-config masks them.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			project, err := sf.loadProject()
+			if err != nil {
+				return err
+			}
+			target := *root
+			if target == "" {
+				target = "."
+			}
+			space, err := resolveSpace(target, *sessionName, nil)
+			if err != nil {
+				return err
+			}
+			sf.apply(cmd, space, project)
+
+			question := strings.Join(args, " ")
+			focus := core.FocusOptions{Limit: limit}
+			if embedCmd != "" {
+				focus.Embed = core.CommandEmbedder(embedCmd)
+			}
+			candidates, err := core.RankFiles(context.Background(), space.RootPath, space.Config, question, focus)
+			if err != nil {
+				return err
+			}
+			out := cmd.OutOrStdout()
+			if len(candidates) == 0 {
+				return fmt.Errorf("no files match %q", question)
+			}
+			printCandidates(out, space.RootPath, candidates)
+
+			if !yes {
+				fmt.Fprintf(out, "Export these %d files to %s? [Y/n, or numbers to leave out] ", len(candidates), space.OutputFilePath)
+				var ok bool
+				if candidates, ok, err = confirmCandidates(cmd.InOrStdin(), candidates); err != nil {
+					return err
+				}
+				if !ok {
+					fmt.Fprintln(out, "Nothing exported.")
+					return nil
+				}
+			}
+
+			space.Config.IncludeMode = true
+			space.Config.IncludePatterns = nil
+			space.Config.ManualDeselections = nil
+			space.Config.ManualSelections = make([]string, len(candidates))
+			for i, c := range candidates {
+				space.Config.ManualSelections[i] = c.Path
+			}
+			opts, err := ef.options()
+			if err != nil {
+				return err
+			}
+			return runHeadless(cmd, []*core.DirectorySpace{space}, space.OutputFilePath, opts, ef)
+		},
+	}
+
+	focusCmd.Flags().IntVar(&limit, "limit", core.DefaultFocusLimit, "Most files to propose")
+	focusCmd.Flags().BoolVar(&yes, "yes", false, "Export the proposal without asking")
+	focusCmd.Flags().StringVar(&embedCmd, "embed-cmd", "", "Command that embeds texts, to rerank matches by meaning")
+
+	return focusCmd
+}
+
+// printCandidates lists candidates numbered from 1, with their scores and
+// the question terms they matched.
+func printCandidates(w io.Writer, root string, candidates []core.FocusCandidate) {
+	width := 0
+	rels := make([]string, len(candidates))
+	for i, c := range candidates {
+		rels[i], _ = filepath.Rel(root, c.Path)
+		width = max(width, len(rels[i]))
+	}
+	for i, c := range candidates {
+		fmt.Fprintf(w, "%3d. %-*s  %6.2f  %s\n", i+1, width, rels[i], c.Score, strings.Join(c.Terms, ", "))
+	}
+}
+
+// confirmCandidates reads the answer to the export prompt from r: whether
+// to export, and which candidates are left after dropping the numbered
+// ones. An empty input, as from a closed stdin, declines.
+func confirmCandidates(r io.Reader, candidates []core.FocusCandidate) ([]core.FocusCandidate, bool, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, false, nil
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	switch answer {
+	case "", "y", "yes":
+		return candidates, true, nil
+	case "n", "no":
+		return nil, false, nil
+	}
+
+	drop := make(map[int]bool)
+	for _, field := range strings.FieldsFunc(answer, func(r rune) bool { return r == ' ' || r == ',' }) {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 1 || n > len(candidates) {
+			return nil, false, fmt.Errorf("not a file number: %s", field)
+		}
+		drop[n-1] = true
+	}
+	var kept []core.FocusCandidate
+	for i, c := range candidates {
+		if !drop[i] {
+			kept = append(kept, c)
+		}
+	}
+	return kept, len(kept) > 0, nil
+}
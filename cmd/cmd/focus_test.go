@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func focusCandidates(root string, names ...string) []core.FocusCandidate {
+	candidates := make([]core.FocusCandidate, len(names))
+	for i, name := range names {
+		candidates[i] = core.FocusCandidate{Path: filepath.Join(root, name), Score: float64(len(names) - i)}
+	}
+	return candidates
+}
+
+func TestConfirmCandidates(t *testing.T) {
+	candidates := focusCandidates("/r", "a.go", "b.go", "c.go")
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		ok      bool
+		wantErr bool
+	}{
+		{name: "enter accepts", input: "\n", want: []string{"a.go", "b.go", "c.go"}, ok: true},
+		{name: "yes accepts", input: " Yes \n", want: []string{"a.go", "b.go", "c.go"}, ok: true},
+		{name: "no declines", input: "n\n", ok: false},
+		{name: "closed stdin declines", input: "", ok: false},
+		{name: "answer without newline", input: "y", want: []string{"a.go", "b.go", "c.go"}, ok: true},
+		{name: "numbers are left out", input: "1, 3\n", want: []string{"b.go"}, ok: true},
+		{name: "leaving out all declines", input: "1 2 3\n", ok: false},
+		{name: "out of range", input: "4\n", wantErr: true},
+		{name: "not a number", input: "maybe\n", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok, err := confirmCandidates(strings.NewReader(tt.input), candidates)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			var names []string
+			for _, c := range got {
+				names = append(names, filepath.Base(c.Path))
+			}
+			if ok != tt.ok || !slices.Equal(names, tt.want) {
+				t.Errorf("got %v, %v; want %v, %v", names, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestPrintCandidates(t *testing.T) {
+	tests := []struct {
+		name       string
+		candidates []core.FocusCandidate
+		want       string
+	}{
+		{name: "none", want: ""},
+		{
+			name: "aligned with terms",
+			candidates: []core.FocusCandidate{
+				{Path: filepath.Join("/r", "internal", "auth", "token.go"), Score: 12.5, Terms: []string{"session", "token"}},
+				{Path: filepath.Join("/r", "web.go"), Score: 3},
+			},
+			want: "  1. internal/auth/token.go   12.50  session, token\n" +
+				"  2. web.go                    3.00  \n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			printCandidates(&buf, "/r", tt.candidates)
+			if got := filepath.ToSlash(buf.String()); got != tt.want {
+				t.Errorf("printed\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// newImportCmd creates the `import` subcommand, which turns the settings of
+// another context tool into a workspace of the session.
+func newImportCmd(sessionName *string) *cobra.Command {
+	var fromCode2Prompt bool
+
+	importCmd := &cobra.Command{
+		Use:   "import <repomix.config.json> | --code2prompt -- <code2prompt args>",
+		Short: "Create a workspace from a repomix config or code2prompt flags",
+		Long: `Maps the include, exclude and output options of another tool onto a new
+workspace in the session, so it opens in the TUI and works with --headless.
+
+  pandabrew import repomix.config.json
+  pandabrew import --code2prompt -- . --include "*.go" --exclude "vendor" -O ctx.md
+
+Include patterns select matching files at export time. Options without a
+PandaBrew equivalent are ignored.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var imported core.ImportedConfig
+			var err error
+			if fromCode2Prompt {
+				imported, err = core.ImportCode2PromptArgs(args)
+			} else {
+				if len(args) != 1 {
+					return fmt.Errorf("expected the path to a repomix config file")
+				}
+				imported, err = core.ImportRepomixConfig(args[0])
+			}
+			if err != nil {
+				return err
+			}
+
+			sm, err := openSessionManager(cmd, *sessionName)
+			if err != nil {
+				return err
+			}
+			session, err := sm.Load()
+			if err != nil {
+				return err
+			}
+			space, err := sm.AddSpaceFromPath(session, imported.Root)
+			if err != nil {
+				return err
+			}
+			imported.Apply(space)
+			if err := sm.Save(session); err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Imported workspace for %s\n", space.RootPath)
+			fmt.Fprintf(out, "  include: %v\n", space.Config.IncludePatterns)
+			fmt.Fprintf(out, "  exclude: %v\n", space.Config.ExcludePatterns)
+			fmt.Fprintf(out, "  output:  %s\n", space.OutputFilePath)
+			return nil
+		},
+	}
+
+	importCmd.Flags().BoolVar(&fromCode2Prompt, "code2prompt", false, "Treat the arguments as a code2prompt command line")
+
+	return importCmd
+}
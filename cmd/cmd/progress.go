@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"pandabrew/internal/core"
+
+	"github.com/mattn/go-isatty"
+)
+
+// progressReporter shows the progress of a headless run.
+type progressReporter interface {
+	update(p core.Progress)
+	finish(meta core.ReportMetadata, err error)
+}
+
+// newProgressReporter returns the reporter for a --progress mode, or nil
+// when nothing should be shown.
+func newProgressReporter(mode string) (progressReporter, error) {
+	switch mode {
+	case "", "auto":
+		if bar := newProgressBar(); bar != nil {
+			return bar, nil
+		}
+		return nil, nil
+	case "json":
+		return &jsonProgress{enc: json.NewEncoder(os.Stderr)}, nil
+	case "none":
+		return nil, nil
+	}
+	return nil, fmt.Errorf("--progress: unknown mode %q (want auto, json or none)", mode)
+}
+
+// progressEvent is one line of --progress json output.
+type progressEvent struct {
+	Event     string `json:"event"` // discovered, processed or done
+	Processed int    `json:"processed"`
+	Total     int    `json:"total"`
+	Tokens    int    `json:"tokens"`
+	File      string `json:"file,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+// jsonProgress writes newline-delimited progress events for wrappers that
+// render their own progress UI.
+type jsonProgress struct {
+	enc  *json.Encoder
+	last core.Progress
+}
+
+func (j *jsonProgress) update(p core.Progress) {
+	event := "processed"
+	if p.File == "" {
+		event = "discovered"
+	}
+	j.last = p
+	j.enc.Encode(progressEvent{Event: event, Processed: p.Processed, Total: p.Total, Tokens: p.Tokens, File: p.File})
+}
+
+func (j *jsonProgress) finish(meta core.ReportMetadata, err error) {
+	done := progressEvent{Event: "done", Processed: j.last.Processed, Total: j.last.Total, Tokens: meta.TotalTokens}
+	if err != nil {
+		done.Error = err.Error()
+	}
+	j.enc.Encode(done)
+}
+
+// progressBarWidth is the number of cells in the bar itself.
+const progressBarWidth = 30
+
+// progressBar draws a single, redrawn status line for headless runs.
+type progressBar struct {
+	w        io.Writer
+	lastDraw time.Time
+	drawn    bool
+}
+
+// newProgressBar returns a bar drawing to stderr, or nil when stderr is not
+// a terminal so piped and logged runs stay clean.
+func newProgressBar() *progressBar {
+	fd := os.Stderr.Fd()
+	if !isatty.IsTerminal(fd) && !isatty.IsCygwinTerminal(fd) {
+		return nil
+	}
+	return &progressBar{w: os.Stderr}
+}
+
+// update redraws the bar, at most every 100ms except for the last file.
+func (b *progressBar) update(p core.Progress) {
+	if p.Processed < p.Total && time.Since(b.lastDraw) < 100*time.Millisecond {
+		return
+	}
+	b.lastDraw = time.Now()
+	b.drawn = true
+
+	filled := progressBarWidth
+	if p.Total > 0 {
+		filled = p.Processed * progressBarWidth / p.Total
+	}
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+	fmt.Fprintf(b.w, "\r\x1b[K%s %d/%d files, ~%d tokens", bar, p.Processed, p.Total, p.Tokens)
+}
+
+// finish erases the bar so the final summary starts on a clean line.
+func (b *progressBar) finish(core.ReportMetadata, error) {
+	if b.drawn {
+		fmt.Fprint(b.w, "\r\x1b[K")
+	}
+}
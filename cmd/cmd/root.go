@@ -2,9 +2,12 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"pandabrew/internal/core"
 	"pandabrew/internal/tui"
@@ -16,8 +19,18 @@ import (
 // NewRootCmd creates and returns the root command for the application.
 func NewRootCmd(version string) *cobra.Command {
 	var root string
+	var sessionName string
 	var headless bool
-	var output string
+	var fresh bool
+	var noSession bool
+	var theme string
+	var transparent bool
+	var lang string
+	var onStart string
+	var recordPath string
+	var replayPath string
+	var sf spaceFlags
+	var ef extractFlags
 
 	rootCmd := &cobra.Command{
 		Use:   "pandabrew [path]",
@@ -26,17 +39,63 @@ func NewRootCmd(version string) *cobra.Command {
 
 A high-performance, headless-first tool to extract codebases into a single
 text file for LLM context. Features an interactive TUI with workspace
-management and smart file filtering.`,
+management and smart file filtering.
+
+What ends up in a report depends on the mode and the visibility options:
+
+  Exclude patterns  Matching paths are never exported, in either mode, and
+                    only listed (as excluded) when excluded files are shown.
+  Include mode      Checked items are exported; folders leading to them are
+                    listed.
+  Exclude mode      Everything but the checked items is exported; left-out
+                    items are not listed unless an option below asks.
+  Context           Lists the neighbours of what is exported: outward from
+                    checked items in include mode, inward into left-out
+                    folders in exclude mode. Context rules cap the levels;
+                    imported packages only count in include mode.
+  Structure         Expanded folders list their children in both modes;
+                    a listed folder whose children stay hidden is rolled
+                    up into a file and token count.`,
 		Version: version, // This will enable the --version flag
+		Args:    cobra.MaximumNArgs(1),
+		// Failed exports are not usage mistakes; main prints the error once
+		SilenceUsage:  true,
+		SilenceErrors: true,
 		Run: func(cmd *cobra.Command, args []string) {
 			// 1. Initialize Session Manager
-			sm := core.NewSessionManager("")
+			sm, err := openSessionManager(cmd, sessionName)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			// Headless runs and replays are throwaway: they read the session
+			// but never add their tabs to it
+			sm.Ephemeral = sm.Ephemeral || headless || noSession || replayPath != ""
 			session, err := sm.Load()
 			if err != nil {
 				// Reset on corruption
 				session = &core.Session{Spaces: []*core.DirectorySpace{}}
 			}
 
+			project, err := sf.loadProject()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			actions, err := tui.ParseStartupActions(onStart)
+			if err != nil {
+				fmt.Printf("Error: --on-start: %v\n", err)
+				os.Exit(1)
+			}
+			if recordPath != "" && replayPath != "" {
+				fmt.Println("Error: --record and --replay cannot be combined")
+				os.Exit(1)
+			}
+			if recordPath != "" && ef.readOnly {
+				fmt.Println("Error: --record writes a file, which --read-only forbids")
+				os.Exit(1)
+			}
+
 			// 2. Determine Initial Workspace
 			var targetPath string
 
@@ -46,17 +105,26 @@ management and smart file filtering.`,
 			} else if len(args) > 0 {
 				// Priority 2: Positional Argument
 				targetPath = args[0]
+			} else if project != nil {
+				// Priority 3: The project config lives at the project root
+				targetPath = filepath.Dir(sf.configPath)
 			}
 
 			var space *core.DirectorySpace
 
 			if targetPath != "" {
-				// User provided a path -> Open/Add it
+				// User provided a path -> Open/Add it. Headless runs reuse the
+				// saved space of the path, keeping its selections and filters
 				absRoot, _ := filepath.Abs(targetPath)
-				space, err = sm.AddSpaceFromPath(session, absRoot)
-				if err != nil {
-					fmt.Printf("Error initializing workspace: %v\n", err)
-					os.Exit(1)
+				if headless && !fresh {
+					space = session.FindSpaceByRoot(absRoot)
+				}
+				if space == nil {
+					space, err = sm.AddSpaceFromPath(session, absRoot)
+					if err != nil {
+						fmt.Printf("Error initializing workspace: %v\n", err)
+						os.Exit(1)
+					}
 				}
 			} else {
 				// No path provided -> Just open session
@@ -67,9 +135,8 @@ management and smart file filtering.`,
 				}
 			}
 
-			// Override default output if flag provided (only if space exists)
-			if space != nil && output != "" {
-				space.OutputFilePath = output
+			if space != nil {
+				sf.apply(cmd, space, project)
 			}
 
 			// 3. Headless Mode
@@ -78,28 +145,502 @@ management and smart file filtering.`,
 					fmt.Println("Error: Headless mode requires a root directory.")
 					os.Exit(1)
 				}
-				fmt.Printf("Starting headless extraction of %s...\n", space.RootPath)
-				meta, err := core.RunExtraction(space)
+				if err := applyHeadlessActions(space, actions); err != nil {
+					fmt.Printf("Error: --on-start: %v\n", err)
+					os.Exit(1)
+				}
+				opts, err := ef.options()
 				if err != nil {
 					fmt.Printf("Error: %v\n", err)
 					os.Exit(1)
 				}
-				fmt.Printf("Done! Processed %d files.\n", meta.TotalFiles)
+				if err := runHeadless(cmd, []*core.DirectorySpace{space}, space.OutputFilePath, opts, &ef); err != nil {
+					fmt.Printf("Error: %v\n", err)
+					os.Exit(1)
+				}
 				return
 			}
 
 			// 4. TUI Mode
-			p := tea.NewProgram(tui.InitialModel(session), tea.WithAltScreen())
-			if _, err := p.Run(); err != nil {
+			if lang == "" {
+				tui.SetLocale(tui.DetectLocale())
+			} else if used := tui.SetLocale(lang); used == "en" && !strings.HasPrefix(strings.ToLower(lang), "en") {
+				fmt.Printf("Error: no translation for %q (want one of %s)\n", lang, strings.Join(tui.Locales(), ", "))
+				os.Exit(1)
+			}
+			if theme != "" {
+				if !slices.Contains(tui.ThemeNames(), theme) {
+					fmt.Printf("Error: unknown theme %q (want one of %s)\n", theme, strings.Join(tui.ThemeNames(), ", "))
+					os.Exit(1)
+				}
+				session.Theme = theme
+			}
+			if cmd.Flags().Changed("transparent") {
+				session.Transparent = transparent
+			}
+			opts, err := ef.options()
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			// A replay starts from the recorded tabs, recreated in a
+			// temporary folder, rather than from the local session
+			var rec *tui.Recording
+			if replayPath != "" {
+				f, err := os.Open(replayPath)
+				if err != nil {
+					fmt.Printf("Error: --replay: %v\n", err)
+					os.Exit(1)
+				}
+				rec, err = tui.LoadRecording(f)
+				f.Close()
+				if err != nil {
+					fmt.Printf("Error: --replay: %v\n", err)
+					os.Exit(1)
+				}
+				dir, err := os.MkdirTemp("", "pandabrew-replay-")
+				if err != nil {
+					fmt.Printf("Error: --replay: %v\n", err)
+					os.Exit(1)
+				}
+				defer os.RemoveAll(dir)
+				replayed, err := rec.Restore(dir)
+				if err != nil {
+					os.RemoveAll(dir)
+					fmt.Printf("Error: --replay: %v\n", err)
+					os.Exit(1)
+				}
+				replayed.Theme, replayed.Transparent = session.Theme, session.Transparent
+				session = replayed
+			}
+			model := tui.InitialModel(session, sm)
+			model.StartupActions = actions
+			model.ReadOnly = ef.readOnly
+			model.ExportOptions = opts
+			var program tea.Model = model
+			var recorder *tui.Recorder
+			var replayer *tui.Replayer
+			switch {
+			case recordPath != "":
+				f, err := os.Create(recordPath)
+				if err != nil {
+					fmt.Printf("Error: --record: %v\n", err)
+					os.Exit(1)
+				}
+				defer f.Close()
+				recorder = tui.NewRecorder(model, f)
+				program = recorder
+			case rec != nil:
+				replayer = tui.NewReplayer(model, rec)
+				program = replayer
+			}
+			p := tea.NewProgram(program, tea.WithAltScreen())
+			// Scripting hook; a second instance simply runs without one, as
+			// do read-only ones and replays, which create no socket
+			if !ef.readOnly && replayer == nil {
+				if ctl, err := tui.ListenControl(p, tui.ControlSocketPath()); err == nil {
+					defer ctl.Close()
+				}
+			}
+			final, err := p.Run()
+			if err != nil {
 				fmt.Printf("Error: %v", err)
 				os.Exit(1)
 			}
+			// Back on the normal screen, leave the result for the shell
+			m, ok := final.(tui.AppModel)
+			switch {
+			case recorder != nil:
+				m, ok = recorder.Model(), true
+				if recorder.Err != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "Warning: recording incomplete: %v\n", recorder.Err)
+				}
+			case replayer != nil:
+				m, ok = replayer.Model(), true
+				out := cmd.OutOrStdout()
+				switch {
+				case !replayer.Done:
+					fmt.Fprintln(out, "Replay stopped before the end of the recording")
+				case len(replayer.Mismatches) == 0:
+					fmt.Fprintln(out, "Replay matched the recording")
+				default:
+					fmt.Fprintf(out, "Replay differed from the recording %d times:\n", len(replayer.Mismatches))
+					for _, mismatch := range replayer.Mismatches {
+						fmt.Fprintln(out, "  "+mismatch)
+					}
+				}
+			}
+			if ok && m.LastExport != nil {
+				fmt.Fprintln(cmd.OutOrStdout(), m.LastExport)
+			}
 		},
 	}
 
+	rootCmd.PersistentFlags().StringVar(&sf.configPath, "config", "", "Project config file to apply, as written by \"pandabrew config export\"")
 	rootCmd.PersistentFlags().StringVar(&root, "root", "", "Project root directory")
-	rootCmd.PersistentFlags().StringVar(&output, "output", "", "Output file path (default: parent_dir/project_name.txt)")
+	rootCmd.PersistentFlags().StringVar(&sessionName, "session", core.DefaultSessionName, "Named session to load and save workspaces in")
+	rootCmd.PersistentFlags().StringVar(&sf.output, "output", "", "Output file path (default: parent_dir/project_name.txt)")
 	rootCmd.PersistentFlags().BoolVar(&headless, "headless", false, "Run in headless mode without TUI")
+	rootCmd.PersistentFlags().BoolVar(&noSession, "no-session", false, "Use the saved session without writing any change to it")
+	rootCmd.PersistentFlags().StringVar(&theme, "theme", "", "Color theme of the TUI, e.g. nord or tokyonight (default: the saved one)")
+	rootCmd.PersistentFlags().StringVar(&onStart, "on-start", "", `Actions to run on start, e.g. "select internal/**; export; quit"`)
+	rootCmd.PersistentFlags().StringVar(&lang, "lang", "", "Language of the TUI, e.g. de or es (default: from PANDABREW_LANG or LANG)")
+	rootCmd.PersistentFlags().StringVar(&recordPath, "record", "", "Record the open tabs' files, key presses and the resulting UI states to a file for a bug report; typed text and names are anonymized and no contents are kept")
+	rootCmd.PersistentFlags().StringVar(&replayPath, "replay", "", "Replay a file written by --record on its tabs, recreated in a temporary folder, and report where the UI state differs; nothing is saved or exported")
+	rootCmd.PersistentFlags().BoolVar(&transparent, "transparent", false, "Let the terminal background show through the TUI instead of the theme's")
+	rootCmd.PersistentFlags().BoolVar(&fresh, "fresh", false, "With --headless, start from a new space instead of the one saved for the path")
+	rootCmd.PersistentFlags().IntVar(&ef.jobs, "jobs", 0, "Number of files read concurrently (default: number of CPUs)")
+	rootCmd.PersistentFlags().StringVar(&ef.readRate, "read-rate", "", "Cap disk reads per second, e.g. 20MB (default: unlimited)")
+	rootCmd.PersistentFlags().StringVar(&ef.maxFileSize, "max-file-size", "32MB", "Truncate file contents beyond this size (0 = no limit)")
+	rootCmd.PersistentFlags().IntVar(&sf.structureDepth, "structure-depth", 0, "Levels listed in the structure section; deeper folders are summarized (0 = no limit)")
+	rootCmd.PersistentFlags().IntVar(&sf.contextRadius, "context-radius", 0, "Folder levels around the selection listed as context (1 = siblings)")
+	rootCmd.PersistentFlags().BoolVar(&sf.contextSignatures, "context-signatures", false, "With --context-radius, add declarations of sibling files")
+	rootCmd.PersistentFlags().BoolVar(&sf.gitInfo, "git-info", false, "Annotate each file with its last commit, author and date")
+	rootCmd.PersistentFlags().StringVar(&sf.headerFields, "header-fields", "", "Metadata added to each file header: size, tokens, language, mtime, sha256 (comma-separated)")
+	rootCmd.PersistentFlags().StringVar(&sf.fileHeader, "file-header", "", "Line opening each file; {path} stands for the path (default: \"--- file: {path} ---\")")
+	rootCmd.PersistentFlags().StringVar(&sf.fileFooter, "file-footer", "", "Line closing each file (default: \"---\")")
+	rootCmd.PersistentFlags().StringVar(&sf.escaping, "escaping", "", "Guard file delimiters against contents: boundary (marker in the footer) or length (byte count in the header)")
+	rootCmd.PersistentFlags().BoolVar(&sf.documentText, "document-text", false, "Include the plain text of .pdf (needs pdftotext) and .docx files")
+	rootCmd.PersistentFlags().BoolVar(&sf.imagePlaceholders, "image-placeholders", false, "Describe images by format, dimensions and size instead of including their bytes")
+	rootCmd.PersistentFlags().BoolVar(&sf.maskConfig, "mask-config", false, "Include .env, YAML, JSON and INI files with every value masked, keeping keys and structure")
+	rootCmd.PersistentFlags().BoolVar(&sf.lineNumbers, "line-numbers", false, "Prefix each line of the file contents with its number")
+	rootCmd.PersistentFlags().BoolVar(&sf.tokenBreakdown, "token-breakdown", false, "Close the report with its tokens by section and by top-level folder")
+	rootCmd.PersistentFlags().BoolVar(&sf.manifest, "manifest", false, "Also write <output>.manifest.json, listing every included file with its size, tokens, SHA-256 and modification time")
+	rootCmd.PersistentFlags().StringVar(&sf.task, "task", "", "Wrap the report in instructions for a task: "+strings.Join(core.TaskNames(), ", ")+" (empty for none)")
+	rootCmd.PersistentFlags().BoolVar(&sf.minify, "minify", false, "Drop blank lines and trailing whitespace from the file contents")
+	rootCmd.PersistentFlags().IntVar(&sf.recentCommits, "recent-commits", 0, "Add the last N commit messages as a Recent Changes section")
+	rootCmd.PersistentFlags().BoolVar(&sf.recentScoped, "recent-commits-scoped", false, "With --recent-commits, only count commits touching the selection")
+	rootCmd.PersistentFlags().StringVar(&sf.nestedRepos, "nested-repos", "", "Policy for submodules and nested repositories: full, structure or skip")
+	rootCmd.PersistentFlags().StringVar(&sf.generated, "generated", "", "Policy for generated code, minified bundles and license files: full, structure or skip")
+	rootCmd.PersistentFlags().StringVar(&sf.tests, "tests", "", "Policy for test files and folders: full, structure or skip")
+	rootCmd.PersistentFlags().StringVar(&sf.selectMode, "select", "", "Replace the selection, switching to include mode: root (the root folder, so new files are exported too) or files (every file listed now)")
+	rootCmd.PersistentFlags().BoolVar(&sf.contextImports, "context-imports", false, "Treat Go packages imported by the selection as context")
+	rootCmd.PersistentFlags().StringVar(&ef.progress, "progress", "auto", "Progress output on stderr: auto (a bar on terminals), json (one event per line) or none")
+	rootCmd.PersistentFlags().IntVar(&ef.failOverTokens, "fail-over-tokens", 0, "Exit with an error when the report exceeds this many tokens (0 = no limit)")
+	rootCmd.PersistentFlags().StringVar(&ef.format, "format", "", "Comma-separated report formats to write from one run: txt, markdown, json (default: those saved with the workspace, else txt at the output path)")
+	rootCmd.PersistentFlags().BoolVar(&ef.verify, "verify", false, "Fail the export if the structure section does not list exactly the files whose contents are included")
+	rootCmd.PersistentFlags().BoolVar(&ef.confine, "confine", false, "Refuse paths outside the root: symlinks leading out of it are not followed, and selections or output there fail the export; needs --output inside the root, as the default output is next to it")
+	rootCmd.PersistentFlags().BoolVar(&ef.readOnly, "read-only", false, "Write nothing: sessions are not saved and exports are dry runs that only report what they would write")
+	rootCmd.PersistentFlags().StringVar(&ef.fileMode, "output-mode", "", "Octal permissions of written reports, e.g. 600, set whatever the umask (default: 666 less the umask, usually 644)")
+	rootCmd.PersistentFlags().StringVar(&ef.dirMode, "output-dir-mode", "", "Octal permissions of output folders created for reports (default: 755 less the umask)")
+	rootCmd.PersistentFlags().BoolVar(&ef.private, "private", false, "Make reports readable by their owner only: 600 for files and 700 for created folders")
+	rootCmd.PersistentFlags().BoolVar(&ef.force, "force", false, "Overwrite the output file even if it was not created by PandaBrew")
+
+	rootCmd.AddCommand(newExtractCmd(&root, &sessionName, &sf, &ef))
+	rootCmd.AddCommand(newBenchCmd(&root, &ef))
+	rootCmd.AddCommand(newStatsCmd(&root, &sessionName))
+	rootCmd.AddCommand(newImportCmd(&sessionName))
+	rootCmd.AddCommand(newConfigCmd(&root, &sessionName))
+	rootCmd.AddCommand(newSessionsCmd(&sessionName))
+	rootCmd.AddCommand(newServeCmd(&root, &sessionName, &ef))
+	rootCmd.AddCommand(newSendCmd())
+	rootCmd.AddCommand(newDaemonCmd(&sessionName, &ef))
+	rootCmd.AddCommand(newCICmd(&sf, &ef))
+	rootCmd.AddCommand(newFocusCmd(&root, &sessionName, &sf, &ef))
 
 	return rootCmd
 }
+
+// applyHeadlessActions runs an --on-start script against the space of a
+// headless run. Selections apply before the export; export and quit are
+// what a headless run does anyway.
+func applyHeadlessActions(space *core.DirectorySpace, actions []tui.StartupAction) error {
+	for _, action := range actions {
+		switch action.Command {
+		case "select", "deselect":
+			paths, err := core.ExpandPaths(space.RootPath, action.Args)
+			if err != nil {
+				return err
+			}
+			for _, path := range paths {
+				if action.Command == "select" {
+					core.SelectPath(space, path)
+				} else {
+					core.DeselectPath(space, path)
+				}
+			}
+		case "export", "quit":
+		default:
+			return fmt.Errorf("%q needs the TUI", action)
+		}
+	}
+	return nil
+}
+
+// runHeadless exports spaces into one report at output, refusing to
+// overwrite foreign files unless forced.
+func runHeadless(cmd *cobra.Command, spaces []*core.DirectorySpace, output string, opts core.ExtractOptions, ef *extractFlags) error {
+	progress, err := newProgressReporter(ef.progress)
+	if err != nil {
+		return err
+	}
+	if opts.Confine && !opts.DryRun && output == core.DefaultOutputPath(spaces[0].RootPath) {
+		return fmt.Errorf("--confine: the default output %s is outside the root (use --output with a path inside it)", output)
+	}
+	formats := core.OutputFormats(opts, spaces[0].Config)
+	paths := core.FormatOutputPaths(output, formats)
+	if !ef.force && !opts.DryRun {
+		for _, path := range paths {
+			if err := core.CheckOutputPath(path); err != nil {
+				return fmt.Errorf("%w: %s (use --force to overwrite)", err, path)
+			}
+		}
+	}
+	var roots []string
+	for _, space := range spaces {
+		roots = append(roots, space.RootPath)
+		for _, w := range core.CheckSpace(space).Warnings {
+			fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", w)
+		}
+	}
+	out := cmd.OutOrStdout()
+	fmt.Fprintf(out, "Starting headless extraction of %s...\n", strings.Join(roots, ", "))
+	if progress != nil {
+		opts.Progress = progress.update
+	}
+	meta, err := core.RunMultiRootExtraction(spaces, output, opts)
+	if progress != nil {
+		progress.finish(meta, err)
+	}
+	if err != nil {
+		return err
+	}
+	if opts.DryRun {
+		fmt.Fprintf(out, "Read-only: would write %s (%d files, ~%d tokens).\n", strings.Join(paths, ", "), meta.TotalFiles, meta.TotalTokens)
+		return ef.checkBudget(meta)
+	}
+	fmt.Fprintf(out, "Done! Processed %d files.\n", meta.TotalFiles)
+	if len(formats) > 0 {
+		fmt.Fprintf(out, "Wrote %s\n", strings.Join(paths, ", "))
+	}
+	return ef.checkBudget(meta)
+}
+
+// openSessionManager opens the named session. With --read-only it is never
+// written.
+func openSessionManager(cmd *cobra.Command, name string) (*core.SessionManager, error) {
+	sm, err := core.NewNamedSessionManager(name)
+	if err != nil {
+		return nil, err
+	}
+	sm.Ephemeral, _ = cmd.Flags().GetBool("read-only")
+	return sm, nil
+}
+
+// spaceFlags override the settings of the workspace being opened or exported.
+type spaceFlags struct {
+	configPath        string
+	output            string
+	structureDepth    int
+	contextRadius     int
+	contextSignatures bool
+	contextImports    bool
+	gitInfo           bool
+	headerFields      string
+	fileHeader        string
+	fileFooter        string
+	escaping          string
+	documentText      bool
+	imagePlaceholders bool
+	maskConfig        bool
+	lineNumbers       bool
+	tokenBreakdown    bool
+	manifest          bool
+	task              string
+	minify            bool
+	recentCommits     int
+	recentScoped      bool
+	nestedRepos       string
+	generated         string
+	tests             string
+	selectMode        string
+}
+
+// loadProject validates the flags and reads the --config file, if one was
+// given.
+func (f *spaceFlags) loadProject() (*core.ProjectConfig, error) {
+	if f.nestedRepos != "" && !core.ValidNestedRepoPolicy(f.nestedRepos) {
+		return nil, fmt.Errorf("--nested-repos: unknown policy %q (want full, structure or skip)", f.nestedRepos)
+	}
+	if f.generated != "" && !core.ValidNestedRepoPolicy(f.generated) {
+		return nil, fmt.Errorf("--generated: unknown policy %q (want full, structure or skip)", f.generated)
+	}
+	if f.tests != "" && !core.ValidNestedRepoPolicy(f.tests) {
+		return nil, fmt.Errorf("--tests: unknown policy %q (want full, structure or skip)", f.tests)
+	}
+	if _, err := core.ParseHeaderFields(f.headerFields); err != nil {
+		return nil, fmt.Errorf("--header-fields: %w", err)
+	}
+	if f.fileHeader != "" && !core.ValidFileHeader(f.fileHeader) {
+		return nil, fmt.Errorf("--file-header: %q needs a {path} placeholder on one line", f.fileHeader)
+	}
+	if strings.Contains(f.fileFooter, "\n") {
+		return nil, fmt.Errorf("--file-footer: must be a single line")
+	}
+	if f.selectMode != "" && !core.ValidSelectMode(f.selectMode) {
+		return nil, fmt.Errorf("--select: unknown mode %q (want root or files)", f.selectMode)
+	}
+	if !core.ValidEscaping(f.escaping) {
+		return nil, fmt.Errorf("--escaping: unknown mode %q (want boundary or length)", f.escaping)
+	}
+	if _, ok := core.FindTaskPreset(f.task); f.task != "" && !ok {
+		return nil, fmt.Errorf("--task: unknown task %q (want one of %s)", f.task, strings.Join(core.TaskNames(), ", "))
+	}
+	if f.configPath == "" {
+		return nil, nil
+	}
+	p, err := core.LoadProjectConfig(f.configPath)
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// apply layers the project config, then any explicitly set flags, onto space.
+func (f *spaceFlags) apply(cmd *cobra.Command, space *core.DirectorySpace, project *core.ProjectConfig) {
+	if project != nil {
+		project.Apply(space)
+	}
+
+	// Override default output if flag provided
+	if f.output != "" {
+		space.OutputFilePath = f.output
+	}
+	if cmd.Flags().Changed("structure-depth") {
+		space.Config.StructureMaxDepth = f.structureDepth
+	}
+	if cmd.Flags().Changed("context-radius") {
+		// Every level lists its folder; signatures only come from siblings
+		space.Config.ShowContext = f.contextRadius > 0
+		space.Config.ContextRules = make([]core.ContextRule, f.contextRadius)
+		for i := range space.Config.ContextRules {
+			space.Config.ContextRules[i] = core.ContextRule{Listing: true, Signatures: i == 0 && f.contextSignatures}
+		}
+	}
+	if cmd.Flags().Changed("context-imports") {
+		space.Config.ContextImports = f.contextImports
+	}
+	if cmd.Flags().Changed("git-info") {
+		space.Config.GitInfo = f.gitInfo
+	}
+	if cmd.Flags().Changed("header-fields") {
+		space.Config.HeaderFields, _ = core.ParseHeaderFields(f.headerFields) // Checked by loadProject
+	}
+	if cmd.Flags().Changed("file-header") {
+		space.Config.FileHeader = f.fileHeader
+	}
+	if cmd.Flags().Changed("file-footer") {
+		space.Config.FileFooter = f.fileFooter
+	}
+	if cmd.Flags().Changed("escaping") {
+		space.Config.Escaping = f.escaping
+	}
+	if cmd.Flags().Changed("document-text") {
+		space.Config.DocumentText = f.documentText
+	}
+	if cmd.Flags().Changed("image-placeholders") {
+		space.Config.ImagePlaceholders = f.imagePlaceholders
+	}
+	if cmd.Flags().Changed("mask-config") {
+		space.Config.MaskConfigValues = f.maskConfig
+	}
+	if cmd.Flags().Changed("line-numbers") {
+		space.Config.LineNumbers = f.lineNumbers
+	}
+	if cmd.Flags().Changed("token-breakdown") {
+		space.Config.TokenBreakdown = f.tokenBreakdown
+	}
+	if cmd.Flags().Changed("manifest") {
+		space.Config.Manifest = f.manifest
+	}
+	if cmd.Flags().Changed("task") {
+		space.Config.Task = f.task
+	}
+	if cmd.Flags().Changed("minify") {
+		space.Config.MinifyContent = f.minify
+	}
+	if cmd.Flags().Changed("recent-commits") {
+		space.Config.RecentCommits = f.recentCommits
+	}
+	if cmd.Flags().Changed("recent-commits-scoped") {
+		space.Config.RecentCommitsScoped = f.recentScoped
+	}
+	if cmd.Flags().Changed("nested-repos") {
+		space.Config.NestedRepoPolicy = f.nestedRepos
+	}
+	if cmd.Flags().Changed("generated") {
+		space.Config.GeneratedPolicy = f.generated
+	}
+	if cmd.Flags().Changed("tests") {
+		space.Config.TestsPolicy = f.tests
+	}
+	if f.selectMode != "" {
+		// Last, so the files are listed with the final patterns
+		if !space.Config.IncludeMode {
+			fmt.Fprintln(cmd.ErrOrStderr(), "--select: switched the workspace to include mode")
+		}
+		space.Config.IncludeMode = true
+		_ = core.SelectEverything(context.Background(), space, f.selectMode) // Only cancelling fails
+	}
+}
+
+// extractFlags are the IO tuning flags shared by every command that exports.
+type extractFlags struct {
+	jobs        int
+	readRate    string
+	maxFileSize string
+	force       bool   // Overwrite output files PandaBrew didn't write
+	progress    string // auto, json or none
+	format      string // Comma-separated report formats
+	verify      bool   // Check the structure section against the contents
+	confine     bool   // Refuse paths outside the root
+	readOnly    bool   // Write neither sessions nor reports
+	fileMode    string // Octal permissions of reports
+	dirMode     string // Octal permissions of created output folders
+	private     bool   // Reports readable by their owner only
+
+	failOverTokens int // Token budget of a report; 0 means none
+}
+
+func (f *extractFlags) options() (core.ExtractOptions, error) {
+	rate, err := core.ParseByteSize(f.readRate)
+	if err != nil {
+		return core.ExtractOptions{}, fmt.Errorf("--read-rate: %w", err)
+	}
+	maxSize, err := core.ParseByteSize(f.maxFileSize)
+	if err != nil {
+		return core.ExtractOptions{}, fmt.Errorf("--max-file-size: %w", err)
+	}
+	formats, err := core.ParseFormats(f.format)
+	if err != nil {
+		return core.ExtractOptions{}, fmt.Errorf("--format: %w", err)
+	}
+	fileMode, err := core.ParseFileMode(f.fileMode)
+	if err != nil {
+		return core.ExtractOptions{}, fmt.Errorf("--output-mode: %w", err)
+	}
+	dirMode, err := core.ParseFileMode(f.dirMode)
+	if err != nil {
+		return core.ExtractOptions{}, fmt.Errorf("--output-dir-mode: %w", err)
+	}
+	if f.private {
+		if fileMode != 0 || dirMode != 0 {
+			return core.ExtractOptions{}, fmt.Errorf("--private cannot be combined with --output-mode or --output-dir-mode")
+		}
+		fileMode, dirMode = 0o600, 0o700
+	}
+	return core.ExtractOptions{
+		Jobs: f.jobs, ReadRate: rate, MaxFileSize: maxSize, Formats: formats,
+		Verify: f.verify, Confine: f.confine, DryRun: f.readOnly,
+		FileMode: fileMode, DirMode: dirMode,
+	}, nil
+}
+
+// checkBudget fails when a written report is over --fail-over-tokens. The
+// report is kept so it can be inspected.
+func (f *extractFlags) checkBudget(meta core.ReportMetadata) error {
+	if f.failOverTokens > 0 && meta.TotalTokens > f.failOverTokens {
+		return fmt.Errorf("report has ~%d tokens, over the --fail-over-tokens limit of %d", meta.TotalTokens, f.failOverTokens)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestCheckBudget(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		tokens   int
+		readOnly bool
+		wantErr  bool
+	}{
+		{name: "no limit", tokens: 5000},
+		{name: "under", limit: 1000, tokens: 999},
+		{name: "equal", limit: 1000, tokens: 1000},
+		{name: "over", limit: 1000, tokens: 1001, wantErr: true},
+		{name: "over in a dry run", limit: 1000, tokens: 1001, readOnly: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := extractFlags{failOverTokens: tt.limit, readOnly: tt.readOnly}
+			err := f.checkBudget(core.ReportMetadata{TotalTokens: tt.tokens})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReadOnlyBudget(t *testing.T) {
+	useTempConfig(t)
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A dry run reports what it would write, then still fails over budget
+	out, err := execute(t, "extract", "--read-only", "--fail-over-tokens", "1", root)
+	if err == nil || !strings.Contains(err.Error(), "--fail-over-tokens") || !strings.Contains(out, "Read-only") {
+		t.Errorf("err = %v\n%s", err, out)
+	}
+}
+
+func TestHeadlessReusesSavedSpace(t *testing.T) {
+	useTempConfig(t)
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The TUI saved a space for root that exports a.go only
+	sm := core.NewSessionManager("")
+	session, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	space, err := sm.AddSpaceFromPath(session, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	space.Config.IncludeMode = true
+	space.Config.ManualSelections = []string{filepath.Join(root, "a.go")}
+	if err := sm.Save(session); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		args  []string
+		saved bool // Exports a.go, the saved selection
+	}{
+		{name: "saved space", args: []string{"--headless", root}, saved: true},
+		{name: "saved space again", args: []string{"--headless", root}, saved: true},
+		{name: "fresh", args: []string{"--headless", "--fresh", root}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := filepath.Join(t.TempDir(), "report.txt")
+			if _, err := execute(t, append(tt.args, "--output", output)...); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(output)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if strings.Contains(string(data), "--- file: a.go ---") != tt.saved || strings.Contains(string(data), "--- file: b.go ---") {
+				t.Errorf("report, want the saved selection %v:\n%s", tt.saved, data)
+			}
+		})
+	}
+	// Headless runs leave the session as it was
+	if reloaded, err := sm.Load(); err != nil || len(reloaded.Spaces) != 1 {
+		t.Errorf("session has %d spaces after the runs (%v)", len(reloaded.Spaces), err)
+	}
+}
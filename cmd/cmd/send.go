@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"pandabrew/internal/tui"
+
+	"github.com/spf13/cobra"
+)
+
+// newSendCmd creates the `send` subcommand, which drives a running TUI
+// through its control socket.
+func newSendCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "send <command> [args...]",
+		Short: "Send a command to the running TUI",
+		Long: `Sends a command to the PandaBrew TUI running on this machine through its
+control socket ($XDG_RUNTIME_DIR/pandabrew.sock, else pandabrew-<uid>/pandabrew.sock
+in the temp dir) and prints the reply.
+
+Commands:
+  select <path>...     Export these files or folders (relative to the tab root)
+  deselect <path>...   Stop exporting them
+  export               Export the active tab
+  switch-tab <n>       Activate the nth visible tab
+
+For example, in tmux.conf:
+  bind-key E run-shell "pandabrew send export"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			reply, err := tui.SendControl(tui.ControlSocketPath(), args)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), reply)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"path/filepath"
+
+	"pandabrew/internal/server"
+
+	"github.com/spf13/cobra"
+)
+
+// newServeCmd creates the `serve` subcommand, a JSON-RPC server on stdio for
+// editor integrations.
+func newServeCmd(root, sessionName *string, ef *extractFlags) *cobra.Command {
+	serveCmd := &cobra.Command{
+		Use:   "serve [path]",
+		Short: "Serve a workspace over JSON-RPC on stdio for editor plugins",
+		Long: `Speaks JSON-RPC 2.0 on stdin/stdout, one message per line, so editor
+plugins can drive PandaBrew without the TUI. The workspace for path (default:
+the current directory) is opened from the session, or added to it, and
+selection changes are saved there. A TUI already open on the session does
+not see them, and whichever of the two saves last overwrites the other.
+
+Paths sent by the client, including the export output, may be relative to
+the workspace root and must stay inside it.
+
+Methods:
+  select     {"paths": [...]}   Export these files or folders
+  deselect   {"paths": [...]}   Stop exporting them
+  selection                     The checked paths, relative to the root
+  export     {"output": "..."}  Write the report (output is optional; a file
+                                PandaBrew did not write is only replaced
+                                with --force; with --read-only nothing is
+                                written and the result has "dry_run": true)
+  stats                         Selected and total files and tokens
+
+Example:
+  {"jsonrpc":"2.0","id":1,"method":"select","params":{"paths":["main.go"]}}`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := *root
+			if len(args) > 0 {
+				target = args[0]
+			}
+			if target == "" {
+				target = "."
+			}
+			absRoot, err := filepath.Abs(target)
+			if err != nil {
+				return err
+			}
+
+			sm, err := openSessionManager(cmd, *sessionName)
+			if err != nil {
+				return err
+			}
+			session, err := sm.Load()
+			if err != nil {
+				return err
+			}
+			space := session.FindSpaceByRoot(absRoot)
+			if space == nil {
+				if space, err = sm.AddSpaceFromPath(session, absRoot); err != nil {
+					return err
+				}
+			}
+
+			opts, err := ef.options()
+			if err != nil {
+				return err
+			}
+			srv := server.New(sm, session, space, opts)
+			srv.Force = ef.force
+			return srv.Serve(cmd.InOrStdin(), cmd.OutOrStdout())
+		},
+	}
+	return serveCmd
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// newSessionsCmd creates the `sessions` command group for housekeeping of
+// saved sessions.
+func newSessionsCmd(sessionName *string) *cobra.Command {
+	sessionsCmd := &cobra.Command{
+		Use:   "sessions",
+		Short: "Maintain saved sessions",
+	}
+
+	var dryRun bool
+	pruneCmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove dead and duplicate workspaces from the session",
+		Long: `Removes the workspaces whose root directory no longer exists, and all but
+one of the workspaces opened on the same root: the active one, or else the one
+with the most selections. The session file is rewritten compactly.
+
+  pandabrew sessions prune --dry-run
+  pandabrew sessions prune --session work`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			sm, err := openSessionManager(cmd, *sessionName)
+			if err != nil {
+				return err
+			}
+			session, err := sm.Load()
+			if err != nil {
+				return err
+			}
+			total := len(session.Spaces)
+
+			var pruned []core.PrunedSpace
+			verb := "Removed"
+			if dryRun || sm.Ephemeral {
+				pruned = core.PruneSession(session)
+				verb = "Would remove"
+			} else if pruned, err = sm.Prune(session); err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			for _, p := range pruned {
+				fmt.Fprintf(out, "%s %s (%s)\n", verb, p.Space.RootPath, p.Reason)
+			}
+			fmt.Fprintf(out, "%s %d of %d workspaces.\n", verb, len(pruned), total)
+			return nil
+		},
+	}
+	pruneCmd.Flags().BoolVar(&dryRun, "dry-run", false, "List what would be removed without changing the session")
+
+	sessionsCmd.AddCommand(pruneCmd)
+	return sessionsCmd
+}
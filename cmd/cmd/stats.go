@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"pandabrew/internal/core"
+
+	"github.com/spf13/cobra"
+)
+
+// newStatsCmd creates the `stats` subcommand, which reports where the context
+// budget of a workspace goes without writing a report.
+func newStatsCmd(root, sessionName *string) *cobra.Command {
+	var asCSV bool
+	var asTSV bool
+
+	statsCmd := &cobra.Command{
+		Use:   "stats [path]",
+		Short: "Show per-language and per-file size statistics",
+		Long: `Summarizes the files of a project by language, using the saved workspace
+settings for that path if there are any (otherwise everything is selected).
+With --csv or --tsv, prints one row per file instead:
+path, language, bytes, tokens, selected.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if asCSV && asTSV {
+				return fmt.Errorf("--csv and --tsv are mutually exclusive")
+			}
+			space, err := resolveSpace(*root, *sessionName, args)
+			if err != nil {
+				return err
+			}
+
+			stats, err := core.CollectFileStats(space)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			switch {
+			case asCSV:
+				return core.WriteFileStatsCSV(out, stats, ',')
+			case asTSV:
+				return core.WriteFileStatsCSV(out, stats, '\t')
+			}
+
+			selectedFiles, selectedTokens, totalTokens := 0, 0, 0
+			for _, s := range stats {
+				totalTokens += s.Tokens
+				if s.Selected {
+					selectedFiles++
+					selectedTokens += s.Tokens
+				}
+			}
+			fmt.Fprintf(out, "%s\n", space.RootPath)
+			fmt.Fprintf(out, "Selected: %d of %d files, ~%s of ~%s tokens\n\n",
+				selectedFiles, len(stats), core.FormatTokens(selectedTokens), core.FormatTokens(totalTokens))
+
+			tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "LANGUAGE\tFILES\tTOKENS\tSHARE")
+			for _, l := range core.SummarizeLanguages(stats) {
+				share := 0.0
+				if selectedTokens > 0 {
+					share = float64(l.Tokens) / float64(selectedTokens) * 100
+				}
+				fmt.Fprintf(tw, "%s\t%d\t~%s\t%.1f%%\n", l.Language, l.Files, core.FormatTokens(l.Tokens), share)
+			}
+			return tw.Flush()
+		},
+	}
+
+	statsCmd.Flags().BoolVar(&asCSV, "csv", false, "Print per-file rows as CSV")
+	statsCmd.Flags().BoolVar(&asTSV, "tsv", false, "Print per-file rows as TSV")
+
+	return statsCmd
+}
+
+// resolveSpace picks the workspace a subcommand works on: the saved space for the given
+// path, a select-everything space if none is saved, or the active space.
+func resolveSpace(root, sessionName string, args []string) (*core.DirectorySpace, error) {
+	target := root
+	if len(args) > 0 {
+		target = args[0]
+	}
+
+	sm, err := core.NewNamedSessionManager(sessionName)
+	if err != nil {
+		return nil, err
+	}
+	session, err := sm.Load()
+	if err != nil {
+		session = &core.Session{}
+	}
+
+	if target == "" {
+		if space := session.GetActiveSpace(); space != nil {
+			return space, nil
+		}
+		return nil, fmt.Errorf("a root directory is required (via --root or argument) or an active session")
+	}
+
+	absRoot, err := filepath.Abs(target)
+	if err != nil {
+		return nil, err
+	}
+	if space := session.FindSpaceByRoot(absRoot); space != nil {
+		return space, nil
+	}
+
+	space := &core.DirectorySpace{RootPath: absRoot, OutputFilePath: core.DefaultOutputPath(absRoot), Config: core.DefaultExtractionConfig()}
+	space.Config.ManualSelections = []string{absRoot}
+	return space, nil
+}
@@ -0,0 +1,125 @@
+// Package core implements the token breakdown closing a report.
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// rootFilesLabel names the files outside any folder in a TokenBreakdown.
+const rootFilesLabel = "(root files)"
+
+// TokenBreakdown splits the estimated tokens of a text report by section,
+// and those of the file contents by top-level folder, to show what eats the
+// budget. The breakdown itself is not counted.
+type TokenBreakdown struct {
+	Header     int // Report header, section headings, root headers and task instructions
+	Recent     int // Recent Changes sections
+	Structure  int
+	Contents   int
+	Signatures int
+	Folders    []FolderTokens // Contents by top-level folder, largest first
+}
+
+// FolderTokens is the share of the contents from one top-level folder, or
+// from the files at the root when Path is "(root files)". In a multi-root
+// report the folders are the roots.
+type FolderTokens struct {
+	Path   string
+	Tokens int
+}
+
+// newTokenBreakdown adds up the spans of a report of total bytes.
+func newTokenBreakdown(spans []reportSpan, total int64) *TokenBreakdown {
+	var sections [spanSignatures + 1]int64
+	folders := make(map[string]int64)
+	var spanned int64
+	for _, s := range spans {
+		n := s.end - s.start
+		sections[s.kind] += n
+		if s.kind != spanRoot {
+			spanned += n
+		}
+		if s.kind == spanFile {
+			folder, _, found := strings.Cut(filepath.ToSlash(s.name), "/")
+			if !found {
+				folder = rootFilesLabel
+			} else {
+				folder += "/"
+			}
+			folders[folder] += n
+		}
+	}
+
+	b := &TokenBreakdown{
+		Header:     int((total - spanned) / 4),
+		Recent:     int(sections[spanRecent] / 4),
+		Structure:  int(sections[spanStructure] / 4),
+		Contents:   int(sections[spanFile] / 4),
+		Signatures: int(sections[spanSignatures] / 4),
+	}
+	for path, n := range folders {
+		b.Folders = append(b.Folders, FolderTokens{Path: path, Tokens: int(n / 4)})
+	}
+	slices.SortFunc(b.Folders, func(x, y FolderTokens) int {
+		return cmp.Or(cmp.Compare(y.Tokens, x.Tokens), cmp.Compare(x.Path, y.Path))
+	})
+	return b
+}
+
+// Total is the tokens of the report before the breakdown.
+func (b *TokenBreakdown) Total() int {
+	return b.Header + b.Recent + b.Structure + b.Contents + b.Signatures
+}
+
+// breakdownRow is one line of a written TokenBreakdown.
+type breakdownRow struct {
+	label  string
+	tokens int
+}
+
+// rows lists the sections in report order, each folder under Contents.
+// Empty optional sections are left out.
+func (b *TokenBreakdown) rows() []breakdownRow {
+	rows := []breakdownRow{{"Header", b.Header}}
+	if b.Recent > 0 {
+		rows = append(rows, breakdownRow{"Recent Changes", b.Recent})
+	}
+	rows = append(rows, breakdownRow{"Structure", b.Structure}, breakdownRow{"Contents", b.Contents})
+	for _, f := range b.Folders {
+		rows = append(rows, breakdownRow{"  " + f.Path, f.Tokens})
+	}
+	if b.Signatures > 0 {
+		rows = append(rows, breakdownRow{"Signatures", b.Signatures})
+	}
+	return rows
+}
+
+// writeTokenBreakdown closes the text report with b, laid out like the
+// languages of the header.
+func writeTokenBreakdown(w io.Writer, b *TokenBreakdown) error {
+	rows := b.rows()
+	nameWidth := 0
+	for _, r := range rows {
+		nameWidth = max(nameWidth, len(r.label))
+	}
+	if _, err := fmt.Fprint(w, "### Token Breakdown\n\n"); err != nil {
+		return err
+	}
+	total := b.Total()
+	for _, r := range rows {
+		share := 0.0
+		if total > 0 {
+			share = float64(r.tokens) / float64(total) * 100
+		}
+		if _, err := fmt.Fprintf(w, "  %-*s %5.1f%%  ~%s tokens\n", nameWidth, r.label, share, FormatTokens(r.tokens)); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "  %-*s         ~%s tokens\n", nameWidth, "Total", FormatTokens(total))
+	return err
+}
@@ -0,0 +1,141 @@
+// Package core implements confining an export to the root folder of its space.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrOutsideRoot is returned by a confined export asked to read or write a
+// path outside the root folder of its space.
+var ErrOutsideRoot = errors.New("path is outside the root")
+
+// errNotFollowed stands in for the contents of a file whose real location
+// is outside a confined root, typically a crafted symlink.
+var errNotFollowed = fmt.Errorf("not followed: %w", ErrOutsideRoot)
+
+// confinement checks paths against the real location of a root folder, so
+// a symlink can't lead out of it.
+type confinement struct{ root string }
+
+func newConfinement(root string) (confinement, error) {
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return confinement{}, err
+	}
+	abs, err := filepath.Abs(real)
+	return confinement{root: abs}, err
+}
+
+// contains reports whether path, with every symlink in it resolved, is
+// inside the root. Missing parts of path, like an output file not written
+// yet, are taken as they are.
+func (c confinement) contains(path string) bool {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	var missing []string
+	for {
+		real, err := filepath.EvalSymlinks(path)
+		if err == nil {
+			return withinRoot(filepath.Join(append([]string{real}, missing...)...), c.root)
+		}
+		parent := filepath.Dir(path)
+		if !errors.Is(err, fs.ErrNotExist) || parent == path {
+			return false
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+		path = parent
+	}
+}
+
+// open opens path for reading if its real location is inside the root.
+// The opened file is checked again against that location, so a symlink
+// swapped in after the export was planned can't lead out of the root.
+func (c confinement) open(path string) (*os.File, error) {
+	real, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+	if !withinRoot(real, c.root) {
+		return nil, errNotFollowed
+	}
+	f, err := os.Open(real)
+	if err != nil {
+		return nil, err
+	}
+	opened, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	again, err := filepath.EvalSymlinks(real)
+	if err == nil && again == real {
+		var info fs.FileInfo
+		if info, err = os.Stat(real); err == nil && os.SameFile(opened, info) {
+			return f, nil
+		}
+	}
+	f.Close()
+	return nil, errNotFollowed
+}
+
+// confineOutput fails unless every report written to outputPath in formats
+// lands inside one of roots.
+func confineOutput(roots []string, outputPath string, formats []string) error {
+	paths := []string{outputPath}
+	for _, format := range formats {
+		paths = append(paths, FormatOutputPath(outputPath, format))
+	}
+	for _, path := range paths {
+		inside := false
+		for _, root := range roots {
+			c, err := newConfinement(root)
+			if err != nil {
+				return err
+			}
+			inside = inside || c.contains(path)
+		}
+		if !inside {
+			return fmt.Errorf("output %s: %w", path, ErrOutsideRoot)
+		}
+	}
+	return nil
+}
+
+// confine checks the selection of the plan against its root and marks files
+// that resolve outside it, which are then reported without being read.
+// The others are checked again as they are opened. Signatures of files
+// outside the root are dropped.
+func (p *extractionPlan) confine() error {
+	c, err := newConfinement(p.space.RootPath)
+	if err != nil {
+		return err
+	}
+	for _, sel := range p.space.Config.ManualSelections {
+		if !c.contains(sel) {
+			return fmt.Errorf("selection %s: %w", sel, ErrOutsideRoot)
+		}
+	}
+	for i, f := range p.files {
+		if !withinRoot(f.Path, p.space.RootPath) {
+			return fmt.Errorf("file %s: %w", f.Path, ErrOutsideRoot)
+		}
+		if !c.contains(f.Path) {
+			p.files[i].Refused = errNotFollowed
+		}
+		p.files[i].Confined = c.root
+	}
+	sigFiles := p.sigFiles[:0]
+	for _, f := range p.sigFiles {
+		if c.contains(f.Path) {
+			sigFiles = append(sigFiles, f)
+		}
+	}
+	p.sigFiles = sigFiles
+	return nil
+}
@@ -0,0 +1,91 @@
+package core
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfine(t *testing.T) {
+	outside := t.TempDir()
+	secret := filepath.Join(outside, "secret")
+	if err := os.WriteFile(secret, []byte("hunter2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(secret, filepath.Join(root, "passwd")); err != nil {
+		t.Skip("symlinks unsupported:", err)
+	}
+	newSpace := func(output string) *DirectorySpace {
+		cfg := DefaultExtractionConfig()
+		cfg.ManualSelections = []string{root}
+		return &DirectorySpace{RootPath: root, OutputFilePath: output, Config: cfg}
+	}
+	confined := ExtractOptions{Confine: true}
+
+	// A symlink out of the root is listed, but not followed
+	output := filepath.Join(root, "out.txt")
+	if _, err := RunExtractionWithOptions(newSpace(output), confined); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+	if strings.Contains(report, "hunter2") || !strings.Contains(report, "--- file: passwd ---\n[Error reading file: not followed") || !strings.Contains(report, "package main") {
+		t.Errorf("report:\n%s", report)
+	}
+	if _, err := RunExtraction(newSpace(output)); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(output); !strings.Contains(string(data), "hunter2") {
+		t.Error("an unconfined export should follow the symlink")
+	}
+
+	// Writing outside the root, selecting or listing a file there fails
+	if _, err := RunExtractionWithOptions(newSpace(filepath.Join(outside, "out.txt")), confined); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("output outside the root: err = %v", err)
+	}
+	space := newSpace(output)
+	space.Config.ManualSelections = append(space.Config.ManualSelections, secret)
+	if _, err := RunExtractionWithOptions(space, confined); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("selection outside the root: err = %v", err)
+	}
+	listed := ExtractOptions{Confine: true, Files: []string{"main.go", secret}}
+	if _, err := RunExtractionWithOptions(newSpace(output), listed); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("listed file outside the root: err = %v", err)
+	}
+
+	// A path through a symlinked folder leads out too
+	if err := os.Symlink(outside, filepath.Join(root, "etc")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunExtractionWithOptions(newSpace(filepath.Join(root, "etc", "out.txt")), confined); !errors.Is(err, ErrOutsideRoot) {
+		t.Errorf("output through a symlinked folder: err = %v", err)
+	}
+
+	// A file swapped for a symlink after planning is caught as it is opened
+	c, err := newConfinement(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swapped := filepath.Join(root, "swapped.go")
+	if err := os.Symlink(secret, swapped); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	files := []contentFile{{Path: filepath.Join(root, "main.go"), RelPath: "main.go", Confined: c.root}, {Path: swapped, RelPath: "swapped.go", Confined: c.root}}
+	if err := writeContents(&buf, files, ExtractOptions{}, DefaultExtractionConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if report := buf.String(); strings.Contains(report, "hunter2") || !strings.Contains(report, "package main") || !strings.Contains(report, "not followed") {
+		t.Errorf("swapped file was read:\n%s", report)
+	}
+}
@@ -0,0 +1,270 @@
+// Package core implements the context rules applied around a selection.
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ContextRule decides what one level of context contributes to a report.
+// Level 1 is the folder holding a selected item (its siblings), level 2 is
+// that folder's parent, and so on up to the project root. In exclude mode,
+// where the selection is what is left out, context grows inward instead:
+// level 1 is the left-out entries of an exported folder, level 2 the entries
+// of a left-out folder in it, and so on.
+type ContextRule struct {
+	Listing    bool `json:"listing" yaml:"listing"`       // List the folder's entries in the structure section
+	Signatures bool `json:"signatures" yaml:"signatures"` // Add the top-level declarations of its files
+}
+
+// contextPlan resolves ShowContext and the context rules against the
+// current selection once per walk.
+type contextPlan struct {
+	root    string
+	rules   []ContextRule
+	include bool
+	levels  map[string]int // Folder -> closest level, only with rules in include mode
+
+	selections map[string]bool
+}
+
+// newContextPlan returns nil when ShowContext is off. Without ContextRules the
+// plan keeps the original behaviour: every folder on the path to a selection
+// lists its entries.
+func newContextPlan(root string, cfg ExtractionConfig, selections map[string]bool) *contextPlan {
+	if !cfg.ShowContext {
+		return nil
+	}
+	p := &contextPlan{root: root, rules: cfg.ContextRules, include: cfg.IncludeMode, selections: selections}
+	if len(p.rules) == 0 || !p.include {
+		return p
+	}
+
+	p.levels = make(map[string]int)
+	for sel, checked := range selections {
+		if !checked {
+			continue
+		}
+		dir := filepath.Dir(sel)
+		for level := 1; level <= len(p.rules); level++ {
+			p.mark(dir, level)
+			if dir == root || !strings.HasPrefix(dir, root) {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	if cfg.ContextImports {
+		for _, dir := range localImports(root, selections) {
+			p.mark(dir, 1) // Imported packages count as direct neighbours
+		}
+	}
+	return p
+}
+
+func (p *contextPlan) mark(dir string, level int) {
+	if cur, ok := p.levels[dir]; !ok || level < cur {
+		p.levels[dir] = level
+	}
+}
+
+// rule returns the rule for entries whose parent folder is dir.
+func (p *contextPlan) rule(dir string) (ContextRule, bool) {
+	if p == nil {
+		return ContextRule{}, false
+	}
+	if !p.include {
+		level := p.leftOutLevel(dir)
+		if len(p.rules) == 0 {
+			return ContextRule{Listing: true}, level > 0
+		}
+		if level == 0 || level > len(p.rules) {
+			return ContextRule{}, false
+		}
+		return p.rules[level-1], true
+	}
+	if p.levels == nil {
+		return ContextRule{Listing: true}, isRelevantDirectory(dir, p.root, p.selections)
+	}
+	level, ok := p.levels[dir]
+	if !ok {
+		return ContextRule{}, false
+	}
+	return p.rules[level-1], true
+}
+
+// coversDir reports whether dir is, or contains, a folder with context,
+// so the walk must descend into it.
+func (p *contextPlan) coversDir(dir string) bool {
+	if p == nil {
+		return false
+	}
+	if !p.include {
+		// Levels only grow further into left-out folders
+		level := p.leftOutLevel(dir)
+		return level > 0 && (len(p.rules) == 0 || level <= len(p.rules))
+	}
+	if p.levels == nil {
+		return isRelevantDirectory(dir, p.root, p.selections)
+	}
+	prefix := dir + string(os.PathSeparator)
+	for ctxDir := range p.levels {
+		if ctxDir == dir || strings.HasPrefix(ctxDir, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// leftOutLevel is the exclude-mode context level of entries in dir: 1 in a
+// folder whose content is exported, one more per left-out folder between
+// dir and it. It is 0 when nothing above dir is exported.
+func (p *contextPlan) leftOutLevel(dir string) int {
+	for level := 1; ; level++ {
+		if !isPathSelected(dir, p.root, p.selections) {
+			return level
+		}
+		if dir == p.root || !strings.HasPrefix(dir, p.root) {
+			return 0
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// localImports returns the folders of packages inside the root's Go module
+// that the selected Go files import directly. Files inside selected folders
+// are considered one level deep only.
+func localImports(root string, selections map[string]bool) []string {
+	module := goModulePath(root)
+	if module == "" {
+		return nil
+	}
+
+	var goFiles []string
+	for sel, checked := range selections {
+		if !checked {
+			continue
+		}
+		info, err := os.Stat(sel)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			goFiles = append(goFiles, sel)
+			continue
+		}
+		entries, _ := os.ReadDir(sel)
+		for _, e := range entries {
+			if !e.IsDir() {
+				goFiles = append(goFiles, filepath.Join(sel, e.Name()))
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	var dirs []string
+	fset := token.NewFileSet()
+	for _, file := range goFiles {
+		if !strings.HasSuffix(file, ".go") {
+			continue
+		}
+		f, err := parser.ParseFile(fset, file, nil, parser.ImportsOnly)
+		if err != nil {
+			continue
+		}
+		for _, imp := range f.Imports {
+			path, _ := strconv.Unquote(imp.Path.Value)
+			rel, ok := strings.CutPrefix(path, module+"/")
+			if !ok || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			dirs = append(dirs, filepath.Join(root, filepath.FromSlash(rel)))
+		}
+	}
+	return dirs
+}
+
+// goModulePath reads the module path from root/go.mod, if any.
+func goModulePath(root string) string {
+	f, err := os.Open(filepath.Join(root, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if mod, ok := strings.CutPrefix(strings.TrimSpace(scanner.Text()), "module "); ok {
+			return strings.Trim(strings.TrimSpace(mod), `"`)
+		}
+	}
+	return ""
+}
+
+// writeSignatures emits the Context Signatures section. Files without any
+// recognizable declarations are left out.
+func writeSignatures(w io.Writer, files []contentFile, spans *spanRecorder) error {
+	wroteHeader := false
+	for _, f := range files {
+		// Declarations of huge (likely generated) files aren't worth the read
+		if info, err := os.Stat(f.Path); err != nil || info.Size() > prefetchLimit {
+			continue
+		}
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			continue
+		}
+		sigs := extractSignatures(data)
+		if len(sigs) == 0 {
+			continue
+		}
+		if !wroteHeader {
+			if _, err := fmt.Fprint(w, "### Context Signatures\n\n"); err != nil {
+				return err
+			}
+			wroteHeader = true
+		}
+		err = spans.mark(reportSpan{kind: spanSignatures, name: f.RelPath}, func() error {
+			_, err := fmt.Fprintf(w, "--- signatures: %s ---\n%s\n---\n\n", filepath.ToSlash(f.RelPath), strings.Join(sigs, "\n"))
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// signaturePrefixes mark top-level declarations in common languages.
+var signaturePrefixes = []string{
+	"func ", "type ", // Go
+	"def ", "class ", "async def ", // Python
+	"export ", "function ", "interface ", // JS/TS
+	"pub fn ", "fn ", "pub struct ", "struct ", "pub enum ", "enum ", "impl ", "pub trait ", "trait ", // Rust
+}
+
+// extractSignatures returns the unindented declaration lines of a source
+// file, with any trailing opening brace removed.
+func extractSignatures(content []byte) []string {
+	var sigs []string
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		for _, prefix := range signaturePrefixes {
+			if strings.HasPrefix(line, prefix) {
+				sigs = append(sigs, strings.TrimSpace(strings.TrimSuffix(line, "{")))
+				break
+			}
+		}
+	}
+	return sigs
+}
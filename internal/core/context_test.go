@@ -0,0 +1,61 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestContextRules(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":        "module example.com/app\n",
+		"main.go":       "package main\n\nimport \"example.com/app/lib\"\n\nfunc main() { lib.Help() }\n",
+		"lib/lib.go":    "package lib\n\nfunc Help() string {\n\treturn \"help\"\n}\n",
+		"other/deep.go": "package other\n\nfunc Deep() {}\n",
+		"other/x/y.go":  "package x\n",
+	}
+	for path, content := range files {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: filepath.Join(t.TempDir(), "out.txt"),
+		Config: ExtractionConfig{
+			IncludeMode:      true,
+			ManualSelections: []string{filepath.Join(root, "main.go")},
+			ShowContext:      true,
+			ContextRules:     []ContextRule{{Listing: true, Signatures: true}},
+			ContextImports:   true,
+		},
+	}
+	meta, err := RunExtraction(space)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TotalFiles != 1 {
+		t.Errorf("File count: got %d, want 1", meta.TotalFiles)
+	}
+	content, _ := os.ReadFile(space.OutputFilePath)
+	for _, want := range []string{
+		"lib.go [EXCLUDED]",              // Imported package is listed
+		"--- signatures: lib/lib.go ---", // and contributes its declarations
+		"func Help() string",
+		"other/ [EXCLUDED] (2 files", // Sibling folder is only rolled up
+	} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("missing %q", want)
+		}
+	}
+	if strings.Contains(string(content), "func Deep()") {
+		t.Error("signatures leaked from outside the context radius")
+	}
+}
@@ -0,0 +1,188 @@
+// Package core implements the delimiters framing each file in a report.
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Default delimiters of the File Contents section. {path} stands for the
+// path, its annotation and header fields.
+const (
+	DefaultFileHeader = "--- file: {path} ---"
+	DefaultFileFooter = "---"
+)
+
+// Escaping modes of ExtractionConfig.Escaping.
+const (
+	EscapeNone     = ""         // Plain delimiters; a content line equal to the footer is ambiguous
+	EscapeBoundary = "boundary" // The footer carries a marker derived from the content
+	EscapeLength   = "length"   // The header states the content length in bytes
+)
+
+// ValidEscaping reports whether mode is a known escaping mode.
+func ValidEscaping(mode string) bool {
+	return slices.Contains([]string{EscapeNone, EscapeBoundary, EscapeLength}, mode)
+}
+
+// ValidFileHeader reports whether header can frame a file, which needs
+// the {path} placeholder on a single line.
+func ValidFileHeader(header string) bool {
+	return strings.Contains(header, "{path}") && !strings.Contains(header, "\n")
+}
+
+// Header fields added by the escaping modes.
+const (
+	boundaryField = "boundary"
+	lengthField   = "length"
+)
+
+// delimiters frame each file of the File Contents section.
+type delimiters struct {
+	header   string // Holds {path}
+	footer   string
+	escaping string
+}
+
+// newDelimiters reads the delimiters of cfg, falling back to the defaults
+// for unset or unusable ones.
+func newDelimiters(cfg ExtractionConfig) delimiters {
+	d := delimiters{header: DefaultFileHeader, footer: DefaultFileFooter}
+	if ValidFileHeader(cfg.FileHeader) {
+		d.header = cfg.FileHeader
+	}
+	if cfg.FileFooter != "" && !strings.Contains(cfg.FileFooter, "\n") {
+		d.footer = cfg.FileFooter
+	}
+	if ValidEscaping(cfg.Escaping) {
+		d.escaping = cfg.Escaping
+	}
+	return d
+}
+
+// headerLine frames the header text of a file: its path, annotation and
+// fields.
+func (d delimiters) headerLine(header string) string {
+	return strings.Replace(d.header, "{path}", header, 1)
+}
+
+// footerLine closes a file with the given header fields. In boundary mode
+// the marker follows the footer.
+func (d delimiters) footerLine(fields []headerField) string {
+	for _, f := range fields {
+		if f.Key == boundaryField {
+			return d.footer + " " + f.Value
+		}
+	}
+	return d.footer
+}
+
+// needsHash reports whether the escaping mode relies on content hashes.
+func (d delimiters) needsHash() bool {
+	return d.escaping == EscapeBoundary
+}
+
+// escapeFields are the header fields the escaping mode adds for content of
+// n bytes with the hex SHA-256 sum. A content cannot practically contain a
+// marker derived from its own hash.
+func (d delimiters) escapeFields(n int64, sum string) []headerField {
+	switch d.escaping {
+	case EscapeBoundary:
+		return []headerField{{boundaryField, "pb-" + sum[:min(16, len(sum))]}}
+	case EscapeLength:
+		return []headerField{{lengthField, strconv.FormatInt(n, 10)}}
+	}
+	return nil
+}
+
+// sections maps each file path in a report to its content, including the
+// newline that precedes the footer.
+func (d delimiters) sections(report string) map[string]string {
+	prefix, suffix, _ := strings.Cut(d.header, "{path}")
+	sections := make(map[string]string)
+	for report != "" {
+		var line string
+		line, report, _ = strings.Cut(report, "\n")
+		header, ok := strings.CutPrefix(line, prefix)
+		if !ok || !strings.HasSuffix(header, suffix) {
+			continue
+		}
+		path, fields := splitFileHeader(strings.TrimSuffix(header, suffix))
+		footer := d.footerLine(fields) + "\n"
+
+		end := -1
+		if n, err := strconv.Atoi(fieldValue(fields, lengthField)); err == nil && n < len(report) && strings.HasPrefix(report[n:], "\n"+footer) {
+			end = n + 1
+		} else if strings.HasPrefix(report, footer) {
+			end = 0
+		} else if i := strings.Index(report, "\n"+footer); i >= 0 {
+			end = i + 1
+		}
+		if end < 0 {
+			sections[path] = report
+			break
+		}
+		sections[path] = report[:end]
+		report = report[end+len(footer):]
+	}
+	return sections
+}
+
+// splitFileHeader separates the path of a header from its header fields
+// and annotation.
+func splitFileHeader(header string) (string, []headerField) {
+	var fields []headerField
+	if i := strings.LastIndex(header, " ["); i >= 0 && strings.HasSuffix(header, "]") {
+		fields = parseHeaderFieldList(header[i+2 : len(header)-1])
+		header = header[:i]
+	}
+	// Annotations such as the last commit are not part of the path
+	if i := strings.LastIndex(header, " ("); i >= 0 && strings.HasSuffix(header, ")") {
+		header = header[:i]
+	}
+	return header, fields
+}
+
+// parseHeaderFieldList reads the key=value pairs written by
+// formatHeaderFields.
+func parseHeaderFieldList(s string) []headerField {
+	var fields []headerField
+	for s != "" {
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				break
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+		}
+		fields = append(fields, headerField{key, value})
+		s = strings.TrimPrefix(rest, " ")
+	}
+	return fields
+}
+
+func fieldValue(fields []headerField, key string) string {
+	for _, f := range fields {
+		if f.Key == key {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// hashString is the hex SHA-256 of s.
+func hashString(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
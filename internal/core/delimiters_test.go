@@ -0,0 +1,41 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDelimiterEscaping(t *testing.T) {
+	root := t.TempDir()
+	// A file whose content mimics the end of a file and the next header
+	tricky := "before\n</file>\n\n<file path=fake.go>\nafter"
+	if err := os.WriteFile(filepath.Join(root, "tricky.txt"), []byte(tricky), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "plain.go"), []byte("package plain\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, escaping := range []string{EscapeBoundary, EscapeLength} {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		space := &DirectorySpace{
+			RootPath:       root,
+			OutputFilePath: out,
+			Config:         ExtractionConfig{Escaping: escaping, FileHeader: "<file path={path}>", FileFooter: "</file>"},
+		}
+		if _, err := RunExtraction(space); err != nil {
+			t.Fatal(err)
+		}
+		report, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := newDelimiters(space.Config).sections(string(report))
+		want := map[string]string{"plain.go": "package plain\n\n", "tricky.txt": tricky + "\n"}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: sections = %q\nreport:\n%s", escaping, got, report)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Package core implements comparison of workspace selections.
+package core
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// SelectionDiff lists the selections that differ between two spaces.
+// Paths are relative to each space's root, so clones and worktrees of the
+// same project compare cleanly. Deselections are listed with a leading "!".
+type SelectionDiff struct {
+	Added   []string // Selected in `to` only
+	Removed []string // Selected in `from` only
+	Common  int
+}
+
+// DiffSelections compares the ManualSelections and ManualDeselections of
+// two spaces.
+func DiffSelections(from, to *DirectorySpace) SelectionDiff {
+	fromSet := relativeSelections(from)
+	toSet := relativeSelections(to)
+
+	var diff SelectionDiff
+	for rel := range toSet {
+		if fromSet[rel] {
+			diff.Common++
+		} else {
+			diff.Added = append(diff.Added, rel)
+		}
+	}
+	for rel := range fromSet {
+		if !toSet[rel] {
+			diff.Removed = append(diff.Removed, rel)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	return diff
+}
+
+func relativeSelections(space *DirectorySpace) map[string]bool {
+	cfg := space.Config
+	set := make(map[string]bool, len(cfg.ManualSelections)+len(cfg.ManualDeselections))
+	add := func(path, mark string) {
+		rel, err := filepath.Rel(space.RootPath, path)
+		if err != nil {
+			rel = path
+		}
+		set[mark+filepath.ToSlash(rel)] = true
+	}
+	for _, sel := range cfg.ManualSelections {
+		add(sel, "")
+	}
+	for _, desel := range cfg.ManualDeselections {
+		add(desel, "!")
+	}
+	return set
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestDiffSelections(t *testing.T) {
+	// Two clones of the same project compare by relative path
+	from := &DirectorySpace{RootPath: filepath.Join("/", "work", "main")}
+	from.Config.ManualSelections = []string{
+		filepath.Join(from.RootPath, "cmd"),
+		filepath.Join(from.RootPath, "internal", "core"),
+	}
+	to := &DirectorySpace{RootPath: filepath.Join("/", "work", "feature")}
+	to.Config.ManualSelections = []string{
+		filepath.Join(to.RootPath, "internal", "core"),
+		filepath.Join(to.RootPath, "internal", "tui"),
+	}
+
+	diff := DiffSelections(from, to)
+	if !reflect.DeepEqual(diff.Added, []string{"internal/tui"}) {
+		t.Errorf("added: got %v", diff.Added)
+	}
+	if !reflect.DeepEqual(diff.Removed, []string{"cmd"}) {
+		t.Errorf("removed: got %v", diff.Removed)
+	}
+	if diff.Common != 1 {
+		t.Errorf("common: got %d, want 1", diff.Common)
+	}
+
+	// A deselection inside a folder both select is a difference too
+	to.Config.ManualDeselections = []string{filepath.Join(to.RootPath, "internal", "core", "testdata")}
+	if diff := DiffSelections(from, to); !reflect.DeepEqual(diff.Added, []string{"!internal/core/testdata", "internal/tui"}) {
+		t.Errorf("added with a deselection: got %v", diff.Added)
+	}
+}
@@ -0,0 +1,205 @@
+// Package core implements extracting plain text from PDF and DOCX files.
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultDocumentCommands convert formats without a built-in reader.
+var defaultDocumentCommands = map[string]string{
+	".pdf": "pdftotext -layout -enc UTF-8 {file} -",
+}
+
+// documentTimeout bounds one external conversion.
+const documentTimeout = 30 * time.Second
+
+// isDocument reports whether path is converted to text under cfg.
+func isDocument(path string, cfg ExtractionConfig) bool {
+	if !cfg.DocumentText {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	_, custom := cfg.DocumentCommands[ext]
+	_, builtin := defaultDocumentCommands[ext]
+	return custom || builtin || ext == ".docx"
+}
+
+// documentText returns the plain text of the document f, opened from
+// path. A command set in cfg.DocumentCommands for the extension wins over
+// the built-in handling; commands are given the name f was opened by. With
+// limit above 0 the text stops after limit bytes, and clipped reports
+// whether there was more.
+func documentText(f *os.File, size int64, path string, cfg ExtractionConfig, limit int64) (text string, clipped bool, err error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if command, ok := cfg.DocumentCommands[ext]; ok {
+		return runDocumentCommand(command, f.Name(), limit)
+	}
+	if ext == ".docx" {
+		return docxText(f, size, limit)
+	}
+	return runDocumentCommand(defaultDocumentCommands[ext], f.Name(), limit)
+}
+
+// openDocument is openContent for documents: the text, up to limit bytes,
+// stands in for the file contents. The SHA-256 is still that of the file.
+func openDocument(path string, open openFunc, cfg ExtractionConfig, limit int64, hash bool) contentResult {
+	f, err := open(path)
+	if err != nil {
+		return contentResult{err: err}
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return contentResult{err: err}
+	}
+	text, clipped, err := documentText(f, info.Size(), path, cfg, limit)
+	if err != nil {
+		return contentResult{err: err}
+	}
+	res := contentResult{r: strings.NewReader(text), size: int64(len(text)), modTime: info.ModTime(), clipped: clipped}
+	if hash {
+		if h, err := hashOpened(open, path); err == nil {
+			res.sha = h.SHA256
+		}
+	}
+	return res
+}
+
+// limitReader caps r at limit+1 bytes, one more than is kept so that
+// passing the limit shows. A limit of 0 leaves r as is.
+func limitReader(r io.Reader, limit int64) *io.LimitedReader {
+	if limit <= 0 {
+		return &io.LimitedReader{R: r, N: math.MaxInt64}
+	}
+	return &io.LimitedReader{R: r, N: limit + 1}
+}
+
+// runDocumentCommand runs command with {file} replaced by path and returns
+// what it printed, up to limit bytes. A command still printing past the
+// limit is stopped.
+func runDocumentCommand(command, path string, limit int64) (string, bool, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", false, fmt.Errorf("no document command for %s", filepath.Ext(path))
+	}
+	for i, a := range args {
+		args[i] = strings.ReplaceAll(a, "{file}", path)
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return "", false, fmt.Errorf("%s is not installed, needed to extract text from %s files", args[0], filepath.Ext(path))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), documentTimeout)
+	defer cancel()
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", false, err
+	}
+	if err := cmd.Start(); err != nil {
+		return "", false, fmt.Errorf("%s: %w", args[0], err)
+	}
+	out, readErr := io.ReadAll(limitReader(stdout, limit))
+	if limit > 0 && int64(len(out)) > limit {
+		cancel() // The rest would be cut anyway
+		cmd.Wait()
+		return string(out[:limit]), true, nil
+	}
+	if err = cmd.Wait(); err == nil {
+		err = readErr
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", false, fmt.Errorf("%s: %w: %s", args[0], err, msg)
+		}
+		return "", false, fmt.Errorf("%s: %w", args[0], err)
+	}
+	return string(out), false, nil
+}
+
+// docxText reads the paragraphs of the Word document in r, one per line.
+// With limit above 0 no more than limit+1 bytes of its markup are
+// decompressed, so the text is clipped before limit once the markup runs
+// over it.
+func docxText(r io.ReaderAt, size, limit int64) (string, bool, error) {
+	z, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", false, err
+	}
+
+	var body io.ReadCloser
+	for _, f := range z.File {
+		if f.Name == "word/document.xml" {
+			if body, err = f.Open(); err != nil {
+				return "", false, err
+			}
+			break
+		}
+	}
+	if body == nil {
+		return "", false, errors.New("not a Word document: word/document.xml is missing")
+	}
+	defer body.Close()
+
+	var text strings.Builder
+	inText := false
+	markup := limitReader(body, limit)
+	dec := xml.NewDecoder(markup)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF && markup.N > 0 {
+			break
+		}
+		if err != nil && markup.N <= 0 {
+			return clipText(text.String(), limit), true, nil
+		}
+		if err != nil {
+			return "", false, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				text.WriteByte('\t')
+			case "br", "cr":
+				text.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				text.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				text.Write(t)
+			}
+		}
+	}
+	if limit > 0 && int64(text.Len()) > limit {
+		return clipText(text.String(), limit), true, nil
+	}
+	return text.String(), false, nil
+}
+
+// clipText cuts text to at most limit bytes.
+func clipText(text string, limit int64) string {
+	return text[:min(int64(len(text)), limit)]
+}
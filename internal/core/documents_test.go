@@ -0,0 +1,93 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDocumentText(t *testing.T) {
+	root := t.TempDir()
+	var docx bytes.Buffer
+	z := zip.NewWriter(&docx)
+	w, err := z.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`+
+		`<w:p><w:r><w:t>Design</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">notes &amp; goals</w:t></w:r></w:p>`+
+		`<w:p><w:r><w:t>Second paragraph</w:t></w:r></w:p></w:body></w:document>`)
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "spec.docx"), docx.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "plan.pdf"), []byte("converted by the hook\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "report.txt")
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: out,
+		Config: ExtractionConfig{
+			DocumentText:     true,
+			DocumentCommands: map[string]string{".pdf": "cat {file}"},
+		},
+	}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := newDelimiters(space.Config).sections(string(report))
+	want := map[string]string{
+		"plan.pdf":  "converted by the hook\n\n",
+		"spec.docx": "Design\tnotes & goals\nSecond paragraph\n\n",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %q", got)
+	}
+}
+
+func TestDocumentTextLimit(t *testing.T) {
+	var docx bytes.Buffer
+	z := zip.NewWriter(&docx)
+	w, err := z.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.WriteString(w, `<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>`)
+	for range 1000 {
+		io.WriteString(w, `<w:p><w:r><w:t>paragraph</w:t></w:r></w:p>`)
+	}
+	io.WriteString(w, `</w:body></w:document>`)
+	if err := z.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(docx.Bytes())
+
+	full, clipped, err := docxText(r, r.Size(), 0)
+	if err != nil || clipped || len(full) != 10000 {
+		t.Fatalf("no limit: %d bytes, clipped %v, err %v", len(full), clipped, err)
+	}
+	// The markup is limited too, so less text than the limit is kept
+	text, clipped, err := docxText(r, r.Size(), 4000)
+	if err != nil || !clipped || len(text) == 0 || len(text) > 4000 || !strings.HasPrefix(full, text) {
+		t.Errorf("limit: %d bytes, clipped %v, err %v", len(text), clipped, err)
+	}
+
+	// A command that never stops printing is cut off at the limit
+	text, clipped, err = runDocumentCommand("yes {file}", "page", 100)
+	if err != nil || !clipped || text != strings.Repeat("page\n", 20) {
+		t.Errorf("command: %q, clipped %v, err %v", text, clipped, err)
+	}
+}
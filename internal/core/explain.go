@@ -0,0 +1,154 @@
+// Package core implements explaining what an export does with one path.
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Verdicts of an Explanation.
+const (
+	VerdictExported = "exported"
+	VerdictListed   = "listed in the structure only"
+	VerdictLeftOut  = "left out"
+)
+
+// Explanation is what an export of a space does with one path, and why.
+type Explanation struct {
+	Path    string   // Relative to the root, slash-separated
+	Verdict string   // VerdictExported, VerdictListed or VerdictLeftOut
+	Reasons []string // The decisions leading to it, in the order the export makes them
+}
+
+// ExplainPath retraces the decisions the walk of an export makes for path:
+// junk and exclude patterns, nested repositories, the selection, the
+// generated and test policies, context and earlier reports. For a folder,
+// exported means some of its contents are.
+func ExplainPath(space *DirectorySpace, path string) Explanation {
+	cfg, root := space.Config, space.RootPath
+	rel := relativeTo(root, path)
+	e := Explanation{Path: rel, Verdict: VerdictLeftOut}
+	switch {
+	case rel == "":
+		e.Reasons = append(e.Reasons, "Lies outside the project root")
+		return e
+	case rel == ".":
+		e.Verdict = VerdictListed
+		e.Reasons = append(e.Reasons, "Is the project root, which heads the structure")
+		return e
+	case path == space.OutputFilePath:
+		e.Reasons = append(e.Reasons, "Is the output file of this export")
+		return e
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		e.Reasons = append(e.Reasons, "Cannot be read: "+err.Error())
+		return e
+	}
+	isDir := info.IsDir()
+
+	rules := newPathRules(root, cfg)
+	repo, policy := enclosingRepo(root, path, isDir, cfg)
+	d := rules.decide(path, filepath.FromSlash(rel), isDir, repo, policy, &e.Reasons)
+	switch {
+	case d.skip:
+		return e
+	case d.excluded:
+		e.Verdict = VerdictListed
+		e.Reasons = append(e.Reasons, "Show Excluded lists it in the structure anyway")
+		return e
+	case d.listOnly:
+		e.Verdict = VerdictListed
+	}
+
+	keep := d.keep
+	if !keep && !d.listOnly {
+		if isDir && cfg.IncludeMode && isRelevantDirectory(path, root, rules.selections) {
+			e.Reasons = append(e.Reasons, "Holds selected items, which are exported")
+			keep = true
+		} else if d.isContext {
+			e.Verdict = VerdictListed
+			reason := "Sits next to the selection, so Show Context lists it"
+			if d.context.Signatures && !isDir {
+				reason += " with its declarations"
+			}
+			e.Reasons = append(e.Reasons, reason)
+		}
+	}
+
+	if !isDir && (keep || e.Verdict == VerdictListed) && isPreviousReport(path, rel, cfg.OutputGlobs) {
+		e.Verdict = VerdictLeftOut
+		e.Reasons = append(e.Reasons, "Is an earlier PandaBrew report, which is never included")
+		return e
+	}
+	if keep {
+		e.Verdict = VerdictExported
+	}
+	if depth := strings.Count(rel, "/"); e.Verdict != VerdictLeftOut && cfg.StructureMaxDepth > 0 && depth >= cfg.StructureMaxDepth {
+		e.Reasons = append(e.Reasons, fmt.Sprintf("Lies below structure depth %d, so the structure only counts it", cfg.StructureMaxDepth))
+	}
+	return e
+}
+
+// enclosingRepo finds the nested repository whose policy applies to path,
+// as the walk meets them: one skipped wins, otherwise the innermost listed
+// as structure. The policy is "" when neither applies.
+func enclosingRepo(root, path string, isDir bool, cfg ExtractionConfig) (repo, policy string) {
+	for dir := path; dir != root && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if dir == path && !isDir || !isNestedRepo(dir) {
+			continue
+		}
+		switch p := cfg.NestedRepoPolicyFor(relativeTo(root, dir)); p {
+		case NestedRepoSkip:
+			return dir, p
+		case NestedRepoStructure:
+			if policy == "" {
+				repo, policy = dir, p
+			}
+		}
+	}
+	return repo, policy
+}
+
+// selectionReason tells how the selection of cfg covers path, if at all.
+func selectionReason(root string, cfg ExtractionConfig, path, rel string, selected bool) string {
+	mode := "include mode exports only checked items"
+	if !cfg.IncludeMode {
+		mode = "exclude mode leaves checked items out"
+	}
+	if slices.Contains(cfg.ManualSelections, path) {
+		return "Is checked, and " + mode
+	}
+	for dir := filepath.Dir(path); dir != root && dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		if slices.Contains(cfg.ManualSelections, dir) {
+			return fmt.Sprintf("Inherits the check on %s, and %s", relativeTo(root, dir), mode)
+		}
+	}
+	if cfg.IncludeMode && selected {
+		for p := filepath.ToSlash(rel); p != "."; p = filepath.ToSlash(filepath.Dir(p)) {
+			for _, pattern := range cfg.IncludePatterns {
+				if isExcluded(p, []string{pattern}) {
+					return fmt.Sprintf("Matches include pattern %q, and %s", pattern, mode)
+				}
+			}
+		}
+	}
+	if cfg.IncludeMode {
+		return "Is not checked, and " + mode
+	}
+	return "Is not checked, and exclude mode exports everything unchecked"
+}
+
+// lowValueName describes a DetectLowValue kind.
+func lowValueName(kind string) string {
+	switch kind {
+	case LowValueGenerated:
+		return "generated code"
+	case LowValueMinified:
+		return "a minified bundle"
+	}
+	return "a license file"
+}
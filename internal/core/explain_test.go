@@ -0,0 +1,80 @@
+package core
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestExplainPath(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"src/app.go", "src/app_test.go", "docs/guide.md", "dist/bundle.js", "README.md"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	space := &DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(root, "out.txt"), Config: ExtractionConfig{
+		IncludeMode:      true,
+		IncludePatterns:  []string{"*.md"},
+		ExcludePatterns:  []string{"dist"},
+		ManualSelections: []string{filepath.Join(root, "src")},
+		TestsPolicy:      NestedRepoStructure,
+	}}
+
+	for rel, want := range map[string]struct{ verdict, reason string }{
+		"src/app.go":      {VerdictExported, "Inherits the check on src"},
+		"src/app_test.go": {VerdictListed, "Is a test, whose policy is structure"},
+		"docs/guide.md":   {VerdictExported, `Matches include pattern "*.md"`},
+		"dist/bundle.js":  {VerdictLeftOut, `Matches exclude pattern "dist"`},
+		"docs":            {VerdictExported, "Holds selected items"},
+	} {
+		e := ExplainPath(space, filepath.Join(root, filepath.FromSlash(rel)))
+		if e.Verdict != want.verdict || !slices.ContainsFunc(e.Reasons, func(r string) bool { return strings.HasPrefix(r, want.reason) }) {
+			t.Errorf("%s: %s %q, want %s with %q", rel, e.Verdict, e.Reasons, want.verdict, want.reason)
+		}
+	}
+
+	// Exported files agree with the export itself, policies included
+	for _, name := range []string{"vendor/lib/.git/HEAD", "vendor/lib/lib.go", "src/gen.go", "src/.DS_Store"} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("// Code generated by x; DO NOT EDIT.\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	space.Config.ManualSelections = append(space.Config.ManualSelections, filepath.Join(root, "vendor"))
+	space.Config.NestedRepos = map[string]string{"vendor/lib": NestedRepoStructure}
+	space.Config.GeneratedPolicy = NestedRepoSkip
+	space.Config.SkipJunk = true
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(space.OutputFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sections := newDelimiters(space.Config).sections(string(report))
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path == space.OutputFilePath {
+			return err
+		}
+		rel := relativeTo(root, path)
+		_, exported := sections[rel]
+		if e := ExplainPath(space, path); (e.Verdict == VerdictExported) != exported {
+			t.Errorf("%s: explained as %s %q, exported %v", rel, e.Verdict, e.Reasons, exported)
+		}
+		return nil
+	})
+	if len(sections) != 3 {
+		t.Errorf("exported %d files, want 3", len(sections))
+	}
+}
@@ -2,24 +2,55 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 )
 
+// JunkPatterns lists editor and OS artifacts skipped when SkipJunk is on.
+var JunkPatterns = []string{
+	".DS_Store", "Thumbs.db", "desktop.ini",
+	"*.swp", "*.swo", "*~",
+	".idea", ".vscode",
+	"__pycache__", "*.pyc",
+}
+
 // RunExtraction executes the headless export logic for a specific space.
-func RunExtraction(space *DirectorySpace) (meta ReportMetadata, err error) {
-	// 0. Validate Space (Prune missing selections)
-	sm := NewSessionManager("")
-	sm.ValidateSpace(space)
+func RunExtraction(space *DirectorySpace) (ReportMetadata, error) {
+	return RunExtractionWithOptions(space, DefaultExtractOptions())
+}
 
-	config := space.Config
+// RunExtractionWithOptions is RunExtraction with explicit concurrency and IO limits.
+func RunExtractionWithOptions(space *DirectorySpace, opts ExtractOptions) (ReportMetadata, error) {
+	return RunMultiRootExtraction([]*DirectorySpace{space}, space.OutputFilePath, opts)
+}
+
+// RunMultiRootExtraction exports several projects into one report at
+// outputPath, each under a section labeled with its folder name. File paths
+// in the contents are prefixed with that label. With a single space the
+// report is a plain single-project report.
+func RunMultiRootExtraction(spaces []*DirectorySpace, outputPath string, opts ExtractOptions) (meta ReportMetadata, err error) {
+	timings := opts.Timings
+	defer timings.finish(timings.now())
+
+	if len(spaces) == 0 {
+		return meta, fmt.Errorf("nothing to extract")
+	}
+
+	config := spaces[0].Config
+	opts.Formats = OutputFormats(opts, config)
+	if opts.DryRun {
+		opts.Formats = nil // Nothing to convert
+	}
 	meta = ReportMetadata{
 		Timestamp:     time.Now(),
 		SelectionMode: "INCLUDE checked items",
@@ -27,80 +58,456 @@ func RunExtraction(space *DirectorySpace) (meta ReportMetadata, err error) {
 	if !config.IncludeMode {
 		meta.SelectionMode = "EXCLUDE checked items"
 	}
-
-	if err := os.MkdirAll(filepath.Dir(space.OutputFilePath), 0o755); err != nil {
-		return meta, fmt.Errorf("failed to create output dir: %w", err)
+	if opts.Files != nil {
+		meta.SelectionMode = "Explicit file list"
+	}
+	if meta.Task, err = taskFor(config); err != nil {
+		return meta, err
 	}
 
-	outFile, err := os.Create(space.OutputFilePath)
-	if err != nil {
-		return meta, fmt.Errorf("failed to create output file: %w", err)
+	// With formats, the text report is written first and converted
+	textPath := outputPath
+	if len(opts.Formats) > 0 {
+		var temp bool
+		if textPath, temp, err = textReportPath(outputPath, opts.Formats); err != nil {
+			return meta, err
+		}
+		if temp {
+			defer os.Remove(textPath)
+		}
+	}
+	absOutPath, _ := filepath.Abs(textPath)
+	if opts.Confine && !opts.DryRun {
+		roots := make([]string, len(spaces))
+		for i, space := range spaces {
+			roots[i] = space.RootPath
+		}
+		if err := confineOutput(roots, outputPath, opts.Formats); err != nil {
+			return meta, err
+		}
 	}
 
-	// We wrap the file writer to count bytes automatically
-	countingWriter := &TokenCountingWriter{Writer: outFile}
+	// Collect the content files up front so the header can describe them
+	labels := rootLabels(spaces)
+	var plans []*extractionPlan
+	var allFiles []contentFile
+	for i, space := range spaces {
+		// 0. Validate Space (Drop duplicate selections)
+		sm := NewSessionManager("")
+		sm.ValidateSpace(space)
 
-	defer func() {
-		if closeErr := outFile.Close(); closeErr != nil && err == nil {
-			err = closeErr
+		plan, err := planExtraction(space, opts, absOutPath)
+		if err != nil {
+			return meta, err
 		}
-	}()
+		plans = append(plans, plan)
+		allFiles = append(allFiles, plan.files...)
+		for _, d := range vendoredFiles(space.RootPath, plan.files) {
+			if len(spaces) > 1 {
+				d.Path = labels[i] + "/" + d.Path
+			}
+			meta.Vendored = append(meta.Vendored, d)
+		}
+	}
+	meta.TotalFiles = len(allFiles)
+	meta.Languages = languageStats(allFiles, opts.MaxFileSize)
+	if len(spaces) == 1 {
+		meta.Branch = describeBranch(spaces[0].RootPath)
+	}
 
-	if err := writeHeader(countingWriter, meta); err != nil {
-		return meta, err
+	var out io.Writer = io.Discard
+	if !opts.DryRun {
+		if err := mkdirReport(filepath.Dir(outputPath), opts.DirMode); err != nil {
+			return meta, fmt.Errorf("failed to create output dir: %w", err)
+		}
+
+		var outFile *os.File
+		outFile, err = createReport(textPath, opts.FileMode)
+		if err != nil {
+			return meta, fmt.Errorf("failed to create output file: %w", err)
+		}
+		defer func() {
+			if closeErr := outFile.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}()
+		out = outFile
 	}
 
-	absOutPath, _ := filepath.Abs(space.OutputFilePath)
+	// We wrap the file writer to count bytes automatically
+	countingWriter := &TokenCountingWriter{Writer: out, timings: timings}
 
-	if _, err := fmt.Fprintln(countingWriter, "### Project Structure"); err != nil {
-		return meta, err
+	if len(opts.Formats) > 0 || config.TokenBreakdown {
+		opts.spans = &spanRecorder{w: countingWriter}
 	}
-	if _, err := fmt.Fprintln(countingWriter); err != nil {
-		return meta, err
+	if config.Manifest && !opts.DryRun {
+		opts.manifest = &manifestRecorder{}
+	}
+	if opts.Progress != nil {
+		progress := Progress{Total: meta.TotalFiles}
+		opts.fileWritten = func(relPath string) {
+			progress.Processed++
+			progress.File = filepath.ToSlash(relPath)
+			progress.Tokens = countingWriter.EstimatedTokens
+			opts.Progress(progress)
+		}
+		opts.Progress(progress)
 	}
 
-	if err := walkAndProcess(space.RootPath, config, countingWriter, true, &meta, absOutPath); err != nil {
+	if err := writeHeader(countingWriter, meta); err != nil {
 		return meta, err
 	}
-	if _, err := fmt.Fprintln(countingWriter); err != nil {
-		return meta, err
+	if meta.Task != nil {
+		if err := writeTaskInstructions(countingWriter, meta.Task); err != nil {
+			return meta, err
+		}
 	}
 
-	if !config.FilenamesOnly {
-		if _, err := fmt.Fprintln(countingWriter, "### File Contents"); err != nil {
+	for i, plan := range plans {
+		label := ""
+		if len(plans) > 1 {
+			label = labels[i]
+			err := opts.spans.mark(reportSpan{kind: spanRoot, name: label}, func() error {
+				return writeRootHeader(countingWriter, label, plan.space.RootPath)
+			})
+			if err != nil {
+				return meta, err
+			}
+		}
+		if err := plan.write(countingWriter, opts, label); err != nil {
 			return meta, err
 		}
-		if _, err := fmt.Fprintln(countingWriter); err != nil {
+	}
+
+	if meta.Task != nil {
+		if err := writeTaskResponse(countingWriter, meta.Task); err != nil {
 			return meta, err
 		}
-		if err := walkAndProcess(space.RootPath, config, countingWriter, false, &meta, absOutPath); err != nil {
+	}
+	if config.TokenBreakdown {
+		meta.Breakdown = newTokenBreakdown(opts.spans.spans, countingWriter.BytesWritten)
+		if err := writeTokenBreakdown(countingWriter, meta.Breakdown); err != nil {
 			return meta, err
 		}
 	}
 
 	// Finalize token count from our tracking writer
 	meta.TotalTokens = countingWriter.EstimatedTokens
+	if len(opts.Formats) > 0 {
+		if err := writeFormats(textPath, outputPath, opts.Formats, opts.FileMode, meta, opts.spans.spans); err != nil {
+			return meta, err
+		}
+	}
+	if opts.manifest != nil {
+		roots := make([]string, len(spaces))
+		for i, space := range spaces {
+			roots[i] = space.RootPath
+		}
+		report := FormatOutputPaths(outputPath, opts.Formats)[0]
+		if err := writeManifest(outputPath, report, opts.FileMode, meta, roots, opts.manifest); err != nil {
+			return meta, err
+		}
+	}
 	return meta, nil
 }
 
+// writeRootHeader opens the section of one root in a multi-root report.
+func writeRootHeader(w io.Writer, label, rootPath string) error {
+	if _, err := fmt.Fprintf(w, "## Root: %s\nPath: %s\n", label, rootPath); err != nil {
+		return err
+	}
+	if branch := describeBranch(rootPath); branch != "" {
+		if _, err := fmt.Fprintf(w, "Branch: %s\n", branch); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// extractionPlan is what one project contributes to a report.
+type extractionPlan struct {
+	space          *DirectorySpace
+	files          []contentFile
+	sigFiles       []contentFile
+	writeStructure func(w io.Writer) error
+}
+
+// planExtraction collects the content files of space, or resolves the
+// explicit file list of opts against it.
+func planExtraction(space *DirectorySpace, opts ExtractOptions, absOutPath string) (*extractionPlan, error) {
+	timings := opts.Timings
+	config := space.Config
+	plan := &extractionPlan{space: space}
+	plan.writeStructure = func(w io.Writer) error {
+		return walkAndProcess(space.RootPath, config, w, nil, absOutPath, timings)
+	}
+
+	switch {
+	case opts.Files != nil:
+		// An explicit list replaces the walk, and the selection with it
+		plan.files = listedFiles(space.RootPath, opts.Files, absOutPath)
+		plan.writeStructure = func(w io.Writer) error {
+			return printFileList(w, space.RootPath, plan.files)
+		}
+	case config.FilenamesOnly:
+		return plan, nil
+	default:
+		collect := func(f contentFile) {
+			if f.SignaturesOnly {
+				plan.sigFiles = append(plan.sigFiles, f)
+			} else {
+				plan.files = append(plan.files, f)
+			}
+		}
+		if err := walkAndProcess(space.RootPath, config, io.Discard, collect, absOutPath, timings); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Confine {
+		if err := plan.confine(); err != nil {
+			return nil, err
+		}
+	}
+	if config.GitInfo {
+		annotateGitInfo(space.RootPath, plan.files)
+	}
+	return plan, nil
+}
+
+// write emits the structure and contents sections. A non-empty label
+// prefixes every file path, to keep paths unique across roots.
+func (p *extractionPlan) write(w io.Writer, opts ExtractOptions, label string) error {
+	if n := p.space.Config.RecentCommits; n > 0 {
+		err := opts.spans.mark(reportSpan{kind: spanRecent}, func() error {
+			return writeRecentChanges(w, p.space, n)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w, "### Project Structure"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+
+	var listing *listingWriter
+	err := opts.spans.mark(reportSpan{kind: spanStructure}, func() error {
+		if !verifying(opts) {
+			return p.writeStructure(w)
+		}
+		listing = newListingWriter(w)
+		return p.writeStructure(listing)
+	})
+	if err != nil {
+		return err
+	}
+	if listing != nil && !p.space.Config.FilenamesOnly {
+		if err := listing.verify(p.files); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+
+	if p.space.Config.FilenamesOnly {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, "### File Contents"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	if err := writeContents(w, labeledFiles(p.files, label), opts, p.space.Config); err != nil {
+		return err
+	}
+	return writeSignatures(w, labeledFiles(p.sigFiles, label), opts.spans)
+}
+
+func labeledFiles(files []contentFile, label string) []contentFile {
+	if label == "" {
+		return files
+	}
+	labeled := make([]contentFile, len(files))
+	for i, f := range files {
+		f.RelPath = filepath.Join(label, f.RelPath)
+		labeled[i] = f
+	}
+	return labeled
+}
+
+// rootLabels names each root after its folder, numbering repeated names.
+func rootLabels(spaces []*DirectorySpace) []string {
+	seen := make(map[string]int)
+	labels := make([]string, len(spaces))
+	for i, space := range spaces {
+		name := filepath.Base(space.RootPath)
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s-%d", name, n)
+		}
+		labels[i] = name
+	}
+	return labels
+}
+
 // TokenCountingWriter is a wrapper that estimates tokens (chars / 4)
 type TokenCountingWriter struct {
 	Writer          io.Writer
+	BytesWritten    int64
 	EstimatedTokens int
+
+	timings *Timings
 }
 
 func (w *TokenCountingWriter) Write(p []byte) (n int, err error) {
 	n, err = w.Writer.Write(p)
-	// Standard heuristic: ~4 characters per token
-	w.EstimatedTokens += n / 4
+	defer w.timings.add(stageTokenize, w.timings.now())
+	// Standard heuristic: ~4 characters per token. Derived from the running
+	// byte total so streamed chunks don't each lose their remainder.
+	w.BytesWritten += int64(n)
+	w.EstimatedTokens = int(w.BytesWritten / 4)
 	return n, err
 }
 
-func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, structOnly bool, meta *ReportMetadata, absOutPath string) error {
-	selectionMap := make(map[string]bool, len(cfg.ManualSelections))
-	for _, p := range cfg.ManualSelections {
-		selectionMap[p] = true
+// pathRules hold what the export decisions for the paths of one walk
+// share: the selection, with include patterns resolved, and the context
+// plan around it.
+type pathRules struct {
+	root       string
+	cfg        ExtractionConfig
+	selections map[string]bool
+	context    *contextPlan
+}
+
+func newPathRules(root string, cfg ExtractionConfig) *pathRules {
+	// Include patterns act as selections resolved at export time
+	var matched []string
+	if cfg.IncludeMode {
+		matched = matchIncludePatterns(root, cfg)
+	}
+	selections := cfg.checkedSet(matched...)
+	return &pathRules{root: root, cfg: cfg, selections: selections, context: newContextPlan(root, cfg, selections)}
+}
+
+// pathDecision is what an export does with one path, before the structure
+// listing decides whether it shows.
+type pathDecision struct {
+	skip      bool        // Left out, with everything below it
+	excluded  bool        // Matches an exclude pattern, so only Show Excluded lists it
+	keep      bool        // Its contents are exported
+	listOnly  bool        // Listed instead of exported, by a structure policy
+	note      string      // Why it is only listed: a DetectLowValue kind or "test"
+	isContext bool        // Show Context lists it, not being kept
+	context   ContextRule // What Show Context makes of it
+}
+
+// decide makes the export decisions for path in order: junk and exclude
+// patterns, the policy of the nested repository it lies in (repoPolicy for
+// repo, "" outside one), the selection, the generated and test policies and
+// context. Each decision made is told in why, if set.
+func (r *pathRules) decide(path, rel string, isDir bool, repo, repoPolicy string, why *[]string) pathDecision {
+	cfg := r.cfg
+	reason := func(format string, args ...any) {
+		if why != nil {
+			*why = append(*why, fmt.Sprintf(format, args...))
+		}
+	}
+
+	var d pathDecision
+	// Editor and OS droppings never belong in a report, not even as structure
+	if cfg.SkipJunk {
+		if pattern := matchingPattern(rel, JunkPatterns); pattern != "" {
+			reason("Matches junk pattern %q, skipped while Skip Junk is on", pattern)
+			d.skip = true
+			return d
+		}
+	}
+	if pattern := matchingPattern(rel, cfg.ExcludePatterns); pattern != "" {
+		reason("Matches exclude pattern %q, on itself or a parent folder", pattern)
+		d.excluded = true
+		d.skip = !cfg.ShowExcluded
+		if d.skip {
+			return d
+		}
 	}
+	switch repoPolicy {
+	case NestedRepoSkip:
+		reason("Lies in nested repository %s, whose policy is skip", relativeTo(r.root, repo))
+		d.skip = true
+		return d
+	case NestedRepoStructure:
+		reason("Lies in nested repository %s, whose policy is structure", relativeTo(r.root, repo))
+	}
+	if d.excluded {
+		return d // Exclude patterns win over any selection, in either mode
+	}
+
+	selected := isPathSelected(path, r.root, r.selections)
+	if why != nil {
+		*why = append(*why, selectionReason(r.root, cfg, path, rel, selected))
+	}
+	d.keep = selected == cfg.IncludeMode
+	// Inside a structure-only repository, what would be exported is only listed
+	if d.keep && repoPolicy == NestedRepoStructure {
+		d.keep, d.listOnly = false, true
+	}
+	// Generated code, minified bundles and licenses may be listed or left out
+	if policy := cfg.GeneratedPolicyFor(); d.keep && !isDir && policy != NestedRepoFull {
+		if kind := DetectLowValue(path); kind != "" {
+			reason("Is %s, whose policy is %s", lowValueName(kind), policy)
+			if policy == NestedRepoSkip {
+				d.keep, d.skip = false, true
+				return d
+			}
+			d.keep, d.listOnly, d.note = false, true, kind
+		}
+	}
+	// So may tests, down to whole test folders
+	if policy := cfg.TestsPolicyFor(); d.keep && policy != NestedRepoFull && (IsTestPath(rel) || isDir && testDirNames[filepath.Base(path)]) {
+		reason("Is a test, whose policy is %s", policy)
+		if policy == NestedRepoSkip {
+			d.keep, d.skip = false, true
+			return d
+		}
+		d.keep, d.listOnly = false, true
+		if !isDir {
+			d.note = "test"
+		}
+	}
+
+	// The plan decides, per level around the selection, what neighbours contribute
+	if !d.keep {
+		d.context, d.isContext = r.context.rule(filepath.Dir(path))
+		d.isContext = d.isContext && d.context.Listing
+	}
+	return d
+}
+
+// matchingPattern returns the first of patterns matching relPath or one of
+// its parents, or "" if none does.
+func matchingPattern(relPath string, patterns []string) string {
+	for _, pattern := range patterns {
+		if isExcluded(relPath, []string{pattern}) {
+			return pattern
+		}
+	}
+	return ""
+}
+
+// walkAndProcess prints the structure tree to w, or, when collect is set,
+// hands every file selected for content to collect in walk order instead.
+func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, collect func(contentFile), absOutPath string, timings *Timings) error {
+	structOnly := collect == nil
+	defer timings.add(stageWalk, timings.now())
+
+	rules := newPathRules(root, cfg)
+	selectionMap, ctxPlan := rules.selections, rules.context
 
 	// Map for expanded folders (Always Show Structure)
 	expandedMap := make(map[string]bool, len(cfg.AlwaysShowStructure))
@@ -108,6 +515,8 @@ func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, structOnly b
 		expandedMap[p] = true
 	}
 
+	structureOnlyRepo := "" // Nested repository being walked under NestedRepoStructure
+
 	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return nil
@@ -116,6 +525,8 @@ func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, structOnly b
 			return nil
 		}
 
+		defer timings.add(stageVisit, timings.now())
+
 		relPath, _ := filepath.Rel(root, path)
 		if relPath == "." {
 			if structOnly {
@@ -126,37 +537,35 @@ func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, structOnly b
 			return nil
 		}
 
-		// Check exclusion early, BUT we must respect AlwaysShowStructure
-		// If the parent is expanded, we show it in structure even if it matches exclude pattern (optionally)
-		// For now, we stick to strict exclude unless ShowExcluded is on.
-		if isExcluded(relPath, cfg.ExcludePatterns) {
-			if cfg.ShowExcluded && structOnly {
-				// Continue to print, but mark as excluded
-			} else {
-				if d.IsDir() {
-					return filepath.SkipDir
-				}
-				return nil
+		// Nested repositories follow their own policy
+		if structureOnlyRepo != "" && !strings.HasPrefix(path, structureOnlyRepo+string(os.PathSeparator)) {
+			structureOnlyRepo = ""
+		}
+		repo, repoPolicy := structureOnlyRepo, NestedRepoStructure
+		if d.IsDir() && isNestedRepo(path) {
+			switch policy := cfg.NestedRepoPolicyFor(filepath.ToSlash(relPath)); policy {
+			case NestedRepoSkip:
+				repo, repoPolicy = path, policy
+			case NestedRepoStructure:
+				repo, structureOnlyRepo = path, path
 			}
 		}
-
-		// 1. Content Selection Logic (Manual + Include/Exclude Mode)
-		isSelected := isPathSelected(path, root, selectionMap)
-		shouldKeepContent := false
-		if cfg.IncludeMode {
-			shouldKeepContent = isSelected
-		} else {
-			shouldKeepContent = !isSelected
+		if repo == "" {
+			repoPolicy = ""
 		}
 
-		// 2. Context Logic
-		isContext := false
-		if !shouldKeepContent && cfg.ShowContext {
-			parent := filepath.Dir(path)
-			if isRelevantDirectory(parent, root, selectionMap) {
-				isContext = true
+		// Junk and exclude patterns, the selection and the policies decide
+		// what becomes of it, as ExplainPath tells; excluded paths are only
+		// walked to be listed under Show Excluded
+		decision := rules.decide(path, relPath, d.IsDir(), repo, repoPolicy, nil)
+		if decision.skip || decision.excluded && !structOnly {
+			if d.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
 		}
+		shouldKeepContent, listOnly, note := decision.keep, decision.listOnly, decision.note
+		isContext, ctxRule := decision.isContext, decision.context
 
 		// 3. Structure Visibility Logic (Expanded Folders)
 		// A file/folder is visible in structure if its parent is in the expanded list.
@@ -165,7 +574,7 @@ func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, structOnly b
 		parent := filepath.Dir(path)
 
 		// If the parent is in the list of "Always Show Structure" (Expanded folders), we show this node.
-		if expandedMap[parent] {
+		if expandedMap[parent] || listOnly {
 			isStructureVisible = true
 		}
 
@@ -173,30 +582,77 @@ func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, structOnly b
 		// If it's a directory and NOT in the map (collapsed), we still render the directory line itself
 		// if its parent is expanded.
 
+		// 4. Previous Exports
+		// Old reports sitting inside the tree would balloon the new one, so skip them.
+		// We only sniff files we would otherwise emit to keep the walk cheap.
+		if !d.IsDir() && (shouldKeepContent || isContext || isStructureVisible || cfg.ShowExcluded || cfg.FullTreeMap) {
+			if isPreviousReport(path, relPath, cfg.OutputGlobs) {
+				return nil
+			}
+		}
+
 		// --- DECISION TIME ---
 
 		// Case A: Printing Structure
 		if structOnly {
+			// Nothing below the depth limit is listed; its parent carries a summary
+			depth := strings.Count(relPath, string(os.PathSeparator))
+			if cfg.StructureMaxDepth > 0 && depth >= cfg.StructureMaxDepth {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+
 			// We print if:
 			// 1. It is selected for content
 			// 2. It is context
 			// 3. It is visible in the view (StructureVisible)
 			// 4. ShowExcluded is on (already handled partially above)
+			// 5. FullTreeMap is on (everything not excluded)
+			// 6. It is a folder on the way to a selection
+
+			// Folders leading to an export are listed so nested files keep their
+			// place in the tree: to a checked item in include mode, to an
+			// unchecked one inside a left-out folder in exclude mode
+			isAncestor := d.IsDir() && cfg.IncludeMode && isRelevantDirectory(path, root, selectionMap) ||
+				d.IsDir() && !cfg.IncludeMode && hasBelow(path, selectionMap, false)
+
+			if shouldKeepContent || isContext || isStructureVisible || isAncestor || cfg.ShowExcluded || cfg.FullTreeMap {
+				defer timings.add(stageWalkWrite, timings.now())
+
+				// A folder whose children won't be listed gets a roll-up instead
+				if d.IsDir() && childrenHidden(path, root, cfg, selectionMap, expandedMap, ctxPlan) {
+					note := rollUp(path, root, cfg).String()
+					if err := printTreeNode(w, relPath, true, shouldKeepContent, note); err != nil {
+						return err
+					}
+					summarized(w, relPath)
+					return filepath.SkipDir
+				}
 
-			if shouldKeepContent || isContext || isStructureVisible || cfg.ShowExcluded {
-				return printTreeNode(w, relPath, d.IsDir(), shouldKeepContent)
+				if err := printTreeNode(w, relPath, d.IsDir(), shouldKeepContent, note); err != nil {
+					return err
+				}
+				if d.IsDir() && cfg.StructureMaxDepth > 0 && depth+1 >= cfg.StructureMaxDepth {
+					summarized(w, relPath)
+					if r := rollUp(path, root, cfg); r.Files > 0 {
+						if err := printSummaryNode(w, depth+1, r); err != nil {
+							return err
+						}
+					}
+					return filepath.SkipDir
+				}
+				return nil
 			}
 		}
 
 		// Case B: Printing Content
 		if !structOnly && !d.IsDir() {
 			if shouldKeepContent {
-				meta.TotalFiles++
-				if err := printFileContent(w, path, relPath); err != nil {
-					if _, writeErr := fmt.Fprintf(w, "--- file: %s ---\n[Error reading file: %v]\n---\n\n", relPath, err); writeErr != nil {
-						return writeErr
-					}
-				}
+				collect(contentFile{Path: path, RelPath: relPath})
+			} else if ctxRule.Signatures {
+				collect(contentFile{Path: path, RelPath: relPath, SignaturesOnly: true})
 			}
 		}
 
@@ -205,7 +661,11 @@ func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, structOnly b
 		// However, we must be careful: if a child IS selected deep down, isRelevantDirectory handles that.
 		if d.IsDir() {
 			// If this folder is not relevant (no selected children), and not expanded, we can skip
-			if cfg.IncludeMode && !isRelevantDirectory(path, root, selectionMap) && !expandedMap[path] {
+			// The full tree map needs every folder during the structure pass
+			if structOnly && cfg.FullTreeMap {
+				return nil
+			}
+			if cfg.IncludeMode && !isRelevantDirectory(path, root, selectionMap) && !expandedMap[path] && !ctxPlan.coversDir(path) {
 				return filepath.SkipDir
 			}
 		}
@@ -214,56 +674,238 @@ func walkAndProcess(root string, cfg ExtractionConfig, w io.Writer, structOnly b
 	})
 }
 
+// matchIncludePatterns lists the files and folders under root matched by the
+// include patterns, skipping excluded and junk paths. A matched folder covers
+// everything below it.
+func matchIncludePatterns(root string, cfg ExtractionConfig) []string {
+	if len(cfg.IncludePatterns) == 0 {
+		return nil
+	}
+	var matches []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		relPath, _ := filepath.Rel(root, path)
+		skip := isExcluded(relPath, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(relPath, JunkPatterns))
+		// Same matching rules as exclude patterns
+		matched := !skip && isExcluded(relPath, cfg.IncludePatterns)
+		if matched {
+			matches = append(matches, path)
+		}
+		if d.IsDir() && (skip || matched) {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return matches
+}
+
+// listedFiles resolves an explicit file list against root, dropping
+// duplicates, folders, missing files and the output itself. The result is
+// sorted so the report reads like a tree walk.
+func listedFiles(root string, list []string, absOutPath string) []contentFile {
+	seen := make(map[string]bool)
+	var files []contentFile
+	for _, entry := range list {
+		path := filepath.FromSlash(entry)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		path = filepath.Clean(path)
+		if seen[path] || path == absOutPath {
+			continue
+		}
+		seen[path] = true
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		relPath := path // Outside the root: keep the full path as its label
+		if rel := relativeTo(root, path); rel != "" {
+			relPath = filepath.FromSlash(rel)
+		}
+		files = append(files, contentFile{Path: path, RelPath: relPath})
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return filepath.ToSlash(files[i].RelPath) < filepath.ToSlash(files[j].RelPath)
+	})
+	return files
+}
+
+// printFileList writes the structure section for an explicit file list:
+// the listed files and the folders leading to them.
+func printFileList(w io.Writer, root string, files []contentFile) error {
+	if _, err := fmt.Fprintln(w, filepath.Base(root)); err != nil {
+		return err
+	}
+	printed := make(map[string]bool)
+	for _, f := range files {
+		if filepath.IsAbs(f.RelPath) {
+			// Listed by name at the top, noting where it lives
+			if _, err := fmt.Fprintf(w, "├── %s (%s)\n", filepath.Base(f.RelPath), filepath.Dir(f.RelPath)); err != nil {
+				return err
+			}
+			listed(w, f.RelPath, false, true)
+			continue
+		}
+		parts := strings.Split(f.RelPath, string(os.PathSeparator))
+		for i := 1; i < len(parts); i++ {
+			dir := filepath.Join(parts[:i]...)
+			if !printed[dir] {
+				printed[dir] = true
+				if err := printTreeNode(w, dir, true, true, ""); err != nil {
+					return err
+				}
+			}
+		}
+		if err := printTreeNode(w, f.RelPath, false, true, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Helper functions (Reuse previous implementations)
 func isRelevantDirectory(currentPath, root string, selections map[string]bool) bool {
 	if isPathSelected(currentPath, root, selections) {
 		return true
 	}
-	prefix := currentPath + string(os.PathSeparator)
-	for sel := range selections {
-		if strings.HasPrefix(sel, prefix) {
+	return hasBelow(currentPath, selections, true)
+}
+
+// hasBelow reports whether selections holds a path inside dir that is
+// checked (or, for checked false, unchecked).
+func hasBelow(dir string, selections map[string]bool, checked bool) bool {
+	prefix := dir + string(os.PathSeparator)
+	for sel, c := range selections {
+		if c == checked && strings.HasPrefix(sel, prefix) {
 			return true
 		}
 	}
 	return false
 }
 
+// isPathSelected reports whether path is checked: by its own entry in
+// selections, or else by that of its nearest folder up to root. Paths are
+// compared clean, and a folder only counts when it is root or inside it.
 func isPathSelected(path, root string, selections map[string]bool) bool {
-	if selections[path] {
-		return true
+	path, root = filepath.Clean(path), filepath.Clean(root)
+	if checked, ok := selections[path]; ok {
+		return checked
 	}
-	current := filepath.Dir(path)
-	for strings.HasPrefix(current, root) {
-		if selections[current] {
-			return true
-		}
-		if current == root || current == "." || current == "/" {
-			break
-		}
+	for current := path; current != root && withinRoot(current, root); {
 		current = filepath.Dir(current)
+		if checked, ok := selections[current]; ok {
+			return checked
+		}
 	}
 	return false
 }
 
+// withinRoot reports whether the clean path is root or below it.
+func withinRoot(path, root string) bool {
+	if path == root {
+		return true
+	}
+	if root == "." {
+		return filepath.IsLocal(path)
+	}
+	prefix := root
+	if !strings.HasSuffix(prefix, string(os.PathSeparator)) {
+		prefix += string(os.PathSeparator)
+	}
+	return strings.HasPrefix(path, prefix)
+}
+
+// isExcluded reports whether relPath, or a folder it is in, matches one of
+// patterns. A pattern matches a path as a doublestar glob, as the path
+// itself or, without a slash, as the name of any of its folders or itself.
+// The path may use either separator and carry a trailing one.
 func isExcluded(relPath string, patterns []string) bool {
+	relPath = strings.Trim(path.Clean(filepath.ToSlash(relPath)), "/")
+	if relPath == "." || relPath == "" {
+		return false
+	}
 	for _, p := range patterns {
-		if matched, _ := doublestar.Match(p, relPath); matched {
-			return true
-		}
-		if strings.HasPrefix(relPath, p+"/") || relPath == p {
-			return true
+		p = strings.TrimSuffix(p, "/") // "build/" is the folder build
+		if p == "" {
+			continue
 		}
-		if !strings.Contains(p, "/") {
-			if matched, _ := doublestar.Match(p, filepath.Base(relPath)); matched {
+		for prefix := relPath; ; {
+			if prefix == p {
+				return true
+			}
+			if matched, _ := doublestar.Match(p, prefix); matched {
 				return true
 			}
+			if !strings.Contains(p, "/") {
+				if matched, _ := doublestar.Match(p, path.Base(prefix)); matched {
+					return true
+				}
+			}
+			i := strings.LastIndex(prefix, "/")
+			if i < 0 {
+				break
+			}
+			prefix = prefix[:i]
 		}
 	}
 	return false
 }
 
+// ReportHeaderLine is the first line of every report PandaBrew writes.
+// It is used to recognize our own outputs before overwriting them.
+const ReportHeaderLine = "--- Project Extraction Report ---"
+
+// ErrForeignOutput is returned when the output path holds a file that
+// was not produced by PandaBrew.
+var ErrForeignOutput = errors.New("output file exists and is not a PandaBrew report")
+
+// IsReportFile reports whether the file at path starts with the report
+// header, or is a JSON report.
+func IsReportFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	buf := make([]byte, len(jsonReportPrefix))
+	n, _ := io.ReadFull(f, buf)
+	head := string(buf[:n])
+	return strings.HasPrefix(head, ReportHeaderLine) || head == jsonReportPrefix
+}
+
+// isPreviousReport reports whether a file is an earlier PandaBrew export,
+// either because it matches one of the output globs or by its header.
+func isPreviousReport(path, relPath string, outputGlobs []string) bool {
+	if len(outputGlobs) > 0 && isExcluded(relPath, outputGlobs) {
+		return true
+	}
+	return IsReportFile(path)
+}
+
+// CheckOutputPath returns ErrForeignOutput if writing to path would
+// truncate a non-empty file that PandaBrew did not create.
+func CheckOutputPath(path string) error {
+	info, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("output path is a directory: %s", path)
+	}
+	if info.Size() == 0 || IsReportFile(path) {
+		return nil
+	}
+	return ErrForeignOutput
+}
+
 func writeHeader(w io.Writer, meta ReportMetadata) error {
-	if _, err := fmt.Fprintln(w, "--- Project Extraction Report ---"); err != nil {
+	if _, err := fmt.Fprintln(w, ReportHeaderLine); err != nil {
 		return err
 	}
 	if _, err := fmt.Fprintf(w, "Timestamp: %s\n", meta.Timestamp.Format(time.RFC3339)); err != nil {
@@ -272,6 +914,17 @@ func writeHeader(w io.Writer, meta ReportMetadata) error {
 	if _, err := fmt.Fprintf(w, "Selection Mode: %s\n", meta.SelectionMode); err != nil {
 		return err
 	}
+	if meta.Branch != "" {
+		if _, err := fmt.Fprintf(w, "Branch: %s\n", meta.Branch); err != nil {
+			return err
+		}
+	}
+	if err := writeLanguageStats(w, meta.Languages); err != nil {
+		return err
+	}
+	if err := writeVendoredNote(w, meta.Vendored); err != nil {
+		return err
+	}
 	if _, err := fmt.Fprintln(w, "---"); err != nil {
 		return err
 	}
@@ -281,7 +934,8 @@ func writeHeader(w io.Writer, meta ReportMetadata) error {
 	return nil
 }
 
-func printTreeNode(w io.Writer, relPath string, isDir, isSelected bool) error {
+// printTreeNode writes one structure line. note, if set, follows the name.
+func printTreeNode(w io.Writer, relPath string, isDir, isSelected bool, note string) error {
 	depth := strings.Count(relPath, string(os.PathSeparator))
 	indent := strings.Repeat("│   ", depth)
 	marker := ""
@@ -292,23 +946,90 @@ func printTreeNode(w io.Writer, relPath string, isDir, isSelected bool) error {
 	if isDir {
 		name += "/"
 	}
+	if note != "" {
+		marker += " " + note
+	}
+	listed(w, relPath, isDir, isSelected)
 	_, err := fmt.Fprintf(w, "%s├── %s%s\n", indent, name, marker)
 	return err
 }
 
-func printFileContent(w io.Writer, fullPath, relPath string) error {
-	content, err := os.ReadFile(fullPath)
-	if err != nil {
-		return err
+// printSummaryNode stands in for a subtree cut off by StructureMaxDepth.
+func printSummaryNode(w io.Writer, depth int, r subtreeTotals) error {
+	indent := strings.Repeat("│   ", depth)
+	_, err := fmt.Fprintf(w, "%s├── … %s\n", indent, r)
+	return err
+}
+
+// subtreeTotals summarizes the files under a folder that isn't listed.
+type subtreeTotals struct {
+	Files int
+	Bytes int64
+}
+
+func (r subtreeTotals) String() string {
+	tokens := int(r.Bytes / 4)
+	return fmt.Sprintf("(%d %s, ~%s %s)", r.Files, plural(r.Files, "file"), FormatTokens(tokens), plural(tokens, "token"))
+}
+
+// childrenHidden reports whether none of dir's children would be listed in
+// the structure section, so the walk can summarize it and move on.
+func childrenHidden(dir, root string, cfg ExtractionConfig, selections, expanded map[string]bool, ctxPlan *contextPlan) bool {
+	if cfg.ShowExcluded || cfg.FullTreeMap || expanded[dir] {
+		return false
+	}
+	if ctxPlan.coversDir(dir) {
+		return false
+	}
+	if cfg.IncludeMode {
+		return !isRelevantDirectory(dir, root, selections)
+	}
+	return isPathSelected(dir, root, selections) && !hasBelow(dir, selections, false)
+}
+
+// rollUp totals the files under dir that the walk would consider,
+// i.e. not matched by junk or (unless ShowExcluded is on) exclude patterns.
+func rollUp(dir, root string, cfg ExtractionConfig) subtreeTotals {
+	var r subtreeTotals
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return nil
+		}
+		relPath, _ := filepath.Rel(root, path)
+		skip := (cfg.SkipJunk && isExcluded(relPath, JunkPatterns)) ||
+			(!cfg.ShowExcluded && isExcluded(relPath, cfg.ExcludePatterns))
+		if skip {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.IsDir() {
+			r.Files++
+			if info, err := d.Info(); err == nil {
+				r.Bytes += info.Size()
+			}
+		}
+		return nil
+	})
+	return r
+}
+
+func printFileContent(w io.Writer, d delimiters, f contentFile, content io.Reader) error {
+	header := filepath.ToSlash(f.RelPath)
+	if f.Annotation != "" {
+		header += " (" + f.Annotation + ")"
+	}
+	if len(f.Fields) > 0 {
+		header += " " + formatHeaderFields(f.Fields)
 	}
-	displayPath := filepath.ToSlash(relPath)
-	if _, err := fmt.Fprintf(w, "--- file: %s ---\n", displayPath); err != nil {
+	if _, err := fmt.Fprintln(w, d.headerLine(header)); err != nil {
 		return err
 	}
-	if _, err := w.Write(content); err != nil {
+	if _, err := io.Copy(w, content); err != nil {
 		return err
 	}
-	if _, err := fmt.Fprintln(w, "\n---"); err != nil {
+	if _, err := fmt.Fprintf(w, "\n%s\n", d.footerLine(f.Fields)); err != nil {
 		return err
 	}
 	if _, err := fmt.Fprintln(w); err != nil {
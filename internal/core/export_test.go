@@ -0,0 +1,610 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Every export run by the tests checks that its structure and contents
+// sections agree.
+func init() { verifyAll = true }
+
+func setupTestDir(t testing.TB) string {
+	t.Helper()
+	root := t.TempDir()
+
+	files := map[string]string{
+		"src/main.go":               "package main",
+		"src/utils.go":              "package main",
+		"src/data.txt":              "some data",
+		"src/lib/helper.go":         "package lib",
+		"node_modules/pkg/index.js": "console.log",
+		"README.md":                 "# Readme",
+		".env":                      "SECRET=123",
+	}
+
+	for path, content := range files {
+		fullPath := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(fullPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return root
+}
+
+func TestExtractionScenarios(t *testing.T) {
+	root := setupTestDir(t)
+	outputDir := t.TempDir()
+
+	tests := []struct {
+		name            string
+		config          ExtractionConfig
+		wantFiles       int
+		wantContains    []string
+		wantNotContains []string
+	}{
+		{
+			name: "Include Mode - Recursive Folder",
+			config: ExtractionConfig{
+				IncludeMode: true,
+				ManualSelections: []string{
+					filepath.Join(root, "src"),
+				},
+			},
+			wantFiles:       4, // main.go, utils.go, data.txt, lib/helper.go
+			wantContains:    []string{"src/main.go", "src/utils.go", "Languages:", "Go    ", "3 files", "Text  "},
+			wantNotContains: []string{"README.md", "node_modules"},
+		},
+		{
+			name: "Include Mode - Include Patterns",
+			config: ExtractionConfig{
+				IncludeMode:     true,
+				IncludePatterns: []string{"*.go", "README.md"},
+				ExcludePatterns: []string{"src/lib"},
+			},
+			wantFiles:       3, // main.go, utils.go, README.md
+			wantContains:    []string{"--- file: src/main.go", "--- file: README.md"},
+			wantNotContains: []string{"data.txt", "helper.go"},
+		},
+		{
+			name: "Include Mode - Deselected Inside Folder",
+			config: ExtractionConfig{
+				IncludeMode:        true,
+				ManualSelections:   []string{filepath.Join(root, "src"), filepath.Join(root, "src", "lib", "helper.go")},
+				ManualDeselections: []string{filepath.Join(root, "src", "data.txt"), filepath.Join(root, "src", "lib")},
+			},
+			wantFiles:       3, // main.go, utils.go and the re-selected lib/helper.go
+			wantContains:    []string{"--- file: src/main.go", "--- file: src/lib/helper.go"},
+			wantNotContains: []string{"--- file: src/data.txt"},
+		},
+		{
+			name: "Exclude Mode - Deselected Inside Excluded Folder",
+			config: ExtractionConfig{
+				ManualSelections:   []string{filepath.Join(root, "src"), filepath.Join(root, "node_modules"), filepath.Join(root, ".env")},
+				ManualDeselections: []string{filepath.Join(root, "src", "main.go")},
+			},
+			wantFiles:       2, // README.md and src/main.go
+			wantContains:    []string{"--- file: src/main.go", "--- file: README.md"},
+			wantNotContains: []string{"--- file: src/utils.go"},
+		},
+		{
+			name: "Include Mode - Single File",
+			config: ExtractionConfig{
+				IncludeMode: true,
+				ManualSelections: []string{
+					filepath.Join(root, "README.md"),
+				},
+			},
+			wantFiles:       1,
+			wantContains:    []string{"README.md"},
+			wantNotContains: []string{"src/main.go"},
+		},
+		{
+			name: "View Structure Mode - Expanded Folders",
+			config: ExtractionConfig{
+				IncludeMode: true,
+				ManualSelections: []string{
+					filepath.Join(root, "src", "main.go"), // Only Content for main.go
+				},
+				AlwaysShowStructure: []string{
+					root,
+					filepath.Join(root, "src"), // src is expanded
+					// src/lib is NOT expanded
+				},
+			},
+			wantFiles: 1, // Only content for src/main.go
+			wantContains: []string{
+				"src/main.go",
+				// Adjusted expectations: The structure tree prints indented names, not full paths.
+				// utils.go is a sibling of main.go, inside src/. src/ is expanded.
+				"utils.go [EXCLUDED]",
+				// lib/ is a child of src/. src/ is expanded.
+				// It is collapsed, so it carries a roll-up of its contents.
+				"lib/ [EXCLUDED] (1 file, ~2 tokens)",
+			},
+			wantNotContains: []string{
+				"src/lib/helper.go", // Child of collapsed folder, should NOT be visible
+			},
+		},
+		{
+			name: "Select All Efficiency Check",
+			config: ExtractionConfig{
+				IncludeMode: true,
+				// Select Root to simulate Ctrl+A
+				ManualSelections: []string{
+					root,
+				},
+				// strictly exclude node_modules to match real app behavior
+				ExcludePatterns: []string{"node_modules"},
+			},
+			// Expect 6 files:
+			// 1. src/main.go
+			// 2. src/utils.go
+			// 3. src/data.txt
+			// 4. src/lib/helper.go
+			// 5. README.md
+			// 6. .env
+			// (node_modules is excluded)
+			wantFiles:       6,
+			wantContains:    []string{"src/main.go", "README.md", "src/lib/helper.go"},
+			wantNotContains: []string{"node_modules"},
+		},
+		{
+			name: "Full Tree Map",
+			config: ExtractionConfig{
+				IncludeMode:      true,
+				ManualSelections: []string{filepath.Join(root, "README.md")},
+				ExcludePatterns:  []string{"node_modules"},
+				FullTreeMap:      true,
+			},
+			wantFiles: 1, // Only README.md content
+			wantContains: []string{
+				"helper.go [EXCLUDED]", // Deep file listed in the structure
+				".env [EXCLUDED]",
+			},
+			wantNotContains: []string{"node_modules", "--- file: src/main.go"},
+		},
+		{
+			name: "Structure Depth Limit",
+			config: ExtractionConfig{
+				IncludeMode:       true,
+				ManualSelections:  []string{root},
+				ExcludePatterns:   []string{"node_modules"},
+				StructureMaxDepth: 1,
+			},
+			wantFiles:       6, // Contents are not affected by the depth limit
+			wantContains:    []string{"├── src/", "│   ├── … (4 files, ~"},
+			wantNotContains: []string{"├── main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			space := &DirectorySpace{
+				ID:             "test-space",
+				RootPath:       root,
+				OutputFilePath: filepath.Join(outputDir, "output_"+strings.ReplaceAll(tt.name, " ", "_")+".txt"),
+				Config:         tt.config,
+			}
+
+			meta, err := RunExtraction(space)
+			if err != nil {
+				t.Fatalf("Extraction failed: %v", err)
+			}
+
+			if !tt.config.FilenamesOnly && meta.TotalFiles != tt.wantFiles {
+				t.Errorf("File count: got %d, want %d", meta.TotalFiles, tt.wantFiles)
+			}
+
+			content, _ := os.ReadFile(space.OutputFilePath)
+			strContent := string(content)
+
+			for _, s := range tt.wantContains {
+				s = filepath.ToSlash(s)
+				if !strings.Contains(strContent, s) {
+					t.Errorf("Missing expected string: %s", s)
+				}
+			}
+
+			for _, s := range tt.wantNotContains {
+				s = filepath.ToSlash(s)
+				if strings.Contains(strContent, s) {
+					t.Errorf("Unexpected string found: %s", s)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckOutputPath(t *testing.T) {
+	root := setupTestDir(t)
+	dir := t.TempDir()
+
+	// Missing files are always safe to write
+	if err := CheckOutputPath(filepath.Join(dir, "missing.txt")); err != nil {
+		t.Errorf("missing file: got %v, want nil", err)
+	}
+
+	// A file with foreign content must be protected
+	foreign := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(foreign, []byte("my notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckOutputPath(foreign); err != ErrForeignOutput {
+		t.Errorf("foreign file: got %v, want ErrForeignOutput", err)
+	}
+
+	// A previous report may be overwritten freely
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: filepath.Join(dir, "report.txt"),
+		Config:         ExtractionConfig{IncludeMode: true, ManualSelections: []string{root}},
+	}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	if err := CheckOutputPath(space.OutputFilePath); err != nil {
+		t.Errorf("previous report: got %v, want nil", err)
+	}
+}
+
+func TestPreviousReportsAreSkipped(t *testing.T) {
+	root := setupTestDir(t)
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: filepath.Join(root, "old_export.txt"),
+		Config:         ExtractionConfig{IncludeMode: true, ManualSelections: []string{root}},
+	}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "bundle.ctx"), []byte("stale bundle"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second export elsewhere in the tree must not swallow the first one
+	space.OutputFilePath = filepath.Join(root, "new_export.txt")
+	space.Config.OutputGlobs = []string{"*.ctx"}
+	meta, err := RunExtraction(space)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TotalFiles != 7 { // Every fixture file, no excludes
+		t.Errorf("File count: got %d, want 7", meta.TotalFiles)
+	}
+	content, _ := os.ReadFile(space.OutputFilePath)
+	for _, name := range []string{"old_export.txt", "bundle.ctx"} {
+		if strings.Contains(string(content), name) {
+			t.Errorf("previous export %s was included", name)
+		}
+	}
+}
+
+func TestJunkIsSkipped(t *testing.T) {
+	root := setupTestDir(t)
+	for _, junk := range []string{".DS_Store", "src/.main.go.swp", ".idea/workspace.xml"} {
+		path := filepath.Join(root, junk)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("junk"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: filepath.Join(t.TempDir(), "out.txt"),
+		Config: ExtractionConfig{
+			IncludeMode:      true,
+			ManualSelections: []string{root},
+			ShowExcluded:     true,
+			SkipJunk:         true,
+		},
+	}
+	meta, err := RunExtraction(space)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TotalFiles != 7 {
+		t.Errorf("File count: got %d, want 7", meta.TotalFiles)
+	}
+	content, _ := os.ReadFile(space.OutputFilePath)
+	for _, name := range []string{".DS_Store", ".main.go.swp", ".idea"} {
+		if strings.Contains(string(content), name) {
+			t.Errorf("junk %s was included", name)
+		}
+	}
+}
+
+func TestExplicitFileList(t *testing.T) {
+	root := setupTestDir(t)
+	out := filepath.Join(t.TempDir(), "out.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: DefaultExtractionConfig()}
+
+	opts := DefaultExtractOptions()
+	opts.Files = []string{"src/lib/helper.go", "README.md", "missing.go", "src/lib/helper.go", "src"}
+	meta, err := RunExtractionWithOptions(space, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TotalFiles != 2 {
+		t.Errorf("got %d files, want 2", meta.TotalFiles)
+	}
+
+	data, _ := os.ReadFile(out)
+	report := string(data)
+	wantOrder := []string{"├── README.md", "├── src/", "│   ├── lib/", "│   │   ├── helper.go", "--- file: README.md", "--- file: src/lib/helper.go"}
+	pos := 0
+	for _, want := range wantOrder {
+		i := strings.Index(report[pos:], want)
+		if i < 0 {
+			t.Fatalf("missing %q in order:\n%s", want, report)
+		}
+		pos += i
+	}
+	if strings.Contains(report, "main.go") {
+		t.Error("files outside the list were exported")
+	}
+}
+
+func TestMultiRootExtraction(t *testing.T) {
+	base := t.TempDir()
+	var spaces []*DirectorySpace
+	for _, dir := range []string{"backend", filepath.Join("other", "backend")} {
+		root := filepath.Join(base, dir)
+		if err := os.MkdirAll(root, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		cfg := DefaultExtractionConfig()
+		cfg.IncludeMode = false
+		spaces = append(spaces, &DirectorySpace{RootPath: root, Config: cfg})
+	}
+
+	out := filepath.Join(base, "combined.txt")
+	meta, err := RunMultiRootExtraction(spaces, out, DefaultExtractOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TotalFiles != 2 {
+		t.Errorf("got %d files, want 2", meta.TotalFiles)
+	}
+
+	data, _ := os.ReadFile(out)
+	report := string(data)
+	for _, want := range []string{"## Root: backend\n", "## Root: backend-2\n", "--- file: backend/main.go", "--- file: backend-2/main.go"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("missing %q in report:\n%s", want, report)
+		}
+	}
+}
+
+func TestGlobSelectionListsParentFolders(t *testing.T) {
+	root := setupTestDir(t)
+	out := filepath.Join(t.TempDir(), "out.txt")
+	cfg := DefaultExtractionConfig()
+	cfg.IncludePatterns = []string{"src/**/*.go", "README.md"}
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: cfg}
+
+	meta, err := RunExtraction(space)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TotalFiles != 4 {
+		t.Errorf("got %d files, want 4", meta.TotalFiles)
+	}
+	data, _ := os.ReadFile(out)
+	report := string(data)
+	for _, want := range []string{"├── src/", "│   ├── lib/", "│   │   ├── helper.go"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("missing %q in report:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "data.txt") {
+		t.Error("unmatched file was exported")
+	}
+}
+
+func TestExtractionProgress(t *testing.T) {
+	root := setupTestDir(t)
+	space := &DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(t.TempDir(), "out.txt"), Config: DefaultExtractionConfig()}
+	space.Config.IncludeMode = false
+
+	var events []Progress
+	opts := DefaultExtractOptions()
+	opts.Progress = func(p Progress) { events = append(events, p) }
+	meta, err := RunExtractionWithOptions(space, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != meta.TotalFiles+1 {
+		t.Fatalf("got %d events for %d files", len(events), meta.TotalFiles)
+	}
+	if first := events[0]; first.Processed != 0 || first.Total != meta.TotalFiles || first.File != "" {
+		t.Errorf("first event = %+v", first)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Processed != i || events[i].File == "" || events[i].Tokens < events[i-1].Tokens {
+			t.Errorf("event %d = %+v after %+v", i, events[i], events[i-1])
+		}
+	}
+}
+
+func TestSelectionMatrix(t *testing.T) {
+	root := t.TempDir()
+	for _, path := range []string{"keep/a.go", "left/b.go", "left/deep/c.go", "gen/d.go"} {
+		full := filepath.Join(root, path)
+		if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, []byte("package x\n\nfunc F() {}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	leftOut := []string{filepath.Join(root, "left")}
+	picked := []string{filepath.Join(root, "keep", "a.go")}
+	radius1 := []ContextRule{{Listing: true}}
+	radius2 := []ContextRule{{Listing: true}, {Listing: true, Signatures: true}}
+
+	// What each probe ends up as: exported in full, its signatures only,
+	// listed in the structure, or absent
+	type want struct{ a, left, b, c, d string }
+	tests := []struct {
+		name     string
+		include  bool
+		context  bool
+		rules    []ContextRule
+		expanded []string
+		excluded bool // ShowExcluded
+		want     want
+	}{
+		{"include", true, false, nil, nil, false, want{"file", "", "", "", ""}},
+		{"include context", true, true, nil, nil, false, want{"file", "listed", "", "", ""}},
+		{"include context radius 1", true, true, radius1, nil, false, want{"file", "", "", "", ""}},
+		{"include context radius 2", true, true, radius2, nil, false, want{"file", "listed", "", "", ""}},
+		{"include structure", true, false, nil, []string{root}, false, want{"file", "listed", "", "", ""}},
+		{"include context structure", true, true, nil, []string{root}, false, want{"file", "listed", "", "", ""}},
+		{"include show excluded", true, false, nil, nil, true, want{"file", "listed", "listed", "listed", "listed"}},
+		{"exclude", false, false, nil, nil, false, want{"file", "", "", "", ""}},
+		{"exclude context", false, true, nil, nil, false, want{"file", "listed", "listed", "listed", ""}},
+		{"exclude context radius 1", false, true, radius1, nil, false, want{"file", "listed", "", "", ""}},
+		{"exclude context radius 2", false, true, radius2, nil, false, want{"file", "listed", "signatures", "", ""}},
+		{"exclude structure", false, false, nil, []string{root}, false, want{"file", "listed", "", "", ""}},
+		{"exclude structure nested", false, false, nil, []string{root, leftOut[0]}, false, want{"file", "listed", "listed", "", ""}},
+		{"exclude context structure", false, true, radius1, []string{root, leftOut[0]}, false, want{"file", "listed", "listed", "", ""}},
+		{"exclude show excluded", false, false, nil, nil, true, want{"file", "listed", "listed", "listed", "listed"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := ExtractionConfig{
+				IncludeMode:         tt.include,
+				ExcludePatterns:     []string{"gen"},
+				ShowContext:         tt.context,
+				ContextRules:        tt.rules,
+				AlwaysShowStructure: tt.expanded,
+				ShowExcluded:        tt.excluded,
+			}
+			if tt.include {
+				cfg.ManualSelections = picked
+			} else {
+				cfg.ManualSelections = leftOut
+			}
+			space := &DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(t.TempDir(), "out.txt"), Config: cfg}
+			if _, err := RunExtraction(space); err != nil {
+				t.Fatal(err)
+			}
+			data, _ := os.ReadFile(space.OutputFilePath)
+			content := string(data)
+			outcome := func(relPath string) string {
+				name := filepath.Base(relPath)
+				if !strings.Contains(relPath, ".") {
+					name += "/"
+				}
+				switch {
+				case strings.Contains(content, "--- file: "+relPath+" ---"):
+					return "file"
+				case strings.Contains(content, "--- signatures: "+relPath+" ---"):
+					return "signatures"
+				case strings.Contains(content, name+" [EXCLUDED]"):
+					return "listed"
+				}
+				return ""
+			}
+			got := want{outcome("keep/a.go"), outcome("left"), outcome("left/b.go"), outcome("left/deep/c.go"), outcome("gen/d.go")}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v\n%s", got, tt.want, content)
+			}
+		})
+	}
+}
+
+func FuzzIsExcluded(f *testing.F) {
+	for _, seed := range []struct{ path, pattern string }{
+		{"src/build/x.go", "build"},
+		{"build/", "build/"},
+		{"a.go/x.txt", "*.go"},
+		{`docs\api\index.md`, "docs/**"},
+		{"./node_modules//pkg", "node_modules"},
+		{"../outside/x", ".."},
+		{"データ/ファイル.txt", "データ"},
+		{"src/main.go", "src/*.go"},
+		{".env", ".*"},
+	} {
+		f.Add(seed.path, seed.pattern, "child")
+	}
+	f.Fuzz(func(t *testing.T, relPath, pattern, child string) {
+		patterns := []string{pattern}
+		excluded := isExcluded(relPath, patterns)
+		// Spelling the same path differently never changes the answer
+		for _, variant := range []string{relPath + "/", "./" + relPath, strings.ReplaceAll(relPath, "/", "//")} {
+			if filepath.ToSlash(relPath) == relPath && isExcluded(variant, patterns) != excluded {
+				t.Fatalf("isExcluded(%q) = %v but isExcluded(%q) = %v for %q", relPath, excluded, variant, !excluded, pattern)
+			}
+		}
+		// Whatever is inside an excluded folder is excluded too
+		if excluded && child != "" && !strings.ContainsAny(child, `/\`) && child != "." && child != ".." {
+			if !isExcluded(relPath+"/"+child, patterns) {
+				t.Fatalf("%q is excluded by %q but %q is not", relPath, pattern, relPath+"/"+child)
+			}
+		}
+	})
+}
+
+func FuzzIsPathSelected(f *testing.F) {
+	f.Add("/r", "src/main.go", true)
+	f.Add("/r/", "src/", false)
+	f.Add("/r", "../rx/y", true)
+	f.Add("/", "a/b", true)
+	f.Add("/proj", "ü/../ß.go", false)
+	f.Fuzz(func(t *testing.T, root, rel string, checked bool) {
+		if !filepath.IsAbs(root) {
+			return
+		}
+		path := filepath.Join(root, rel)
+		selections := map[string]bool{filepath.Clean(root): checked}
+		got := isPathSelected(path, root, selections)
+		inside := withinRoot(filepath.Clean(path), filepath.Clean(root))
+		if inside && got != checked {
+			t.Fatalf("isPathSelected(%q) under root %q checked %v = %v", path, root, checked, got)
+		}
+		if !inside && got {
+			t.Fatalf("isPathSelected(%q) outside root %q = true", path, root)
+		}
+		// A trailing separator or an unclean spelling is the same path
+		if unclean := root + string(os.PathSeparator) + rel + string(os.PathSeparator); isPathSelected(unclean, root+string(os.PathSeparator), selections) != got {
+			t.Fatalf("isPathSelected(%q) differs from isPathSelected(%q)", unclean, path)
+		}
+	})
+}
+
+func TestDryRun(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	output := filepath.Join(t.TempDir(), "reports", "out.txt")
+	cfg := DefaultExtractionConfig()
+	cfg.ManualSelections = []string{root}
+	cfg.Formats = []string{FormatText, FormatJSON}
+	space := &DirectorySpace{RootPath: root, OutputFilePath: output, Config: cfg}
+
+	meta, err := RunExtractionWithOptions(space, ExtractOptions{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TotalFiles != 1 || meta.TotalTokens == 0 {
+		t.Errorf("meta = %+v", meta)
+	}
+	if _, err := os.Stat(filepath.Dir(output)); !os.IsNotExist(err) {
+		t.Errorf("dry run created the output folder: %v", err)
+	}
+}
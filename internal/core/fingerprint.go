@@ -0,0 +1,52 @@
+// Package core implements change detection between exports.
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Fingerprint summarizes everything an export of space depends on: its
+// settings, the structure section and the size and modification time of
+// every file whose contents it would include. Equal fingerprints mean a
+// re-export would only differ in its timestamp. No file contents are read.
+func Fingerprint(space *DirectorySpace, opts ExtractOptions) (string, error) {
+	absOutPath, _ := filepath.Abs(space.OutputFilePath)
+	plan, err := planExtraction(space, opts, absOutPath)
+	if err != nil {
+		return "", err
+	}
+
+	cfg := space.Config
+	cfg.SelectionHashes = nil // Bookkeeping updated by every export
+	h := sha256.New()
+	settings, err := json.Marshal(struct {
+		Root        string
+		Output      string
+		Config      ExtractionConfig
+		Files       []string
+		MaxFileSize int64
+	}{space.RootPath, absOutPath, cfg, opts.Files, opts.MaxFileSize})
+	if err != nil {
+		return "", err
+	}
+	h.Write(settings)
+	if err := plan.writeStructure(h); err != nil {
+		return "", err
+	}
+	for _, files := range [][]contentFile{plan.files, plan.sigFiles} {
+		for _, f := range files {
+			info, err := os.Stat(f.Path)
+			if err != nil {
+				fmt.Fprintf(h, "%s missing\n", f.RelPath)
+				continue
+			}
+			fmt.Fprintf(h, "%s %d %d\n", f.RelPath, info.Size(), info.ModTime().UnixNano())
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
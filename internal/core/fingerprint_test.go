@@ -0,0 +1,48 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	root := setupTestDir(t)
+	space := &DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(t.TempDir(), "out.txt"), Config: DefaultExtractionConfig()}
+	space.Config.ManualSelections = []string{filepath.Join(root, "src")}
+	opts := DefaultExtractOptions()
+
+	fingerprint := func() string {
+		t.Helper()
+		fp, err := Fingerprint(space, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return fp
+	}
+	base := fingerprint()
+	if fingerprint() != base {
+		t.Fatal("fingerprint is not stable")
+	}
+
+	// Unselected files don't matter
+	if err := os.WriteFile(filepath.Join(root, "README.md"), []byte("# Changed readme"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if fingerprint() != base {
+		t.Error("changing an unselected file changed the fingerprint")
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main // edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	edited := fingerprint()
+	if edited == base {
+		t.Error("editing a selected file kept the fingerprint")
+	}
+
+	space.Config.MinifyContent = true
+	if fingerprint() == edited {
+		t.Error("changing a setting kept the fingerprint")
+	}
+}
@@ -0,0 +1,283 @@
+// Package core implements ranking the files of a project by how likely they
+// are to answer a question, to propose a selection for it.
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+const (
+	// DefaultFocusLimit is how many files RankFiles proposes by default.
+	DefaultFocusLimit = 15
+	// focusMaxFileSize is the largest file whose contents are scored;
+	// larger ones are ranked by name only.
+	focusMaxFileSize = 256 << 10
+	// focusEmbedHead is how much of each file is sent to an embedder.
+	focusEmbedHead = 2 << 10
+	// focusEmbedPool is how many times the limit of top candidates an
+	// embedder reranks.
+	focusEmbedPool = 3
+)
+
+// focusStopwords are the words of a question that say nothing about where
+// the answer is.
+var focusStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "was": true, "were": true, "how": true,
+	"does": true, "did": true, "what": true, "when": true, "where": true, "which": true, "who": true,
+	"why": true, "this": true, "that": true, "these": true, "those": true, "with": true, "from": true,
+	"into": true, "work": true, "works": true, "handle": true, "handled": true, "used": true,
+	"use": true, "can": true, "should": true, "there": true, "their": true, "about": true,
+	"have": true, "has": true, "get": true, "gets": true, "happen": true, "happens": true,
+	"code": true, "file": true, "files": true, "implemented": true, "implement": true,
+}
+
+// focusSuffixes are stripped from question words, longest first, so
+// "caching" also finds "cache" and "sessions" finds "session".
+var focusSuffixes = []string{"ication", "ation", "ments", "ment", "ing", "ies", "ed", "es", "s"}
+
+// FocusCandidate is a file proposed for a question.
+type FocusCandidate struct {
+	Path  string
+	Score float64
+	Terms []string // Question terms found in its path or contents
+	head  string   // Start of its contents, for an embedder
+}
+
+// Embedder returns one vector per text. RankFiles embeds the question
+// first, then the candidates.
+type Embedder func(ctx context.Context, texts []string) ([][]float64, error)
+
+// FocusOptions tune RankFiles.
+type FocusOptions struct {
+	Limit int      // Files proposed; 0 means DefaultFocusLimit
+	Embed Embedder // Reranks the best candidates by similarity, if set
+}
+
+// FocusTerms splits question into the lowercase terms RankFiles looks for:
+// its words of three or more letters, minus stopwords and common suffixes.
+func FocusTerms(question string) []string {
+	var terms []string
+	words := strings.FieldsFunc(strings.ToLower(question), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	for _, w := range words {
+		if len(w) < 3 || focusStopwords[w] {
+			continue
+		}
+		for _, suffix := range focusSuffixes {
+			if stem := strings.TrimSuffix(w, suffix); stem != w && len(stem) >= 4 {
+				w = stem
+				break
+			}
+		}
+		if !slices.Contains(terms, w) {
+			terms = append(terms, w)
+		}
+	}
+	return terms
+}
+
+// RankFiles scores the files listed under root for question and returns the
+// best ones, highest first. A term counts most in a file name, less in a
+// folder name, and in the contents by how often it occurs, weighted by how
+// few files contain it. Files matching more of the terms rank higher.
+func RankFiles(ctx context.Context, root string, cfg ExtractionConfig, question string, opts FocusOptions) ([]FocusCandidate, error) {
+	terms := FocusTerms(question)
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("no search terms in %q", question)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultFocusLimit
+	}
+
+	type scored struct {
+		FocusCandidate
+		name   float64
+		counts []int
+	}
+	var files []scored
+	docFreq := make([]int, len(terms))
+	err := walkIndexed(ctx, root, root, cfg, func(path string, entry IndexEntry) {
+		rel, _ := filepath.Rel(root, path)
+		f := scored{FocusCandidate: FocusCandidate{Path: path}, counts: make([]int, len(terms))}
+		f.name = focusNameScore(strings.ToLower(filepath.ToSlash(rel)), terms)
+
+		if entry.Size <= focusMaxFileSize {
+			if data, err := os.ReadFile(path); err == nil && bytes.IndexByte(data[:min(len(data), 8000)], 0) < 0 {
+				lower := bytes.ToLower(data)
+				for i, term := range terms {
+					if f.counts[i] = bytes.Count(lower, []byte(term)); f.counts[i] > 0 {
+						docFreq[i]++
+					}
+				}
+				if opts.Embed != nil {
+					f.head = embedHead(path, data, cfg)
+				}
+			}
+		}
+		files = append(files, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var ranked []FocusCandidate
+	for _, f := range files {
+		content, matched := 0.0, 0
+		rel, _ := filepath.Rel(root, f.Path)
+		lowerRel := strings.ToLower(rel)
+		for i, term := range terms {
+			inName := strings.Contains(lowerRel, term) || len(term) >= 6 && strings.Contains(lowerRel, term[:4])
+			if f.counts[i] > 0 {
+				idf := math.Log(1 + float64(len(files))/float64(1+docFreq[i]))
+				content += idf * (1 + math.Log(float64(f.counts[i])))
+			}
+			if f.counts[i] > 0 || inName {
+				matched++
+				f.Terms = append(f.Terms, term)
+			}
+		}
+		if matched == 0 && f.name == 0 {
+			continue
+		}
+		coverage := float64(max(matched, 1)) / float64(len(terms))
+		f.Score = (f.name + content) * coverage
+		ranked = append(ranked, f.FocusCandidate)
+	}
+	sortCandidates(ranked)
+
+	if opts.Embed != nil && len(ranked) > 0 {
+		pool := ranked[:min(len(ranked), limit*focusEmbedPool)]
+		if err := rerankByEmbedding(ctx, root, question, pool, opts.Embed); err != nil {
+			return nil, err
+		}
+	}
+	return ranked[:min(len(ranked), limit)], nil
+}
+
+// focusNameScore scores the terms found in relPath: most in the file name,
+// less in a folder name. A long term also matches by its first four letters,
+// as "authentication" does auth.go, for less.
+func focusNameScore(relPath string, terms []string) float64 {
+	dir, base := filepath.Split(relPath)
+	score := 0.0
+	for _, term := range terms {
+		switch {
+		case strings.Contains(base, term):
+			score += 3
+		case strings.Contains(dir, term):
+			score += 1.5
+		case len(term) >= 6 && strings.Contains(base, term[:4]):
+			score += 1
+		case len(term) >= 6 && strings.Contains(dir, term[:4]):
+			score += 0.5
+		}
+	}
+	return score
+}
+
+// embedHead is the start of a file as an embedder sees it: as the report
+// would show it, so values that cfg masks are masked before they leave.
+func embedHead(path string, data []byte, cfg ExtractionConfig) string {
+	text := string(data)
+	if isMaskedConfig(path, cfg) {
+		text = configMasker(path)(text)
+	}
+	return text[:min(len(text), focusEmbedHead)]
+}
+
+// rerankByEmbedding reorders candidates by the similarity of their paths
+// and first lines to question, blended evenly with their keyword scores.
+func rerankByEmbedding(ctx context.Context, root, question string, candidates []FocusCandidate, embed Embedder) error {
+	texts := []string{question}
+	for _, c := range candidates {
+		rel, _ := filepath.Rel(root, c.Path)
+		texts = append(texts, filepath.ToSlash(rel)+"\n"+c.head)
+	}
+	vectors, err := embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("embedding: %w", err)
+	}
+	if len(vectors) != len(texts) {
+		return fmt.Errorf("embedding: got %d vectors for %d texts", len(vectors), len(texts))
+	}
+	best := candidates[0].Score
+	for i := range candidates {
+		similarity := cosine(vectors[0], vectors[i+1])
+		candidates[i].Score = candidates[i].Score/best/2 + max(similarity, 0)/2
+	}
+	sortCandidates(candidates)
+	return nil
+}
+
+func sortCandidates(candidates []FocusCandidate) {
+	slices.SortStableFunc(candidates, func(a, b FocusCandidate) int {
+		if a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a.Path, b.Path)
+	})
+}
+
+// cosine is the cosine similarity of a and b, 0 if either is empty or their
+// lengths differ.
+func cosine(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(na*nb)
+}
+
+// CommandEmbedder embeds texts with an external command, which reads a JSON
+// array of strings on stdin and prints a JSON array of as many vectors.
+func CommandEmbedder(command string) Embedder {
+	return func(ctx context.Context, texts []string) ([][]float64, error) {
+		args := strings.Fields(command)
+		if len(args) == 0 {
+			return nil, fmt.Errorf("no embedding command")
+		}
+		input, err := json.Marshal(texts)
+		if err != nil {
+			return nil, err
+		}
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("%s: %w: %s", args[0], err, msg)
+			}
+			return nil, fmt.Errorf("%s: %w", args[0], err)
+		}
+		var vectors [][]float64
+		if err := json.Unmarshal(out, &vectors); err != nil {
+			return nil, fmt.Errorf("%s: expected a JSON array of vectors: %w", args[0], err)
+		}
+		return vectors, nil
+	}
+}
@@ -0,0 +1,93 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRankFiles(t *testing.T) {
+	if got, want := FocusTerms("How does the Session caching work?"), []string{"session", "cach"}; !slices.Equal(got, want) {
+		t.Errorf("FocusTerms = %v, want %v", got, want)
+	}
+
+	root := t.TempDir()
+	files := map[string]string{
+		"internal/auth/login.go":  "package auth\n\n// Login checks a password and issues a session token.\nfunc Login() {}\n",
+		"internal/auth/token.go":  "package auth\n\n// Token signs a session token.\nfunc Token() {}\n",
+		"web/routes.go":           "package web\n\n// Routes wires the session token middleware.\n",
+		"docs/readme.md":          "# Docs\n",
+		"node_modules/x/token.js": "token token token session",
+	}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := DefaultExtractionConfig()
+
+	got, err := RankFiles(context.Background(), root, cfg, "where is the session token signed?", FocusOptions{Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, c := range got {
+		rel, _ := filepath.Rel(root, c.Path)
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	if want := []string{"internal/auth/token.go", "internal/auth/login.go"}; !slices.Equal(paths, want) {
+		t.Errorf("ranked %v, want %v", paths, want)
+	}
+	if !slices.Equal(got[0].Terms, []string{"session", "token", "sign"}) {
+		t.Errorf("terms = %v", got[0].Terms)
+	}
+
+	// An embedder reorders the best candidates by similarity to the question
+	embed := func(_ context.Context, texts []string) ([][]float64, error) {
+		vectors := [][]float64{{1, 0}}
+		for _, text := range texts[1:] {
+			if strings.HasPrefix(text, "web/") {
+				vectors = append(vectors, []float64{1, 0})
+			} else {
+				vectors = append(vectors, []float64{0, 1})
+			}
+		}
+		return vectors, nil
+	}
+	got, err = RankFiles(context.Background(), root, cfg, "session token", FocusOptions{Limit: 1, Embed: embed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 || filepath.Base(got[0].Path) != "routes.go" {
+		t.Errorf("reranked = %+v", got)
+	}
+
+	// Masked configuration values never reach the embedder
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("SESSION_TOKEN=hunter2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var sent []string
+	spy := func(_ context.Context, texts []string) ([][]float64, error) {
+		sent = append(sent, texts...)
+		return make([][]float64, len(texts)), nil
+	}
+	masked := cfg
+	masked.MaskConfigValues = true
+	if _, err := RankFiles(context.Background(), root, masked, "session token", FocusOptions{Embed: spy}); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(sent, ".env\nSESSION_TOKEN=***\n") || strings.Contains(strings.Join(sent, "\n"), "hunter2") {
+		t.Errorf("embedded %q", sent)
+	}
+
+	if _, err := RankFiles(context.Background(), root, cfg, "how does it work?", FocusOptions{}); err == nil {
+		t.Error("question without terms: no error")
+	}
+}
@@ -0,0 +1,485 @@
+// Package core implements writing a report in several formats from one walk.
+package core
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Report formats accepted by ExtractOptions.Formats.
+const (
+	FormatText     = "txt"
+	FormatMarkdown = "markdown"
+	FormatJSON     = "json"
+)
+
+// ReportFormats lists the supported formats in the order they are written.
+var ReportFormats = []string{FormatText, FormatMarkdown, FormatJSON}
+
+// formatAliases maps alternative spellings to a format name.
+var formatAliases = map[string]string{"text": FormatText, "md": FormatMarkdown}
+
+// formatExt is the file extension each format is written with.
+var formatExt = map[string]string{FormatText: ".txt", FormatMarkdown: ".md", FormatJSON: ".json"}
+
+// jsonReportPrefix starts every JSON report, so it is recognized like the
+// text and Markdown ones, which begin with ReportHeaderLine.
+const jsonReportPrefix = "{\n  \"header\": \"" + ReportHeaderLine + "\""
+
+// ParseFormats reads a comma-separated list such as "txt,markdown,json".
+// Duplicates are dropped and the result follows ReportFormats order.
+func ParseFormats(list string) ([]string, error) {
+	return parseNameList(list, ReportFormats, formatAliases)
+}
+
+// OutputFormats returns the formats an export with opts writes: the ones
+// in opts, or else those saved in cfg.
+func OutputFormats(opts ExtractOptions, cfg ExtractionConfig) []string {
+	if len(opts.Formats) > 0 {
+		return opts.Formats
+	}
+	return cfg.Formats
+}
+
+// FormatOutputPath is where the report in format goes: output with its
+// extension replaced by the one of the format.
+func FormatOutputPath(output, format string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + formatExt[format]
+}
+
+// FormatOutputPaths lists the files an export to output writes. Without
+// formats that is output itself.
+func FormatOutputPaths(output string, formats []string) []string {
+	if len(formats) == 0 {
+		return []string{output}
+	}
+	paths := make([]string, len(formats))
+	for i, f := range formats {
+		paths[i] = FormatOutputPath(output, f)
+	}
+	return paths
+}
+
+// textReportPath is where the text report is written when several formats
+// are requested: its own output path, or a temporary file the other
+// formats are converted from. temp reports which one it is.
+func textReportPath(output string, formats []string) (path string, temp bool, err error) {
+	if slices.Contains(formats, FormatText) {
+		return FormatOutputPath(output, FormatText), false, nil
+	}
+	f, err := os.CreateTemp("", "pandabrew-report-*.txt")
+	if err != nil {
+		return "", false, err
+	}
+	return f.Name(), true, f.Close()
+}
+
+// spanKind tells what a recorded part of the text report holds.
+type spanKind int
+
+const (
+	spanRoot spanKind = iota
+	spanRecent
+	spanStructure
+	spanFile
+	spanSignatures
+)
+
+// reportSpan locates one part of the text report by byte offsets.
+type reportSpan struct {
+	kind       spanKind
+	name       string // Root label, or the path of a file
+	annotation string
+	fields     []headerField
+	footer     string // Closing line of a file, if not the default
+	start, end int64
+}
+
+// spanRecorder notes where each part of the text report was written, so
+// the other formats can be cut from it instead of walking the tree again.
+type spanRecorder struct {
+	w     *TokenCountingWriter
+	spans []reportSpan
+}
+
+// mark runs write and records the bytes it wrote as span. A nil recorder
+// just runs write.
+func (r *spanRecorder) mark(span reportSpan, write func() error) error {
+	if r == nil {
+		return write()
+	}
+	span.start = r.w.BytesWritten
+	err := write()
+	span.end = r.w.BytesWritten
+	r.spans = append(r.spans, span)
+	return err
+}
+
+// writeFormats converts the text report at textPath into the other formats
+// requested, next to output.
+func writeFormats(textPath, output string, formats []string, mode fs.FileMode, meta ReportMetadata, spans []reportSpan) error {
+	text, err := os.ReadFile(textPath)
+	if err != nil {
+		return err
+	}
+	report := parseSpans(text, spans)
+	for _, format := range formats {
+		var write func(io.Writer, ReportMetadata, *parsedReport) error
+		switch format {
+		case FormatMarkdown:
+			write = writeMarkdown
+		case FormatJSON:
+			write = writeJSON
+		default:
+			continue
+		}
+		if err := writeFormatFile(FormatOutputPath(output, format), mode, meta, report, write); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeFormatFile(path string, mode fs.FileMode, meta ReportMetadata, report *parsedReport, write func(io.Writer, ReportMetadata, *parsedReport) error) (err error) {
+	f, err := createReport(path, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	return write(f, meta, report)
+}
+
+// parsedReport is the text report split into its parts.
+type parsedReport struct {
+	Roots []*reportRoot
+}
+
+type reportRoot struct {
+	Label, Path, Branch string
+	RecentChanges       []string
+	Structure           string
+	Files               []reportFile
+	Signatures          []reportFile
+}
+
+type reportFile struct {
+	Path       string
+	Annotation string
+	Fields     []headerField
+	Content    string
+	Note       string // Trailing remark such as the truncation notice
+}
+
+// parseSpans cuts the recorded spans out of text. Contents are framed by a
+// header line and a footer line; the last footer of a span is the closing
+// one even when the content itself has such lines.
+func parseSpans(text []byte, spans []reportSpan) *parsedReport {
+	report := &parsedReport{}
+	root := func() *reportRoot {
+		if len(report.Roots) == 0 {
+			report.Roots = append(report.Roots, &reportRoot{})
+		}
+		return report.Roots[len(report.Roots)-1]
+	}
+	for _, s := range spans {
+		body := string(text[s.start:s.end])
+		switch s.kind {
+		case spanRoot:
+			r := &reportRoot{Label: s.name}
+			for _, line := range strings.Split(body, "\n") {
+				if v, ok := strings.CutPrefix(line, "Path: "); ok {
+					r.Path = v
+				} else if v, ok := strings.CutPrefix(line, "Branch: "); ok {
+					r.Branch = v
+				}
+			}
+			report.Roots = append(report.Roots, r)
+		case spanRecent:
+			log := strings.TrimSpace(strings.TrimPrefix(body, "### Recent Changes\n"))
+			root().RecentChanges = strings.Split(log, "\n")
+		case spanStructure:
+			root().Structure = body
+		case spanFile, spanSignatures:
+			f := reportFile{Path: filepath.ToSlash(s.name), Annotation: s.annotation, Fields: s.fields}
+			_, body, _ = strings.Cut(body, "\n") // Header line
+			footer := "\n" + cmp.Or(s.footer, DefaultFileFooter) + "\n"
+			if i := strings.LastIndex(body, footer); i >= 0 {
+				f.Content = body[:i]
+				f.Note = strings.TrimSpace(body[i+len(footer):])
+			}
+			if s.kind == spanFile {
+				root().Files = append(root().Files, f)
+			} else {
+				root().Signatures = append(root().Signatures, f)
+			}
+		}
+	}
+	return report
+}
+
+// writeMarkdown renders the report with headings and fenced code blocks.
+// It starts with ReportHeaderLine like the text report.
+func writeMarkdown(w io.Writer, meta ReportMetadata, report *parsedReport) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", ReportHeaderLine)
+	fmt.Fprintf(&b, "- Timestamp: %s\n", meta.Timestamp.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- Selection Mode: %s\n", meta.SelectionMode)
+	if meta.Branch != "" {
+		fmt.Fprintf(&b, "- Branch: %s\n", meta.Branch)
+	}
+	if len(meta.Languages) > 0 {
+		langs := make([]string, len(meta.Languages))
+		for i, l := range meta.Languages {
+			langs[i] = fmt.Sprintf("%s (%d %s, ~%s %s)", l.Language, l.Files, plural(l.Files, "file"), FormatTokens(l.Tokens), plural(l.Tokens, "token"))
+		}
+		fmt.Fprintf(&b, "- Languages: %s\n", strings.Join(langs, ", "))
+	}
+	if len(meta.Vendored) > 0 {
+		b.WriteString("\n> **Note:** Vendored code is included; consider structure-only listing or signatures instead:\n")
+		for _, d := range meta.Vendored {
+			fmt.Fprintf(&b, "> - %s (%d files, %s)\n", d.Path, d.Files, d.Reason)
+		}
+	}
+	b.WriteString("\n")
+	if meta.Task != nil {
+		fmt.Fprintf(&b, "### Task: %s\n\n%s\n\n", meta.Task.Title, meta.Task.Instructions)
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return err
+	}
+
+	for _, root := range report.Roots {
+		b.Reset()
+		if root.Label != "" {
+			fmt.Fprintf(&b, "## Root: %s\n\n- Path: %s\n", root.Label, root.Path)
+			if root.Branch != "" {
+				fmt.Fprintf(&b, "- Branch: %s\n", root.Branch)
+			}
+			b.WriteString("\n")
+		}
+		if len(root.RecentChanges) > 0 {
+			b.WriteString("### Recent Changes\n\n")
+			for _, c := range root.RecentChanges {
+				fmt.Fprintf(&b, "- %s\n", c)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("### Project Structure\n\n")
+		writeFence(&b, root.Structure, "text")
+		if len(root.Files) > 0 {
+			b.WriteString("### File Contents\n\n")
+			writeMarkdownFiles(&b, root.Files)
+		}
+		if len(root.Signatures) > 0 {
+			b.WriteString("### Context Signatures\n\n")
+			writeMarkdownFiles(&b, root.Signatures)
+		}
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return err
+		}
+	}
+	b.Reset()
+	if meta.Task != nil {
+		fmt.Fprintf(&b, "### Response Format\n\n%s\n\n", meta.Task.Response)
+	}
+	if meta.Breakdown != nil {
+		b.WriteString("### Token Breakdown\n\n| Section | Tokens |\n| --- | ---: |\n")
+		for _, r := range meta.Breakdown.rows() {
+			label := r.label
+			if folder, ok := strings.CutPrefix(label, "  "); ok {
+				label = "&nbsp;&nbsp;`" + folder + "`"
+			}
+			fmt.Fprintf(&b, "| %s | ~%s |\n", label, FormatTokens(r.tokens))
+		}
+		fmt.Fprintf(&b, "| **Total** | ~%s |\n", FormatTokens(meta.Breakdown.Total()))
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownFiles(b *strings.Builder, files []reportFile) {
+	for _, f := range files {
+		fmt.Fprintf(b, "#### `%s`", f.Path)
+		if f.Annotation != "" {
+			fmt.Fprintf(b, " (%s)", f.Annotation)
+		}
+		if len(f.Fields) > 0 {
+			fmt.Fprintf(b, " %s", formatHeaderFields(f.Fields))
+		}
+		b.WriteString("\n\n")
+		writeFence(b, f.Content, fenceLanguage(f.Path))
+		if f.Note != "" {
+			fmt.Fprintf(b, "%s\n\n", f.Note)
+		}
+	}
+}
+
+// writeFence writes body as a code block whose fence is longer than any
+// run of backticks inside it.
+func writeFence(b *strings.Builder, body, lang string) {
+	longest, run := 0, 0
+	for _, r := range body {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	fmt.Fprintf(b, "%s%s\n%s", fence, lang, body)
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(b, "%s\n\n", fence)
+}
+
+// fenceTags maps display languages whose code block tag is not simply
+// their lower-cased name.
+var fenceTags = map[string]string{
+	"C++": "cpp", "C#": "csharp", "Shell": "sh", "reStructuredText": "rst",
+	"Go Module": "", "Text": "", "Other": "",
+}
+
+// fenceLanguage is the code block tag for path, or "" when unknown.
+func fenceLanguage(path string) string {
+	lang := LanguageOf(path)
+	if tag, ok := fenceTags[lang]; ok {
+		return tag
+	}
+	return strings.ToLower(lang)
+}
+
+// jsonReport is the layout of a JSON report.
+type jsonReport struct {
+	Header        string         `json:"header"` // Always ReportHeaderLine
+	Timestamp     time.Time      `json:"timestamp"`
+	SelectionMode string         `json:"selection_mode"`
+	Branch        string         `json:"branch,omitempty"`
+	TotalFiles    int            `json:"total_files"`
+	TotalTokens   int            `json:"total_tokens"` // Estimated for the text report
+	Languages     []jsonLanguage `json:"languages,omitempty"`
+	Vendored      []jsonVendored `json:"vendored,omitempty"`
+	Task          *jsonTask      `json:"task,omitempty"`
+	Breakdown     *jsonBreakdown `json:"token_breakdown,omitempty"`
+	Roots         []jsonRoot     `json:"roots"`
+}
+
+type jsonTask struct {
+	Name           string `json:"name"`
+	Title          string `json:"title"`
+	Instructions   string `json:"instructions"`
+	ResponseFormat string `json:"response_format"`
+}
+
+type jsonBreakdown struct {
+	Header     int                `json:"header"`
+	Recent     int                `json:"recent_changes,omitempty"`
+	Structure  int                `json:"structure"`
+	Contents   int                `json:"contents"`
+	Signatures int                `json:"signatures,omitempty"`
+	Folders    []jsonFolderTokens `json:"folders,omitempty"`
+}
+
+type jsonFolderTokens struct {
+	Path   string `json:"path"`
+	Tokens int    `json:"tokens"`
+}
+
+type jsonLanguage struct {
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+	Tokens   int    `json:"tokens"`
+}
+
+type jsonVendored struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	Files  int    `json:"files"`
+}
+
+type jsonRoot struct {
+	Label         string     `json:"label,omitempty"`
+	Path          string     `json:"path,omitempty"`
+	Branch        string     `json:"branch,omitempty"`
+	RecentChanges []string   `json:"recent_changes,omitempty"`
+	Structure     string     `json:"structure"`
+	Files         []jsonFile `json:"files"`
+	Signatures    []jsonFile `json:"signatures,omitempty"`
+}
+
+type jsonFile struct {
+	Path       string            `json:"path"`
+	Annotation string            `json:"annotation,omitempty"`
+	Metadata   map[string]string `json:"metadata,omitempty"` // The HeaderFields
+	Content    string            `json:"content"`
+	Note       string            `json:"note,omitempty"`
+}
+
+// writeJSON renders the report as one JSON document.
+func writeJSON(w io.Writer, meta ReportMetadata, report *parsedReport) error {
+	doc := jsonReport{
+		Header:        ReportHeaderLine,
+		Timestamp:     meta.Timestamp,
+		SelectionMode: meta.SelectionMode,
+		Branch:        meta.Branch,
+		TotalFiles:    meta.TotalFiles,
+		TotalTokens:   meta.TotalTokens,
+		Roots:         []jsonRoot{},
+	}
+	for _, l := range meta.Languages {
+		doc.Languages = append(doc.Languages, jsonLanguage(l))
+	}
+	for _, d := range meta.Vendored {
+		doc.Vendored = append(doc.Vendored, jsonVendored(d))
+	}
+	if t := meta.Task; t != nil {
+		doc.Task = &jsonTask{Name: t.Name, Title: t.Title, Instructions: t.Instructions, ResponseFormat: t.Response}
+	}
+	if b := meta.Breakdown; b != nil {
+		doc.Breakdown = &jsonBreakdown{Header: b.Header, Recent: b.Recent, Structure: b.Structure, Contents: b.Contents, Signatures: b.Signatures}
+		for _, f := range b.Folders {
+			doc.Breakdown.Folders = append(doc.Breakdown.Folders, jsonFolderTokens(f))
+		}
+	}
+	files := func(in []reportFile) []jsonFile {
+		out := make([]jsonFile, len(in))
+		for i, f := range in {
+			out[i] = jsonFile{Path: f.Path, Annotation: f.Annotation, Content: f.Content, Note: f.Note}
+			if len(f.Fields) > 0 {
+				out[i].Metadata = make(map[string]string, len(f.Fields))
+				for _, field := range f.Fields {
+					out[i].Metadata[field.Key] = field.Value
+				}
+			}
+		}
+		return out
+	}
+	for _, r := range report.Roots {
+		doc.Roots = append(doc.Roots, jsonRoot{
+			Label:         r.Label,
+			Path:          r.Path,
+			Branch:        r.Branch,
+			RecentChanges: r.RecentChanges,
+			Structure:     r.Structure,
+			Files:         files(r.Files),
+			Signatures:    files(r.Signatures),
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(doc)
+}
@@ -0,0 +1,68 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportFormats(t *testing.T) {
+	if got, err := ParseFormats("md, json,txt,json"); err != nil || !reflect.DeepEqual(got, []string{FormatText, FormatMarkdown, FormatJSON}) {
+		t.Errorf("ParseFormats = %v, %v", got, err)
+	}
+	if _, err := ParseFormats("pdf"); err == nil {
+		t.Error("ParseFormats accepted an unknown format")
+	}
+
+	root := t.TempDir()
+	notes := "---\ntitle: Notes\n---\nuse ```go fences```\n"
+	if err := os.WriteFile(filepath.Join(root, "notes.md"), []byte(notes), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(root, "report.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out}
+	opts := DefaultExtractOptions()
+	opts.Formats = []string{FormatMarkdown, FormatJSON}
+
+	for range 2 { // The second run must skip the reports of the first
+		meta, err := RunExtractionWithOptions(space, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.TotalFiles != 1 {
+			t.Fatalf("exported %d files, want 1", meta.TotalFiles)
+		}
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("text report written although not requested")
+	}
+
+	md, err := os.ReadFile(filepath.Join(root, "report.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(md), ReportHeaderLine) {
+		t.Error("markdown report lacks the header line")
+	}
+	if !strings.Contains(string(md), "#### `notes.md`\n\n````markdown\n"+notes+"````\n") {
+		t.Errorf("markdown report:\n%s", md)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, "report.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc jsonReport
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if len(doc.Roots) != 1 || len(doc.Roots[0].Files) != 1 || doc.Roots[0].Files[0].Content != notes {
+		t.Errorf("json report = %+v", doc)
+	}
+	if !IsReportFile(filepath.Join(root, "report.json")) {
+		t.Error("json report not recognized as a report")
+	}
+}
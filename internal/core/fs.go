@@ -2,15 +2,75 @@
 package core
 
 import (
+	"io/fs"
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
+	"time"
 )
 
+// FileSystem is where the TUI browses: its directory listings and the
+// checks of saved spaces. OSFileSystem is the disk; tests substitute an
+// in-memory tree with FileSystemAt. Exports and the indexes always read the
+// disk.
+type FileSystem interface {
+	ReadDir(path string) ([]fs.DirEntry, error)
+	Stat(path string) (fs.FileInfo, error)
+}
+
+type osFileSystem struct{}
+
+func (osFileSystem) ReadDir(path string) ([]fs.DirEntry, error) { return os.ReadDir(path) }
+func (osFileSystem) Stat(path string) (fs.FileInfo, error)      { return os.Stat(path) }
+
+// OSFileSystem reads the real disk.
+var OSFileSystem FileSystem = osFileSystem{}
+
+// mountedFS serves the absolute paths under root from an fs.FS.
+type mountedFS struct {
+	root string
+	fsys fs.FS
+}
+
+// FileSystemAt mounts fsys, e.g. an fstest.MapFS, at the absolute path root.
+// Paths outside root do not exist.
+func FileSystemAt(root string, fsys fs.FS) FileSystem {
+	return mountedFS{root: filepath.Clean(root), fsys: fsys}
+}
+
+func (m mountedFS) name(path string) (string, error) {
+	rel, err := filepath.Rel(m.root, path)
+	if err != nil || !filepath.IsLocal(rel) && rel != "." {
+		return "", &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+func (m mountedFS) ReadDir(path string) ([]fs.DirEntry, error) {
+	name, err := m.name(path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.ReadDir(m.fsys, name)
+}
+
+func (m mountedFS) Stat(path string) (fs.FileInfo, error) {
+	name, err := m.name(path)
+	if err != nil {
+		return nil, err
+	}
+	return fs.Stat(m.fsys, name)
+}
+
 // ListDir returns the immediate children of a directory.
 // Used by the TUI to lazily load folder contents on expansion.
 func ListDir(path string) ([]DirEntry, error) {
-	entries, err := os.ReadDir(path)
+	return listDir(OSFileSystem, path)
+}
+
+func listDir(fsys FileSystem, path string) ([]DirEntry, error) {
+	entries, err := fsys.ReadDir(path)
 	if err != nil {
 		return nil, err
 	}
@@ -39,3 +99,62 @@ func ListDir(path string) ([]DirEntry, error) {
 
 	return results, nil
 }
+
+// DirCache memoizes ListDir results per directory. An entry is reused for as
+// long as the directory's mtime is unchanged, which catches added, removed and
+// renamed children but not edits to existing files.
+type DirCache struct {
+	fsys     FileSystem
+	mu       sync.Mutex
+	listings map[string]cachedListing
+}
+
+type cachedListing struct {
+	modTime time.Time
+	entries []DirEntry
+}
+
+// NewDirCache creates an empty cache of the disk.
+func NewDirCache() *DirCache {
+	return NewDirCacheFS(OSFileSystem)
+}
+
+// NewDirCacheFS creates an empty cache of listings read from fsys.
+func NewDirCacheFS(fsys FileSystem) *DirCache {
+	return &DirCache{fsys: fsys, listings: make(map[string]cachedListing)}
+}
+
+// List returns the children of path, hitting the disk only for a stat when
+// the cached listing is still current.
+func (c *DirCache) List(path string) ([]DirEntry, error) {
+	info, err := c.fsys.Stat(path)
+	if err != nil {
+		c.mu.Lock()
+		delete(c.listings, path)
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	c.mu.Lock()
+	cached, ok := c.listings[path]
+	c.mu.Unlock()
+	if ok && cached.modTime.Equal(info.ModTime()) {
+		return cached.entries, nil
+	}
+
+	entries, err := listDir(c.fsys, path)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	c.listings[path] = cachedListing{modTime: info.ModTime(), entries: entries}
+	c.mu.Unlock()
+	return entries, nil
+}
+
+// Invalidate drops every cached listing.
+func (c *DirCache) Invalidate() {
+	c.mu.Lock()
+	c.listings = make(map[string]cachedListing)
+	c.mu.Unlock()
+}
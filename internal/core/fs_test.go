@@ -0,0 +1,41 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDirCache(t *testing.T) {
+	root := setupTestDir(t)
+	src := filepath.Join(root, "src")
+	cache := NewDirCache()
+
+	first, err := cache.List(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 4 {
+		t.Fatalf("got %d entries, want 4", len(first))
+	}
+
+	// Adding a child bumps the directory mtime and must invalidate the listing
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(src, past, past); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.List(src); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "new.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	second, err := cache.List(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(second) != 5 {
+		t.Errorf("got %d entries after adding a file, want 5", len(second))
+	}
+}
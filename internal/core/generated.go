@@ -0,0 +1,122 @@
+// Package core implements detecting generated, minified, license and test
+// files.
+package core
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Kinds of low-value files reported by DetectLowValue.
+const (
+	LowValueGenerated = "generated"
+	LowValueMinified  = "minified"
+	LowValueLicense   = "license"
+)
+
+const (
+	// lowValueSniff is how much of a file is read to classify it.
+	lowValueSniff = 64 << 10
+	// minifiedLineLength is the line length beyond which code counts as minified.
+	minifiedLineLength = 1000
+)
+
+// licenseNames are the base names, upper-cased and without extension, of
+// license files.
+var licenseNames = map[string]bool{
+	"LICENSE": true, "LICENCE": true, "COPYING": true, "NOTICE": true, "UNLICENSE": true,
+}
+
+// generatedMarker matches the line that marks generated code by Go's
+// convention, such as "// Code generated by stringer; DO NOT EDIT.". It must
+// be a whole line, so code and prose that only mention it do not count.
+var generatedMarker = regexp.MustCompile(`(?m)^// Code generated .* DO NOT EDIT\.$`)
+
+// minifiedExts are the extensions of files that are checked for minified
+// lines; elsewhere a long line is data, not a bundle.
+var minifiedExts = map[string]bool{".js": true, ".mjs": true, ".cjs": true, ".css": true}
+
+// testDirNames hold nothing but tests and their fixtures.
+var testDirNames = map[string]bool{
+	"test": true, "tests": true, "__tests__": true, "testdata": true, "spec": true, "e2e": true,
+}
+
+// testFileName matches the test files of Go, JavaScript/TypeScript, Python
+// and Ruby.
+var testFileName = regexp.MustCompile(`(_test\.go|\.(test|spec)\.[cm]?[jt]sx?|^test_.*\.py|_test\.py|_spec\.rb)$`)
+
+// IsTestPath reports whether relPath is a test file or lies in a test
+// directory.
+func IsTestPath(relPath string) bool {
+	parts := strings.Split(filepath.ToSlash(relPath), "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if testDirNames[dir] {
+			return true
+		}
+	}
+	return testFileName.MatchString(parts[len(parts)-1])
+}
+
+// GeneratedPolicyFor returns the policy for generated code, minified bundles
+// and license files: one of the nested repository policies.
+func (c ExtractionConfig) GeneratedPolicyFor() string {
+	if c.GeneratedPolicy != "" {
+		return c.GeneratedPolicy
+	}
+	return NestedRepoFull
+}
+
+// TestsPolicyFor returns the policy for test files and folders: one of the
+// nested repository policies.
+func (c ExtractionConfig) TestsPolicyFor() string {
+	if c.TestsPolicy != "" {
+		return c.TestsPolicy
+	}
+	return NestedRepoFull
+}
+
+// DetectLowValue classifies the file at path as LowValueGenerated,
+// LowValueMinified or LowValueLicense, or returns "" for anything else.
+// Only the start of the file is read, and only JavaScript and CSS count as
+// minified by their line length.
+func DetectLowValue(path string) string {
+	base := filepath.Base(path)
+	name := strings.ToUpper(strings.TrimSuffix(base, filepath.Ext(base)))
+	if licenseNames[name] || strings.HasPrefix(name, "LICENSE-") || strings.HasPrefix(name, "LICENSE_") {
+		return LowValueLicense
+	}
+	lower := strings.ToLower(base)
+	if strings.HasSuffix(lower, ".min.js") || strings.HasSuffix(lower, ".min.css") {
+		return LowValueMinified
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	head := make([]byte, lowValueSniff)
+	n, _ := io.ReadFull(f, head)
+	head = head[:n]
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "" // Binary
+	}
+
+	// The marker sits in the leading comment
+	if generatedMarker.Match(head[:min(len(head), 4096)]) {
+		return LowValueGenerated
+	}
+	if !minifiedExts[filepath.Ext(lower)] {
+		return ""
+	}
+	for line := range bytes.SplitSeq(head, []byte("\n")) {
+		if len(line) >= minifiedLineLength {
+			return LowValueMinified
+		}
+	}
+	return ""
+}
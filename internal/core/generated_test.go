@@ -0,0 +1,93 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGeneratedPolicy(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":       "package main\n",
+		"enum_gen.go":   "// Code generated by stringer; DO NOT EDIT.\n\npackage main\n",
+		"LICENSE":       "MIT License\n",
+		"app.js":        "var a=1;" + strings.Repeat("b();", 400) + "\n",
+		"vendor.min.js": "x\n",
+		"data.json":     `{"a":"` + strings.Repeat("b", 2000) + "\"}\n",
+		"notes.go":      "package main\n\n// A file with \"// Code generated by x; DO NOT EDIT.\" is skipped\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, want := range map[string]string{"main.go": "", "enum_gen.go": LowValueGenerated, "LICENSE": LowValueLicense, "app.js": LowValueMinified, "vendor.min.js": LowValueMinified, "data.json": "", "notes.go": ""} {
+		if got := DetectLowValue(filepath.Join(root, name)); got != want {
+			t.Errorf("DetectLowValue(%s) = %q, want %q", name, got, want)
+		}
+	}
+
+	for _, policy := range []string{NestedRepoStructure, NestedRepoSkip} {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: ExtractionConfig{GeneratedPolicy: policy}}
+		meta, err := RunExtraction(space)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.TotalFiles != 3 {
+			t.Errorf("%s: exported %d files, want main.go, notes.go and data.json", policy, meta.TotalFiles)
+		}
+		report, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		listed := strings.Contains(string(report), "├── LICENSE [EXCLUDED] license\n")
+		if listed != (policy == NestedRepoStructure) {
+			t.Errorf("%s: license listed = %v\n%s", policy, listed, report)
+		}
+	}
+}
+
+func TestTestsPolicy(t *testing.T) {
+	root := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":                 "package main\n",
+		"main_test.go":            "package main\n",
+		"web/App.spec.ts":         "it()\n",
+		"web/__tests__/button.js": "test()\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for policy, want := range map[string]int{NestedRepoFull: 4, NestedRepoStructure: 1, NestedRepoSkip: 1} {
+		out := filepath.Join(t.TempDir(), "report.txt")
+		space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: ExtractionConfig{TestsPolicy: policy}}
+		meta, err := RunExtraction(space)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if meta.TotalFiles != want {
+			t.Errorf("%s: exported %d files, want %d", policy, meta.TotalFiles, want)
+		}
+		report, err := os.ReadFile(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		listed := strings.Contains(string(report), "├── main_test.go [EXCLUDED] test\n") &&
+			strings.Contains(string(report), "│   │   ├── button.js [EXCLUDED] test\n")
+		if listed != (policy == NestedRepoStructure) {
+			t.Errorf("%s: tests listed = %v\n%s", policy, listed, report)
+		}
+		if policy == NestedRepoSkip && strings.Contains(string(report), "__tests__") {
+			t.Errorf("skip still lists the test folder:\n%s", report)
+		}
+	}
+}
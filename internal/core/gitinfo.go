@@ -0,0 +1,179 @@
+// Package core implements the git history shown in reports.
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// gitRecordSep starts each commit line in the git log output parsed below.
+const gitRecordSep = "\x1e"
+
+// annotateGitInfo sets the annotation of every file to the last commit that
+// touched it: short hash, author and date. Files git doesn't know are marked
+// as uncommitted. Outside a git repository, or without git, files are left
+// alone.
+func annotateGitInfo(root string, files []contentFile) {
+	if len(files) == 0 {
+		return
+	}
+	want := make(map[string]int, len(files)) // Slash path relative to root -> index
+	for i, f := range files {
+		want[filepath.ToSlash(f.RelPath)] = i
+	}
+
+	// One pass over history, newest first, with paths relative to root
+	cmd := exec.Command("git", "-C", root, "log", "--relative", "--no-renames", "--name-only",
+		"--date=short", "--format="+gitRecordSep+"%h\x1f%an\x1f%ad")
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return
+	}
+	if err := cmd.Start(); err != nil {
+		return
+	}
+	defer func() {
+		_ = cmd.Process.Kill() // We usually stop reading long before history ends
+		_ = cmd.Wait()
+	}()
+
+	found := make(map[string]string, len(files))
+	commit := ""
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() && len(found) < len(want) {
+		line := scanner.Text()
+		if rest, ok := strings.CutPrefix(line, gitRecordSep); ok {
+			if parts := strings.Split(rest, "\x1f"); len(parts) == 3 {
+				commit = "last commit " + parts[0] + " by " + parts[1] + " on " + parts[2]
+			}
+			continue
+		}
+		if _, ok := want[line]; ok && commit != "" && found[line] == "" {
+			found[line] = commit
+		}
+	}
+	if commit == "" {
+		return // Not a repository, or no history at all
+	}
+
+	for path, i := range want {
+		if note, ok := found[path]; ok {
+			files[i].Annotation = note
+		} else {
+			files[i].Annotation = "not committed"
+		}
+	}
+}
+
+// writeRecentChanges emits the Recent Changes section: the last n commits,
+// one line each. Outside a git repository nothing is written.
+func writeRecentChanges(w io.Writer, space *DirectorySpace, n int) error {
+	var specs []string
+	if space.Config.RecentCommitsScoped {
+		specs = selectionPathspecs(space)
+		if len(specs) == 0 && space.Config.IncludeMode {
+			n = 0 // Nothing selected, so no commit matches
+		}
+	}
+	args := append([]string{"-C", space.RootPath, "log", "-n", fmt.Sprint(n), "--date=short", "--format=%h %ad %an: %s", "--"}, specs...)
+	out, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil // Not a repository, or git is missing
+	}
+	log := strings.TrimSpace(string(out))
+	if log == "" {
+		log = "(no commits)"
+	}
+	_, err = fmt.Fprintf(w, "### Recent Changes\n\n%s\n\n", log)
+	return err
+}
+
+// selectionPathspecs turns the selection into git pathspecs relative to the
+// root: checked paths and include patterns in include mode, minus the
+// deselections inside them; exclusions in exclude mode. Git can't include a
+// path again inside an excluded one, so an exclusion with deselections below
+// it is dropped, which scopes to more commits rather than fewer.
+func selectionPathspecs(space *DirectorySpace) []string {
+	cfg := space.Config
+	var specs []string
+	for _, sel := range cfg.ManualSelections {
+		rel := relativeTo(space.RootPath, sel)
+		switch {
+		case rel == "":
+			continue
+		case cfg.IncludeMode:
+			specs = append(specs, ":(literal)"+rel)
+		case !slices.ContainsFunc(cfg.ManualDeselections, func(d string) bool { return strings.HasPrefix(d, sel+string(filepath.Separator)) }):
+			specs = append(specs, ":(exclude,literal)"+rel)
+		}
+	}
+	if cfg.IncludeMode {
+		for _, p := range cfg.IncludePatterns {
+			specs = append(specs, ":(glob)"+p)
+		}
+		for _, desel := range cfg.ManualDeselections {
+			if rel := relativeTo(space.RootPath, desel); rel != "" && len(specs) > 0 {
+				specs = append(specs, ":(exclude,literal)"+rel)
+			}
+		}
+	}
+	return specs
+}
+
+// GitBranch describes what is checked out in a repository.
+type GitBranch struct {
+	Name  string // Branch name, or the short commit hash when detached
+	Dirty bool   // Uncommitted changes, untracked files included
+}
+
+// String returns the branch name, suffixed with "*" when dirty.
+func (b GitBranch) String() string {
+	if b.Dirty {
+		return b.Name + "*"
+	}
+	return b.Name
+}
+
+// ReadGitBranch reports the branch checked out at root. ok is false outside
+// a git repository or without git.
+func ReadGitBranch(root string) (branch GitBranch, ok bool) {
+	out, err := exec.Command("git", "-C", root, "status", "--porcelain=v1", "--branch").Output()
+	if err != nil {
+		return GitBranch{}, false
+	}
+	lines := strings.Split(strings.TrimRight(string(out), "\n"), "\n")
+	head, _ := strings.CutPrefix(lines[0], "## ")
+	branch.Dirty = len(lines) > 1
+
+	switch {
+	case strings.HasPrefix(head, "HEAD (no branch)"):
+		hash, err := exec.Command("git", "-C", root, "rev-parse", "--short", "HEAD").Output()
+		if err != nil {
+			return GitBranch{}, false
+		}
+		branch.Name = strings.TrimSpace(string(hash))
+	case strings.HasPrefix(head, "No commits yet on "):
+		branch.Name = strings.TrimPrefix(head, "No commits yet on ")
+	default:
+		name, _, _ := strings.Cut(head, "...")
+		branch.Name, _, _ = strings.Cut(name, " ")
+	}
+	return branch, true
+}
+
+// describeBranch is the Branch line of a report, or "" outside a repository.
+func describeBranch(root string) string {
+	branch, ok := ReadGitBranch(root)
+	if !ok {
+		return ""
+	}
+	if branch.Dirty {
+		return branch.Name + " (uncommitted changes)"
+	}
+	return branch.Name
+}
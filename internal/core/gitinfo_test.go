@@ -0,0 +1,106 @@
+package core
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGitInfo(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := setupTestDir(t)
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=Ada", "-c", "user.email=ada@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q")
+	git("add", "src/main.go")
+	git("commit", "-q", "-m", "init")
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: DefaultExtractionConfig()}
+	space.Config.ManualSelections = []string{filepath.Join(root, "src", "main.go"), filepath.Join(root, "src", "utils.go")}
+	space.Config.GitInfo = true
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(out)
+	report := string(data)
+	if !regexp.MustCompile(`--- file: src/main\.go \(last commit [0-9a-f]+ by Ada on \d{4}-\d{2}-\d{2}\) ---`).MatchString(report) {
+		t.Errorf("missing commit annotation:\n%s", report)
+	}
+	if !strings.Contains(report, "--- file: src/utils.go (not committed) ---") {
+		t.Errorf("missing uncommitted annotation:\n%s", report)
+	}
+
+	git("add", "README.md")
+	git("commit", "-q", "-m", "docs")
+	space.Config.GitInfo = false
+	space.Config.RecentCommits = 5
+	for _, scoped := range []bool{false, true} {
+		space.Config.RecentCommitsScoped = scoped
+		if _, err := RunExtraction(space); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(out)
+		report := string(data)
+		if !strings.Contains(report, "### Recent Changes") || !strings.Contains(report, "Ada: init") {
+			t.Errorf("scoped=%v: missing recent changes:\n%s", scoped, report)
+		}
+		// README.md is not selected, so its commit only shows unscoped
+		if got := strings.Contains(report, "Ada: docs"); got == scoped {
+			t.Errorf("scoped=%v: docs commit listed = %v", scoped, got)
+		}
+	}
+}
+
+func TestGitBranch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := setupTestDir(t)
+	if _, ok := ReadGitBranch(root); ok {
+		t.Fatal("branch reported outside a repository")
+	}
+	git := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-C", root, "-c", "user.name=Ada", "-c", "user.email=ada@example.com"}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	git("init", "-q", "-b", "trunk")
+	if b, ok := ReadGitBranch(root); !ok || b != (GitBranch{Name: "trunk", Dirty: true}) {
+		t.Errorf("before first commit: got %+v, %v", b, ok)
+	}
+	git("add", "-A")
+	git("commit", "-q", "-m", "init")
+	if b, _ := ReadGitBranch(root); b.String() != "trunk" {
+		t.Errorf("clean: got %q", b)
+	}
+
+	os.WriteFile(filepath.Join(root, "README.md"), []byte("changed"), 0o644)
+	out := filepath.Join(t.TempDir(), "out.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: DefaultExtractionConfig()}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	if !strings.Contains(string(data), "Branch: trunk (uncommitted changes)\n") {
+		t.Errorf("missing branch in header:\n%s", data)
+	}
+
+	git("checkout", "-q", "--detach")
+	if b, _ := ReadGitBranch(root); !regexp.MustCompile(`^[0-9a-f]+\*$`).MatchString(b.String()) {
+		t.Errorf("detached: got %q", b)
+	}
+}
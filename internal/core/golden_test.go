@@ -0,0 +1,69 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pandabrew/internal/golden"
+)
+
+// goldenCases are the option combinations whose reports are compared with
+// testdata/golden/<name>.{txt,md,json}. Run with -update after a deliberate
+// change to the report and review the golden diff.
+var goldenCases = []struct {
+	name  string
+	apply func(root string, cfg *ExtractionConfig)
+}{
+	{"include", func(root string, cfg *ExtractionConfig) {}},
+	{"exclude", func(root string, cfg *ExtractionConfig) {
+		cfg.IncludeMode = false
+		cfg.ManualSelections = []string{filepath.Join(root, "docs")}
+	}},
+	{"line-numbers-minify", func(root string, cfg *ExtractionConfig) {
+		cfg.LineNumbers = true
+		cfg.MinifyContent = true
+	}},
+	{"header-fields", func(root string, cfg *ExtractionConfig) {
+		cfg.HeaderFields = []string{"size", "tokens", "language", "sha256"}
+	}},
+	{"custom-delimiters", func(root string, cfg *ExtractionConfig) {
+		cfg.FileHeader = "=== {path} ==="
+		cfg.FileFooter = "=== end ==="
+	}},
+	{"escaping-boundary", func(root string, cfg *ExtractionConfig) { cfg.Escaping = EscapeBoundary }},
+	{"escaping-length", func(root string, cfg *ExtractionConfig) { cfg.Escaping = EscapeLength }},
+	{"structure-depth", func(root string, cfg *ExtractionConfig) {
+		cfg.ManualSelections = []string{filepath.Join(root, "main.go")}
+		cfg.FullTreeMap = true
+		cfg.StructureMaxDepth = 1
+	}},
+	{"token-breakdown", func(root string, cfg *ExtractionConfig) { cfg.TokenBreakdown = true }},
+	{"task", func(root string, cfg *ExtractionConfig) { cfg.Task = "code-review" }},
+}
+
+func TestGoldenReports(t *testing.T) {
+	for _, tc := range goldenCases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := golden.CopyFixture(t, filepath.Join("testdata", "golden", "project"))
+			out := filepath.Join(t.TempDir(), "report.txt")
+			space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: DefaultExtractionConfig()}
+			space.Config.ManualSelections = []string{root}
+			tc.apply(root, &space.Config)
+
+			opts := DefaultExtractOptions()
+			opts.Formats = ReportFormats
+			if _, err := RunExtractionWithOptions(space, opts); err != nil {
+				t.Fatal(err)
+			}
+			for _, format := range ReportFormats {
+				path := FormatOutputPath(out, format)
+				got, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+				golden.Assert(t, filepath.Join("testdata", "golden", tc.name+filepath.Ext(path)), golden.Normalize(got, root))
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Package core implements searching file contents, with ripgrep when present.
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// contentSearchMaxFileSize is the largest file SearchContent reads; larger
+// ones are skipped by both engines.
+const contentSearchMaxFileSize = 1 << 20
+
+// ContentMatch is one line containing the query, with the lines around it.
+type ContentMatch struct {
+	Path   string
+	Line   int // 1-based
+	Text   string
+	Before string // Previous line, if any
+	After  string // Next line, if any
+}
+
+// ContentSearchBackend names the engine SearchContent will use.
+func ContentSearchBackend() string {
+	if _, err := exec.LookPath("rg"); err == nil {
+		return "ripgrep"
+	}
+	return "built-in"
+}
+
+// SearchContent finds up to limit lines under root containing query, case
+// insensitively. It runs ripgrep when installed, which also honours
+// .gitignore, and otherwise scans the files itself. Either way, files
+// excluded by cfg, files over contentSearchMaxFileSize and binary files,
+// those with a NUL byte anywhere, are left out.
+func SearchContent(ctx context.Context, root string, cfg ExtractionConfig, query string, limit int) ([]ContentMatch, error) {
+	if query == "" {
+		return nil, nil
+	}
+	keep := func(path string) bool {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return false
+		}
+		return !isExcluded(rel, cfg.ExcludePatterns) && !(cfg.SkipJunk && isExcluded(rel, JunkPatterns))
+	}
+	if _, err := exec.LookPath("rg"); err == nil {
+		return searchRipgrep(ctx, root, query, limit, keep)
+	}
+	return searchFiles(ctx, root, query, limit, keep)
+}
+
+// rgEvent is the part of a ripgrep --json line we read.
+type rgEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Path struct {
+			Text string `json:"text"`
+		} `json:"path"`
+		Lines struct {
+			Text string `json:"text"`
+		} `json:"lines"`
+		LineNumber   int    `json:"line_number"`
+		BinaryOffset *int64 `json:"binary_offset"` // Set on "end" once a NUL byte was seen
+	} `json:"data"`
+}
+
+func searchRipgrep(ctx context.Context, root, query string, limit int, keep func(string) bool) ([]ContentMatch, error) {
+	cmd := exec.CommandContext(ctx, "rg", "--json", "--fixed-strings", "--ignore-case",
+		"--hidden", "--glob", "!.git", "--max-filesize", strconv.Itoa(contentSearchMaxFileSize),
+		"--context", "1", "--max-columns", "500", "--", query, root)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = cmd.Process.Kill() // We stop reading at the limit
+		_ = cmd.Wait()
+	}()
+	return parseRipgrepJSON(stdout, limit, keep), nil
+}
+
+// parseRipgrepJSON collects matches from ripgrep's JSON stream, attaching
+// the context lines printed around each one. A file's matches are held
+// until its "end" event, and dropped if ripgrep found it to be binary there:
+// it may have printed lines from before the first NUL byte.
+func parseRipgrepJSON(r io.Reader, limit int, keep func(string) bool) []ContentMatch {
+	var matches []ContentMatch
+	var file []ContentMatch // Matches in the file being read
+	var pending string      // Context line that may precede the next match
+	pendingLine := 0
+	pendingPath := ""
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	for scanner.Scan() {
+		var ev rgEvent
+		if json.Unmarshal(scanner.Bytes(), &ev) != nil {
+			continue
+		}
+		path := ev.Data.Path.Text
+		text := strings.TrimRight(ev.Data.Lines.Text, "\r\n")
+		switch ev.Type {
+		case "begin":
+			file = nil
+		case "match":
+			if !keep(path) {
+				continue
+			}
+			m := ContentMatch{Path: path, Line: ev.Data.LineNumber, Text: text}
+			if prev := len(file) - 1; prev >= 0 && file[prev].Line == m.Line-1 {
+				m.Before = file[prev].Text
+				file[prev].After = text
+			} else if pendingPath == path && pendingLine == m.Line-1 {
+				m.Before = pending
+			}
+			file = append(file, m)
+		case "context":
+			if last := len(file) - 1; last >= 0 && file[last].Line == ev.Data.LineNumber-1 {
+				file[last].After = text
+			}
+			pending, pendingLine, pendingPath = text, ev.Data.LineNumber, path
+		case "end":
+			if ev.Data.BinaryOffset == nil {
+				matches = append(matches, file...)
+			}
+			file = nil
+			if len(matches) >= limit {
+				return matches[:limit]
+			}
+		}
+	}
+	return matches
+}
+
+func searchFiles(ctx context.Context, root, query string, limit int, keep func(string) bool) ([]ContentMatch, error) {
+	// Simple case folding, as ripgrep's --ignore-case does
+	q := regexp.MustCompile("(?i)" + regexp.QuoteMeta(query))
+	var matches []ContentMatch
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" || (path != root && !keep(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || !keep(path) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > contentSearchMaxFileSize {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || bytes.IndexByte(data, 0) >= 0 {
+			return nil // Unreadable or binary
+		}
+		lines := strings.Split(string(data), "\n")
+		for i, line := range lines {
+			if !q.MatchString(line) {
+				continue
+			}
+			m := ContentMatch{Path: path, Line: i + 1, Text: strings.TrimRight(line, "\r")}
+			if i > 0 {
+				m.Before = strings.TrimRight(lines[i-1], "\r")
+			}
+			if i+1 < len(lines) {
+				m.After = strings.TrimRight(lines[i+1], "\r")
+			}
+			matches = append(matches, m)
+			if len(matches) >= limit {
+				return filepath.SkipAll
+			}
+		}
+		return nil
+	})
+	return matches, err
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSearchContent(t *testing.T) {
+	root := setupTestDir(t)
+	files := map[string]string{
+		"src/bin.dat":   strings.Repeat("package\n", 2000) + "\x00", // NUL past the first 8000 bytes
+		"src/big.txt":   "package\n" + strings.Repeat("x", contentSearchMaxFileSize),
+		"src/kelvin.md": "\u212Aelvin", // Folds to "kelvin", as in ripgrep
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	keep := func(path string) bool { return !strings.Contains(path, "node_modules") }
+
+	matches, err := searchFiles(context.Background(), root, "PACKAGE", 10, keep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range matches {
+		rel, _ := filepath.Rel(root, m.Path)
+		got = append(got, filepath.ToSlash(rel))
+	}
+	slices.Sort(got)
+	if want := []string{"src/lib/helper.go", "src/main.go", "src/utils.go"}; !slices.Equal(got, want) {
+		t.Errorf("matches = %v, want %v", got, want)
+	}
+	if matches, _ := searchFiles(context.Background(), root, "KELVIN", 10, keep); len(matches) != 1 {
+		t.Errorf("case folded matches = %v, want kelvin.md", matches)
+	}
+
+	// Context lines printed by ripgrep attach to the neighbouring match
+	stream := strings.Join([]string{
+		`{"type":"begin","data":{"path":{"text":"/r/a.go"}}}`,
+		`{"type":"context","data":{"path":{"text":"/r/a.go"},"lines":{"text":"// doc\n"},"line_number":1}}`,
+		`{"type":"match","data":{"path":{"text":"/r/a.go"},"lines":{"text":"func Foo() {\n"},"line_number":2}}`,
+		`{"type":"match","data":{"path":{"text":"/r/a.go"},"lines":{"text":"\tfoo()\n"},"line_number":3}}`,
+		`{"type":"context","data":{"path":{"text":"/r/a.go"},"lines":{"text":"}\n"},"line_number":4}}`,
+		`{"type":"end","data":{"path":{"text":"/r/a.go"},"binary_offset":null}}`,
+		`{"type":"begin","data":{"path":{"text":"/r/skip/b.go"}}}`,
+		`{"type":"match","data":{"path":{"text":"/r/skip/b.go"},"lines":{"text":"foo\n"},"line_number":1}}`,
+		`{"type":"end","data":{"path":{"text":"/r/skip/b.go"},"binary_offset":null}}`,
+		`{"type":"begin","data":{"path":{"text":"/r/c.bin"}}}`,
+		`{"type":"match","data":{"path":{"text":"/r/c.bin"},"lines":{"text":"foo\n"},"line_number":1}}`,
+		`{"type":"end","data":{"path":{"text":"/r/c.bin"},"binary_offset":9000}}`,
+	}, "\n")
+	matches = parseRipgrepJSON(strings.NewReader(stream), 10, func(p string) bool { return !strings.Contains(p, "skip") })
+	want := []ContentMatch{
+		{Path: "/r/a.go", Line: 2, Text: "func Foo() {", Before: "// doc", After: "\tfoo()"},
+		{Path: "/r/a.go", Line: 3, Text: "\tfoo()", Before: "func Foo() {", After: "}"},
+	}
+	if !reflect.DeepEqual(matches, want) {
+		t.Errorf("parsed %+v, want %+v", matches, want)
+	}
+}
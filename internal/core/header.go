@@ -0,0 +1,104 @@
+// Package core implements the optional metadata of file header lines.
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fields accepted by ExtractionConfig.HeaderFields.
+const (
+	HeaderSize     = "size"     // Bytes on disk
+	HeaderTokens   = "tokens"   // Estimated tokens of the content included
+	HeaderLanguage = "language" // As detected by LanguageOf
+	HeaderModTime  = "mtime"    // Last modification, RFC 3339 in UTC
+	HeaderSHA      = "sha256"   // Of the whole file, even when truncated
+)
+
+// HeaderFieldNames lists the header fields in the order they are written.
+var HeaderFieldNames = []string{HeaderSize, HeaderTokens, HeaderLanguage, HeaderModTime, HeaderSHA}
+
+// headerAliases maps alternative spellings to a header field.
+var headerAliases = map[string]string{"lang": HeaderLanguage, "sha": HeaderSHA, "modified": HeaderModTime}
+
+// ParseHeaderFields reads a comma-separated list such as "size,tokens,sha".
+// Duplicates are dropped and the result follows HeaderFieldNames order.
+func ParseHeaderFields(list string) ([]string, error) {
+	return parseNameList(list, HeaderFieldNames, headerAliases)
+}
+
+// parseNameList picks the known names out of a comma-separated list, in
+// the order of known.
+func parseNameList(list string, known []string, aliases map[string]string) ([]string, error) {
+	want := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if alias, ok := aliases[name]; ok {
+			name = alias
+		}
+		if !slices.Contains(known, name) {
+			return nil, fmt.Errorf("unknown value %q (want %s)", name, strings.Join(known, ", "))
+		}
+		want[name] = true
+	}
+	var names []string
+	for _, n := range known {
+		if want[n] {
+			names = append(names, n)
+		}
+	}
+	return names, nil
+}
+
+// headerField is one key=value pair of a file header line.
+type headerField struct {
+	Key, Value string
+}
+
+// headerFields computes the requested fields for a file that was read.
+func headerFields(fields []string, f contentFile, res contentResult, maxSize int64) []headerField {
+	var out []headerField
+	for _, key := range fields {
+		var value string
+		switch key {
+		case HeaderSize:
+			value = strconv.FormatInt(res.size, 10)
+		case HeaderTokens:
+			included := res.size
+			if maxSize > 0 {
+				included = min(included, maxSize)
+			}
+			value = strconv.FormatInt(included/4, 10)
+		case HeaderLanguage:
+			value = LanguageOf(f.Path)
+		case HeaderModTime:
+			value = res.modTime.UTC().Format(time.RFC3339)
+		case HeaderSHA:
+			value = res.sha
+		}
+		if value != "" {
+			out = append(out, headerField{key, value})
+		}
+	}
+	return out
+}
+
+// formatHeaderFields renders fields as "[size=120 language="Go Module"]".
+// Values with spaces are quoted.
+func formatHeaderFields(fields []headerField) string {
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		value := f.Value
+		if strings.ContainsAny(value, " \"]") {
+			value = strconv.Quote(value)
+		}
+		parts[i] = f.Key + "=" + value
+	}
+	return "[" + strings.Join(parts, " ") + "]"
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestHeaderFields(t *testing.T) {
+	if got, err := ParseHeaderFields("sha, lang,size"); err != nil || !reflect.DeepEqual(got, []string{HeaderSize, HeaderLanguage, HeaderSHA}) {
+		t.Errorf("ParseHeaderFields = %v, %v", got, err)
+	}
+
+	root := t.TempDir()
+	content := "package main\n"
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "report.txt")
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: out,
+		Config:         ExtractionConfig{HeaderFields: HeaderFieldNames},
+	}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	header := regexp.MustCompile(`--- file: main\.go \[size=13 tokens=3 language=Go mtime=\S+Z sha256=` + hex.EncodeToString(sum[:]) + `\] ---\n`)
+	if !header.Match(report) {
+		t.Errorf("report lacks the header fields:\n%s", report)
+	}
+	if got := newDelimiters(space.Config).sections(string(report)); got["main.go"] != content+"\n" {
+		t.Errorf("sections = %q", got)
+	}
+}
@@ -0,0 +1,133 @@
+// Package core implements placeholders describing image files.
+package core
+
+import (
+	"encoding/xml"
+	"fmt"
+	"image"
+	_ "image/gif" // Decoders for image.DecodeConfig
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// imageFormats names the image types replaced by a placeholder.
+var imageFormats = map[string]string{
+	".png": "PNG", ".jpg": "JPEG", ".jpeg": "JPEG", ".gif": "GIF",
+	".webp": "WebP", ".bmp": "BMP", ".ico": "ICO", ".tiff": "TIFF", ".tif": "TIFF",
+	".svg": "SVG",
+}
+
+// isImage reports whether path is described by a placeholder under cfg.
+func isImage(path string, cfg ExtractionConfig) bool {
+	_, ok := imageFormats[strings.ToLower(filepath.Ext(path))]
+	return ok && cfg.ImagePlaceholders
+}
+
+// openImage is openContent for images: a one-line description stands in
+// for the file contents. The SHA-256 is still that of the file.
+func openImage(path string, open openFunc, hash bool) contentResult {
+	f, err := open(path)
+	if err != nil {
+		return contentResult{err: err}
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return contentResult{err: err}
+	}
+
+	text := imagePlaceholder(f, imageFormats[strings.ToLower(filepath.Ext(path))], info.Size())
+	res := contentResult{r: strings.NewReader(text), size: int64(len(text)), modTime: info.ModTime()}
+	if hash {
+		if h, err := hashOpened(open, path); err == nil {
+			res.sha = h.SHA256
+		}
+	}
+	return res
+}
+
+// imagePlaceholder describes the image in r, e.g.
+// "[Image: PNG, 640x480, 12.3 KB]". Dimensions are left out when the
+// format cannot be decoded.
+func imagePlaceholder(r io.Reader, format string, size int64) string {
+	if format == "SVG" {
+		return svgPlaceholder(r, size)
+	}
+	parts := []string{format}
+	if cfg, _, err := image.DecodeConfig(r); err == nil {
+		parts = append(parts, fmt.Sprintf("%dx%d", cfg.Width, cfg.Height))
+	}
+	parts = append(parts, FormatBytes(size))
+	return "[Image: " + strings.Join(parts, ", ") + "]"
+}
+
+// svgPlaceholder summarizes the root element of an SVG: its size and what
+// its top-level elements are, e.g.
+// "[Image: SVG, 24x24, viewBox 0 0 24 24, 1.2 KB; elements: 3 path, 1 g]".
+func svgPlaceholder(r io.Reader, size int64) string {
+	parts := []string{"SVG"}
+	counts := make(map[string]int)
+	dec := xml.NewDecoder(r)
+	depth := 0
+	for depth >= 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch depth {
+			case 1:
+				var width, height, viewBox string
+				for _, a := range t.Attr {
+					switch a.Name.Local {
+					case "width":
+						width = a.Value
+					case "height":
+						height = a.Value
+					case "viewBox":
+						viewBox = a.Value
+					}
+				}
+				if width != "" && height != "" {
+					parts = append(parts, width+"x"+height)
+				}
+				if viewBox != "" {
+					parts = append(parts, "viewBox "+viewBox)
+				}
+			case 2:
+				counts[t.Name.Local]++
+			}
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				depth = -1 // Done with the root element
+			}
+		}
+	}
+	parts = append(parts, FormatBytes(size))
+
+	text := "[Image: " + strings.Join(parts, ", ")
+	if len(counts) > 0 {
+		names := make([]string, 0, len(counts))
+		for name := range counts {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			if counts[names[i]] != counts[names[j]] {
+				return counts[names[i]] > counts[names[j]]
+			}
+			return names[i] < names[j]
+		})
+		for i, name := range names {
+			names[i] = fmt.Sprintf("%d %s", counts[name], name)
+		}
+		text += "; elements: " + strings.Join(names, ", ")
+	}
+	return text + "]"
+}
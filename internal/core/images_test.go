@@ -0,0 +1,45 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestImagePlaceholders(t *testing.T) {
+	root := t.TempDir()
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 3, 2))); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "logo.png"), img.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	svg := `<svg xmlns="http://www.w3.org/2000/svg" width="24" height="24" viewBox="0 0 24 24"><g><path d="M0 0"/></g><path d="M1 1"/><path d="M2 2"/></svg>`
+	if err := os.WriteFile(filepath.Join(root, "icon.svg"), []byte(svg), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "report.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: ExtractionConfig{ImagePlaceholders: true}}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := newDelimiters(space.Config).sections(string(report))
+	want := map[string]string{
+		"icon.svg": fmt.Sprintf("[Image: SVG, 24x24, viewBox 0 0 24 24, %d B; elements: 2 path, 1 g]\n", len(svg)),
+		"logo.png": fmt.Sprintf("[Image: PNG, 3x2, %d B]\n", img.Len()),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("sections = %q, want %q", got, want)
+	}
+}
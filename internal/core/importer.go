@@ -0,0 +1,158 @@
+// Package core implements importing settings from other context tools.
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ImportedConfig holds the settings read from another tool's configuration,
+// ready to be applied to a space.
+type ImportedConfig struct {
+	Root            string // Project root, absolute
+	Output          string // Output file; relative paths are resolved against Root
+	IncludePatterns []string
+	ExcludePatterns []string
+	Minify          bool
+}
+
+// Apply copies the imported settings onto space. Exclude patterns are added
+// to the space's defaults.
+func (c ImportedConfig) Apply(space *DirectorySpace) {
+	if c.Output != "" {
+		out := c.Output
+		if !filepath.IsAbs(out) {
+			out = filepath.Join(c.Root, out)
+		}
+		space.OutputFilePath = out
+	}
+	space.Config.IncludeMode = true
+	space.Config.IncludePatterns = append(space.Config.IncludePatterns, c.IncludePatterns...)
+	if len(space.Config.IncludePatterns) == 0 && len(space.Config.ManualSelections) == 0 {
+		// Other tools export everything unless told otherwise
+		space.Config.IncludePatterns = []string{"**/*"}
+	}
+	for _, p := range c.ExcludePatterns {
+		if !slices.Contains(space.Config.ExcludePatterns, p) {
+			space.Config.ExcludePatterns = append(space.Config.ExcludePatterns, p)
+		}
+	}
+	space.Config.MinifyContent = space.Config.MinifyContent || c.Minify
+}
+
+// repomixConfig is the subset of repomix.config.json that maps onto a space.
+type repomixConfig struct {
+	Output struct {
+		FilePath         string `json:"filePath"`
+		RemoveEmptyLines bool   `json:"removeEmptyLines"`
+	} `json:"output"`
+	Include []string `json:"include"`
+	Ignore  struct {
+		CustomPatterns []string `json:"customPatterns"`
+	} `json:"ignore"`
+}
+
+// ImportRepomixConfig reads a repomix.config.json. The project root is the
+// directory holding the config file.
+func ImportRepomixConfig(path string) (ImportedConfig, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return ImportedConfig{}, err
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		return ImportedConfig{}, err
+	}
+	var rc repomixConfig
+	if err := json.Unmarshal(data, &rc); err != nil {
+		return ImportedConfig{}, fmt.Errorf("invalid repomix config: %w", err)
+	}
+
+	return ImportedConfig{
+		Root:            filepath.Dir(absPath),
+		Output:          rc.Output.FilePath,
+		IncludePatterns: cleanImportedPatterns(rc.Include),
+		ExcludePatterns: cleanImportedPatterns(rc.Ignore.CustomPatterns),
+		Minify:          rc.Output.RemoveEmptyLines,
+	}, nil
+}
+
+// code2promptValueFlags take a value; only include, exclude and output are used.
+var code2promptValueFlags = map[string]bool{
+	"-i": true, "--include": true,
+	"-e": true, "--exclude": true,
+	"-o": true, "--output": true,
+	"-O": true, "--output-file": true,
+	"-t": true, "--template": true,
+	"-c": true, "--encoding": true,
+	"-F": true, "--output-format": true,
+	"--sort": true,
+}
+
+// ImportCode2PromptArgs maps a code2prompt command line (without the program
+// name) onto a config: the path argument, --include, --exclude and the output
+// file. Other flags are ignored.
+func ImportCode2PromptArgs(args []string) (ImportedConfig, error) {
+	var cfg ImportedConfig
+	var root string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		name, value, hasValue := strings.Cut(arg, "=")
+		if !strings.HasPrefix(arg, "-") {
+			if root == "" {
+				root = arg
+			}
+			continue
+		}
+		if !code2promptValueFlags[name] {
+			continue // Boolean flag we don't map
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return ImportedConfig{}, fmt.Errorf("flag %s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "-i", "--include":
+			cfg.IncludePatterns = append(cfg.IncludePatterns, cleanImportedPatterns(strings.Split(value, ","))...)
+		case "-e", "--exclude":
+			cfg.ExcludePatterns = append(cfg.ExcludePatterns, cleanImportedPatterns(strings.Split(value, ","))...)
+		case "-o", "--output", "-O", "--output-file":
+			cfg.Output = value
+		}
+	}
+	if root == "" {
+		return ImportedConfig{}, fmt.Errorf("code2prompt arguments must include the project path")
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return ImportedConfig{}, err
+	}
+	cfg.Root = absRoot
+	// code2prompt resolves the output against the working directory
+	if cfg.Output != "" {
+		if cfg.Output, err = filepath.Abs(cfg.Output); err != nil {
+			return ImportedConfig{}, err
+		}
+	}
+	return cfg, nil
+}
+
+// cleanImportedPatterns trims patterns and drops a trailing slash, which
+// other tools use for folders.
+func cleanImportedPatterns(patterns []string) []string {
+	var out []string
+	for _, p := range patterns {
+		p = strings.TrimSuffix(strings.TrimSpace(p), "/")
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestImportConfigs(t *testing.T) {
+	root := t.TempDir()
+	config := filepath.Join(root, "repomix.config.json")
+	data := `{"output":{"filePath":"ctx.xml"},"include":["src/**/*.ts"],"ignore":{"customPatterns":["dist/","**/*.log"]}}`
+	if err := os.WriteFile(config, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	imported, err := ImportRepomixConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	space := &DirectorySpace{RootPath: root, Config: DefaultExtractionConfig()}
+	imported.Apply(space)
+	if space.OutputFilePath != filepath.Join(root, "ctx.xml") {
+		t.Errorf("output: got %q", space.OutputFilePath)
+	}
+	if !reflect.DeepEqual(space.Config.IncludePatterns, []string{"src/**/*.ts"}) {
+		t.Errorf("include: got %v", space.Config.IncludePatterns)
+	}
+	if !slices.Contains(space.Config.ExcludePatterns, "dist") || !slices.Contains(space.Config.ExcludePatterns, "**/*.log") {
+		t.Errorf("exclude: got %v", space.Config.ExcludePatterns)
+	}
+
+	imported, err = ImportCode2PromptArgs([]string{root, "--include=*.go,*.md", "--line-numbers", "-e", "vendor/", "-O", "out.md"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Root != root || !reflect.DeepEqual(imported.IncludePatterns, []string{"*.go", "*.md"}) ||
+		!reflect.DeepEqual(imported.ExcludePatterns, []string{"vendor"}) || !filepath.IsAbs(imported.Output) {
+		t.Errorf("code2prompt: got %+v", imported)
+	}
+	if _, err := ImportCode2PromptArgs([]string{"--include", "*.go"}); err == nil {
+		t.Error("expected an error without a path")
+	}
+}
@@ -0,0 +1,244 @@
+// Package core implements the size and token index of a workspace.
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IndexEntry holds the totals for a file, or for everything under a directory.
+type IndexEntry struct {
+	Size   int64 `json:"size"`
+	Tokens int   `json:"tokens"`
+	Files  int   `json:"files"`
+}
+
+// Index maps every indexed path under RootPath to its totals.
+// Directory entries are pre-aggregated so lookups stay O(1).
+type Index struct {
+	RootPath string                `json:"root_path"`
+	BuiltAt  time.Time             `json:"built_at"`
+	Entries  map[string]IndexEntry `json:"entries"`
+}
+
+// indexProgressEvery controls how often BuildIndex and EstimateDir report
+// progress.
+const indexProgressEvery = 250
+
+// BuildIndex walks root once and records the size and estimated token count of
+// every file not matched by the exclude or junk patterns. The estimate uses the
+// same chars / 4 heuristic as TokenCountingWriter, so no contents are read.
+// progress, if set, is called periodically with the number of files indexed.
+func BuildIndex(ctx context.Context, root string, cfg ExtractionConfig, progress func(files int)) (*Index, error) {
+	ix := &Index{RootPath: root, Entries: make(map[string]IndexEntry)}
+	count := 0
+
+	err := walkIndexed(ctx, root, root, cfg, func(path string, entry IndexEntry) {
+		ix.Entries[path] = entry
+
+		// Roll the file up into every ancestor up to the root
+		for dir := filepath.Dir(path); strings.HasPrefix(dir, root); dir = filepath.Dir(dir) {
+			total := ix.Entries[dir]
+			total.Size += entry.Size
+			total.Tokens += entry.Tokens
+			total.Files++
+			ix.Entries[dir] = total
+			if dir == root {
+				break
+			}
+		}
+
+		count++
+		if progress != nil && count%indexProgressEvery == 0 {
+			progress(count)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ix.BuiltAt = time.Now()
+	return ix, nil
+}
+
+// EstimateDir totals the files under dir, a folder of root, as BuildIndex
+// would record them, without keeping an entry per file. progress, if
+// non-nil, is called periodically with the number of files seen so far.
+func EstimateDir(ctx context.Context, root, dir string, cfg ExtractionConfig, progress func(files int)) (IndexEntry, error) {
+	var total IndexEntry
+	err := walkIndexed(ctx, root, dir, cfg, func(_ string, entry IndexEntry) {
+		total.Size += entry.Size
+		total.Tokens += entry.Tokens
+		total.Files++
+		if progress != nil && total.Files%indexProgressEvery == 0 {
+			progress(total.Files)
+		}
+	})
+	return total, err
+}
+
+// walkIndexed calls visit with the totals of every file under dir not
+// matched by the exclude or junk patterns, which apply relative to root.
+func walkIndexed(ctx context.Context, root, dir string, cfg ExtractionConfig, visit func(path string, entry IndexEntry)) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ctxErr
+		}
+		if err != nil {
+			return nil
+		}
+
+		relPath, _ := filepath.Rel(root, path)
+		if relPath != "." {
+			if isExcluded(relPath, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(relPath, JunkPatterns)) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		visit(path, IndexEntry{Size: info.Size(), Tokens: int(info.Size() / 4), Files: 1})
+		return nil
+	})
+}
+
+// Lookup returns the totals for a path, if it was indexed.
+func (ix *Index) Lookup(path string) (IndexEntry, bool) {
+	if ix == nil {
+		return IndexEntry{}, false
+	}
+	e, ok := ix.Entries[path]
+	return e, ok
+}
+
+// EstimateSelection returns the estimated tokens of the files a space would
+// export, ignoring structure and header overhead.
+func (ix *Index) EstimateSelection(cfg ExtractionConfig) int {
+	if ix == nil {
+		return 0
+	}
+	selections := cfg.checkedSet()
+	selected := 0
+	for _, sel := range cfg.ManualSelections {
+		// Nested selections are already covered by their ancestor
+		if isPathSelected(filepath.Dir(sel), ix.RootPath, selections) {
+			continue
+		}
+		selected += ix.Entries[sel].Tokens
+	}
+	for _, desel := range cfg.ManualDeselections {
+		// Only a deselection inside a selected folder takes anything away
+		if isPathSelected(filepath.Dir(desel), ix.RootPath, selections) {
+			selected -= ix.Entries[desel].Tokens
+		}
+	}
+	if cfg.IncludeMode {
+		return selected
+	}
+	return max(0, ix.Entries[ix.RootPath].Tokens-selected)
+}
+
+// FormatTokens renders a token count compactly, e.g. 950, 12.3k, 4.1M.
+func FormatTokens(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(n)/1_000_000)
+	case n >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(n)/1_000)
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// plural returns noun, made plural unless n is one.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return noun
+	}
+	return noun + "s"
+}
+
+// FormatBytes renders a size compactly, e.g. 512 B, 12.3 KB, 4.1 MB.
+func FormatBytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func toSet(paths []string) map[string]bool {
+	set := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		set[p] = true
+	}
+	return set
+}
+
+// IndexPath returns where the index for root is cached on disk.
+func IndexPath(root string) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(cacheDir, "pandabrew", "index", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// SaveIndex writes the index to its cache file.
+func SaveIndex(ix *Index) error {
+	path, err := IndexPath(ix.RootPath)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(ix)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// LoadIndex reads the cached index for root. A missing cache is not an error
+// and returns a nil index.
+func LoadIndex(root string) (*Index, error) {
+	path, err := IndexPath(root)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ix Index
+	if err := json.Unmarshal(data, &ix); err != nil {
+		return nil, fmt.Errorf("corrupt index file: %w", err)
+	}
+	return &ix, nil
+}
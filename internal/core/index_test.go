@@ -0,0 +1,82 @@
+package core
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestBuildIndex(t *testing.T) {
+	root := setupTestDir(t)
+	cfg := ExtractionConfig{ExcludePatterns: []string{"node_modules"}}
+
+	ix, err := BuildIndex(context.Background(), root, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ix.Lookup(filepath.Join(root, "node_modules", "pkg", "index.js")); ok {
+		t.Error("excluded file was indexed")
+	}
+	src, _ := ix.Lookup(filepath.Join(root, "src"))
+	if src.Files != 4 {
+		t.Errorf("src files: got %d, want 4", src.Files)
+	}
+	total, _ := ix.Lookup(root)
+	if total.Files != 6 {
+		t.Errorf("root files: got %d, want 6", total.Files)
+	}
+
+	// Nested selections must not be counted twice
+	cfg.IncludeMode = true
+	cfg.ManualSelections = []string{filepath.Join(root, "src"), filepath.Join(root, "src", "main.go")}
+	if got := ix.EstimateSelection(cfg); got != src.Tokens {
+		t.Errorf("include estimate: got %d, want %d", got, src.Tokens)
+	}
+	cfg.IncludeMode = false
+	if got, want := ix.EstimateSelection(cfg), total.Tokens-src.Tokens; got != want {
+		t.Errorf("exclude estimate: got %d, want %d", got, want)
+	}
+
+	// Deselecting inside a selected folder takes the file away again
+	cfg.IncludeMode = true
+	cfg.ManualSelections = []string{filepath.Join(root, "src")}
+	cfg.ManualDeselections = []string{filepath.Join(root, "src", "main.go")}
+	main, _ := ix.Lookup(filepath.Join(root, "src", "main.go"))
+	if got, want := ix.EstimateSelection(cfg), src.Tokens-main.Tokens; got != want {
+		t.Errorf("estimate with a deselection: got %d, want %d", got, want)
+	}
+	cfg.ManualDeselections = nil
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := BuildIndex(ctx, root, cfg, nil); err == nil {
+		t.Error("expected error from cancelled build")
+	}
+}
+
+func TestEstimateDir(t *testing.T) {
+	root := setupTestDir(t)
+	cfg := ExtractionConfig{ExcludePatterns: []string{"node_modules"}}
+	ix, err := BuildIndex(context.Background(), root, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := filepath.Join(root, "src")
+	got, err := EstimateDir(context.Background(), root, src, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, _ := ix.Lookup(src); got != want {
+		t.Errorf("EstimateDir(src) = %+v, want %+v", got, want)
+	}
+	if got, _ := EstimateDir(context.Background(), root, filepath.Join(root, "node_modules"), cfg, nil); got.Files != 0 {
+		t.Errorf("excluded folder counted %d files", got.Files)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := EstimateDir(ctx, root, src, cfg, nil); err == nil {
+		t.Error("expected error from cancelled estimate")
+	}
+}
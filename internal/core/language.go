@@ -0,0 +1,121 @@
+// Package core implements language detection and per-language statistics.
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// languageByExt maps lower-case file extensions to display names.
+var languageByExt = map[string]string{
+	".go": "Go", ".py": "Python", ".rb": "Ruby", ".rs": "Rust",
+	".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript",
+	".java": "Java", ".kt": "Kotlin", ".scala": "Scala", ".swift": "Swift",
+	".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++", ".cs": "C#",
+	".php": "PHP", ".lua": "Lua", ".dart": "Dart", ".ex": "Elixir", ".exs": "Elixir",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".ps1": "PowerShell",
+	".sql": "SQL", ".proto": "Protobuf", ".graphql": "GraphQL",
+	".html": "HTML", ".htm": "HTML", ".css": "CSS", ".scss": "SCSS", ".vue": "Vue", ".svelte": "Svelte",
+	".md": "Markdown", ".mdx": "Markdown", ".rst": "reStructuredText", ".txt": "Text",
+	".json": "JSON", ".yaml": "YAML", ".yml": "YAML", ".toml": "TOML", ".xml": "XML", ".ini": "INI",
+	".mod": "Go Module", ".sum": "Go Module",
+}
+
+// languageByName covers well-known files without a telling extension.
+var languageByName = map[string]string{
+	"Makefile": "Makefile", "Dockerfile": "Dockerfile", "Jenkinsfile": "Groovy",
+	"Gemfile": "Ruby", "Rakefile": "Ruby", "CMakeLists.txt": "CMake",
+}
+
+// LanguageOf guesses the language of a file from its name.
+func LanguageOf(path string) string {
+	base := filepath.Base(path)
+	if lang, ok := languageByName[base]; ok {
+		return lang
+	}
+	if lang, ok := languageByExt[strings.ToLower(filepath.Ext(base))]; ok {
+		return lang
+	}
+	return "Other"
+}
+
+// languageStats groups files by language, largest token share first.
+// Sizes are capped at maxSize to match what the report will contain.
+func languageStats(files []contentFile, maxSize int64) []LanguageStat {
+	acc := make(languageTotals)
+	for _, f := range files {
+		if f.Refused != nil {
+			continue
+		}
+		info, err := os.Stat(f.Path)
+		if err != nil {
+			continue
+		}
+		size := info.Size()
+		if maxSize > 0 {
+			size = min(size, maxSize)
+		}
+		acc.add(LanguageOf(f.Path), int(size/4))
+	}
+	return acc.sorted()
+}
+
+// languageTotals accumulates LanguageStats by language name.
+type languageTotals map[string]*LanguageStat
+
+func (t languageTotals) add(lang string, tokens int) {
+	stat, ok := t[lang]
+	if !ok {
+		stat = &LanguageStat{Language: lang}
+		t[lang] = stat
+	}
+	stat.Files++
+	stat.Tokens += tokens
+}
+
+func (t languageTotals) sorted() []LanguageStat {
+	stats := make([]LanguageStat, 0, len(t))
+	for _, stat := range t {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Tokens != stats[j].Tokens {
+			return stats[i].Tokens > stats[j].Tokens
+		}
+		return stats[i].Language < stats[j].Language
+	})
+	return stats
+}
+
+// writeLanguageStats writes the Languages block of the report header.
+func writeLanguageStats(w io.Writer, stats []LanguageStat) error {
+	if len(stats) == 0 {
+		return nil
+	}
+	total := 0
+	nameWidth := 0
+	for _, s := range stats {
+		total += s.Tokens
+		nameWidth = max(nameWidth, len(s.Language))
+	}
+
+	if _, err := fmt.Fprintln(w, "Languages:"); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		share := 0.0
+		if total > 0 {
+			share = float64(s.Tokens) / float64(total) * 100
+		}
+		if _, err := fmt.Fprintf(w, "  %-*s %5.1f%%  %d %s, ~%s %s\n",
+			nameWidth, s.Language, share, s.Files, plural(s.Files, "file"), FormatTokens(s.Tokens), plural(s.Tokens, "token")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,20 @@
+package core
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteLanguageStats(t *testing.T) {
+	var b strings.Builder
+	stats := []LanguageStat{{Language: "Go", Files: 2, Tokens: 3}, {Language: "Text", Files: 1, Tokens: 1}}
+	if err := writeLanguageStats(&b, stats); err != nil {
+		t.Fatal(err)
+	}
+	want := "Languages:\n" +
+		"  Go    75.0%  2 files, ~3 tokens\n" +
+		"  Text  25.0%  1 file, ~1 token\n"
+	if b.String() != want {
+		t.Errorf("got\n%s\nwant\n%s", b.String(), want)
+	}
+}
@@ -0,0 +1,97 @@
+// Package core implements the manifest written next to a report, listing
+// the files it includes for tools that should not parse the report.
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+)
+
+// ManifestPath is where the manifest of the report at output goes.
+func ManifestPath(output string) string {
+	return output + ".manifest.json"
+}
+
+// Manifest describes the files whose contents a report includes.
+type Manifest struct {
+	Header      string          `json:"header"` // Always ReportHeaderLine
+	Timestamp   time.Time       `json:"timestamp"`
+	Report      string          `json:"report"` // Base name of the text report
+	Roots       []string        `json:"roots"`
+	TotalFiles  int             `json:"total_files"`
+	TotalTokens int             `json:"total_tokens"`
+	Files       []ManifestEntry `json:"files"`
+}
+
+// ManifestEntry is one included file. Size, Tokens, SHA256 and ModTime are
+// those of the header fields of the same name; Error is set instead when
+// the file could not be read.
+type ManifestEntry struct {
+	Path      string    `json:"path"` // Relative to its root, with the root label in a multi-root report
+	Size      int64     `json:"size"`
+	Tokens    int64     `json:"tokens"`
+	SHA256    string    `json:"sha256,omitempty"`
+	ModTime   time.Time `json:"mtime,omitzero"`
+	Truncated bool      `json:"truncated,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// manifestRecorder collects an entry per file written to the contents.
+type manifestRecorder struct {
+	entries []ManifestEntry
+}
+
+// add notes f as read into res. A nil recorder does nothing.
+func (r *manifestRecorder) add(f contentFile, res contentResult, maxSize int64) {
+	if r == nil {
+		return
+	}
+	entry := ManifestEntry{Path: filepath.ToSlash(f.RelPath)}
+	if res.err != nil {
+		entry.Error = res.err.Error()
+	} else {
+		included := res.size
+		if maxSize > 0 {
+			included = min(included, maxSize)
+		}
+		entry.Size, entry.Tokens = res.size, included/4
+		entry.SHA256, entry.ModTime = res.sha, res.modTime.UTC()
+		entry.Truncated = included < res.size || res.clipped
+	}
+	r.entries = append(r.entries, entry)
+}
+
+// writeManifest writes the manifest of report, written for output, with
+// the entries recorded while writing it.
+func writeManifest(output, report string, mode fs.FileMode, meta ReportMetadata, roots []string, r *manifestRecorder) (err error) {
+	m := Manifest{
+		Header:      ReportHeaderLine,
+		Timestamp:   meta.Timestamp,
+		Report:      filepath.Base(report),
+		Roots:       roots,
+		TotalFiles:  meta.TotalFiles,
+		TotalTokens: meta.TotalTokens,
+		Files:       r.entries,
+	}
+	if m.Files == nil {
+		m.Files = []ManifestEntry{}
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	f, err := createReport(ManifestPath(output), mode)
+	if err != nil {
+		return fmt.Errorf("failed to create manifest: %w", err)
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}()
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
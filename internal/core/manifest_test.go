@@ -0,0 +1,106 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{"main.go": "package main\n", "lib/big.txt": strings.Repeat("x", 64)}
+	for name, data := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	output := filepath.Join(t.TempDir(), "out.txt")
+	cfg := DefaultExtractionConfig()
+	cfg.ManualSelections = []string{root}
+	cfg.Manifest = true
+	space := &DirectorySpace{RootPath: root, OutputFilePath: output, Config: cfg}
+
+	meta, err := RunExtractionWithOptions(space, ExtractOptions{MaxFileSize: 32})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(ManifestPath(output))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Report != "out.txt" || m.TotalFiles != meta.TotalFiles || m.TotalTokens != meta.TotalTokens || len(m.Roots) != 1 || m.Roots[0] != root {
+		t.Errorf("manifest = %+v", m)
+	}
+	if len(m.Files) != 2 {
+		t.Fatalf("files = %+v", m.Files)
+	}
+	for _, e := range m.Files {
+		content := files[e.Path]
+		sum := sha256.Sum256([]byte(content))
+		if e.Size != int64(len(content)) || e.SHA256 != hex.EncodeToString(sum[:]) || e.ModTime.IsZero() {
+			t.Errorf("entry = %+v", e)
+		}
+		if truncated := len(content) > 32; e.Truncated != truncated || e.Tokens != int64(min(len(content), 32)/4) {
+			t.Errorf("entry %s: truncated %v, tokens %d", e.Path, e.Truncated, e.Tokens)
+		}
+	}
+
+	// Without the option, or in a dry run, there is no manifest
+	output = filepath.Join(t.TempDir(), "out.txt")
+	space.OutputFilePath = output
+	if _, err := RunExtractionWithOptions(space, ExtractOptions{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	space.Config.Manifest = false
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(ManifestPath(output)); !os.IsNotExist(err) {
+		t.Errorf("manifest written: %v", err)
+	}
+}
+
+func TestSubstitutedFileHashes(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{".env": "TOKEN=abc\n", "icon.svg": `<svg xmlns="http://www.w3.org/2000/svg"><path/></svg>`}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := filepath.Join(t.TempDir(), "out.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: ExtractionConfig{
+		MaskConfigValues:  true,
+		ImagePlaceholders: true,
+		HeaderFields:      []string{HeaderSHA},
+	}}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	report, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Masks and placeholders stand in for the file, whose hash is kept
+	for name, data := range files {
+		if header := "--- file: " + name + " [sha256=" + hashString(data) + "] ---\n"; !strings.Contains(string(report), header) {
+			t.Errorf("report lacks %q:\n%s", header, report)
+		}
+	}
+	if !strings.Contains(string(report), "TOKEN=***\n") {
+		t.Errorf(".env not masked:\n%s", report)
+	}
+}
@@ -11,7 +11,9 @@ type Session struct {
 	ID            string            `json:"id"`
 	ActiveSpaceID string            `json:"active_space_id"`
 	Spaces        []*DirectorySpace `json:"spaces"`
-	Theme         string            `json:"theme"` // Added for persistence
+	Theme         string            `json:"theme"`                  // Added for persistence
+	Transparent   bool              `json:"transparent,omitempty"`  // Leave the theme background to the terminal
+	ActiveGroup   string            `json:"active_group,omitempty"` // Only this group's tabs are shown; empty shows all
 	CreatedAt     time.Time         `json:"created_at"`
 	UpdatedAt     time.Time         `json:"updated_at"`
 }
@@ -24,6 +26,13 @@ type DirectorySpace struct {
 	Config         ExtractionConfig `json:"config"`
 	ExpandedPaths  []string         `json:"expanded_paths"`
 	CursorPath     string           `json:"cursor_path"`
+	Group          string           `json:"group,omitempty"` // Named profile ("work", "oss", ...) the tab belongs to
+	// Recent tree and global searches, newest first
+	SearchHistory []string `json:"search_history,omitempty"`
+
+	// Where the tab was left, restored when its view is rebuilt
+	ScrollOffset int    `json:"scroll_offset,omitempty"` // First visible tree row
+	SearchQuery  string `json:"search_query,omitempty"`
 }
 
 // ExtractionConfig controls how the walker and generator behave.
@@ -31,21 +40,118 @@ type ExtractionConfig struct {
 	IncludePatterns  []string `json:"include_patterns"`
 	ExcludePatterns  []string `json:"exclude_patterns"`
 	ManualSelections []string `json:"manual_selections"`
+	// ManualDeselections are paths unchecked inside a checked folder. The
+	// nearest checked or unchecked path above a file decides it.
+	ManualDeselections []string `json:"manual_deselections,omitempty"`
 
 	// AlwaysShowStructure contains paths (directories) whose immediate children
 	// should be listed in the structure view regardless of exclusion status.
 	// This is the data payload derived from the TUI state.
 	AlwaysShowStructure []string `json:"always_show_structure"`
 
+	// OutputGlobs match previous exports inside the tree that must never be
+	// re-included. Files starting with the report header are skipped regardless.
+	OutputGlobs []string `json:"output_globs,omitempty"`
+
 	// Options
 	IncludeMode   bool `json:"include_mode"`
 	FilenamesOnly bool `json:"filenames_only"`
 	MinifyContent bool `json:"minify_content"`
+	SkipJunk      bool `json:"skip_junk"`                 // Skip JunkPatterns (.DS_Store, *.swp, .idea/, ...)
+	GitInfo       bool `json:"git_info,omitempty"`        // Annotate file headers with their last commit
+	AutoSelectNew bool `json:"auto_select_new,omitempty"` // TUI: select new files whose same-type siblings are all selected
+	DimExcluded   bool `json:"dim_excluded,omitempty"`    // TUI: grey out excluded files in the tree, naming the pattern
+
+	// HeaderFields adds metadata (HeaderSize, HeaderTokens, HeaderLanguage,
+	// HeaderModTime, HeaderSHA) to every file header line, so consumers can
+	// tell files apart without going back to the disk.
+	HeaderFields []string `json:"header_fields,omitempty"`
+	// FileHeader and FileFooter replace the "--- file: {path} ---" and "---"
+	// lines around each file; {path} stands for the path. Escaping
+	// (EscapeBoundary or EscapeLength) keeps contents that contain the
+	// footer from breaking parsers of the report.
+	FileHeader string `json:"file_header,omitempty"`
+	FileFooter string `json:"file_footer,omitempty"`
+	Escaping   string `json:"escaping,omitempty"`
+
+	// DocumentText replaces .pdf and .docx files with their plain text. DOCX
+	// is read natively and PDF needs pdftotext. DocumentCommands overrides
+	// or adds converters by extension, e.g. ".odt": "odt2txt {file}"; it is
+	// kept in the session only, never in a shared project config.
+	DocumentText     bool              `json:"document_text,omitempty"`
+	DocumentCommands map[string]string `json:"document_commands,omitempty"`
+	// ImagePlaceholders replaces images with a line giving their format,
+	// dimensions and size; SVGs also list their top-level elements.
+	ImagePlaceholders bool `json:"image_placeholders,omitempty"`
+	// MaskConfigValues includes .env, YAML, JSON and INI style files with
+	// every value replaced by "***", keeping keys and structure.
+	MaskConfigValues bool `json:"mask_config_values,omitempty"`
 
 	// Visibility Options
 	ShowExcluded  bool `json:"show_excluded"`  // Show EVERYTHING
 	ShowContext   bool `json:"show_context"`   // Show SIBLINGS of selected items
 	StructureView bool `json:"structure_view"` // Toggle: If true, expanded TUI folders are added to AlwaysShowStructure
+	FullTreeMap   bool `json:"full_tree_map"`  // List the whole project (minus excludes) regardless of selection
+
+	// ContextRules tune ShowContext per level around the selection (index 0 is
+	// the siblings). Empty keeps the classic behaviour of listing every folder
+	// on the path to a selection.
+	ContextRules []ContextRule `json:"context_rules,omitempty"`
+	// ContextImports treats Go packages imported by the selection as siblings.
+	// It applies in include mode only.
+	ContextImports bool `json:"context_imports,omitempty"`
+
+	// Formats lists the report formats (see ReportFormats) written next to
+	// the output path, used when ExtractOptions.Formats is empty. Empty
+	// writes the text report alone.
+	Formats []string `json:"formats,omitempty"`
+	// LineNumbers prefixes each line of the contents with its number. With
+	// MinifyContent, blank lines and trailing whitespace are dropped.
+	LineNumbers bool `json:"line_numbers,omitempty"`
+	// TokenBreakdown closes the report with its tokens by section and by
+	// top-level folder.
+	TokenBreakdown bool `json:"token_breakdown,omitempty"`
+	// Manifest writes a JSON manifest of the included files next to the
+	// report, see ManifestPath.
+	Manifest bool `json:"manifest,omitempty"`
+	// Task names a TaskPreset whose instructions wrap the report. Empty
+	// means none.
+	Task string `json:"task,omitempty"`
+
+	// StructureMaxDepth caps how many levels the structure section lists.
+	// Deeper folders are summarized by file count. 0 means unlimited.
+	StructureMaxDepth int `json:"structure_max_depth,omitempty"`
+
+	// RecentCommits adds the last N commit messages as a Recent Changes
+	// section. With RecentCommitsScoped only commits touching the selection count.
+	RecentCommits       int  `json:"recent_commits,omitempty"`
+	RecentCommitsScoped bool `json:"recent_commits_scoped,omitempty"`
+
+	// NestedRepos sets the policy (NestedRepoFull, NestedRepoStructure or
+	// NestedRepoSkip) of submodules and nested repositories by relative path.
+	// Others follow NestedRepoPolicy, which defaults to NestedRepoFull.
+	NestedRepos      map[string]string `json:"nested_repos,omitempty"`
+	NestedRepoPolicy string            `json:"nested_repo_policy,omitempty"`
+
+	// GeneratedPolicy applies a nested repository policy to generated code,
+	// minified bundles and license files (see DetectLowValue): list them as
+	// structure only, or skip them. Empty means NestedRepoFull.
+	GeneratedPolicy string `json:"generated_policy,omitempty"`
+
+	// TestsPolicy applies a nested repository policy to test files and
+	// folders (see IsTestPath). Empty means NestedRepoFull.
+	TestsPolicy string `json:"tests_policy,omitempty"`
+
+	// SelectionHashes records the content of selected files as of the last
+	// export, so a selected file that moves can be found again by content.
+	SelectionHashes map[string]FileHash `json:"selection_hashes,omitempty"`
+}
+
+// FileHash identifies a file's content.
+type FileHash struct {
+	Size    int64     `json:"size"`
+	SHA256  string    `json:"sha256"`
+	ModTime time.Time `json:"mod_time,omitzero"` // Modification time when hashed, to skip unchanged files
 }
 
 // ReportMetadata holds data for the final report header.
@@ -54,6 +160,18 @@ type ReportMetadata struct {
 	TotalFiles    int
 	TotalTokens   int
 	SelectionMode string
+	Branch        string // Checked-out branch of a single-root report, if any
+	Languages     []LanguageStat
+	Vendored      []VendoredDir   // Vendored directories files were included from
+	Breakdown     *TokenBreakdown // Set with ExtractionConfig.TokenBreakdown
+	Task          *TaskPreset     // Set with ExtractionConfig.Task
+}
+
+// LanguageStat summarizes the included files of one language.
+type LanguageStat struct {
+	Language string
+	Files    int
+	Tokens   int
 }
 
 // DirEntry represents a single file/folder for lazy loading.
@@ -0,0 +1,65 @@
+// Package core implements the handling of nested git repositories.
+package core
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Policies for git submodules and other repositories nested in a project.
+const (
+	NestedRepoFull      = "full"      // Treat like any other folder
+	NestedRepoStructure = "structure" // List files in the structure, never their contents
+	NestedRepoSkip      = "skip"      // Leave out entirely
+)
+
+// NestedRepoPolicies lists the valid policies in the order the TUI cycles them.
+var NestedRepoPolicies = []string{NestedRepoFull, NestedRepoStructure, NestedRepoSkip}
+
+// ValidNestedRepoPolicy reports whether p is one of NestedRepoPolicies.
+func ValidNestedRepoPolicy(p string) bool {
+	return slices.Contains(NestedRepoPolicies, p)
+}
+
+// NestedRepoPolicyFor returns the policy for the nested repository at the
+// slash-separated relative path rel.
+func (c ExtractionConfig) NestedRepoPolicyFor(rel string) string {
+	if p, ok := c.NestedRepos[rel]; ok {
+		return p
+	}
+	if c.NestedRepoPolicy != "" {
+		return c.NestedRepoPolicy
+	}
+	return NestedRepoFull
+}
+
+// isNestedRepo reports whether dir holds a .git folder, or the .git file
+// of a submodule or worktree.
+func isNestedRepo(dir string) bool {
+	_, err := os.Lstat(filepath.Join(dir, ".git"))
+	return err == nil
+}
+
+// FindNestedRepos lists the repositories nested under root, as slash paths
+// relative to it, skipping excluded and junk folders. Repositories inside
+// nested repositories are not searched for.
+func FindNestedRepos(root string, cfg ExtractionConfig) []string {
+	var repos []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == root {
+			return nil
+		}
+		relPath, _ := filepath.Rel(root, path)
+		if isExcluded(relPath, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(relPath, JunkPatterns)) || d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		if isNestedRepo(path) {
+			repos = append(repos, filepath.ToSlash(relPath))
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return repos
+}
@@ -0,0 +1,49 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNestedRepoPolicies(t *testing.T) {
+	root := setupTestDir(t)
+	for _, repo := range []string{"vendored", "libs/sub"} {
+		dir := filepath.Join(root, filepath.FromSlash(repo))
+		if err := os.MkdirAll(filepath.Join(dir, ".git"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "code.go"), []byte("package sub"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg := DefaultExtractionConfig()
+	if got := FindNestedRepos(root, cfg); !slices.Equal(got, []string{"libs/sub", "vendored"}) {
+		t.Fatalf("FindNestedRepos = %v", got)
+	}
+
+	out := filepath.Join(t.TempDir(), "out.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: cfg}
+	space.Config.IncludeMode = false
+	space.Config.NestedRepoPolicy = NestedRepoStructure
+	space.Config.NestedRepos = map[string]string{"vendored": NestedRepoSkip}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(out)
+	report := string(data)
+	if strings.Contains(report, "vendored") {
+		t.Error("skipped repository is in the report")
+	}
+	if !strings.Contains(report, "│   ├── sub/") || !strings.Contains(report, "code.go") {
+		t.Errorf("structure-only repository is not listed:\n%s", report)
+	}
+	if strings.Contains(report, "--- file: libs/sub/code.go") {
+		t.Error("structure-only repository contents were exported")
+	}
+	if !strings.Contains(report, "--- file: src/main.go") {
+		t.Error("regular files are missing")
+	}
+}
@@ -0,0 +1,43 @@
+// Package core implements checking include and exclude patterns for
+// mistakes that would make them match nothing.
+package core
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// PatternIssue is a problem with one include or exclude pattern.
+type PatternIssue struct {
+	Pattern string
+	Message string
+	Invalid bool // The pattern never matches; otherwise it is a warning
+}
+
+// strayBackslash matches a backslash that escapes no glob character, most
+// likely meant as a Windows path separator.
+var strayBackslash = regexp.MustCompile(`\\([^*?\[\]{}\\]|$)`)
+
+// CheckPatterns reports the patterns that are not valid doublestar syntax,
+// and warns about absolute paths, backslashes and a leading "./", which
+// match nothing since patterns are matched against slash-separated paths
+// relative to the root.
+func CheckPatterns(patterns []string) []PatternIssue {
+	var issues []PatternIssue
+	for _, p := range patterns {
+		switch {
+		case !doublestar.ValidatePattern(p):
+			issues = append(issues, PatternIssue{Pattern: p, Message: "invalid pattern syntax", Invalid: true})
+		case strings.HasPrefix(p, "/") || filepath.IsAbs(p):
+			issues = append(issues, PatternIssue{Pattern: p, Message: "absolute path; patterns are relative to the root"})
+		case strayBackslash.MatchString(p):
+			issues = append(issues, PatternIssue{Pattern: p, Message: `backslash escapes the next character; use / between folders`})
+		case strings.HasPrefix(p, "./"):
+			issues = append(issues, PatternIssue{Pattern: p, Message: `leading "./" never matches; drop it`})
+		}
+	}
+	return issues
+}
@@ -0,0 +1,19 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestCheckPatterns(t *testing.T) {
+	issues := CheckPatterns([]string{"src/**", "*.{go,md}", "[a-", "/abs/path", `cmd\main.go`, "./docs"})
+	var got []string
+	for _, i := range issues {
+		got = append(got, fmt.Sprintf("%s %v", i.Pattern, i.Invalid))
+	}
+	want := []string{"[a- true", "/abs/path false", `cmd\main.go false`, "./docs false"}
+	if !slices.Equal(got, want) {
+		t.Errorf("CheckPatterns = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,207 @@
+// Package core implements turning a manual selection into include patterns,
+// and a path into an exclude pattern.
+package core
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// SelectionToPatterns converts the manual selections of an include-mode
+// space into include patterns that select the same files today and keep
+// selecting new files added later:
+//
+//   - a selected folder becomes "dir/**"
+//   - two or more selected files that are every file with their extension
+//     in a folder become "dir/*.ext", or "dir/**/*.ext" when that also holds
+//     for every subfolder
+//   - any other file becomes its own path
+//
+// Files directly in the root are only grouped, never turned into their own
+// pattern: a pattern without a slash matches at any depth, so they are
+// returned as kept selections instead. A selected folder with deselections
+// below it counts as its remaining files, so the patterns leave the
+// deselections out and the space needs none.
+func SelectionToPatterns(space *DirectorySpace) (patterns, kept []string) {
+	root := space.RootPath
+	cfg := space.Config
+	skip := func(rel string) bool {
+		return isExcluded(rel, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(rel, JunkPatterns))
+	}
+	deselected := make(map[string]bool, len(cfg.ManualDeselections))
+	for _, desel := range cfg.ManualDeselections {
+		deselected[desel] = true
+	}
+	holes := func(dir string) bool {
+		return slices.ContainsFunc(cfg.ManualDeselections, func(d string) bool {
+			return dir == root || strings.HasPrefix(d, dir+string(filepath.Separator))
+		})
+	}
+
+	selected := make(map[string]bool) // Slash paths of selected files not under a selected folder
+	var folders []string
+	for _, sel := range cfg.ManualSelections {
+		rel := relativeTo(root, sel)
+		if rel == "." && !holes(root) {
+			return []string{"**"}, nil
+		}
+		if rel == "" {
+			continue // Outside the root
+		}
+		info, err := os.Stat(sel)
+		switch {
+		case err != nil || !info.IsDir():
+			selected[rel] = true
+		case !holes(sel):
+			folders = append(folders, rel)
+		default:
+			_ = filepath.WalkDir(sel, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || p == sel {
+					return nil
+				}
+				if deselected[p] || skip(relativeTo(root, p)) {
+					if d.IsDir() {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if !d.IsDir() {
+					selected[relativeTo(root, p)] = true
+				}
+				return nil
+			})
+		}
+	}
+	for rel := range selected {
+		if slices.ContainsFunc(folders, func(f string) bool { return strings.HasPrefix(rel, f+"/") }) {
+			delete(selected, rel)
+		}
+	}
+	for _, f := range folders {
+		if !slices.ContainsFunc(folders, func(other string) bool { return strings.HasPrefix(f, other+"/") }) {
+			patterns = append(patterns, f+"/**")
+		}
+	}
+	if len(selected) == 0 {
+		slices.Sort(patterns)
+		return patterns, nil
+	}
+
+	// Count every exported file by folder and extension, and how many of
+	// them are selected, both directly and including subfolders
+	type group struct{ dir, ext string }
+	total, picked := make(map[group]int), make(map[group]int)
+	deepTotal, deepPicked := make(map[group]int), make(map[group]int)
+	_ = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || p == root {
+			return nil
+		}
+		rel := relativeTo(root, p)
+		if skip(rel) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		ext := path.Ext(rel)
+		if ext == "" {
+			return nil
+		}
+		dir := path.Dir(rel)
+		total[group{dir, ext}]++
+		if selected[rel] {
+			picked[group{dir, ext}]++
+		}
+		for anc := dir; ; anc = path.Dir(anc) {
+			deepTotal[group{anc, ext}]++
+			if selected[rel] {
+				deepPicked[group{anc, ext}]++
+			}
+			if anc == "." {
+				break
+			}
+		}
+		return nil
+	})
+
+	// Shallowest folders first, so a recursive pattern absorbs its subfolders
+	var groups []group
+	for g, n := range picked {
+		if n >= 2 && n == total[g] {
+			groups = append(groups, g)
+		}
+	}
+	slices.SortFunc(groups, func(a, b group) int {
+		if da, db := strings.Count(a.dir, "/"), strings.Count(b.dir, "/"); da != db {
+			return da - db
+		}
+		return strings.Compare(a.dir+a.ext, b.dir+b.ext)
+	})
+	var deep []group // Groups turned into a recursive pattern
+	under := func(dir, ext string) bool {
+		return slices.ContainsFunc(deep, func(g group) bool {
+			return g.ext == ext && (g.dir == "." || dir == g.dir || strings.HasPrefix(dir, g.dir+"/"))
+		})
+	}
+	var shallow []group
+	for _, g := range groups {
+		if under(g.dir, g.ext) {
+			continue
+		}
+		prefix := g.dir + "/"
+		if g.dir == "." {
+			prefix = ""
+		}
+		switch {
+		case deepPicked[g] == deepTotal[g]:
+			deep = append(deep, g)
+			patterns = append(patterns, prefix+"**/*"+g.ext)
+		case g.dir != ".":
+			shallow = append(shallow, g)
+			patterns = append(patterns, prefix+"*"+g.ext)
+		}
+	}
+
+	for rel := range selected {
+		dir, ext := path.Dir(rel), path.Ext(rel)
+		switch {
+		case under(dir, ext) || slices.Contains(shallow, group{dir, ext}):
+		case dir != ".":
+			patterns = append(patterns, rel)
+		default:
+			kept = append(kept, filepath.Join(root, filepath.FromSlash(rel)))
+		}
+	}
+	slices.Sort(patterns)
+	slices.Sort(kept)
+	return patterns, kept
+}
+
+// globMeta are the characters doublestar gives a meaning to.
+const globMeta = `*?[]{}\`
+
+// ExcludePatternFor returns an exclude pattern matching the path at rel,
+// slash-separated and relative to the root, and nothing else: folders are
+// anchored by a trailing "/**" and glob characters in names are escaped. A
+// file at the root can't be anchored, so its pattern also matches files of
+// the same name in subfolders.
+func ExcludePatternFor(rel string, isDir bool) string {
+	var b strings.Builder
+	for _, r := range rel {
+		if strings.ContainsRune(globMeta, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	if isDir {
+		b.WriteString("/**")
+	}
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestSelectionToPatterns(t *testing.T) {
+	root := setupTestDir(t)
+	abs := func(rels ...string) []string {
+		var paths []string
+		for _, rel := range rels {
+			paths = append(paths, filepath.Join(root, filepath.FromSlash(rel)))
+		}
+		return paths
+	}
+	fileHeaders := regexp.MustCompile(`(?m)^--- file: .*$`)
+
+	tests := []struct {
+		name         string
+		selections   []string
+		deselections []string
+		wantPatterns []string
+		wantKept     []string
+	}{
+		{"folder group", abs("src/main.go", "src/utils.go", "src/data.txt"), nil, []string{"src/*.go", "src/data.txt"}, nil},
+		{"recursive group", abs("src/main.go", "src/utils.go", "src/lib/helper.go"), nil, []string{"src/**/*.go"}, nil},
+		{"folders and root files", abs("src/lib", "src/lib/helper.go", "README.md"), nil, []string{"src/lib/**"}, abs("README.md")},
+		{"single file", abs("src/main.go"), nil, []string{"src/main.go"}, nil},
+		{"root", []string{root}, nil, []string{"**"}, nil},
+		{"folder with a deselection", abs("src"), abs("src/lib"), []string{"src/*.go", "src/data.txt"}, nil},
+		{"root with a deselection", []string{root}, abs("src"), nil, abs(".env", "README.md")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			space := &DirectorySpace{RootPath: root, Config: DefaultExtractionConfig()}
+			space.Config.ManualSelections = tt.selections
+			space.Config.ManualDeselections = tt.deselections
+			patterns, kept := SelectionToPatterns(space)
+			if !reflect.DeepEqual(patterns, tt.wantPatterns) || !reflect.DeepEqual(kept, tt.wantKept) {
+				t.Fatalf("got %v, kept %v; want %v, kept %v", patterns, kept, tt.wantPatterns, tt.wantKept)
+			}
+
+			// The converted space exports the same files
+			export := func(space *DirectorySpace) []string {
+				space.OutputFilePath = filepath.Join(t.TempDir(), "out.txt")
+				if _, err := RunExtraction(space); err != nil {
+					t.Fatal(err)
+				}
+				data, _ := os.ReadFile(space.OutputFilePath)
+				return fileHeaders.FindAllString(string(data), -1)
+			}
+			converted := &DirectorySpace{RootPath: root, Config: space.Config.Clone()}
+			converted.Config.ManualSelections = kept
+			converted.Config.ManualDeselections = nil
+			converted.Config.IncludePatterns = patterns
+			if before, after := export(space), export(converted); !reflect.DeepEqual(before, after) {
+				t.Errorf("exports differ:\nselections: %v\npatterns:   %v", before, after)
+			}
+		})
+	}
+}
+
+func TestExcludePatternFor(t *testing.T) {
+	cfg := ExtractionConfig{ExcludePatterns: []string{
+		ExcludePatternFor("dist", true),
+		ExcludePatternFor("app/[id].tsx", false),
+	}}
+	for rel, want := range map[string]bool{
+		"dist/app.js":   true,
+		"web/dist/x.js": false, // Only the folder at the root
+		"app/[id].tsx":  true,
+		"app/i.tsx":     false, // Brackets are not a character class
+	} {
+		if got := cfg.Excludes(rel); got != want {
+			t.Errorf("%v exclude %s = %v, want %v", cfg.ExcludePatterns, rel, got, want)
+		}
+	}
+	if issues := CheckPatterns(cfg.ExcludePatterns); len(issues) > 0 {
+		t.Errorf("escaped patterns flagged: %v", issues)
+	}
+}
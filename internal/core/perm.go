@@ -0,0 +1,74 @@
+// Package core implements the permissions of the files and folders an
+// export creates.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ParseFileMode parses an octal permission like "600" or "0o750". An empty
+// string parses as 0, the default.
+func ParseFileMode(raw string) (fs.FileMode, error) {
+	s := strings.TrimSpace(raw)
+	if s == "" {
+		return 0, nil
+	}
+	s = strings.TrimPrefix(strings.TrimPrefix(s, "0o"), "0O")
+	n, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || n > 0o777 {
+		return 0, fmt.Errorf("invalid permissions %q: want octal like 600 or 0644", raw)
+	}
+	return fs.FileMode(n), nil
+}
+
+// createReport creates or truncates the report at path. A non-zero mode is
+// set exactly, whatever the umask, also on a report written before with
+// other permissions.
+func createReport(path string, mode fs.FileMode) (*os.File, error) {
+	if mode == 0 {
+		return os.Create(path)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Chmod(mode); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// mkdirReport creates dir and its missing parents. A non-zero mode is set
+// exactly on the folders created, whatever the umask; existing ones are left
+// as they are.
+func mkdirReport(dir string, mode fs.FileMode) error {
+	if mode == 0 {
+		return os.MkdirAll(dir, 0o755)
+	}
+	var missing []string
+	for d := filepath.Clean(dir); ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); !errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return err
+	}
+	for i := len(missing) - 1; i >= 0; i-- {
+		if err := os.Chmod(missing[i], mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package core
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestOutputPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	for _, raw := range []string{"0x1", "800", "1777", "abc"} {
+		if _, err := ParseFileMode(raw); err == nil {
+			t.Errorf("ParseFileMode(%q) accepted", raw)
+		}
+	}
+	if mode, err := ParseFileMode("0o640"); err != nil || mode != 0o640 {
+		t.Errorf("ParseFileMode(0o640) = %o, %v", mode, err)
+	}
+
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outDir := t.TempDir()
+	output := filepath.Join(outDir, "reports", "daily", "out.txt")
+	cfg := DefaultExtractionConfig()
+	cfg.ManualSelections = []string{root}
+	cfg.Formats = []string{FormatText, FormatJSON}
+	space := &DirectorySpace{RootPath: root, OutputFilePath: output, Config: cfg}
+
+	// The modes apply whatever the umask, to the folders created only
+	if err := os.Mkdir(filepath.Join(outDir, "reports"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunExtractionWithOptions(space, ExtractOptions{FileMode: 0o600, DirMode: 0o750}); err != nil {
+		t.Fatal(err)
+	}
+	for path, want := range map[string]fs.FileMode{
+		output:                               0o600,
+		FormatOutputPath(output, FormatJSON): 0o600,
+		filepath.Dir(output):                 0o750,
+		filepath.Join(outDir, "reports"):     0o755, // Existed already
+	} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s: mode %o, want %o", path, got, want)
+		}
+	}
+
+	// A report written before with other permissions gets the mode too
+	if err := os.Chmod(output, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := RunExtractionWithOptions(space, ExtractOptions{FileMode: 0o600}); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(output); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("rewritten report: %v, %v", info.Mode(), err)
+	}
+}
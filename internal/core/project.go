@@ -0,0 +1,243 @@
+// Package core implements the shareable project config format.
+package core
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigVersion is the current version of the project config format.
+const ProjectConfigVersion = 1
+
+// ProjectConfig is a space's settings in a form that can be committed to a
+// repository: every path is relative to the project root and slash-separated.
+type ProjectConfig struct {
+	Version int    `yaml:"version"`
+	Output  string `yaml:"output,omitempty"`
+
+	IncludeMode bool     `yaml:"include_mode"`
+	Include     []string `yaml:"include,omitempty"`
+	Exclude     []string `yaml:"exclude,omitempty"`
+	Selections  []string `yaml:"selections,omitempty"`
+	Deselected  []string `yaml:"deselected,omitempty"`
+	Structure   []string `yaml:"always_show_structure,omitempty"`
+	OutputGlobs []string `yaml:"output_globs,omitempty"`
+
+	FilenamesOnly     bool          `yaml:"filenames_only,omitempty"`
+	MinifyContent     bool          `yaml:"minify_content,omitempty"`
+	SkipJunk          bool          `yaml:"skip_junk"`
+	GitInfo           bool          `yaml:"git_info,omitempty"`
+	HeaderFields      []string      `yaml:"header_fields,omitempty"`
+	FileHeader        string        `yaml:"file_header,omitempty"`
+	FileFooter        string        `yaml:"file_footer,omitempty"`
+	Escaping          string        `yaml:"escaping,omitempty"`
+	ShowExcluded      bool          `yaml:"show_excluded,omitempty"`
+	ShowContext       bool          `yaml:"show_context,omitempty"`
+	StructureView     bool          `yaml:"structure_view,omitempty"`
+	FullTreeMap       bool          `yaml:"full_tree_map,omitempty"`
+	ContextRules      []ContextRule `yaml:"context_rules,omitempty"`
+	ContextImports    bool          `yaml:"context_imports,omitempty"`
+	StructureMaxDepth int           `yaml:"structure_max_depth,omitempty"`
+	Formats           []string      `yaml:"formats,omitempty"`
+	LineNumbers       bool          `yaml:"line_numbers,omitempty"`
+	TokenBreakdown    bool          `yaml:"token_breakdown,omitempty"`
+	Manifest          bool          `yaml:"manifest,omitempty"`
+	Task              string        `yaml:"task,omitempty"`
+
+	RecentCommits       int  `yaml:"recent_commits,omitempty"`
+	RecentCommitsScoped bool `yaml:"recent_commits_scoped,omitempty"`
+
+	NestedRepos      map[string]string `yaml:"nested_repos,omitempty"`
+	NestedRepoPolicy string            `yaml:"nested_repo_policy,omitempty"`
+	GeneratedPolicy  string            `yaml:"generated_policy,omitempty"`
+	TestsPolicy      string            `yaml:"tests_policy,omitempty"`
+
+	// Converter commands are left out: a committed config must not be able
+	// to run programs on the machine of whoever exports the project.
+	DocumentText bool `yaml:"document_text,omitempty"`
+
+	ImagePlaceholders bool `yaml:"image_placeholders,omitempty"`
+	MaskConfigValues  bool `yaml:"mask_config_values,omitempty"`
+}
+
+// NewProjectConfig captures the settings of space. Paths outside the root
+// are dropped since they would not resolve on another machine.
+func NewProjectConfig(space *DirectorySpace) ProjectConfig {
+	cfg := space.Config
+	return ProjectConfig{
+		Version:           ProjectConfigVersion,
+		Output:            relativeTo(space.RootPath, space.OutputFilePath),
+		IncludeMode:       cfg.IncludeMode,
+		Include:           cfg.IncludePatterns,
+		Exclude:           cfg.ExcludePatterns,
+		Selections:        relativePaths(space.RootPath, cfg.ManualSelections),
+		Deselected:        relativePaths(space.RootPath, cfg.ManualDeselections),
+		Structure:         relativePaths(space.RootPath, cfg.AlwaysShowStructure),
+		OutputGlobs:       cfg.OutputGlobs,
+		FilenamesOnly:     cfg.FilenamesOnly,
+		MinifyContent:     cfg.MinifyContent,
+		SkipJunk:          cfg.SkipJunk,
+		GitInfo:           cfg.GitInfo,
+		HeaderFields:      cfg.HeaderFields,
+		FileHeader:        cfg.FileHeader,
+		FileFooter:        cfg.FileFooter,
+		Escaping:          cfg.Escaping,
+		ShowExcluded:      cfg.ShowExcluded,
+		ShowContext:       cfg.ShowContext,
+		StructureView:     cfg.StructureView,
+		FullTreeMap:       cfg.FullTreeMap,
+		ContextRules:      cfg.ContextRules,
+		ContextImports:    cfg.ContextImports,
+		StructureMaxDepth: cfg.StructureMaxDepth,
+		Formats:           cfg.Formats,
+		LineNumbers:       cfg.LineNumbers,
+		TokenBreakdown:    cfg.TokenBreakdown,
+		Manifest:          cfg.Manifest,
+		Task:              cfg.Task,
+
+		RecentCommits:       cfg.RecentCommits,
+		RecentCommitsScoped: cfg.RecentCommitsScoped,
+
+		NestedRepos:      cfg.NestedRepos,
+		NestedRepoPolicy: cfg.NestedRepoPolicy,
+		GeneratedPolicy:  cfg.GeneratedPolicy,
+		TestsPolicy:      cfg.TestsPolicy,
+
+		DocumentText: cfg.DocumentText,
+
+		ImagePlaceholders: cfg.ImagePlaceholders,
+		MaskConfigValues:  cfg.MaskConfigValues,
+	}
+}
+
+// Apply replaces the settings of space with the project config, resolving
+// paths against the space root. The document commands of space, set by the
+// user, are kept.
+func (p ProjectConfig) Apply(space *DirectorySpace) {
+	commands := space.Config.DocumentCommands
+	if p.Output != "" {
+		space.OutputFilePath = filepath.Join(space.RootPath, filepath.FromSlash(p.Output))
+	}
+	space.Config = ExtractionConfig{
+		IncludePatterns:     append([]string{}, p.Include...),
+		ExcludePatterns:     append([]string{}, p.Exclude...),
+		ManualSelections:    absolutePaths(space.RootPath, p.Selections),
+		ManualDeselections:  absolutePaths(space.RootPath, p.Deselected),
+		AlwaysShowStructure: absolutePaths(space.RootPath, p.Structure),
+		OutputGlobs:         p.OutputGlobs,
+		IncludeMode:         p.IncludeMode,
+		FilenamesOnly:       p.FilenamesOnly,
+		MinifyContent:       p.MinifyContent,
+		SkipJunk:            p.SkipJunk,
+		GitInfo:             p.GitInfo,
+		HeaderFields:        slices.Clone(p.HeaderFields),
+		FileHeader:          p.FileHeader,
+		FileFooter:          p.FileFooter,
+		Escaping:            p.Escaping,
+		DocumentText:        p.DocumentText,
+		DocumentCommands:    commands,
+		ImagePlaceholders:   p.ImagePlaceholders,
+		MaskConfigValues:    p.MaskConfigValues,
+		ShowExcluded:        p.ShowExcluded,
+		ShowContext:         p.ShowContext,
+		StructureView:       p.StructureView,
+		FullTreeMap:         p.FullTreeMap,
+		ContextRules:        p.ContextRules,
+		ContextImports:      p.ContextImports,
+		StructureMaxDepth:   p.StructureMaxDepth,
+		Formats:             slices.Clone(p.Formats),
+		LineNumbers:         p.LineNumbers,
+		TokenBreakdown:      p.TokenBreakdown,
+		Manifest:            p.Manifest,
+		Task:                p.Task,
+		RecentCommits:       p.RecentCommits,
+		RecentCommitsScoped: p.RecentCommitsScoped,
+		NestedRepos:         maps.Clone(p.NestedRepos),
+		NestedRepoPolicy:    p.NestedRepoPolicy,
+		GeneratedPolicy:     p.GeneratedPolicy,
+		TestsPolicy:         p.TestsPolicy,
+	}
+}
+
+// WriteProjectConfig writes p as YAML with a short usage comment.
+func WriteProjectConfig(w io.Writer, p ProjectConfig) error {
+	if _, err := fmt.Fprintln(w, "# PandaBrew project config. Reproduce with: pandabrew --config <this file>"); err != nil {
+		return err
+	}
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(p); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// LoadProjectConfig reads a project config file.
+func LoadProjectConfig(path string) (ProjectConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ProjectConfig{}, err
+	}
+	var p ProjectConfig
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return ProjectConfig{}, fmt.Errorf("invalid project config %s: %w", path, err)
+	}
+	if p.Version > ProjectConfigVersion {
+		return ProjectConfig{}, fmt.Errorf("project config %s has version %d; this build supports up to %d", path, p.Version, ProjectConfigVersion)
+	}
+	for _, policy := range append([]string{p.NestedRepoPolicy}, slices.Collect(maps.Values(p.NestedRepos))...) {
+		if policy != "" && !ValidNestedRepoPolicy(policy) {
+			return ProjectConfig{}, fmt.Errorf("project config %s: unknown nested repo policy %q", path, policy)
+		}
+	}
+	// Shared configs must not reach out of the project they came with
+	for _, rel := range slices.Concat([]string{p.Output}, p.Selections, p.Deselected, p.Structure) {
+		if rel != "" && !filepath.IsLocal(filepath.FromSlash(rel)) {
+			return ProjectConfig{}, fmt.Errorf("project config %s: path %q is outside the project root", path, rel)
+		}
+	}
+	formats, err := ParseFormats(strings.Join(p.Formats, ","))
+	if err != nil {
+		return ProjectConfig{}, fmt.Errorf("project config %s: %w", path, err)
+	}
+	p.Formats = formats
+	return p, nil
+}
+
+// relativeTo returns path relative to root, slash-separated, or "" if path
+// is empty or outside root.
+func relativeTo(root, path string) string {
+	if path == "" {
+		return ""
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+func relativePaths(root string, paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		if rel := relativeTo(root, p); rel != "" {
+			out = append(out, rel)
+		}
+	}
+	return out
+}
+
+func absolutePaths(root string, rels []string) []string {
+	out := []string{}
+	for _, rel := range rels {
+		out = append(out, filepath.Join(root, filepath.FromSlash(rel)))
+	}
+	return out
+}
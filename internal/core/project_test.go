@@ -0,0 +1,85 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProjectConfigRoundTrip(t *testing.T) {
+	root := setupTestDir(t)
+	space := &DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(root, "out", "ctx.txt"), Config: DefaultExtractionConfig()}
+	space.Config.ManualSelections = []string{filepath.Join(root, "src", "lib"), filepath.Join(t.TempDir(), "elsewhere")}
+	space.Config.ContextRules = []ContextRule{{Listing: true, Signatures: true}}
+
+	var buf strings.Builder
+	if err := WriteProjectConfig(&buf, NewProjectConfig(space)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), root) || !strings.Contains(buf.String(), "- src/lib") {
+		t.Errorf("paths should be relative to the root:\n%s", buf.String())
+	}
+
+	path := filepath.Join(t.TempDir(), "pandabrew.project.yaml")
+	if err := os.WriteFile(path, []byte(buf.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadProjectConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A teammate's checkout lives somewhere else
+	clone := &DirectorySpace{RootPath: filepath.Join("/", "home", "mate", "project")}
+	loaded.Apply(clone)
+	if want := []string{filepath.Join(clone.RootPath, "src", "lib")}; !reflect.DeepEqual(clone.Config.ManualSelections, want) {
+		t.Errorf("selections: got %v, want %v", clone.Config.ManualSelections, want)
+	}
+	if clone.OutputFilePath != filepath.Join(clone.RootPath, "out", "ctx.txt") {
+		t.Errorf("output: got %q", clone.OutputFilePath)
+	}
+	if !reflect.DeepEqual(clone.Config.ContextRules, space.Config.ContextRules) || !clone.Config.SkipJunk {
+		t.Errorf("options not restored: %+v", clone.Config)
+	}
+}
+
+func TestProjectConfigOutsideRoot(t *testing.T) {
+	for _, entry := range []string{"output: ../../.bashrc", "selections: [src, ../../.ssh/id_rsa]", "deselected: [/etc]", "always_show_structure: [a/../../b]"} {
+		path := filepath.Join(t.TempDir(), "pandabrew.project.yaml")
+		if err := os.WriteFile(path, []byte("version: 1\n"+entry+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadProjectConfig(path); err == nil || !strings.Contains(err.Error(), "outside the project root") {
+			t.Errorf("%s: got %v", entry, err)
+		}
+	}
+}
+
+func TestProjectConfigDocumentCommands(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pandabrew.project.yaml")
+	config := "version: 1\ndocument_text: true\ndocument_commands:\n  .pdf: touch /tmp/pwned {file}\n"
+	if err := os.WriteFile(path, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadProjectConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Converters come from the user's own session, never from the project
+	own := map[string]string{".odt": "odt2txt {file}"}
+	space := &DirectorySpace{RootPath: t.TempDir(), Config: ExtractionConfig{DocumentCommands: own}}
+	loaded.Apply(space)
+	if !space.Config.DocumentText || !reflect.DeepEqual(space.Config.DocumentCommands, own) {
+		t.Errorf("document settings = %v, %v", space.Config.DocumentText, space.Config.DocumentCommands)
+	}
+	var buf strings.Builder
+	if err := WriteProjectConfig(&buf, NewProjectConfig(space)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "odt2txt") {
+		t.Errorf("document commands written to the project config:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,140 @@
+// Package core implements detecting the type of a project and the settings
+// suited to it.
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ProjectType is the kind of project found at a root, with the settings a
+// new space for it starts with.
+type ProjectType struct {
+	Name            string // e.g. "Go"
+	Manifest        string // The file that identified it, e.g. "go.mod"
+	IncludePatterns []string
+	ExcludePatterns []string
+	OutputPath      string
+	Selections      []string // Source directories, absolute
+}
+
+// projectProfile describes how to recognize one kind of project.
+type projectProfile struct {
+	name       string
+	manifest   string
+	include    []string // Patterns for the manifest and root-level sources
+	exclude    []string // Build output and caches, added to the defaults
+	sourceDirs []string // Candidates for the suggested selection
+	moduleName func(manifest []byte) string
+}
+
+// projectProfiles are tried in order; the first manifest found wins.
+var projectProfiles = []projectProfile{
+	{
+		name: "Go", manifest: "go.mod",
+		include:    []string{"go.mod", "*.go", "README*"},
+		exclude:    []string{"bin", "dist"},
+		sourceDirs: []string{"cmd", "internal", "pkg", "api"},
+		moduleName: func(data []byte) string {
+			name := firstSubmatch(goModuleLine, data)
+			return name[strings.LastIndex(name, "/")+1:]
+		},
+	},
+	{
+		name: "Rust", manifest: "Cargo.toml",
+		include:    []string{"Cargo.toml", "README*"},
+		exclude:    []string{"target"},
+		sourceDirs: []string{"src", "crates", "benches", "examples"},
+		moduleName: func(data []byte) string { return firstSubmatch(tomlNameLine, data) },
+	},
+	{
+		name: "Node", manifest: "package.json",
+		include:    []string{"package.json", "tsconfig.json", "README*"},
+		exclude:    []string{"dist", "build", "coverage", ".next", ".turbo"},
+		sourceDirs: []string{"src", "lib", "app", "pages", "components", "packages"},
+		moduleName: func(data []byte) string {
+			var pkg struct {
+				Name string `json:"name"`
+			}
+			_ = json.Unmarshal(data, &pkg)
+			return pkg.Name[strings.LastIndex(pkg.Name, "/")+1:]
+		},
+	},
+	{
+		name: "Python", manifest: "pyproject.toml",
+		include:    []string{"pyproject.toml", "*.py", "README*"},
+		exclude:    []string{".venv", "venv", "build", "dist", "*.egg-info", ".pytest_cache", ".mypy_cache", ".tox"},
+		sourceDirs: []string{"src", "app"},
+		moduleName: func(data []byte) string { return firstSubmatch(tomlNameLine, data) },
+	},
+}
+
+var (
+	goModuleLine = regexp.MustCompile(`(?m)^module\s+"?([^\s"]+)`)
+	tomlNameLine = regexp.MustCompile(`(?m)^name\s*=\s*["']([^"']+)["']`)
+)
+
+func firstSubmatch(re *regexp.Regexp, data []byte) string {
+	if m := re.FindSubmatch(data); m != nil {
+		return string(m[1])
+	}
+	return ""
+}
+
+// DetectProjectType recognizes a Go, Rust, Node or Python project at root by
+// its manifest and tailors the patterns, output name and selection to it.
+func DetectProjectType(root string) (ProjectType, bool) {
+	for _, p := range projectProfiles {
+		data, err := os.ReadFile(filepath.Join(root, p.manifest))
+		if err != nil {
+			continue
+		}
+
+		pt := ProjectType{
+			Name:            p.name,
+			Manifest:        p.manifest,
+			IncludePatterns: slices.Clone(p.include),
+			ExcludePatterns: DefaultExtractionConfig().ExcludePatterns,
+			OutputPath:      DefaultOutputPath(root),
+		}
+		for _, pattern := range p.exclude {
+			if !slices.Contains(pt.ExcludePatterns, pattern) {
+				pt.ExcludePatterns = append(pt.ExcludePatterns, pattern)
+			}
+		}
+		if name := p.moduleName(data); name != "" && !strings.ContainsAny(name, `/\`) {
+			pt.OutputPath = filepath.Join(filepath.Dir(root), name+".txt")
+		}
+
+		dirs := p.sourceDirs
+		if p.name == "Python" {
+			// Flat layouts keep the package next to pyproject.toml
+			if name := strings.ReplaceAll(p.moduleName(data), "-", "_"); name != "" {
+				dirs = append(slices.Clone(dirs), name)
+			}
+		}
+		for _, dir := range dirs {
+			path := filepath.Join(root, dir)
+			if info, err := os.Stat(path); err == nil && info.IsDir() {
+				pt.Selections = append(pt.Selections, path)
+			}
+		}
+		return pt, true
+	}
+	return ProjectType{}, false
+}
+
+// Apply replaces the patterns, output path and selection of space with the
+// tailored ones.
+func (pt ProjectType) Apply(space *DirectorySpace) {
+	space.OutputFilePath = pt.OutputPath
+	space.Config.IncludeMode = true
+	space.Config.IncludePatterns = slices.Clone(pt.IncludePatterns)
+	space.Config.ExcludePatterns = slices.Clone(pt.ExcludePatterns)
+	space.Config.ManualSelections = slices.Clone(pt.Selections)
+	space.Config.ManualDeselections = nil
+}
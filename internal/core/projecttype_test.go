@@ -0,0 +1,55 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestDetectProjectType(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "checkout")
+	for _, dir := range []string{"cmd", "internal", "docs"} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := DetectProjectType(root); ok {
+		t.Fatal("detected a project without a manifest")
+	}
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module github.com/acme/widget\n\ngo 1.22\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pt, ok := DetectProjectType(root)
+	if !ok || pt.Name != "Go" || pt.Manifest != "go.mod" {
+		t.Fatalf("DetectProjectType = %+v, %v, want a Go project", pt, ok)
+	}
+	if want := filepath.Join(filepath.Dir(root), "widget.txt"); pt.OutputPath != want {
+		t.Errorf("output = %s, want %s", pt.OutputPath, want)
+	}
+	if want := []string{filepath.Join(root, "cmd"), filepath.Join(root, "internal")}; !slices.Equal(pt.Selections, want) {
+		t.Errorf("selections = %v, want %v", pt.Selections, want)
+	}
+	if !slices.Contains(pt.ExcludePatterns, ".git") || !slices.Contains(pt.ExcludePatterns, "bin") {
+		t.Errorf("exclude patterns %v lack the defaults or the Go extras", pt.ExcludePatterns)
+	}
+
+	space := &DirectorySpace{RootPath: root, Config: DefaultExtractionConfig()}
+	pt.Apply(space)
+	out := filepath.Join(t.TempDir(), "report.txt")
+	space.OutputFilePath = out
+	if err := os.WriteFile(filepath.Join(root, "cmd", "main.go"), []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "docs", "notes.txt"), []byte("notes\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	meta, err := RunExtraction(space)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if meta.TotalFiles != 2 {
+		t.Errorf("exported %d files, want go.mod and cmd/main.go", meta.TotalFiles)
+	}
+}
@@ -0,0 +1,74 @@
+// Package core implements pruning dead and duplicate spaces from a session.
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// PrunedSpace is a space PruneSession removed, and why.
+type PrunedSpace struct {
+	Space  *DirectorySpace
+	Reason string // "root missing" or "duplicate of <kept space ID>"
+}
+
+// PruneSession drops the spaces of s whose root no longer exists, and all
+// but one of the spaces sharing a root: the active one, or else the one with
+// the most selections. Nothing is written; see SessionManager.Prune.
+func PruneSession(s *Session) []PrunedSpace {
+	active := s.GetActiveSpace()
+	missing := make(map[*DirectorySpace]bool)
+	keep := make(map[string]*DirectorySpace) // Root -> space kept for it
+	for _, space := range s.Spaces {
+		if _, err := os.Stat(space.RootPath); os.IsNotExist(err) {
+			missing[space] = true
+			continue
+		}
+		kept := keep[space.RootPath]
+		if kept == nil || space == active || kept != active && len(space.Config.ManualSelections) > len(kept.Config.ManualSelections) {
+			keep[space.RootPath] = space
+		}
+	}
+
+	var pruned []PrunedSpace
+	for _, space := range s.Spaces {
+		if missing[space] {
+			pruned = append(pruned, PrunedSpace{Space: space, Reason: "root missing"})
+		} else if kept := keep[space.RootPath]; kept != space {
+			pruned = append(pruned, PrunedSpace{Space: space, Reason: "duplicate of " + kept.ID})
+		}
+	}
+
+	s.Spaces = slices.DeleteFunc(s.Spaces, func(space *DirectorySpace) bool {
+		return keep[space.RootPath] != space
+	})
+	if s.GetActiveSpace() != active {
+		s.ActiveSpaceID = ""
+		if len(s.Spaces) > 0 {
+			s.ActiveSpaceID = s.Spaces[0].ID
+		}
+	}
+	return pruned
+}
+
+// Prune prunes s, saves it and deletes the files of the removed spaces.
+// Saving rewrites the session file compactly: spaces kept inline by older
+// versions move to their own files, and references to space files that are
+// gone are dropped.
+func (sm *SessionManager) Prune(s *Session) ([]PrunedSpace, error) {
+	pruned := PruneSession(s)
+	if sm.Ephemeral {
+		return pruned, nil
+	}
+	if err := sm.Save(s); err != nil {
+		return pruned, err
+	}
+	for _, p := range pruned {
+		if err := os.Remove(sm.spacePath(p.Space.ID)); err != nil && !os.IsNotExist(err) {
+			return pruned, fmt.Errorf("removing space %s: %w", filepath.Base(p.Space.RootPath), err)
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPruneSession(t *testing.T) {
+	root := t.TempDir()
+	sm := NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	session, _ := sm.Load()
+	add := func(path string, selections ...string) *DirectorySpace {
+		space := &DirectorySpace{ID: generateRandomID(), RootPath: path, Config: ExtractionConfig{ManualSelections: selections}}
+		session.Spaces = append(session.Spaces, space)
+		return space
+	}
+	dead := add(filepath.Join(root, "gone"))
+	empty := add(root)
+	rich := add(root, "a", "b")
+	other := add(t.TempDir())
+	session.ActiveSpaceID = dead.ID
+	if err := sm.Save(session); err != nil {
+		t.Fatal(err)
+	}
+
+	pruned, err := sm.Prune(session)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PrunedSpace{{Space: dead, Reason: "root missing"}, {Space: empty, Reason: "duplicate of " + rich.ID}}
+	if !reflect.DeepEqual(pruned, want) {
+		t.Fatalf("pruned = %+v", pruned)
+	}
+	if session.ActiveSpaceID != rich.ID {
+		t.Errorf("active space = %s, want the first kept", session.ActiveSpaceID)
+	}
+	saved, _ := sm.Load()
+	if len(saved.Spaces) != 2 || saved.Spaces[1].ID != other.ID {
+		t.Errorf("saved %d spaces", len(saved.Spaces))
+	}
+	if _, err := os.Stat(sm.spacePath(empty.ID)); !os.IsNotExist(err) {
+		t.Error("file of the pruned space kept")
+	}
+
+	// The active space wins among duplicates
+	active := add(root)
+	session.ActiveSpaceID = active.ID
+	if pruned := PruneSession(session); len(pruned) != 1 || pruned[0].Space != rich {
+		t.Errorf("pruned %+v, want the inactive duplicate", pruned)
+	}
+}
@@ -0,0 +1,397 @@
+// Package core implements concurrent, throttled reading of file contents.
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxFileSize is the per-file content cap used by DefaultExtractOptions.
+const DefaultMaxFileSize = 32 << 20
+
+// prefetchLimit is the largest file a worker reads ahead into memory.
+// Anything bigger is streamed straight to the report when its turn comes.
+const prefetchLimit = 1 << 20
+
+// ExtractOptions tunes how hard an extraction hits the disk.
+// Unlike ExtractionConfig these are runtime knobs and are never persisted.
+type ExtractOptions struct {
+	// Files, when non-nil, is the explicit list of files to export. Relative
+	// entries are resolved against the root. The walk and the space's
+	// selection are skipped entirely.
+	Files []string
+	// Jobs is the number of files read concurrently. Values below 1 mean runtime.NumCPU().
+	Jobs int
+	// ReadRate caps file content reads in bytes per second. 0 means unlimited.
+	ReadRate int64
+	// MaxFileSize truncates file contents after this many bytes. 0 means unlimited.
+	MaxFileSize int64
+	// Timings, when set, is filled with a per-stage breakdown of the run.
+	Timings *Timings
+	// Progress, when set, is called once the files are known and again after
+	// each file is written. Calls come from a single goroutine.
+	Progress func(Progress)
+	// Formats lists the report formats to write, each next to the output
+	// path with its own extension. Empty means just the text report at the
+	// output path. The tree is walked once and the other formats are
+	// converted from the text report.
+	Formats []string
+	// Verify checks that the structure section lists exactly the files whose
+	// contents are exported, failing with ErrStructureMismatch otherwise.
+	// Tests always verify.
+	Verify bool
+	// Confine refuses paths outside the root of each space: selections and
+	// listed files there fail the export with ErrOutsideRoot, as does an
+	// output path, and files that resolve outside it through a symlink are
+	// reported without being read.
+	Confine bool
+	// DryRun runs the export without writing anything: the report is
+	// discarded and only its metadata is returned.
+	DryRun bool
+	// FileMode and DirMode are the permissions of the reports and folders
+	// the export creates, set exactly whatever the umask. A rewritten report
+	// gets FileMode too. 0 keeps the defaults, 0666 and 0755 less the umask.
+	FileMode fs.FileMode
+	DirMode  fs.FileMode
+
+	fileWritten func(relPath string) // Set by the extraction to drive Progress
+	spans       *spanRecorder        // Set by the extraction when converting to Formats
+	manifest    *manifestRecorder    // Set by the extraction when writing a manifest
+}
+
+// Progress describes how far an extraction has got.
+type Progress struct {
+	Processed int    // Files written to the report so far
+	Total     int    // Files selected for content
+	Tokens    int    // Estimated tokens written so far
+	File      string // Relative path of the file just written, if any
+}
+
+// DefaultExtractOptions returns options sized for the current machine.
+func DefaultExtractOptions() ExtractOptions {
+	return ExtractOptions{Jobs: runtime.NumCPU(), MaxFileSize: DefaultMaxFileSize}
+}
+
+func (o ExtractOptions) jobs() int {
+	if o.Jobs < 1 {
+		return runtime.NumCPU()
+	}
+	return o.Jobs
+}
+
+// contentFile is a file selected for the File Contents section.
+type contentFile struct {
+	Path    string
+	RelPath string
+
+	// SignaturesOnly marks a context file that contributes only its
+	// declarations, to the Context Signatures section.
+	SignaturesOnly bool
+
+	// Annotation is shown next to the path in the file's header line.
+	Annotation string
+	// Fields follow the annotation, see ExtractionConfig.HeaderFields.
+	Fields []headerField
+	// Refused, when set, is why the file is not read, see ExtractOptions.Confine.
+	Refused error
+	// Confined is the real root folder the file must still lie in when it
+	// is opened, set by a confined export.
+	Confined string
+}
+
+// openFunc opens a file of the report for reading.
+type openFunc func(path string) (*os.File, error)
+
+// opener is how f is opened: checked against its root when confined.
+func (f contentFile) opener() openFunc {
+	if f.Confined == "" {
+		return os.Open
+	}
+	return confinement{root: f.Confined}.open
+}
+
+// contentResult is either a prefetched small file or an open handle
+// to a large one that the writer streams.
+type contentResult struct {
+	r       io.Reader
+	file    *os.File
+	size    int64
+	modTime time.Time
+	sha     string // Hex SHA-256, when requested
+	clipped bool   // The start of a longer text, already cut at the size limit
+	err     error
+}
+
+func (r contentResult) close() {
+	if r.file != nil {
+		r.file.Close()
+	}
+}
+
+// writeContents reads files with up to opts.Jobs workers and writes them in
+// their original order. At most Jobs files are in flight at once, and only
+// small ones are buffered, so memory stays bounded whatever the file sizes.
+// cfg sets the header fields and delimiters of each file.
+func writeContents(w io.Writer, files []contentFile, opts ExtractOptions, cfg ExtractionConfig) error {
+	limiter := newRateLimiter(opts.ReadRate)
+	delims := newDelimiters(cfg)
+	hash := slices.Contains(cfg.HeaderFields, HeaderSHA) || delims.needsHash() || opts.manifest != nil
+	sem := make(chan struct{}, opts.jobs())
+	done := make(chan struct{})
+	var workers sync.WaitGroup
+
+	results := make([]chan contentResult, len(files))
+	for i := range results {
+		results[i] = make(chan contentResult, 1)
+	}
+
+	workers.Add(1) // The producer itself, so Wait also covers late spawns
+	go func() {
+		defer workers.Done()
+		for i, f := range files {
+			select {
+			case sem <- struct{}{}:
+			case <-done:
+				return
+			}
+			workers.Add(1)
+			go func() {
+				defer workers.Done()
+				start := opts.Timings.now()
+				var res contentResult
+				open := f.opener()
+				switch {
+				case f.Refused != nil:
+					res = contentResult{err: f.Refused}
+				case isImage(f.Path, cfg):
+					res = openImage(f.Path, open, hash)
+				case isDocument(f.Path, cfg):
+					res = openDocument(f.Path, open, cfg, opts.MaxFileSize, hash)
+				case isMaskedConfig(f.Path, cfg):
+					res = openMasked(f.Path, open, opts.MaxFileSize, hash)
+				default:
+					res = openContent(f.Path, open, limiter, hash)
+				}
+				if !isImage(f.Path, cfg) {
+					res = transformContent(res, cfg, limiter, opts.MaxFileSize)
+				}
+				opts.Timings.add(stageRead, start)
+				results[i] <- res
+			}()
+		}
+	}()
+
+	for i, f := range files {
+		res := <-results[i]
+		start := opts.Timings.now()
+		var fields []headerField
+		if res.err == nil {
+			fields = headerFields(cfg.HeaderFields, f, res, opts.MaxFileSize)
+		}
+		f.Fields = append(fields, res.escapeFields(delims, opts.MaxFileSize)...)
+		opts.manifest.add(f, res, opts.MaxFileSize)
+		span := reportSpan{kind: spanFile, name: f.RelPath, annotation: f.Annotation, fields: fields, footer: delims.footerLine(f.Fields)}
+		err := opts.spans.mark(span, func() error {
+			return writeContent(w, delims, f, res, opts.MaxFileSize)
+		})
+		opts.Timings.add(stageWrite, start)
+		res.close()
+		if opts.fileWritten != nil {
+			opts.fileWritten(f.RelPath)
+		}
+		<-sem // Free the slot only once consumed so memory stays bounded
+		if err != nil {
+			close(done)
+			go discardResults(results[i+1:], &workers)
+			return err
+		}
+	}
+	close(done)
+	return nil
+}
+
+// discardResults closes handles opened by workers still running after the
+// writer bailed out.
+func discardResults(results []chan contentResult, workers *sync.WaitGroup) {
+	workers.Wait()
+	for _, ch := range results {
+		select {
+		case res := <-ch:
+			res.close()
+		default:
+		}
+	}
+}
+
+// openContent opens path for writeContent. With hash set the SHA-256 of
+// the file is computed too, which for streamed files costs an extra read.
+func openContent(path string, open openFunc, limiter *rateLimiter, hash bool) contentResult {
+	f, err := open(path)
+	if err != nil {
+		return contentResult{err: err}
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return contentResult{err: err}
+	}
+
+	r := throttle(f, limiter)
+	if info.Size() > prefetchLimit {
+		res := contentResult{r: r, file: f, size: info.Size(), modTime: info.ModTime()}
+		if hash {
+			if h, err := hashOpened(open, path); err == nil {
+				res.sha = h.SHA256
+			}
+		}
+		return res
+	}
+
+	data, err := io.ReadAll(r)
+	f.Close()
+	if err != nil {
+		return contentResult{err: err}
+	}
+	res := contentResult{r: bytes.NewReader(data), size: int64(len(data)), modTime: info.ModTime()}
+	if hash {
+		sum := sha256.Sum256(data)
+		res.sha = hex.EncodeToString(sum[:])
+	}
+	return res
+}
+
+// errorText stands in for the content of a file that could not be read.
+func (r contentResult) errorText() string {
+	return fmt.Sprintf("[Error reading file: %v]", r.err)
+}
+
+// escapeFields are the header fields d adds for the content written.
+func (r contentResult) escapeFields(d delimiters, maxSize int64) []headerField {
+	if r.err != nil {
+		text := r.errorText()
+		return d.escapeFields(int64(len(text)), hashString(text))
+	}
+	n := r.size
+	if maxSize > 0 {
+		n = min(n, maxSize)
+	}
+	return d.escapeFields(n, r.sha)
+}
+
+func writeContent(w io.Writer, d delimiters, f contentFile, res contentResult, maxSize int64) error {
+	if res.err != nil {
+		return printFileContent(w, d, f, strings.NewReader(res.errorText()))
+	}
+	r := res.r
+	truncated := maxSize > 0 && res.size > maxSize
+	switch {
+	case truncated:
+		r = io.LimitReader(r, maxSize)
+	case d.escaping == EscapeLength:
+		r = io.LimitReader(r, res.size) // Never more than the header states
+	}
+	if err := printFileContent(w, d, f, r); err != nil {
+		return err
+	}
+	switch {
+	case truncated:
+		_, err := fmt.Fprintf(w, "[Truncated: showing %d of %d bytes]\n\n", maxSize, res.size)
+		return err
+	case res.clipped:
+		_, err := fmt.Fprintf(w, "[Truncated: showing the first %d bytes]\n\n", res.size)
+		return err
+	}
+	return nil
+}
+
+// rateLimiter spreads reads over time so the combined throughput of all
+// workers stays under a fixed number of bytes per second.
+type rateLimiter struct {
+	mu   sync.Mutex
+	rate int64
+	next time.Time
+}
+
+func newRateLimiter(bytesPerSec int64) *rateLimiter {
+	if bytesPerSec <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: bytesPerSec}
+}
+
+// wait books n bytes of budget and sleeps until that booking starts.
+func (l *rateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	l.mu.Unlock()
+
+	if delay > 0 {
+		time.Sleep(delay)
+	}
+}
+
+// throttle reads r through limiter, or directly if it is nil.
+func throttle(r io.Reader, limiter *rateLimiter) io.Reader {
+	if limiter == nil {
+		return r
+	}
+	return &throttledReader{r: r, limiter: limiter}
+}
+
+type throttledReader struct {
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.limiter.wait(n)
+	}
+	return n, err
+}
+
+// ParseByteSize parses sizes like "512", "64K", "10MB" or "1.5GiB".
+// Units are powers of 1024. An empty string parses as 0.
+func ParseByteSize(raw string) (int64, error) {
+	s := strings.TrimSpace(strings.ToUpper(raw))
+	if s == "" {
+		return 0, nil
+	}
+	s = strings.TrimSuffix(strings.TrimSuffix(s, "IB"), "B")
+
+	mult := int64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		mult = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		mult = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		mult = 1 << 30
+	}
+	if mult > 1 {
+		s = s[:len(s)-1]
+	}
+
+	n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size: %q", raw)
+	}
+	return int64(n * float64(mult)), nil
+}
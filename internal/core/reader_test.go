@@ -0,0 +1,79 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConcurrentReadsKeepWalkOrder(t *testing.T) {
+	root := setupTestDir(t)
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: filepath.Join(t.TempDir(), "out.txt"),
+		Config:         ExtractionConfig{IncludeMode: true, ManualSelections: []string{filepath.Join(root, "src")}},
+	}
+
+	var want string
+	for i, jobs := range []int{1, 8} {
+		if _, err := RunExtractionWithOptions(space, ExtractOptions{Jobs: jobs}); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := os.ReadFile(space.OutputFilePath)
+		// Drop the timestamp line before comparing
+		_, body, _ := strings.Cut(string(data), "Selection Mode")
+		if i == 0 {
+			want = body
+		} else if body != want {
+			t.Errorf("jobs=%d output differs from jobs=1", jobs)
+		}
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"":       0,
+		"512":    512,
+		"64K":    64 << 10,
+		"10MB":   10 << 20,
+		"1.5GiB": 3 << 29,
+	}
+	for in, want := range tests {
+		got, err := ParseByteSize(in)
+		if err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	if _, err := ParseByteSize("lots"); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
+
+func TestLargeFilesAreStreamedAndTruncated(t *testing.T) {
+	root := t.TempDir()
+	big := filepath.Join(root, "big.log")
+	if err := os.WriteFile(big, []byte(strings.Repeat("@", prefetchLimit+10)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: filepath.Join(t.TempDir(), "out.txt"),
+		Config:         ExtractionConfig{IncludeMode: true, ManualSelections: []string{root}},
+	}
+
+	meta, err := RunExtractionWithOptions(space, ExtractOptions{MaxFileSize: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(space.OutputFilePath)
+	if got := strings.Count(string(content), "@"); got != 1000 {
+		t.Errorf("streamed %d bytes, want 1000", got)
+	}
+	if !strings.Contains(string(content), "[Truncated: showing 1000 of") {
+		t.Error("missing truncation notice")
+	}
+	if want := len(content) / 4; meta.TotalTokens != want {
+		t.Errorf("TotalTokens: got %d, want %d", meta.TotalTokens, want)
+	}
+}
@@ -0,0 +1,286 @@
+// Package core implements comparing a fresh export with the previous report.
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// diffContext is how many unchanged lines surround each hunk.
+	diffContext = 3
+	// maxDiffCells bounds the line-matching table of one file; beyond it the
+	// changed region is reported as rewritten.
+	maxDiffCells = 4_000_000
+)
+
+// FileChange describes how the content of one file differs between reports.
+type FileChange struct {
+	Path    string
+	Status  string // "added", "removed" or "changed"
+	Added   int    // Lines
+	Removed int    // Lines
+}
+
+// ReportDiff compares the file contents of two reports.
+type ReportDiff struct {
+	Files     []FileChange
+	OldTokens int
+	NewTokens int
+	Unified   string // Unified diff of the file contents
+	// NoPrevious is set when there was no earlier report to compare with.
+	NoPrevious bool
+}
+
+// Summary is a one-line description such as "12 files changed, +3.2k tokens".
+func (d ReportDiff) Summary() string {
+	if d.NoPrevious {
+		return fmt.Sprintf("No previous export, ~%s tokens", FormatTokens(d.NewTokens))
+	}
+	delta := d.NewTokens - d.OldTokens
+	sign := "+"
+	if delta < 0 {
+		sign, delta = "-", -delta
+	}
+	noun := "files"
+	if len(d.Files) == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("%d %s changed, %s%s tokens", len(d.Files), noun, sign, FormatTokens(delta))
+}
+
+// PreviewExtraction exports space into a temporary file and compares the
+// result with the report at its output path. Neither space nor the existing
+// report is modified.
+func PreviewExtraction(space *DirectorySpace, opts ExtractOptions) (ReportDiff, error) {
+	old, err := os.ReadFile(space.OutputFilePath)
+	if err != nil && !os.IsNotExist(err) {
+		return ReportDiff{}, err
+	}
+
+	tmp, err := os.CreateTemp("", "pandabrew-preview-*"+filepath.Ext(space.OutputFilePath))
+	if err != nil {
+		return ReportDiff{}, err
+	}
+	_ = tmp.Close()
+	defer os.Remove(tmp.Name())
+
+	snapshot := *space
+	snapshot.Config = space.Config.Clone()
+	if _, err := RunMultiRootExtraction([]*DirectorySpace{&snapshot}, tmp.Name(), opts); err != nil {
+		return ReportDiff{}, err
+	}
+	fresh, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return ReportDiff{}, err
+	}
+
+	diff := diffReports(string(old), string(fresh), newDelimiters(space.Config))
+	diff.NoPrevious = old == nil
+	return diff, nil
+}
+
+// DiffReports compares the file sections of two reports written with the
+// default delimiters.
+func DiffReports(old, fresh string) ReportDiff {
+	return diffReports(old, fresh, newDelimiters(ExtractionConfig{}))
+}
+
+func diffReports(old, fresh string, d delimiters) ReportDiff {
+	diff := ReportDiff{OldTokens: len(old) / 4, NewTokens: len(fresh) / 4}
+	oldFiles, newFiles := d.sections(old), d.sections(fresh)
+
+	paths := make(map[string]bool)
+	for p := range oldFiles {
+		paths[p] = true
+	}
+	for p := range newFiles {
+		paths[p] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for p := range paths {
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+
+	var unified strings.Builder
+	for _, p := range sorted {
+		a, inOld := oldFiles[p]
+		b, inNew := newFiles[p]
+		if inOld && inNew && a == b {
+			continue
+		}
+		change := FileChange{Path: p, Status: "changed"}
+		switch {
+		case !inOld:
+			change.Status = "added"
+		case !inNew:
+			change.Status = "removed"
+		}
+		aLines, bLines := splitLines(a), splitLines(b)
+		ops := diffLines(aLines, bLines)
+		for _, op := range ops {
+			switch op.kind {
+			case '+':
+				change.Added++
+			case '-':
+				change.Removed++
+			}
+		}
+		diff.Files = append(diff.Files, change)
+		writeUnified(&unified, p, ops, inOld, inNew)
+	}
+	diff.Unified = unified.String()
+	return diff
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffOp is one line of a line diff: ' ' kept, '-' removed, '+' added.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// diffLines matches the lines of a and b by longest common subsequence.
+// Past maxDiffCells it gives up on matching and reports a full rewrite.
+func diffLines(a, b []string) []diffOp {
+	// Common prefix and suffix need no table
+	pre := 0
+	for pre < len(a) && pre < len(b) && a[pre] == b[pre] {
+		pre++
+	}
+	suf := 0
+	for suf < len(a)-pre && suf < len(b)-pre && a[len(a)-1-suf] == b[len(b)-1-suf] {
+		suf++
+	}
+	var ops []diffOp
+	for _, l := range a[:pre] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	ma, mb := a[pre:len(a)-suf], b[pre:len(b)-suf]
+
+	if len(ma)*len(mb) > maxDiffCells {
+		for _, l := range ma {
+			ops = append(ops, diffOp{'-', l})
+		}
+		for _, l := range mb {
+			ops = append(ops, diffOp{'+', l})
+		}
+	} else {
+		// lcs[i][j] is the LCS length of ma[i:] and mb[j:]
+		lcs := make([][]int, len(ma)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(mb)+1)
+		}
+		for i := len(ma) - 1; i >= 0; i-- {
+			for j := len(mb) - 1; j >= 0; j-- {
+				if ma[i] == mb[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(ma) || j < len(mb) {
+			switch {
+			case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+				ops = append(ops, diffOp{' ', ma[i]})
+				i, j = i+1, j+1
+			case j < len(mb) && (i == len(ma) || lcs[i][j+1] >= lcs[i+1][j]):
+				ops = append(ops, diffOp{'+', mb[j]})
+				j++
+			default:
+				ops = append(ops, diffOp{'-', ma[i]})
+				i++
+			}
+		}
+	}
+
+	for _, l := range a[len(a)-suf:] {
+		ops = append(ops, diffOp{' ', l})
+	}
+	return ops
+}
+
+// writeUnified appends the hunks of ops for path in unified diff format.
+func writeUnified(w *strings.Builder, path string, ops []diffOp, inOld, inNew bool) {
+	from, to := "a/"+path, "b/"+path
+	if !inOld {
+		from = "/dev/null"
+	}
+	if !inNew {
+		to = "/dev/null"
+	}
+	fmt.Fprintf(w, "--- %s\n+++ %s\n", from, to)
+
+	oldLine, newLine := 1, 1 // Line numbers at ops[i]
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			oldLine, newLine = oldLine+1, newLine+1
+			i++
+			continue
+		}
+		// Grow the hunk until diffContext*2 unchanged lines separate changes
+		start := max(0, i-diffContext)
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run == len(ops) || run-end > 2*diffContext {
+				end = min(len(ops), end+diffContext)
+				break
+			}
+			end = run
+		}
+
+		lead := i - start
+		hunkOld, hunkNew := oldLine-lead, newLine-lead
+		var oldCount, newCount int
+		var body strings.Builder
+		for _, op := range ops[start:end] {
+			body.WriteByte(op.kind)
+			body.WriteString(op.line)
+			body.WriteByte('\n')
+			if op.kind != '+' {
+				oldCount++
+			}
+			if op.kind != '-' {
+				newCount++
+			}
+		}
+		// An empty side starts at the line before the hunk
+		if oldCount == 0 {
+			hunkOld--
+		}
+		if newCount == 0 {
+			hunkNew--
+		}
+		fmt.Fprintf(w, "@@ -%d,%d +%d,%d @@\n%s", hunkOld, oldCount, hunkNew, newCount, body.String())
+
+		for _, op := range ops[i:end] {
+			if op.kind != '+' {
+				oldLine++
+			}
+			if op.kind != '-' {
+				newLine++
+			}
+		}
+		i = end
+	}
+}
@@ -0,0 +1,54 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportPreview(t *testing.T) {
+	root := setupTestDir(t)
+	out := filepath.Join(t.TempDir(), "report.txt")
+	space := &DirectorySpace{
+		RootPath:       root,
+		OutputFilePath: out,
+		Config: ExtractionConfig{
+			IncludeMode:      true,
+			ManualSelections: []string{filepath.Join(root, "src", "main.go"), filepath.Join(root, "src", "utils.go")},
+		},
+	}
+
+	diff, err := PreviewExtraction(space, DefaultExtractOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.NoPrevious || len(diff.Files) != 2 {
+		t.Errorf("first preview = %+v, want two added files", diff)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Error("previewing wrote the output file")
+	}
+
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main\n\nfunc main() {}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = PreviewExtraction(space, DefaultExtractOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []FileChange{{Path: "src/main.go", Status: "changed", Added: 2}}
+	if !reflect.DeepEqual(diff.Files, want) {
+		t.Errorf("files = %+v, want %+v", diff.Files, want)
+	}
+	if !strings.Contains(diff.Unified, "--- a/src/main.go\n+++ b/src/main.go\n@@ -1,1 +1,3 @@\n package main\n+\n+func main() {}\n") {
+		t.Errorf("unified diff:\n%s", diff.Unified)
+	}
+	if !strings.HasPrefix(diff.Summary(), "1 file changed, +") {
+		t.Errorf("summary = %q", diff.Summary())
+	}
+}
@@ -0,0 +1,384 @@
+// Package core implements masking the values of configuration files.
+package core
+
+import (
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// maskedValue replaces every value of a masked configuration file.
+const maskedValue = "***"
+
+// isMaskedConfig reports whether path is a configuration file whose values
+// cfg masks.
+func isMaskedConfig(path string, cfg ExtractionConfig) bool {
+	return cfg.MaskConfigValues && configMasker(path) != nil
+}
+
+// configMasker picks the masking function for a configuration file, or nil.
+func configMasker(path string) func(string) string {
+	base := strings.ToLower(filepath.Base(path))
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return maskAssignments
+	}
+	switch filepath.Ext(base) {
+	case ".env", ".ini", ".toml", ".cfg", ".conf":
+		return maskAssignments
+	case ".properties":
+		return maskProperties
+	case ".yaml", ".yml":
+		return maskYAML
+	case ".json":
+		return maskJSON
+	}
+	return nil
+}
+
+// openMasked is openContent for configuration files: the masked text
+// stands in for the file contents. With limit above 0 only the first limit
+// bytes of the file are read and masked. The SHA-256 is still that of the
+// file.
+func openMasked(path string, open openFunc, limit int64, hash bool) contentResult {
+	f, err := open(path)
+	if err != nil {
+		return contentResult{err: err}
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return contentResult{err: err}
+	}
+	data, err := io.ReadAll(limitReader(f, limit))
+	if err != nil {
+		return contentResult{err: err}
+	}
+	clipped := limit > 0 && int64(len(data)) > limit
+	if clipped {
+		data = data[:limit]
+	}
+	text := configMasker(path)(string(data))
+	res := contentResult{r: strings.NewReader(text), size: int64(len(text)), modTime: info.ModTime(), clipped: clipped}
+	switch {
+	case hash && clipped:
+		if h, err := hashOpened(open, path); err == nil {
+			res.sha = h.SHA256
+		}
+	case hash:
+		res.sha = hashString(string(data))
+	}
+	return res
+}
+
+// assignmentLine matches "KEY=value", "export KEY=value", "key = value"
+// and "key: value"; TOML keys may be quoted.
+var assignmentLine = regexp.MustCompile(`^(\s*(?:export\s+)?(?:[A-Za-z0-9_.\-]+|"[^"]*"|'[^']*')\s*[=:]\s*)(.*)$`)
+
+// propertyLine matches a .properties entry, whose key may also be separated
+// from the value by whitespace alone, as in "key value".
+var propertyLine = regexp.MustCompile(`^(\s*[^\s=:#!]+(?:\s*[=:]\s*|\s+))(.*)$`)
+
+// sectionLine matches an INI or TOML section header, optionally commented.
+var sectionLine = regexp.MustCompile(`^\s*\[\[?[^\[\]]+\]\]?\s*([#;].*)?$`)
+
+// maskAssignments masks the values of .env, INI and TOML style files.
+// Comments, section headers and keys are kept, except for the values of
+// assignments commented out.
+func maskAssignments(text string) string {
+	return maskAssignmentLines(text, assignmentLine)
+}
+
+// maskProperties masks the values of a .properties file.
+func maskProperties(text string) string {
+	return maskAssignmentLines(text, propertyLine)
+}
+
+// maskAssignmentLines masks the value of every line matching assignment,
+// and every line a value continues on: quoted strings, TOML multi-line
+// strings, arrays and inline tables left open, and lines ending in a
+// backslash. Lines it does not recognize are masked whole.
+func maskAssignmentLines(text string, assignment *regexp.Regexp) string {
+	lines := strings.Split(text, "\n")
+	var open valueState // What the value of the previous line left open
+	for i, line := range lines {
+		if open.open() {
+			open = open.next(line)
+			lines[i] = maskLine(line)
+			continue
+		}
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || sectionLine.MatchString(line) {
+			continue
+		}
+		if strings.IndexByte("#;!", trimmed[0]) >= 0 {
+			lines[i] = maskComment(line)
+			continue
+		}
+		m := assignment.FindStringSubmatch(line)
+		if m == nil {
+			lines[i] = maskLine(line) // Fail closed
+			continue
+		}
+		if strings.TrimSpace(m[2]) == "" {
+			continue
+		}
+		open = openValue(strings.TrimRight(m[2], " \t\r"))
+		lines[i] = m[1] + maskedValue
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commentMarker matches what opens a comment line, or continues a block
+// comment, up to its text.
+var commentMarker = regexp.MustCompile(`^\s*(?:#+|;+|!+|//+|/\*+|\*+)?\s*`)
+
+// maskComment masks the values of assignments in comment, such as a
+// commented-out "# API_KEY=secret", on every line of it. Other text is
+// kept.
+func maskComment(comment string) string {
+	lines := strings.Split(comment, "\n")
+	for i, line := range lines {
+		end := ""
+		if strings.HasSuffix(line, "*/") {
+			line, end = strings.TrimSuffix(line, "*/"), " */"
+		}
+		marker := commentMarker.FindString(line)
+		m := assignmentLine.FindStringSubmatch(line[len(marker):])
+		if m == nil || strings.TrimSpace(m[2]) == "" {
+			continue
+		}
+		lines[i] = marker + m[1] + maskedValue + end
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maskLine masks line whole, keeping its indentation.
+func maskLine(line string) string {
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	if strings.TrimSpace(line) == "" {
+		return line
+	}
+	return line[:indent] + maskedValue
+}
+
+// valueState is what a value leaves open at the end of a line, so that it
+// continues on the next.
+type valueState struct {
+	quote    string // Unclosed string delimiter
+	depth    int    // Unclosed brackets and braces
+	brackets bool   // The value is an array or inline table
+	cont     bool   // The line ended in a backslash
+}
+
+func (s valueState) open() bool {
+	return s.quote != "" || s.depth > 0 || s.cont
+}
+
+// openValue returns what value, the start of a value, leaves open. Quotes
+// and brackets only count at its start: elsewhere in a plain value, as in
+// Bob's, they are literal.
+func openValue(value string) valueState {
+	if value == "" {
+		return valueState{}
+	}
+	switch value[0] {
+	case '"', '\'', '`', '[', '{':
+		return valueState{brackets: value[0] == '[' || value[0] == '{'}.scan(value)
+	}
+	return valueState{cont: strings.HasSuffix(value, `\`)}
+}
+
+// next continues s over line, the next line of the value.
+func (s valueState) next(line string) valueState {
+	if s.cont {
+		return valueState{cont: strings.HasSuffix(strings.TrimRight(line, " \t\r"), `\`)}
+	}
+	return s.scan(line)
+}
+
+// scan continues s over line, part of a quoted value or of an array or
+// inline table. It stops at a comment, or once a quoted value has closed.
+func (s valueState) scan(line string) valueState {
+	for i := 0; i < len(line); i++ {
+		if s.quote != "" {
+			switch {
+			case line[i] == '\\' && s.quote[0] == '"':
+				i++ // Escaped
+			case strings.HasPrefix(line[i:], s.quote):
+				i += len(s.quote) - 1
+				s.quote = ""
+				if !s.brackets {
+					return s
+				}
+			}
+			continue
+		}
+		switch c := line[i]; {
+		case strings.HasPrefix(line[i:], `"""`) || strings.HasPrefix(line[i:], "'''"):
+			s.quote = line[i : i+3]
+			i += 2
+		case c == '"' || c == '\'' || c == '`':
+			s.quote = string(c)
+		case c == '#':
+			return s
+		case s.brackets && (c == '[' || c == '{'):
+			s.depth++
+		case s.brackets && (c == ']' || c == '}'):
+			s.depth = max(s.depth-1, 0)
+		}
+	}
+	return s
+}
+
+// yamlLine splits a YAML line into indentation, an optional list marker,
+// an optional "key:" and the value.
+var yamlLine = regexp.MustCompile(`^(\s*)((?:-\s+)*)((?:"[^"]*"|'[^']*'|[^\s#"'?][^:#]*?)\s*:(?:\s+|$))?(.*)$`)
+
+// yamlBlockScalar matches a value opening a block scalar, after any tag or
+// anchor, as in "|", ">-" or "!!binary |".
+var yamlBlockScalar = regexp.MustCompile(`^(?:[!&]\S*\s+)*[|>][-+0-9]*\s*(#.*)?$`)
+
+// maskYAML masks the scalar values of a YAML file line by line, keeping
+// keys, nesting, list items and comments, but for the values of
+// assignments in comments. Every line of a block scalar, or
+// of a quoted scalar or flow collection spanning lines, is masked. Complex
+// keys and directives are masked whole.
+func maskYAML(text string) string {
+	lines := strings.Split(text, "\n")
+	block := -1 // Indentation of the node owning a block scalar, if inside one
+	var open valueState
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if open.open() {
+			open = open.next(line)
+			lines[i] = maskLine(line)
+			continue
+		}
+		if block >= 0 {
+			if trimmed == "" {
+				continue
+			}
+			if indent > block {
+				lines[i] = maskLine(line)
+				continue
+			}
+			block = -1
+		}
+		if trimmed == "" || trimmed == "---" || trimmed == "..." {
+			continue
+		}
+		if trimmed[0] == '#' {
+			lines[i] = maskComment(line)
+			continue
+		}
+		m := yamlLine.FindStringSubmatch(line)
+		if m == nil || trimmed[0] == '?' || trimmed[0] == '%' {
+			lines[i] = maskLine(line) // Fail closed
+			continue
+		}
+		prefix, value := m[1]+m[2]+m[3], m[4]
+		// A comment starts after the value, past the closing quote if quoted
+		comment, start := "", 0
+		if value != "" && (value[0] == '"' || value[0] == '\'') {
+			if j := strings.IndexByte(value[1:], value[0]); j >= 0 {
+				start = j + 2
+			}
+		}
+		if j := strings.Index(value[start:], " #"); j >= 0 {
+			value, comment = value[:start+j], value[start+j:]
+		}
+		value = strings.TrimSpace(value)
+		switch {
+		case value == "":
+			continue // A key opening a nested mapping or list
+		case yamlBlockScalar.MatchString(value):
+			// Content is indented past the key, or past the list marker of
+			// an item that is itself the scalar
+			block = indent
+			if m[2] != "" && m[3] != "" {
+				block = len(m[1]) + len(m[2])
+			}
+			continue
+		}
+		open = yamlOpenValue(value)
+		lines[i] = prefix + maskedValue + maskComment(comment)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// yamlOpenValue is openValue for YAML, whose plain scalars never continue
+// by a backslash. A quote doubled inside a single-quoted scalar closes
+// and reopens it, which scan gets right.
+func yamlOpenValue(value string) valueState {
+	s := openValue(value)
+	s.cont = false
+	return s
+}
+
+// maskJSON replaces every scalar value of a JSON document with "***",
+// keeping keys, nesting and formatting. Comments, as in tsconfig files,
+// are kept too, but for the values of assignments in them.
+func maskJSON(text string) string {
+	var b strings.Builder
+	var stack []byte // Open '{' and '['
+	expectKey := false
+	for i := 0; i < len(text); {
+		c := text[i]
+		switch {
+		case c == '"':
+			end := i + 1
+			for end < len(text) && text[end] != '"' {
+				if text[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			end = min(end+1, len(text))
+			if expectKey {
+				b.WriteString(text[i:end])
+			} else {
+				b.WriteString(`"` + maskedValue + `"`)
+			}
+			i = end
+			continue
+		case c == '/' && i+1 < len(text) && (text[i+1] == '/' || text[i+1] == '*'):
+			end := len(text)
+			if text[i+1] == '/' {
+				if j := strings.IndexByte(text[i:], '\n'); j >= 0 {
+					end = i + j
+				}
+			} else if j := strings.Index(text[i+2:], "*/"); j >= 0 {
+				end = i + 2 + j + 2
+			}
+			b.WriteString(maskComment(text[i:end]))
+			i = end
+			continue
+		case c == '{' || c == '[':
+			stack = append(stack, c)
+			expectKey = c == '{'
+		case c == '}' || c == ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			expectKey = false
+		case c == ':':
+			expectKey = false
+		case c == ',':
+			expectKey = len(stack) > 0 && stack[len(stack)-1] == '{'
+		case strings.IndexByte(" \t\r\n", c) < 0:
+			// A bare literal: number, true, false or null
+			end := i
+			for end < len(text) && strings.IndexByte(",:]} \t\r\n/", text[end]) < 0 {
+				end++
+			}
+			b.WriteString(`"` + maskedValue + `"`)
+			i = end
+			continue
+		}
+		b.WriteByte(c)
+		i++
+	}
+	return b.String()
+}
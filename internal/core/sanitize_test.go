@@ -0,0 +1,51 @@
+package core
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMaskConfigValues(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{".env", "# Database\nexport DB_URL=postgres://u:secret@db\nEMPTY=\nTOKEN = abc # note\n", "# Database\nexport DB_URL=***\nEMPTY=\nTOKEN = ***\n"},
+		{"app.yaml", "server:\n  port: 8080 # public\n  hosts:\n    - a.example.com\n  - name: \"x #1\" # why\n  cert: |\n    -----BEGIN-----\n    abc\n  url: http://x:1\n", "server:\n  port: *** # public\n  hosts:\n    - ***\n  - name: *** # why\n  cert: |\n    ***\n    ***\n  url: ***\n"},
+		{".env", "KEY=\"-----BEGIN KEY-----\nMIIEsecret\n-----END KEY-----\"\nNAME=Bob's\nNEXT=1\n", "KEY=***\n***\n***\nNAME=***\nNEXT=***\n"},
+		{"config.toml", "[db]\ncert = \"\"\"\n  secret one\n  secret two\"\"\"\nraw = '''x\ny'''\nhosts = [\n  \"a\", # first\n  \"b\",\n]\nport = 5432\n", "[db]\ncert = ***\n  ***\n  ***\nraw = ***\n***\nhosts = ***\n  ***\n  ***\n***\nport = ***\n"},
+		{"app.properties", "# Mail\nmail.password hunter2\nmail.user: ada\nmail.motd = first \\\n    second\nmail.host=smtp\n", "# Mail\nmail.password ***\nmail.user: ***\nmail.motd = ***\n    ***\nmail.host=***\n"},
+		{"keys.yaml", "keys:\n  - |\n    secret: one\n  - !!binary >-\n    c2VjcmV0\nquoted: \"first\n  user: second\"\nflow: [a,\n  b: c]\n? complex\n: value\n", "keys:\n  - |\n    ***\n  - !!binary >-\n    ***\nquoted: ***\n  ***\nflow: ***\n  ***\n***\n***\n"},
+		{"odd.ini", "[main]\nthis line is not an assignment\n", "[main]\n***\n"},
+		{"settings.json", "{\n  // dev only\n  \"key\": \"s3cr\\\"et\",\n  \"nested\": {\"n\": 1, \"list\": [true, null, \"x\"]}\n}", "{\n  // dev only\n  \"key\": \"***\",\n  \"nested\": {\"n\": \"***\", \"list\": [\"***\", \"***\", \"***\"]}\n}"},
+		// Assignments commented out are masked too, other comments kept
+		{".env", "# Old key\n# API_KEY=sk-live-1\n#export OLD=x\n", "# Old key\n# API_KEY=***\n#export OLD=***\n"},
+		{"app.ini", "; password = hunter2\n[db] ; main\n", "; password = ***\n[db] ; main\n"},
+		{"app.yaml", "# token: abc\nport: 1 # was: 2\n", "# token: ***\nport: *** # was: ***\n"},
+		{"tsconfig.json", "{\n  // \"key\": \"old\"\n  /* note\n   * secret=x */\n}", "{\n  // \"key\": ***\n  /* note\n   * secret=*** */\n}"},
+	}
+	for _, tt := range tests {
+		if got := configMasker(tt.name)(tt.in); got != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.name, got, tt.want)
+		}
+	}
+	if configMasker("main.go") != nil {
+		t.Error("main.go treated as a config file")
+	}
+
+	// Only as much as is shown is read and masked
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte(strings.Repeat("KEY=value\n", 1000)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res := openMasked(path, os.Open, 25, false)
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	text, _ := io.ReadAll(res.r)
+	if string(text) != "KEY=***\nKEY=***\nKEY=***" || !res.clipped {
+		t.Errorf("clipped masked text = %q, clipped %v", text, res.clipped)
+	}
+}
@@ -0,0 +1,261 @@
+// Package core implements editing a space's selection path by path.
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// IsIncluded reports whether the selection of space exports path: a checked
+// path or folder or an include pattern in include mode, anything unchecked
+// in exclude mode. Excluded and junk paths are never included.
+func IsIncluded(space *DirectorySpace, path string) bool {
+	cfg := space.Config
+	rel := relativeTo(space.RootPath, path)
+	if rel == "" || rel == "." {
+		return false
+	}
+	if isExcluded(rel, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(rel, JunkPatterns)) {
+		return false
+	}
+	checked := isPathSelected(path, space.RootPath, cfg.checkedSet())
+	if !cfg.IncludeMode {
+		return !checked
+	}
+	if checked {
+		return true
+	}
+	return cfg.MatchesInclude(rel)
+}
+
+// checkedSet maps the manual selections, and any extra paths such as
+// include pattern matches, to true and the deselections to false. The
+// nearest entry at or above a path decides it; see isPathSelected.
+func (c ExtractionConfig) checkedSet(extra ...string) map[string]bool {
+	set := make(map[string]bool, len(c.ManualSelections)+len(extra)+len(c.ManualDeselections))
+	for _, p := range c.ManualSelections {
+		set[p] = true
+	}
+	for _, p := range extra {
+		set[p] = true
+	}
+	for _, p := range c.ManualDeselections {
+		set[p] = false
+	}
+	return set
+}
+
+// IsChecked reports whether path is checked in the tree of space: selected
+// itself, or inside a selected folder without being deselected.
+func IsChecked(space *DirectorySpace, path string) bool {
+	return isPathSelected(path, space.RootPath, space.Config.checkedSet())
+}
+
+// Excludes reports whether the export skips relPath, or a folder holding
+// it, because of an exclude pattern or, with SkipJunk, a junk pattern.
+func (c ExtractionConfig) Excludes(relPath string) bool {
+	pattern, _ := c.ExcludedBy(relPath)
+	return pattern != ""
+}
+
+// ExcludedBy returns the pattern that makes the export skip relPath, and
+// whether it is one of JunkPatterns rather than an exclude pattern. The
+// pattern is "" when relPath is not excluded.
+func (c ExtractionConfig) ExcludedBy(relPath string) (pattern string, junk bool) {
+	for p := filepath.ToSlash(relPath); p != "." && p != "/"; p = filepath.ToSlash(filepath.Dir(p)) {
+		for _, pattern := range c.ExcludePatterns {
+			if isExcluded(p, []string{pattern}) {
+				return pattern, false
+			}
+		}
+		if !c.SkipJunk {
+			continue
+		}
+		for _, pattern := range JunkPatterns {
+			if isExcluded(p, []string{pattern}) {
+				return pattern, true
+			}
+		}
+	}
+	return "", false
+}
+
+// MatchesInclude reports whether an include pattern matches relPath. A
+// pattern matching a folder covers everything below it.
+func (c ExtractionConfig) MatchesInclude(relPath string) bool {
+	for p := filepath.ToSlash(relPath); p != "." && p != "/"; p = filepath.ToSlash(filepath.Dir(p)) {
+		if isExcluded(p, c.IncludePatterns) {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldAutoSelect reports whether a file that just appeared in an
+// include-mode space belongs with the selection although nothing covers it
+// yet: every other file with its extension in the same folder is checked.
+func ShouldAutoSelect(space *DirectorySpace, path string) bool {
+	cfg := space.Config
+	ext := filepath.Ext(path)
+	if !cfg.IncludeMode || ext == "" || IsIncluded(space, path) {
+		return false
+	}
+	rel := relativeTo(space.RootPath, path)
+	if rel == "" || isExcluded(rel, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(rel, JunkPatterns)) {
+		return false
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		return false
+	}
+	siblings := 0
+	for _, e := range entries {
+		sibling := filepath.Join(filepath.Dir(path), e.Name())
+		if e.IsDir() || sibling == path || filepath.Ext(sibling) != ext {
+			continue
+		}
+		if !slices.Contains(cfg.ManualSelections, sibling) {
+			return false
+		}
+		siblings++
+	}
+	return siblings > 0
+}
+
+// SelectPath makes path part of the export. In include mode it is checked;
+// in exclude mode it is unchecked, deselecting it inside a checked folder.
+func SelectPath(space *DirectorySpace, path string) {
+	if space.Config.IncludeMode {
+		CheckPath(space, path)
+	} else {
+		UncheckPath(space, path)
+	}
+}
+
+// DeselectPath stops path from being exported. In include mode a path
+// inside a selected folder is recorded as deselected, so the rest of that
+// folder stays selected. In exclude mode the path is simply checked.
+func DeselectPath(space *DirectorySpace, path string) {
+	if space.Config.IncludeMode {
+		UncheckPath(space, path)
+	} else {
+		CheckPath(space, path)
+	}
+}
+
+// CheckPath checks path, dropping the deselections of it and below it, so a
+// folder checked again is checked whole.
+func CheckPath(space *DirectorySpace, path string) {
+	cfg := &space.Config
+	cfg.ManualDeselections = slices.DeleteFunc(cfg.ManualDeselections, func(p string) bool {
+		return p == path || strings.HasPrefix(p, path+string(filepath.Separator))
+	})
+	if !IsChecked(space, path) {
+		cfg.ManualSelections = append(cfg.ManualSelections, path)
+	}
+}
+
+// UncheckPath unchecks path, deselecting it when a checked folder still
+// covers it.
+func UncheckPath(space *DirectorySpace, path string) {
+	cfg := &space.Config
+	cfg.ManualSelections = slices.DeleteFunc(cfg.ManualSelections, func(p string) bool { return p == path })
+	if IsChecked(space, path) {
+		cfg.ManualDeselections = append(cfg.ManualDeselections, path)
+	}
+}
+
+// ExpandPaths turns paths given relative to root, or absolute, into clean
+// absolute paths. A path holding glob characters such as "internal/**" is
+// matched against everything under root, keeping only the outermost matches;
+// one that matches nothing is an error.
+func ExpandPaths(root string, paths []string) ([]string, error) {
+	var expanded []string
+	for _, arg := range paths {
+		if !strings.ContainsAny(arg, "*?[{") {
+			if !filepath.IsAbs(arg) {
+				arg = filepath.Join(root, arg)
+			}
+			expanded = append(expanded, filepath.Clean(arg))
+			continue
+		}
+		pattern := filepath.ToSlash(arg)
+		if filepath.IsAbs(arg) {
+			rel, err := filepath.Rel(root, arg)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				return nil, fmt.Errorf("pattern %q is outside %s", arg, root)
+			}
+			pattern = filepath.ToSlash(rel)
+		}
+		matches, err := doublestar.Glob(os.DirFS(root), pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %q: %w", arg, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("pattern %q matches nothing", arg)
+		}
+		matched := make(map[string]bool, len(matches))
+		for _, match := range matches {
+			matched[match] = true
+		}
+		sort.Strings(matches)
+		for _, match := range matches {
+			covered := false
+			for dir := path.Dir(match); dir != "." && !covered; dir = path.Dir(dir) {
+				covered = matched[dir]
+			}
+			if !covered {
+				expanded = append(expanded, filepath.Join(root, filepath.FromSlash(match)))
+			}
+		}
+	}
+	return expanded, nil
+}
+
+// Ways of selecting everything, as taken by --select.
+const (
+	// SelectRoot checks the root folder, so files created later are
+	// exported too.
+	SelectRoot = "root"
+	// SelectFiles checks every file under the root that is not excluded,
+	// one by one, so the selection stays as it is when files are added.
+	SelectFiles = "files"
+)
+
+// ValidSelectMode reports whether mode is SelectRoot or SelectFiles.
+func ValidSelectMode(mode string) bool {
+	return mode == SelectRoot || mode == SelectFiles
+}
+
+// ListedFiles returns every file under root not matched by the exclude or
+// junk patterns of cfg.
+func ListedFiles(ctx context.Context, root string, cfg ExtractionConfig) ([]string, error) {
+	var files []string
+	err := walkIndexed(ctx, root, root, cfg, func(path string, _ IndexEntry) {
+		files = append(files, path)
+	})
+	return files, err
+}
+
+// SelectEverything replaces the selection of space as mode says: the root
+// for SelectRoot, every listed file for SelectFiles.
+func SelectEverything(ctx context.Context, space *DirectorySpace, mode string) error {
+	files := []string{space.RootPath}
+	if mode == SelectFiles {
+		var err error
+		if files, err = ListedFiles(ctx, space.RootPath, space.Config); err != nil {
+			return err
+		}
+	}
+	space.Config.ManualSelections = files
+	space.Config.ManualDeselections = nil
+	return nil
+}
@@ -0,0 +1,156 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewFileSelection(t *testing.T) {
+	root := setupTestDir(t)
+	abs := func(rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+	space := &DirectorySpace{RootPath: root, Config: DefaultExtractionConfig()}
+	space.Config.IncludePatterns = []string{"docs/**"}
+	space.Config.ManualSelections = []string{abs("src/main.go"), abs("src/utils.go"), abs("src/lib")}
+
+	for _, rel := range []string{"src/new.go", "src/new.txt", "src/lib/new.go", "docs/guide.md", "other.go"} {
+		if err := os.MkdirAll(filepath.Dir(abs(rel)), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(abs(rel), []byte("new"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	included := map[string]bool{"src/lib/new.go": true, "docs/guide.md": true}
+	auto := map[string]bool{"src/new.go": true}
+	for _, rel := range []string{"src/new.go", "src/new.txt", "src/lib/new.go", "docs/guide.md", "other.go", "node_modules/pkg/index.js"} {
+		if got := IsIncluded(space, abs(rel)); got != included[rel] {
+			t.Errorf("IsIncluded(%s) = %v", rel, got)
+		}
+		if got := ShouldAutoSelect(space, abs(rel)); got != auto[rel] {
+			t.Errorf("ShouldAutoSelect(%s) = %v", rel, got)
+		}
+	}
+
+	space.Config.IncludeMode = false
+	if !IsIncluded(space, abs("other.go")) || IsIncluded(space, abs("src/main.go")) {
+		t.Error("exclude mode: unchecked paths are exported, checked ones are not")
+	}
+}
+
+func TestSelectEverything(t *testing.T) {
+	root := setupTestDir(t)
+	space := &DirectorySpace{RootPath: root, Config: ExtractionConfig{IncludeMode: true, ExcludePatterns: []string{"node_modules"}}}
+
+	if err := SelectEverything(context.Background(), space, SelectRoot); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(space.Config.ManualSelections, []string{root}) {
+		t.Errorf("root mode selected %v", space.Config.ManualSelections)
+	}
+
+	if err := SelectEverything(context.Background(), space, SelectFiles); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(space.Config.ManualSelections); got != 6 {
+		t.Errorf("files mode selected %d files, want 6", got)
+	}
+	for _, sel := range space.Config.ManualSelections {
+		if strings.Contains(sel, "node_modules") {
+			t.Errorf("excluded file selected: %s", sel)
+		}
+	}
+
+	// Files added later are only exported with the root selected
+	added := filepath.Join(root, "added.go")
+	if err := os.WriteFile(added, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if IsIncluded(space, added) {
+		t.Error("new file exported with every listed file selected")
+	}
+}
+
+func TestCheckPathDropsDeselections(t *testing.T) {
+	root := filepath.Join("/", "r")
+	lib, nested := filepath.Join(root, "lib"), filepath.Join(root, "lib", "gen", "x.go")
+	space := &DirectorySpace{RootPath: root, Config: ExtractionConfig{IncludeMode: true}}
+	CheckPath(space, lib)
+	UncheckPath(space, nested)
+	if !reflect.DeepEqual(space.Config.ManualDeselections, []string{nested}) {
+		t.Fatalf("deselections = %v", space.Config.ManualDeselections)
+	}
+	want := []string{":(literal)lib", ":(exclude,literal)lib/gen/x.go"}
+	if specs := selectionPathspecs(space); !reflect.DeepEqual(specs, want) {
+		t.Errorf("pathspecs = %v, want %v", specs, want)
+	}
+
+	// Checking the folder again checks it whole
+	UncheckPath(space, lib)
+	CheckPath(space, lib)
+	if len(space.Config.ManualDeselections) != 0 || !IsChecked(space, nested) {
+		t.Errorf("rechecked folder: selections %v, deselections %v", space.Config.ManualSelections, space.Config.ManualDeselections)
+	}
+
+	// In exclude mode an exclusion with a path included again below it is
+	// not passed to git, which could not include that path again
+	space.Config = ExtractionConfig{ManualSelections: []string{lib, filepath.Join(root, "docs")}, ManualDeselections: []string{nested}}
+	if specs := selectionPathspecs(space); !reflect.DeepEqual(specs, []string{":(exclude,literal)docs"}) {
+		t.Errorf("exclude-mode pathspecs = %v", specs)
+	}
+}
+
+func TestExcludeModeListsPathToDeselection(t *testing.T) {
+	root := setupTestDir(t)
+	output := filepath.Join(t.TempDir(), "out.txt")
+	cfg := DefaultExtractionConfig()
+	cfg.IncludeMode = false
+	cfg.ShowContext = false
+	cfg.ManualSelections = []string{filepath.Join(root, "src")}
+	cfg.ManualDeselections = []string{filepath.Join(root, "src", "lib", "helper.go")}
+	space := &DirectorySpace{RootPath: root, OutputFilePath: output, Config: cfg}
+
+	// The left-out folders above the file included again are listed, so the
+	// file keeps its place in the structure and the sections agree
+	if _, err := RunExtractionWithOptions(space, ExtractOptions{Verify: true}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	structure, _, _ := strings.Cut(string(data), "--- file:")
+	for _, want := range []string{"src", "lib", "helper.go"} {
+		if !strings.Contains(structure, want) {
+			t.Errorf("structure does not list %s:\n%s", want, structure)
+		}
+	}
+	if strings.Contains(structure, "main.go") {
+		t.Errorf("structure lists a left-out file:\n%s", structure)
+	}
+}
+
+func TestExpandPaths(t *testing.T) {
+	root := setupTestDir(t)
+	got, err := ExpandPaths(root, []string{"src/**", "README.md", filepath.Join(root, "*.md"), "**/*.js"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		filepath.Join(root, "src"), // Not the files under it
+		filepath.Join(root, "README.md"),
+		filepath.Join(root, "README.md"),
+		filepath.Join(root, "node_modules", "pkg", "index.js"),
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err := ExpandPaths(root, []string{"docs/**/*.md"}); err == nil {
+		t.Error("pattern matching nothing accepted")
+	}
+}
@@ -2,29 +2,47 @@
 package core
 
 import (
+	"bytes"
 	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"time"
 )
 
 const (
 	// DefaultSessionFilename is just the name, path determines where it lives
 	DefaultSessionFilename = "pandabrew_session.json"
+
+	// DefaultSessionName is the session used when no --session is given.
+	DefaultSessionName = "default"
 )
 
 // SessionManager handles loading, saving, and modifying the global session.
+// The session file is a small index; each space lives in its own file under
+// SpacesDir so that syncing the config directory rarely produces conflicts.
 type SessionManager struct {
-	FilePath string
+	FilePath  string
+	SpacesDir string // Holds <space id>.json for every space
+	Name      string // Session name; empty for an explicit FilePath
+	// Ephemeral sessions are read but never written, so a throwaway run
+	// leaves the saved session as it was
+	Ephemeral bool
 }
 
 // NewSessionManager creates a manager pointing to the system-wide config.
 // If path is provided, it overrides the default logic.
 func NewSessionManager(path string) *SessionManager {
+	name := ""
 	if path == "" {
+		name = DefaultSessionName
 		configDir, err := os.UserConfigDir()
 		if err != nil {
 			// Fallback to local file if user config dir is unavailable
@@ -37,7 +55,57 @@ func NewSessionManager(path string) *SessionManager {
 			path = filepath.Join(appDir, DefaultSessionFilename)
 		}
 	}
-	return &SessionManager{FilePath: path}
+	return &SessionManager{FilePath: path, SpacesDir: filepath.Join(filepath.Dir(path), "spaces"), Name: name}
+}
+
+// NewNamedSessionManager creates a manager for a named session, stored next
+// to the default session under sessions/<name>.json. An empty name or
+// DefaultSessionName selects the default session.
+func NewNamedSessionManager(name string) (*SessionManager, error) {
+	if name == "" || name == DefaultSessionName {
+		return NewSessionManager(""), nil
+	}
+	if !validSessionName(name) {
+		return nil, fmt.Errorf("invalid session name %q: use letters, digits, '.', '_' or '-'", name)
+	}
+	dir := sessionsDir()
+	_ = os.MkdirAll(dir, 0o755)
+	return &SessionManager{
+		FilePath:  filepath.Join(dir, name+".json"),
+		SpacesDir: NewSessionManager("").SpacesDir, // Shared: space IDs are unique
+		Name:      name,
+	}, nil
+}
+
+// ListSessions returns the default session followed by every named session
+// saved on disk, sorted by name.
+func ListSessions() []string {
+	names := []string{DefaultSessionName}
+	entries, _ := os.ReadDir(sessionsDir())
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if ok && !e.IsDir() && validSessionName(name) && name != DefaultSessionName {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names[1:])
+	return names
+}
+
+func sessionsDir() string {
+	return filepath.Join(filepath.Dir(NewSessionManager("").FilePath), "sessions")
+}
+
+func validSessionName(name string) bool {
+	if name == "" || strings.HasPrefix(name, ".") {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
 }
 
 // Load reads the session from disk. If not found, returns a fresh session.
@@ -56,28 +124,95 @@ func (sm *SessionManager) Load() (*Session, error) {
 	}
 
 	var session Session
-	if err := json.Unmarshal(data, &session); err != nil {
+	index := sessionIndex{Session: &session}
+	if err := json.Unmarshal(data, &index); err != nil {
 		return nil, fmt.Errorf("corrupt session file: %w", err)
 	}
 
-	// Validate and clean loaded spaces
+	// Older session files keep their spaces inline; they are split on save
+	session.Spaces = index.Spaces
+	for _, id := range index.SpaceIDs {
+		space, err := sm.loadSpace(id)
+		if err != nil {
+			continue // Deleted or unsynced space: drop the tab
+		}
+		session.Spaces = append(session.Spaces, space)
+	}
+	if session.Spaces == nil {
+		session.Spaces = []*DirectorySpace{}
+	}
+
+	// Only tidy here; stat-ing every selection of a large session is slow,
+	// so checking the disk is left to ValidateSpace or CheckSpace
 	for _, space := range session.Spaces {
-		sm.ValidateSpace(space)
+		TidySpace(space)
 	}
 
 	return &session, nil
 }
 
-// Save persists the session to disk.
+// sessionIndex is the on-disk form of a Session: spaces are referenced by ID
+// and stored in their own files.
+type sessionIndex struct {
+	*Session
+	Spaces   []*DirectorySpace `json:"spaces,omitempty"` // Legacy inline spaces, read only
+	SpaceIDs []string          `json:"space_ids"`
+}
+
+// Save persists the session to disk. Space files are only rewritten when
+// their contents change.
 func (sm *SessionManager) Save(s *Session) error {
+	if sm.Ephemeral {
+		return nil
+	}
+	if err := os.MkdirAll(sm.SpacesDir, 0o755); err != nil {
+		return err
+	}
+	index := sessionIndex{Session: s, SpaceIDs: []string{}}
+	for _, space := range s.Spaces {
+		data, err := json.MarshalIndent(space, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeFileIfChanged(sm.spacePath(space.ID), data); err != nil {
+			return err
+		}
+		index.SpaceIDs = append(index.SpaceIDs, space.ID)
+	}
+
 	s.UpdatedAt = time.Now()
-	data, err := json.MarshalIndent(s, "", "  ")
+	data, err := json.MarshalIndent(index, "", "  ")
 	if err != nil {
 		return err
 	}
 	return os.WriteFile(sm.FilePath, data, 0o644)
 }
 
+func (sm *SessionManager) spacePath(id string) string {
+	return filepath.Join(sm.SpacesDir, filepath.Base(id)+".json")
+}
+
+func (sm *SessionManager) loadSpace(id string) (*DirectorySpace, error) {
+	data, err := os.ReadFile(sm.spacePath(id))
+	if err != nil {
+		return nil, err
+	}
+	var space DirectorySpace
+	if err := json.Unmarshal(data, &space); err != nil {
+		return nil, fmt.Errorf("corrupt space file %s: %w", id, err)
+	}
+	return &space, nil
+}
+
+// writeFileIfChanged leaves identical files untouched so sync tools see no
+// change.
+func writeFileIfChanged(path string, data []byte) error {
+	if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, data) {
+		return nil
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
 // AddSpaceFromPath creates a new DirectorySpace for the given path.
 func (sm *SessionManager) AddSpaceFromPath(s *Session, rawPath string) (*DirectorySpace, error) {
 	absPath, err := filepath.Abs(rawPath)
@@ -97,23 +232,12 @@ func (sm *SessionManager) AddSpaceFromPath(s *Session, rawPath string) (*Directo
 	// 2. Create New Space (Always unique)
 	id := generateRandomID()
 
-	// 3. Create Smart Default Output Path
-	parentDir := filepath.Dir(absPath)
-	dirName := filepath.Base(absPath)
-	defaultOutput := filepath.Join(parentDir, dirName+".txt")
-
 	newSpace := &DirectorySpace{
 		ID:             id,
 		RootPath:       absPath,
-		OutputFilePath: defaultOutput,
-		Config: ExtractionConfig{
-			IncludeMode:      true,
-			IncludePatterns:  []string{},
-			ExcludePatterns:  []string{".git", "node_modules", "__pycache__", "vendor"},
-			ManualSelections: []string{},
-			StructureView:    false, // Default off
-			ShowExcluded:     false, // Default off (explicit)
-		},
+		OutputFilePath: DefaultOutputPath(absPath),
+		Config:         DefaultExtractionConfig(),
+		Group:          s.ActiveGroup,
 	}
 
 	s.Spaces = append(s.Spaces, newSpace)
@@ -125,6 +249,78 @@ func (sm *SessionManager) AddSpaceFromPath(s *Session, rawPath string) (*Directo
 	return newSpace, nil
 }
 
+// DefaultOutputPath places the report next to the project folder, named
+// after it, so it never lands inside the tree it describes.
+func DefaultOutputPath(root string) string {
+	return filepath.Join(filepath.Dir(root), filepath.Base(root)+".txt")
+}
+
+// DefaultExtractionConfig returns the settings a new space starts with.
+func DefaultExtractionConfig() ExtractionConfig {
+	return ExtractionConfig{
+		IncludeMode:      true,
+		IncludePatterns:  []string{},
+		ExcludePatterns:  []string{".git", "node_modules", "__pycache__", "vendor"},
+		ManualSelections: []string{},
+		StructureView:    false, // Default off
+		ShowExcluded:     false, // Default off (explicit)
+		SkipJunk:         true,
+	}
+}
+
+// DuplicateSpace copies the root, selections, filters and tree state of a
+// space into a new space placed right after it, which becomes active. The copy
+// gets its own output file so the two never overwrite each other.
+func (sm *SessionManager) DuplicateSpace(s *Session, spaceID string) (*DirectorySpace, error) {
+	idx := slices.IndexFunc(s.Spaces, func(space *DirectorySpace) bool { return space.ID == spaceID })
+	if idx < 0 {
+		return nil, fmt.Errorf("space not found: %s", spaceID)
+	}
+	orig := s.Spaces[idx]
+
+	output := orig.OutputFilePath
+	if output != "" {
+		ext := filepath.Ext(output)
+		output = strings.TrimSuffix(output, ext) + "-copy" + ext
+	}
+
+	newSpace := &DirectorySpace{
+		ID:             generateRandomID(),
+		RootPath:       orig.RootPath,
+		OutputFilePath: output,
+		Config:         orig.Config.Clone(),
+		ExpandedPaths:  slices.Clone(orig.ExpandedPaths),
+		CursorPath:     orig.CursorPath,
+		Group:          orig.Group,
+		SearchHistory:  slices.Clone(orig.SearchHistory),
+	}
+
+	s.Spaces = slices.Insert(s.Spaces, idx+1, newSpace)
+	s.ActiveSpaceID = newSpace.ID
+
+	// Auto-save
+	_ = sm.Save(s)
+
+	return newSpace, nil
+}
+
+// Clone returns a copy of the config that shares no slices with c.
+func (c ExtractionConfig) Clone() ExtractionConfig {
+	c.IncludePatterns = slices.Clone(c.IncludePatterns)
+	c.ExcludePatterns = slices.Clone(c.ExcludePatterns)
+	c.ManualSelections = slices.Clone(c.ManualSelections)
+	c.ManualDeselections = slices.Clone(c.ManualDeselections)
+	c.AlwaysShowStructure = slices.Clone(c.AlwaysShowStructure)
+	c.OutputGlobs = slices.Clone(c.OutputGlobs)
+	c.HeaderFields = slices.Clone(c.HeaderFields)
+	c.Formats = slices.Clone(c.Formats)
+	c.ContextRules = slices.Clone(c.ContextRules)
+	c.NestedRepos = maps.Clone(c.NestedRepos)
+	c.DocumentCommands = maps.Clone(c.DocumentCommands)
+	c.SelectionHashes = maps.Clone(c.SelectionHashes)
+	return c
+}
+
 // RemoveSpace removes a space by ID and adjusts the active space if needed.
 func (sm *SessionManager) RemoveSpace(s *Session, spaceID string) error {
 	if len(s.Spaces) <= 1 {
@@ -153,61 +349,103 @@ func (sm *SessionManager) RemoveSpace(s *Session, spaceID string) error {
 		}
 	}
 
+	s.ensureActiveVisible()
+
+	if !sm.Ephemeral {
+		_ = os.Remove(sm.spacePath(spaceID))
+	}
 	_ = sm.Save(s)
 	return nil
 }
 
 // ValidateSpace checks if the RootPath exists and cleans selections.
+// Selections whose path is gone are kept, and reported as warnings, so they
+// can be reviewed (see FindStaleSelections) rather than silently lost.
 func (sm *SessionManager) ValidateSpace(space *DirectorySpace) []string {
-	var warnings []string
+	TidySpace(space)
+	check := CheckSpace(space)
+	check.Apply(space)
+	return check.Warnings
+}
 
-	// 1. Validate Root
-	if _, err := os.Stat(space.RootPath); os.IsNotExist(err) {
-		warnings = append(warnings, fmt.Sprintf("CRITICAL: Root path missing: %s", space.RootPath))
-	}
+// TidySpace drops empty and duplicate selections, deselections and expanded
+// paths. Unlike ValidateSpace it never touches the disk.
+func TidySpace(space *DirectorySpace) {
+	space.Config.ManualSelections = uniquePaths(space.Config.ManualSelections)
+	space.Config.ManualDeselections = uniquePaths(space.Config.ManualDeselections)
+	space.ExpandedPaths = uniquePaths(space.ExpandedPaths)
+}
 
-	// 2. Validate & Clean Selections
-	var validSelections []string
+func uniquePaths(paths []string) []string {
+	var unique []string
 	seen := make(map[string]bool)
-
-	for _, sel := range space.Config.ManualSelections {
-		if sel == "" {
-			continue
-		}
-		if seen[sel] {
-			continue
-		}
-		if _, err := os.Stat(sel); os.IsNotExist(err) {
+	for _, p := range paths {
+		if p == "" || seen[p] {
 			continue
 		}
+		unique = append(unique, p)
+		seen[p] = true
+	}
+	return unique
+}
+
+// SpaceCheck is what ValidateSpace finds on disk for a space.
+type SpaceCheck struct {
+	SpaceID      string
+	Warnings     []string
+	GoneExpanded []string // Expanded folders that no longer exist
+	CursorGone   bool     // The cursor path no longer exists
+}
+
+// CheckSpace stats the root, selections, expanded folders and cursor path
+// of space without changing it, so a copy can be checked in the background.
+func CheckSpace(space *DirectorySpace) SpaceCheck {
+	return CheckSpaceFS(OSFileSystem, space)
+}
+
+// CheckSpaceFS is CheckSpace against fsys.
+func CheckSpaceFS(fsys FileSystem, space *DirectorySpace) SpaceCheck {
+	check := SpaceCheck{SpaceID: space.ID}
+
+	// 1. Validate Root
+	if _, err := fsys.Stat(space.RootPath); errors.Is(err, fs.ErrNotExist) {
+		check.Warnings = append(check.Warnings, fmt.Sprintf("CRITICAL: Root path missing: %s", space.RootPath))
+	}
 
-		validSelections = append(validSelections, sel)
-		seen[sel] = true
+	// 2. Validate Selections
+	for _, sel := range space.Config.ManualSelections {
+		if _, err := fsys.Stat(sel); errors.Is(err, fs.ErrNotExist) {
+			check.Warnings = append(check.Warnings, fmt.Sprintf("Selection missing: %s", sel))
+		}
 	}
-	space.Config.ManualSelections = validSelections
 
 	// 3. Validate Expanded Paths
-	var validExpanded []string
-	seenExpanded := make(map[string]bool)
 	for _, p := range space.ExpandedPaths {
-		if p == "" || seenExpanded[p] {
-			continue
-		}
-		if _, err := os.Stat(p); err == nil {
-			validExpanded = append(validExpanded, p)
-			seenExpanded[p] = true
+		if _, err := fsys.Stat(p); err != nil {
+			check.GoneExpanded = append(check.GoneExpanded, p)
 		}
 	}
-	space.ExpandedPaths = validExpanded
 
 	// 4. Validate Cursor Path
 	if space.CursorPath != "" {
-		if _, err := os.Stat(space.CursorPath); os.IsNotExist(err) {
-			space.CursorPath = ""
+		if _, err := fsys.Stat(space.CursorPath); errors.Is(err, fs.ErrNotExist) {
+			check.CursorGone = true
 		}
 	}
 
-	return warnings
+	return check
+}
+
+// Apply drops the expanded folders and cursor path the check found gone.
+// Missing selections are kept for review.
+func (c SpaceCheck) Apply(space *DirectorySpace) {
+	if len(c.GoneExpanded) > 0 {
+		gone := toSet(c.GoneExpanded)
+		space.ExpandedPaths = slices.DeleteFunc(space.ExpandedPaths, func(p string) bool { return gone[p] })
+	}
+	if c.CursorGone {
+		space.CursorPath = ""
+	}
 }
 
 func (s *Session) GetActiveSpace() *DirectorySpace {
@@ -222,6 +460,85 @@ func (s *Session) GetActiveSpace() *DirectorySpace {
 	return s.Spaces[0]
 }
 
+// FindSpaceByRoot returns the first space whose root is path, or nil.
+func (s *Session) FindSpaceByRoot(path string) *DirectorySpace {
+	for _, space := range s.Spaces {
+		if space.RootPath == path {
+			return space
+		}
+	}
+	return nil
+}
+
+// Groups lists the distinct group names of the session's spaces, sorted.
+func (s *Session) Groups() []string {
+	var groups []string
+	for _, space := range s.Spaces {
+		if space.Group != "" && !slices.Contains(groups, space.Group) {
+			groups = append(groups, space.Group)
+		}
+	}
+	slices.Sort(groups)
+	return groups
+}
+
+// VisibleSpaces returns the spaces of the active group, or every space when
+// no group is active.
+func (s *Session) VisibleSpaces() []*DirectorySpace {
+	if s.ActiveGroup == "" {
+		return s.Spaces
+	}
+	var visible []*DirectorySpace
+	for _, space := range s.Spaces {
+		if space.Group == s.ActiveGroup {
+			visible = append(visible, space)
+		}
+	}
+	return visible
+}
+
+// SwitchGroup shows only the spaces of the named group ("" shows all) and
+// activates one of them if the active space is hidden.
+func (s *Session) SwitchGroup(name string) {
+	s.ActiveGroup = name
+	s.ensureActiveVisible()
+}
+
+// ensureActiveVisible moves the active space into the visible set, dropping
+// the group filter if it no longer matches any space.
+func (s *Session) ensureActiveVisible() {
+	visible := s.VisibleSpaces()
+	if len(visible) == 0 {
+		s.ActiveGroup = ""
+		visible = s.Spaces
+	}
+	if len(visible) == 0 {
+		return
+	}
+	active := s.GetActiveSpace()
+	if !slices.Contains(visible, active) {
+		s.ActiveSpaceID = visible[0].ID
+	}
+}
+
+// MaxSearchHistory caps how many searches a space remembers.
+const MaxSearchHistory = 20
+
+// RecordSearch puts query at the front of the search history of space,
+// dropping an older copy of it and the oldest entries past the cap.
+func RecordSearch(space *DirectorySpace, query string) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	history := slices.DeleteFunc(space.SearchHistory, func(q string) bool { return q == query })
+	history = slices.Insert(history, 0, query)
+	if len(history) > MaxSearchHistory {
+		history = history[:MaxSearchHistory]
+	}
+	space.SearchHistory = history
+}
+
 func generateRandomID() string {
 	bytes := make([]byte, 6)
 	if _, err := rand.Read(bytes); err != nil {
@@ -0,0 +1,224 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionManager(t *testing.T) {
+	tmpDir := t.TempDir()
+	sm := NewSessionManager(filepath.Join(tmpDir, "session.json"))
+
+	session, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := setupTestDir(t)
+	space, err := sm.AddSpaceFromPath(session, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if session.ActiveSpaceID != space.ID {
+		t.Error("Active space not updated")
+	}
+
+	if err := sm.Save(session); err != nil {
+		t.Fatal(err)
+	}
+
+	session2, _ := sm.Load()
+	if len(session2.Spaces) != 1 {
+		t.Error("Session persistence failed")
+	}
+}
+
+func TestDuplicateSpace(t *testing.T) {
+	sm := NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	session, _ := sm.Load()
+
+	root := setupTestDir(t)
+	orig, err := sm.AddSpaceFromPath(session, root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig.Config.ManualSelections = []string{filepath.Join(root, "src")}
+	if _, err := sm.AddSpaceFromPath(session, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	dup, err := sm.DuplicateSpace(session, orig.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Spaces[1] != dup || session.ActiveSpaceID != dup.ID {
+		t.Error("duplicate should be placed after the original and become active")
+	}
+	if dup.RootPath != orig.RootPath || dup.OutputFilePath == orig.OutputFilePath {
+		t.Errorf("unexpected paths: root %q, output %q", dup.RootPath, dup.OutputFilePath)
+	}
+
+	// Editing the copy must leave the original alone
+	dup.Config.ManualSelections[0] = filepath.Join(root, "lib")
+	if orig.Config.ManualSelections[0] != filepath.Join(root, "src") {
+		t.Error("duplicate shares selections with the original")
+	}
+}
+
+func TestSessionGroups(t *testing.T) {
+	session := &Session{Spaces: []*DirectorySpace{
+		{ID: "a", Group: "work"},
+		{ID: "b", Group: "oss"},
+		{ID: "c"},
+		{ID: "d", Group: "work"},
+	}, ActiveSpaceID: "b"}
+
+	if got := session.Groups(); !reflect.DeepEqual(got, []string{"oss", "work"}) {
+		t.Errorf("groups: got %v", got)
+	}
+
+	// Switching away from the active tab's group activates a visible tab
+	session.SwitchGroup("work")
+	if got := len(session.VisibleSpaces()); got != 2 {
+		t.Errorf("visible: got %d, want 2", got)
+	}
+	if session.ActiveSpaceID != "a" {
+		t.Errorf("active: got %q, want a", session.ActiveSpaceID)
+	}
+
+	// A group without tabs falls back to showing everything
+	session.SwitchGroup("clients")
+	if session.ActiveGroup != "" || len(session.VisibleSpaces()) != 4 {
+		t.Errorf("empty group should show all tabs, got %q", session.ActiveGroup)
+	}
+}
+
+func TestNamedSessions(t *testing.T) {
+	def, err := NewNamedSessionManager("")
+	if err != nil || def.Name != DefaultSessionName {
+		t.Fatalf("default session: %v, %+v", err, def)
+	}
+	work, err := NewNamedSessionManager("work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.FilePath == def.FilePath || filepath.Base(work.FilePath) != "work.json" {
+		t.Errorf("unexpected session file %q", work.FilePath)
+	}
+	for _, bad := range []string{"../escape", ".hidden", "a/b", "with space"} {
+		if _, err := NewNamedSessionManager(bad); err == nil {
+			t.Errorf("%q: expected an error", bad)
+		}
+	}
+}
+
+func TestSessionSpacesAreStoredSeparately(t *testing.T) {
+	dir := t.TempDir()
+	sm := NewSessionManager(filepath.Join(dir, "session.json"))
+	session, _ := sm.Load()
+	space, err := sm.AddSpaceFromPath(session, setupTestDir(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The index only references the space; its settings live in spaces/<id>.json
+	index, _ := os.ReadFile(sm.FilePath)
+	if strings.Contains(string(index), space.RootPath) || !strings.Contains(string(index), space.ID) {
+		t.Errorf("unexpected index:\n%s", index)
+	}
+	spaceFile := filepath.Join(dir, "spaces", space.ID+".json")
+	before, err := os.Stat(spaceFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Unchanged spaces are not rewritten
+	time.Sleep(10 * time.Millisecond)
+	if err := sm.Save(session); err != nil {
+		t.Fatal(err)
+	}
+	if after, _ := os.Stat(spaceFile); !after.ModTime().Equal(before.ModTime()) {
+		t.Error("unchanged space file was rewritten")
+	}
+
+	loaded, err := sm.Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Spaces) != 1 || loaded.Spaces[0].RootPath != space.RootPath {
+		t.Errorf("round trip failed: %+v", loaded.Spaces)
+	}
+
+	// Legacy single-file sessions still load
+	legacy := filepath.Join(dir, "legacy.json")
+	data := `{"id":"default","spaces":[{"id":"abc","root_path":"` + filepath.ToSlash(space.RootPath) + `"}]}`
+	if err := os.WriteFile(legacy, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old, err := NewSessionManager(legacy).Load()
+	if err != nil || len(old.Spaces) != 1 || old.Spaces[0].ID != "abc" {
+		t.Errorf("legacy load failed: %v, %+v", err, old)
+	}
+}
+
+func TestCheckSpace(t *testing.T) {
+	root := setupTestDir(t)
+	src := filepath.Join(root, "src")
+	gone := filepath.Join(root, "gone")
+	space := &DirectorySpace{
+		ID:            "a",
+		RootPath:      root,
+		Config:        ExtractionConfig{ManualSelections: []string{src, "", gone, src}},
+		ExpandedPaths: []string{src, gone, src},
+		CursorPath:    gone,
+	}
+
+	TidySpace(space)
+	if !slices.Equal(space.Config.ManualSelections, []string{src, gone}) || !slices.Equal(space.ExpandedPaths, []string{src, gone}) {
+		t.Fatalf("TidySpace left %v and %v", space.Config.ManualSelections, space.ExpandedPaths)
+	}
+
+	check := CheckSpace(space)
+	if len(check.Warnings) != 1 || !slices.Equal(check.GoneExpanded, []string{gone}) || !check.CursorGone {
+		t.Fatalf("CheckSpace = %+v", check)
+	}
+	if len(space.ExpandedPaths) != 2 || space.CursorPath != gone {
+		t.Error("CheckSpace changed the space")
+	}
+	check.Apply(space)
+	if !slices.Equal(space.ExpandedPaths, []string{src}) || space.CursorPath != "" || len(space.Config.ManualSelections) != 2 {
+		t.Errorf("after Apply: expanded %v, cursor %q, selections %v", space.ExpandedPaths, space.CursorPath, space.Config.ManualSelections)
+	}
+}
+
+func TestEphemeralSession(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session.json")
+	sm := NewSessionManager(path)
+	session, _ := sm.Load()
+	kept, err := sm.AddSpaceFromPath(session, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sm.Ephemeral = true
+	if _, err := sm.AddSpaceFromPath(session, t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	if err := sm.RemoveSpace(session, kept.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	saved, err := NewSessionManager(path).Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.Spaces) != 1 || saved.Spaces[0].ID != kept.ID {
+		t.Errorf("ephemeral changes were saved: %d spaces", len(saved.Spaces))
+	}
+}
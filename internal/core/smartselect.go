@@ -0,0 +1,138 @@
+// Package core implements picking a starting selection for a project.
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// smartConfigMaxSize is the largest root config file smart select picks.
+	smartConfigMaxSize = 16 << 10
+	// smartFileMaxSize is the largest source file smart select picks.
+	smartFileMaxSize = 64 << 10
+)
+
+// smartSourceDirs are the usual homes of source code, used when the project
+// type suggests none.
+var smartSourceDirs = []string{"src", "lib", "app", "cmd", "internal", "pkg", "api"}
+
+// smartConfigFiles are the root files describing how a project is built.
+var smartConfigFiles = map[string]bool{
+	"go.mod": true, "package.json": true, "tsconfig.json": true, "Cargo.toml": true,
+	"pyproject.toml": true, "setup.py": true, "setup.cfg": true, "requirements.txt": true,
+	"Makefile": true, "Dockerfile": true, "docker-compose.yml": true, "docker-compose.yaml": true,
+}
+
+// nonCodeLanguages are the LanguageOf results that are docs or data.
+var nonCodeLanguages = map[string]bool{
+	"Markdown": true, "reStructuredText": true, "Text": true, "JSON": true, "YAML": true,
+	"TOML": true, "XML": true, "INI": true, "Go Module": true, "Other": true,
+}
+
+// SmartSelection proposes a starting selection for root: the code and
+// small config files at its top, plus the source directories, leaving out
+// tests, generated code, vendored code and large files. A directory whose
+// files all qualify is selected whole; otherwise its qualifying files are.
+func SmartSelection(root string, cfg ExtractionConfig) ([]string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	s := smartSelector{root: root, cfg: cfg, vendor: newVendorDetector(root)}
+
+	var selections []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		path := filepath.Join(root, e.Name())
+		info, err := e.Info()
+		if err != nil || s.skipped(e.Name()) {
+			continue
+		}
+		config := smartConfigFiles[e.Name()] || strings.HasPrefix(strings.ToUpper(e.Name()), "README")
+		if config && info.Size() <= smartConfigMaxSize || s.wanted(path, e.Name(), info.Size()) {
+			selections = append(selections, path)
+		}
+	}
+
+	dirs := smartSourceDirs
+	if pt, ok := DetectProjectType(root); ok && len(pt.Selections) > 0 {
+		dirs = nil
+		for _, sel := range pt.Selections {
+			dirs = append(dirs, filepath.Base(sel))
+		}
+	}
+	for _, dir := range dirs {
+		if info, err := os.Stat(filepath.Join(root, dir)); err != nil || !info.IsDir() || s.skipped(dir) {
+			continue
+		}
+		picked, _ := s.pickDir(filepath.Join(root, dir))
+		selections = append(selections, picked...)
+	}
+	return selections, nil
+}
+
+// smartSelector walks the source directories for SmartSelection.
+type smartSelector struct {
+	root   string
+	cfg    ExtractionConfig
+	vendor *vendorDetector
+}
+
+// skipped reports whether the export ignores relPath anyway.
+func (s smartSelector) skipped(relPath string) bool {
+	return isExcluded(relPath, s.cfg.ExcludePatterns) || (s.cfg.SkipJunk && isExcluded(relPath, JunkPatterns))
+}
+
+// wanted reports whether the code file at path belongs in the selection.
+func (s smartSelector) wanted(path, relPath string, size int64) bool {
+	return !nonCodeLanguages[LanguageOf(path)] && !IsTestPath(relPath) && size <= smartFileMaxSize && DetectLowValue(path) == ""
+}
+
+// smallDataFile reports whether path holds docs or data small enough to
+// come along with the code next to it.
+func smallDataFile(path, relPath string, size int64) bool {
+	lang := LanguageOf(path)
+	return lang != "Other" && nonCodeLanguages[lang] && size <= smartConfigMaxSize && !IsTestPath(relPath)
+}
+
+// pickDir returns the selections under dir and whether they cover all of it.
+func (s smartSelector) pickDir(dir string) ([]string, bool) {
+	relDir, _ := filepath.Rel(s.root, dir)
+	if testDirNames[filepath.Base(dir)] || s.vendor.dirReason(filepath.ToSlash(relDir)) != "" {
+		return nil, false
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, false
+	}
+
+	var picked []string
+	complete := true
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		relPath := filepath.Join(relDir, e.Name())
+		if s.skipped(relPath) {
+			continue
+		}
+		if e.IsDir() {
+			sub, all := s.pickDir(path)
+			picked = append(picked, sub...)
+			complete = complete && all
+			continue
+		}
+		info, err := e.Info()
+		if err == nil && (s.wanted(path, relPath, info.Size()) || smallDataFile(path, relPath, info.Size())) {
+			picked = append(picked, path)
+		} else {
+			complete = false
+		}
+	}
+	if complete {
+		return []string{dir}, true
+	}
+	return picked, false
+}
@@ -0,0 +1,55 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSmartSelection(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                       "module example.com/app\n",
+		"README.md":                    "# App\n",
+		"main.go":                      "package main\n",
+		"main_test.go":                 "package main\n",
+		"notes.bin":                    "\x00\x01",
+		"docs/guide.md":                "# Guide\n",
+		"cmd/app/run.go":               "package app\n",
+		"internal/store/store.go":      "package store\n",
+		"internal/store/store_test.go": "package store\n",
+		"internal/store/enum_gen.go":   "// Code generated by stringer; DO NOT EDIT.\n\npackage store\n",
+		"internal/api/api.go":          "package api\n",
+		"internal/api/testdata/x.json": "{}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, want := range map[string]bool{"a_test.go": true, "src/App.spec.tsx": true, "pkg/__tests__/x.js": true, "tests/test_api.py": true, "app/test_utils.py": true, "contest.go": false, "latest/x.go": false} {
+		if got := IsTestPath(path); got != want {
+			t.Errorf("IsTestPath(%s) = %v, want %v", path, got, want)
+		}
+	}
+
+	got, err := SmartSelection(root, DefaultExtractionConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range got {
+		got[i], _ = filepath.Rel(root, p)
+		got[i] = filepath.ToSlash(got[i])
+	}
+	slices.Sort(got)
+	// Folders with tests or generated code are broken up into their other files
+	want := []string{"README.md", "cmd", "go.mod", "internal/api/api.go", "internal/store/store.go", "main.go"}
+	if !slices.Equal(got, want) {
+		t.Errorf("SmartSelection = %v, want %v", got, want)
+	}
+}
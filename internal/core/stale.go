@@ -0,0 +1,200 @@
+// Package core implements finding selections whose paths no longer exist.
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// maxMoveSuggestions caps how many new locations are offered per selection.
+const maxMoveSuggestions = 5
+
+// StaleSelection is a selection whose path no longer exists, with the paths
+// it may have moved to, best guess first.
+type StaleSelection struct {
+	Path        string
+	Suggestions []string
+	// Moved is the file with the recorded content of Path, if one exists.
+	// It is also the first suggestion.
+	Moved string
+}
+
+// FindStaleSelections lists the selections of space that no longer exist.
+// Each is offered the files or folders under the root with the same name,
+// ranked by how many of the surrounding folder names they share with the
+// old path. A file whose content is recorded in SelectionHashes is matched
+// with any file of identical content, whatever its name. The root is only
+// walked when something is missing.
+func FindStaleSelections(space *DirectorySpace) []StaleSelection {
+	var stale []StaleSelection
+	byName := make(map[string][]int) // Base name -> indexes into stale
+	bySize := make(map[int64][]int)  // Recorded size -> indexes into stale
+	for _, sel := range space.Config.ManualSelections {
+		if _, err := os.Stat(sel); !os.IsNotExist(err) {
+			continue
+		}
+		byName[filepath.Base(sel)] = append(byName[filepath.Base(sel)], len(stale))
+		if h, ok := space.Config.SelectionHashes[sel]; ok {
+			bySize[h.Size] = append(bySize[h.Size], len(stale))
+		}
+		stale = append(stale, StaleSelection{Path: sel})
+	}
+	if len(stale) == 0 {
+		return nil
+	}
+
+	cfg := space.Config
+	_ = filepath.WalkDir(space.RootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == space.RootPath {
+			return nil
+		}
+		rel, _ := filepath.Rel(space.RootPath, path)
+		if isExcluded(rel, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(rel, JunkPatterns)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for _, i := range byName[d.Name()] {
+			stale[i].Suggestions = append(stale[i].Suggestions, path)
+		}
+		if d.IsDir() {
+			return nil
+		}
+		// Only files of a recorded size are worth hashing
+		info, err := d.Info()
+		if err != nil || len(bySize[info.Size()]) == 0 {
+			return nil
+		}
+		h, err := hashFile(path)
+		if err != nil {
+			return nil
+		}
+		for _, i := range bySize[info.Size()] {
+			if stale[i].Moved == "" && space.Config.SelectionHashes[stale[i].Path].SHA256 == h.SHA256 {
+				stale[i].Moved = path
+			}
+		}
+		return nil
+	})
+
+	for i := range stale {
+		old := stale[i].Path
+		sort.SliceStable(stale[i].Suggestions, func(a, b int) bool {
+			return pathAffinity(old, stale[i].Suggestions[a]) > pathAffinity(old, stale[i].Suggestions[b])
+		})
+		if moved := stale[i].Moved; moved != "" {
+			if j := slices.Index(stale[i].Suggestions, moved); j >= 0 {
+				stale[i].Suggestions = slices.Delete(stale[i].Suggestions, j, j+1)
+			}
+			stale[i].Suggestions = slices.Insert(stale[i].Suggestions, 0, moved)
+		}
+		if len(stale[i].Suggestions) > maxMoveSuggestions {
+			stale[i].Suggestions = stale[i].Suggestions[:maxMoveSuggestions]
+		}
+	}
+	return stale
+}
+
+// pathAffinity counts the folder names two paths share from the start and,
+// separately, from the end.
+func pathAffinity(a, b string) int {
+	as := strings.Split(filepath.Dir(a), string(filepath.Separator))
+	bs := strings.Split(filepath.Dir(b), string(filepath.Separator))
+	score := 0
+	for i := 0; i < len(as) && i < len(bs) && as[i] == bs[i]; i++ {
+		score++
+	}
+	for i := 1; i <= len(as) && i <= len(bs) && as[len(as)-i] == bs[len(bs)-i]; i++ {
+		score++
+	}
+	return score
+}
+
+// RemapSelection replaces the selection from with to, keeping its place.
+// If to is already selected, from is simply dropped.
+func RemapSelection(space *DirectorySpace, from, to string) {
+	sels := space.Config.ManualSelections
+	i := slices.Index(sels, from)
+	if i < 0 {
+		return
+	}
+	if h, ok := space.Config.SelectionHashes[from]; ok {
+		delete(space.Config.SelectionHashes, from)
+		space.Config.SelectionHashes[to] = h
+	}
+	if slices.Contains(sels, to) {
+		space.Config.ManualSelections = slices.Delete(sels, i, i+1)
+		return
+	}
+	sels[i] = to
+}
+
+// RemoveSelection drops path from the selections.
+func RemoveSelection(space *DirectorySpace, path string) {
+	delete(space.Config.SelectionHashes, path)
+	if i := slices.Index(space.Config.ManualSelections, path); i >= 0 {
+		space.Config.ManualSelections = slices.Delete(space.Config.ManualSelections, i, i+1)
+	}
+}
+
+// RecordSelectionHashes stores SelectionHashes(space) in the config.
+func RecordSelectionHashes(space *DirectorySpace) {
+	space.Config.SelectionHashes = SelectionHashes(space)
+}
+
+// SelectionHashes returns the content hash of every selected file of space,
+// leaving space untouched. Files of the same size and modification time as
+// when last recorded keep their hash rather than being read again. Entries
+// of missing files are kept so they can still be found after a move;
+// entries of paths no longer selected are dropped.
+func SelectionHashes(space *DirectorySpace) map[string]FileHash {
+	hashes := make(map[string]FileHash)
+	for _, sel := range space.Config.ManualSelections {
+		old, known := space.Config.SelectionHashes[sel]
+		info, err := os.Stat(sel)
+		switch {
+		case os.IsNotExist(err):
+			if known {
+				hashes[sel] = old
+			}
+		case err == nil && info.Mode().IsRegular():
+			if known && old.Size == info.Size() && old.ModTime.Equal(info.ModTime()) {
+				hashes[sel] = old
+			} else if h, err := hashFile(sel); err == nil {
+				h.ModTime = info.ModTime()
+				hashes[sel] = h
+			}
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	return hashes
+}
+
+func hashFile(path string) (FileHash, error) {
+	return hashOpened(os.Open, path)
+}
+
+// hashOpened is hashFile reading the file opened by open.
+func hashOpened(open openFunc, path string) (FileHash, error) {
+	f, err := open(path)
+	if err != nil {
+		return FileHash{}, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return FileHash{}, err
+	}
+	return FileHash{Size: n, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
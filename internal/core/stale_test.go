@@ -0,0 +1,61 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestStaleSelections(t *testing.T) {
+	root := setupTestDir(t)
+	abs := func(rel string) string { return filepath.Join(root, filepath.FromSlash(rel)) }
+	space := &DirectorySpace{RootPath: root, Config: DefaultExtractionConfig()}
+	space.Config.ManualSelections = []string{abs("src/main.go"), abs("src/lib/helper.go"), abs("src/data.txt"), abs("gone.txt")}
+	RecordSelectionHashes(space)
+	if len(space.Config.SelectionHashes) != 3 {
+		t.Fatalf("SelectionHashes = %v", space.Config.SelectionHashes)
+	}
+	// Unchanged files keep their recorded hash without being read again
+	recorded := space.Config.SelectionHashes[abs("src/main.go")]
+	space.Config.SelectionHashes[abs("src/main.go")] = FileHash{Size: recorded.Size, SHA256: "kept", ModTime: recorded.ModTime}
+	if h := SelectionHashes(space)[abs("src/main.go")]; h.SHA256 != "kept" {
+		t.Errorf("unchanged file hashed again: %+v", h)
+	}
+	space.Config.SelectionHashes[abs("src/main.go")] = recorded
+
+	if err := os.MkdirAll(abs("pkg/lib"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for from, to := range map[string]string{"src/lib/helper.go": "pkg/lib/helper.go", "src/data.txt": "pkg/records.txt"} {
+		if err := os.Rename(abs(from), abs(to)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sm := NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	if warnings := sm.ValidateSpace(space); len(warnings) != 3 || len(space.Config.ManualSelections) != 4 {
+		t.Fatalf("missing selections were dropped or not reported: %v", warnings)
+	}
+
+	stale := FindStaleSelections(space)
+	want := []StaleSelection{
+		{Path: abs("src/lib/helper.go"), Suggestions: []string{abs("pkg/lib/helper.go")}, Moved: abs("pkg/lib/helper.go")},
+		{Path: abs("src/data.txt"), Suggestions: []string{abs("pkg/records.txt")}, Moved: abs("pkg/records.txt")},
+		{Path: abs("gone.txt")},
+	}
+	if !reflect.DeepEqual(stale, want) {
+		t.Fatalf("FindStaleSelections = %+v", stale)
+	}
+
+	RemapSelection(space, stale[0].Path, stale[0].Suggestions[0])
+	RemapSelection(space, stale[1].Path, stale[1].Moved)
+	RemoveSelection(space, abs("gone.txt"))
+	if got := space.Config.ManualSelections; !slices.Equal(got, []string{abs("src/main.go"), abs("pkg/lib/helper.go"), abs("pkg/records.txt")}) {
+		t.Errorf("selections = %v", got)
+	}
+	if _, ok := space.Config.SelectionHashes[abs("pkg/records.txt")]; !ok {
+		t.Error("the recorded hash did not follow the remap")
+	}
+}
@@ -0,0 +1,104 @@
+// Package core implements per-file statistics for a workspace.
+package core
+
+import (
+	"encoding/csv"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strconv"
+)
+
+// FileStat describes one file of a workspace for stats output.
+type FileStat struct {
+	Path     string // Relative to the root, slash-separated
+	Language string
+	Bytes    int64
+	Tokens   int
+	Selected bool // Whether the file's contents would be exported
+}
+
+// CollectFileStats lists every file under the space root that is not
+// excluded or junk, flagging the ones the current selection exports.
+func CollectFileStats(space *DirectorySpace) ([]FileStat, error) {
+	cfg := space.Config
+	absOutPath, _ := filepath.Abs(space.OutputFilePath)
+
+	selected := make(map[string]bool)
+	collect := func(f contentFile) {
+		if !f.SignaturesOnly {
+			selected[f.Path] = true
+		}
+	}
+	if err := walkAndProcess(space.RootPath, cfg, io.Discard, collect, absOutPath, nil); err != nil {
+		return nil, err
+	}
+
+	var stats []FileStat
+	err := filepath.WalkDir(space.RootPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || path == absOutPath {
+			return nil
+		}
+		relPath, _ := filepath.Rel(space.RootPath, path)
+		if relPath == "." {
+			return nil
+		}
+		if isExcluded(relPath, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(relPath, JunkPatterns)) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		stats = append(stats, FileStat{
+			Path:     filepath.ToSlash(relPath),
+			Language: LanguageOf(path),
+			Bytes:    info.Size(),
+			Tokens:   int(info.Size() / 4),
+			Selected: selected[path],
+		})
+		return nil
+	})
+	return stats, err
+}
+
+// WriteFileStatsCSV writes one row per file with a header line.
+// Use ',' for CSV or '\t' for TSV.
+func WriteFileStatsCSV(w io.Writer, stats []FileStat, comma rune) error {
+	cw := csv.NewWriter(w)
+	cw.Comma = comma
+	if err := cw.Write([]string{"path", "language", "bytes", "tokens", "selected"}); err != nil {
+		return err
+	}
+	for _, s := range stats {
+		row := []string{
+			s.Path,
+			s.Language,
+			strconv.FormatInt(s.Bytes, 10),
+			strconv.Itoa(s.Tokens),
+			strconv.FormatBool(s.Selected),
+		}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// SummarizeLanguages groups the selected files by language, largest first.
+func SummarizeLanguages(stats []FileStat) []LanguageStat {
+	acc := make(languageTotals)
+	for _, s := range stats {
+		if s.Selected {
+			acc.add(s.Language, s.Tokens)
+		}
+	}
+	return acc.sorted()
+}
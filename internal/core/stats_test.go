@@ -0,0 +1,43 @@
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollectFileStats(t *testing.T) {
+	root := setupTestDir(t)
+	space := &DirectorySpace{
+		RootPath: root,
+		Config: ExtractionConfig{
+			IncludeMode:      true,
+			ManualSelections: []string{filepath.Join(root, "src")},
+			ExcludePatterns:  []string{"node_modules"},
+		},
+	}
+	stats, err := CollectFileStats(space)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 6 {
+		t.Fatalf("got %d rows, want 6", len(stats))
+	}
+	selected := 0
+	for _, s := range stats {
+		if s.Selected {
+			selected++
+		}
+	}
+	if selected != 4 {
+		t.Errorf("selected: got %d, want 4", selected)
+	}
+
+	var buf strings.Builder
+	if err := WriteFileStatsCSV(&buf, stats, ','); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "src/main.go,Go,12,3,true") {
+		t.Errorf("unexpected CSV:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,102 @@
+// Package core implements the task presets that wrap a report in
+// instructions for a common job.
+package core
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// TaskPreset is a prompt template for one kind of task: Instructions open
+// the report, after its header, and Response closes it with the format the
+// answer should take.
+type TaskPreset struct {
+	Name         string // As given to --task
+	Title        string
+	Instructions string
+	Response     string
+}
+
+// TaskPresets are the tasks a report can be prepared for.
+var TaskPresets = []TaskPreset{
+	{
+		Name:  "code-review",
+		Title: "Code Review",
+		Instructions: "Review the code in this report as you would a pull request. Look for bugs, unclear logic, " +
+			"missing error handling, security problems and departures from the conventions the code already " +
+			"follows. Ignore formatting a linter would fix.",
+		Response: "List the findings from most to least severe. For each, give the file and function, a one-line " +
+			"summary, why it matters and a concrete fix. End with what is done well and worth keeping.",
+	},
+	{
+		Name:  "bug-hunt",
+		Title: "Bug Hunt",
+		Instructions: "Find bugs in the code in this report: logic errors, off-by-one mistakes, unhandled errors " +
+			"and edge cases, races, resource leaks and wrong assumptions about inputs. Follow how data flows " +
+			"between files instead of reading each file alone.",
+		Response: "For each bug, give the file and function, the input or sequence of events that triggers it, " +
+			"what happens and what should, and a minimal fix. Say how confident you are. Leave out style issues.",
+	},
+	{
+		Name:  "docs",
+		Title: "Generate Docs",
+		Instructions: "Write documentation for the code in this report, for a developer new to the project. " +
+			"Explain what it does, how the parts fit together and how to use its public interface, based only " +
+			"on what the code shows.",
+		Response: "Answer in Markdown: an overview, an architecture section naming the main files and their " +
+			"roles, usage examples for the public interface and the configuration options. Mark anything you " +
+			"had to guess.",
+	},
+	{
+		Name:  "tests",
+		Title: "Write Tests",
+		Instructions: "Write tests for the code in this report. Cover the main behaviour, edge cases and error " +
+			"paths, using the test framework, layout and helpers the project already has.",
+		Response: "Give each test file in full, with its path, in a code block. Before the code, list the cases " +
+			"covered and any behaviour that looked wrong while writing them.",
+	},
+}
+
+// FindTaskPreset looks up a task preset by name, ignoring case.
+func FindTaskPreset(name string) (TaskPreset, bool) {
+	for _, t := range TaskPresets {
+		if strings.EqualFold(t.Name, strings.TrimSpace(name)) {
+			return t, true
+		}
+	}
+	return TaskPreset{}, false
+}
+
+// TaskNames lists the names of the task presets.
+func TaskNames() []string {
+	names := make([]string, len(TaskPresets))
+	for i, t := range TaskPresets {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// taskFor returns the preset of cfg, nil without one.
+func taskFor(cfg ExtractionConfig) (*TaskPreset, error) {
+	if cfg.Task == "" {
+		return nil, nil
+	}
+	task, ok := FindTaskPreset(cfg.Task)
+	if !ok {
+		return nil, fmt.Errorf("unknown task %q (want one of %s)", cfg.Task, strings.Join(TaskNames(), ", "))
+	}
+	return &task, nil
+}
+
+// writeTaskInstructions opens the report body with the instructions of task.
+func writeTaskInstructions(w io.Writer, task *TaskPreset) error {
+	_, err := fmt.Fprintf(w, "### Task: %s\n\n%s\n\n", task.Title, task.Instructions)
+	return err
+}
+
+// writeTaskResponse closes the report with the response format of task.
+func writeTaskResponse(w io.Writer, task *TaskPreset) error {
+	_, err := fmt.Fprintf(w, "### Response Format\n\n%s\n\n", task.Response)
+	return err
+}
@@ -0,0 +1,26 @@
+package core
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTaskPresets(t *testing.T) {
+	if task, ok := FindTaskPreset(" Bug-Hunt "); !ok || task.Title != "Bug Hunt" {
+		t.Errorf("FindTaskPreset = %+v, %v", task, ok)
+	}
+	for _, task := range TaskPresets {
+		if task.Name == "" || task.Title == "" || task.Instructions == "" || task.Response == "" {
+			t.Errorf("incomplete preset %+v", task)
+		}
+	}
+
+	root := t.TempDir()
+	cfg := DefaultExtractionConfig()
+	cfg.Task = "haiku"
+	space := &DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(t.TempDir(), "out.txt"), Config: cfg}
+	if _, err := RunExtraction(space); err == nil || !strings.Contains(err.Error(), "code-review") {
+		t.Errorf("unknown task: err = %v", err)
+	}
+}
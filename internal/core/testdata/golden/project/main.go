@@ -0,0 +1,10 @@
+package main
+
+import "fmt"
+
+// main greets.
+func main() {
+	fmt.Println("hello")
+
+	fmt.Println("bye")
+}
@@ -0,0 +1,77 @@
+// Package core implements per-stage timing of the extraction pipeline.
+package core
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timings breaks an extraction down by pipeline stage.
+// Read is summed across workers, so with Jobs > 1 it can exceed Total.
+type Timings struct {
+	Walk     time.Duration // Directory traversal itself
+	Filter   time.Duration // Exclude, junk and selection decisions per entry
+	Read     time.Duration // Opening and prefetching file contents
+	Write    time.Duration // Writing structure and contents, incl. streamed large files
+	Tokenize time.Duration // Token estimation in TokenCountingWriter
+	Total    time.Duration
+
+	read      atomic.Int64
+	tokenize  atomic.Int64
+	visit     time.Duration // Time inside the WalkDir callback
+	walkWrite time.Duration // Structure lines written from the callback
+}
+
+type stage int
+
+const (
+	stageWalk stage = iota
+	stageVisit
+	stageWalkWrite
+	stageWrite
+	stageRead
+	stageTokenize
+)
+
+// now returns the start of a measurement, or the zero time when not timing.
+func (t *Timings) now() time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// add charges the time elapsed since start to a stage. Safe on a nil receiver.
+func (t *Timings) add(s stage, start time.Time) {
+	if t == nil {
+		return
+	}
+	d := time.Since(start)
+	switch s {
+	case stageWalk:
+		t.Walk += d
+	case stageVisit:
+		t.visit += d
+	case stageWalkWrite:
+		t.walkWrite += d
+		t.Write += d
+	case stageWrite:
+		t.Write += d
+	case stageRead:
+		t.read.Add(int64(d))
+	case stageTokenize:
+		t.tokenize.Add(int64(d))
+	}
+}
+
+// finish derives the exclusive stage times once the run is over.
+func (t *Timings) finish(start time.Time) {
+	if t == nil {
+		return
+	}
+	t.Read = time.Duration(t.read.Load())
+	t.Tokenize = time.Duration(t.tokenize.Load())
+	t.Filter = t.visit - t.walkWrite
+	t.Walk -= t.visit
+	t.Total = time.Since(start)
+}
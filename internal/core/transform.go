@@ -0,0 +1,214 @@
+// Package core implements rewriting file contents on their way into the
+// report.
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// needsTransform reports whether cfg rewrites the contents of files.
+func needsTransform(cfg ExtractionConfig) bool {
+	return cfg.LineNumbers || cfg.MinifyContent
+}
+
+// transformContent prefixes each line of res with its number and, with
+// MinifyContent, drops blank lines and trailing whitespace. Lines are
+// numbered before minifying so the numbers match the file on disk. Streamed
+// files, larger than prefetchLimit, are measured in a first pass through
+// limiter, and clipped at limit bytes of rewritten text when it is above 0;
+// binary contents are left as they are. The SHA-256 of res stays that of
+// the file, not of the rewritten text.
+func transformContent(res contentResult, cfg ExtractionConfig, limiter *rateLimiter, limit int64) contentResult {
+	if res.err != nil || !needsTransform(cfg) {
+		return res
+	}
+	if res.file != nil {
+		return transformStreamed(res, cfg, limiter, limit)
+	}
+	data, err := io.ReadAll(res.r)
+	if err != nil {
+		return contentResult{err: err}
+	}
+	if bytes.IndexByte(data, 0) >= 0 {
+		res.r = bytes.NewReader(data)
+		return res
+	}
+
+	var out []byte
+	if len(bytes.TrimSuffix(data, []byte("\n"))) > 0 {
+		m, _ := measureLines(bytes.NewReader(data), cfg, 0)
+		out, _ = io.ReadAll(newLineTransformer(bytes.NewReader(data), cfg, m.lines))
+	}
+	res.r = bytes.NewReader(out)
+	res.size = int64(len(out))
+	return res
+}
+
+// transformStreamed rewrites a streamed file as it is written, after a
+// pass over the file that measures the rewritten text.
+func transformStreamed(res contentResult, cfg ExtractionConfig, limiter *rateLimiter, limit int64) contentResult {
+	m, err := measureLines(throttle(res.file, limiter), cfg, limit)
+	if err == nil {
+		_, err = res.file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		res.err = err
+		return res
+	}
+	if m.binary {
+		return res
+	}
+	res.r = newLineTransformer(res.r, cfg, m.lines)
+	res.size = m.size
+	if m.clipped {
+		res.r = io.LimitReader(res.r, limit)
+		res.size, res.clipped = limit, true
+	}
+	return res
+}
+
+// lineMeasure is what measureLines found of a text rewritten by cfg.
+type lineMeasure struct {
+	lines   int   // Lines read, the last one with or without a final newline
+	size    int64 // Bytes of the rewritten text
+	clipped bool  // The text passes the limit, so lines and size stop short
+	binary  bool  // A NUL byte was read
+}
+
+// measureLines reads r rewritten by cfg, without its line numbers since
+// their width is only known at the end. With limit above 0 it stops as
+// soon as the rewritten text is sure to pass limit bytes, so only about
+// as much of r is read as can be shown.
+func measureLines(r io.Reader, cfg ExtractionConfig, limit int64) (lineMeasure, error) {
+	plain := cfg
+	plain.LineNumbers = false
+	t := newLineTransformer(r, plain, 0)
+	var prefix int64 // Smallest line number prefix, "1 | "
+	if cfg.LineNumbers {
+		prefix = 4
+	}
+
+	var m lineMeasure
+	var kept int64
+	buf := make([]byte, 32<<10)
+	for {
+		n, err := t.Read(buf)
+		m.size += int64(n)
+		kept += int64(bytes.Count(buf[:n], []byte("\n")))
+		if t.binary {
+			m.binary = true
+			return m, nil
+		}
+		if limit > 0 && m.size+kept*prefix > limit {
+			m.clipped = true
+			break
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return m, err
+		}
+	}
+	m.lines = t.lines()
+	if cfg.LineNumbers {
+		m.size += kept * int64(len(strconv.Itoa(m.lines))+len(" | "))
+	}
+	return m, nil
+}
+
+// lineTransformer rewrites its source line by line as it is read, so files
+// of any size are numbered and minified without holding them in memory.
+// Only the trailing whitespace of the current line is held back.
+type lineTransformer struct {
+	src     io.Reader
+	numbers bool
+	minify  bool
+	width   int    // Digits of the last line number
+	line    int    // Number of the line being read, from 1
+	started bool   // Whether the current line has been written out
+	pending []byte // Whitespace that may turn out to be trailing
+	in      []byte
+	out     []byte
+	off     int // Start of out not yet read
+	eof     bool
+	binary  bool // Whether a NUL byte was read
+}
+
+func newLineTransformer(src io.Reader, cfg ExtractionConfig, lines int) *lineTransformer {
+	return &lineTransformer{
+		src:     src,
+		numbers: cfg.LineNumbers,
+		minify:  cfg.MinifyContent,
+		width:   len(strconv.Itoa(lines)),
+		line:    1,
+		in:      make([]byte, 32<<10),
+	}
+}
+
+func (t *lineTransformer) Read(p []byte) (int, error) {
+	for t.off == len(t.out) {
+		if t.eof {
+			return 0, io.EOF
+		}
+		t.out, t.off = t.out[:0], 0
+		n, err := t.src.Read(t.in)
+		t.binary = t.binary || bytes.IndexByte(t.in[:n], 0) >= 0
+		for _, c := range t.in[:n] {
+			t.feed(c)
+		}
+		if err == io.EOF {
+			t.eof = true
+			if t.started {
+				t.out = append(t.out, '\n') // Every line ends with one
+			}
+		} else if err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, t.out[t.off:])
+	t.off += n
+	return n, nil
+}
+
+// feed rewrites one byte of the source into out.
+func (t *lineTransformer) feed(c byte) {
+	switch {
+	case c == '\n':
+		if !t.minify && !t.started {
+			t.startLine()
+		}
+		if t.started {
+			t.out = append(t.out, '\n')
+		}
+		t.started, t.pending = false, t.pending[:0]
+		t.line++
+	case t.minify && (c == ' ' || c == '\t' || c == '\r'):
+		t.pending = append(t.pending, c)
+	default:
+		if !t.started {
+			t.startLine()
+		}
+		t.out = append(append(t.out, t.pending...), c)
+		t.pending = t.pending[:0]
+	}
+}
+
+// lines returns the number of lines read so far, counting a last one
+// without a final newline.
+func (t *lineTransformer) lines() int {
+	if t.started || len(t.pending) > 0 {
+		return t.line
+	}
+	return t.line - 1
+}
+
+func (t *lineTransformer) startLine() {
+	t.started = true
+	if t.numbers {
+		t.out = fmt.Appendf(t.out, "%*d | ", t.width, t.line)
+	}
+}
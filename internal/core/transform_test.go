@@ -0,0 +1,154 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestOutputOptions(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "main.go"), []byte("package main  \n\nfunc main() {}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(t.TempDir(), "report.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: ExtractionConfig{
+		LineNumbers:   true,
+		MinifyContent: true,
+		Formats:       []string{FormatMarkdown},
+	}}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("text report written without the txt format: %v", err)
+	}
+	report, err := os.ReadFile(FormatOutputPath(out, FormatMarkdown))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Numbers follow the file on disk, past the dropped blank line
+	if !strings.Contains(string(report), "1 | package main\n3 | func main() {}\n") {
+		t.Errorf("contents not numbered and minified:\n%s", report)
+	}
+
+	// Formats from the command line win over the saved ones
+	if got := OutputFormats(ExtractOptions{Formats: []string{FormatJSON}}, space.Config); !slices.Equal(got, []string{FormatJSON}) {
+		t.Errorf("OutputFormats = %v", got)
+	}
+}
+
+func TestLineNumbersLargeFile(t *testing.T) {
+	root := t.TempDir()
+	var b strings.Builder
+	for b.Len() <= prefetchLimit {
+		b.WriteString("x := 1  \r\n\n")
+	}
+	b.WriteString("end")
+	content := b.String()
+	for name, text := range map[string]string{"big.go": content, "small.go": "a\n\nb  \n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(text), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := filepath.Join(t.TempDir(), "report.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: ExtractionConfig{
+		LineNumbers:   true,
+		MinifyContent: true,
+		HeaderFields:  []string{HeaderSHA},
+		Escaping:      EscapeLength,
+	}}
+	if _, err := RunExtraction(space); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report := string(data)
+
+	// Streamed like any other: numbered, minified and of the stated length
+	lines := strings.Count(content, "\n") + 1
+	width := len(strconv.Itoa(lines))
+	sections := newDelimiters(space.Config).sections(report)
+	big := sections["big.go"]
+	if header := "--- file: big.go [sha256=" + hashString(content) + " length=" + strconv.Itoa(len(big)-1) + "] ---\n"; !strings.Contains(report, header) {
+		t.Errorf("report lacks header %q", header)
+	}
+	if want := fmt.Sprintf("%*d | x := 1\n%*d | x := 1\n", width, 1, width, 3); !strings.HasPrefix(big, want) {
+		t.Errorf("big.go starts %q, want %q", big[:min(len(big), 40)], want)
+	}
+	if want := fmt.Sprintf("%*d | end\n\n", width, lines); !strings.HasSuffix(big, want) {
+		t.Errorf("big.go ends %q, want %q", big[max(0, len(big)-40):], want)
+	}
+	// The hash is that of the file, not of the numbered text
+	if !strings.Contains(report, "small.go [sha256="+hashString("a\n\nb  \n")+" length=12] ---\n1 | a\n3 | b\n") {
+		t.Errorf("small.go not hashed from disk:\n%s", report[strings.Index(report, "small.go"):])
+	}
+}
+
+// countingReader counts the bytes read from it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func TestLineNumbersClipped(t *testing.T) {
+	// Only about as much as can be shown is read to measure the text
+	src := &countingReader{r: io.LimitReader(neverEnding('x'), 1<<30)}
+	cfg := ExtractionConfig{LineNumbers: true}
+	m, err := measureLines(src, cfg, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.clipped || src.n > 64<<10 {
+		t.Errorf("measure = %+v after reading %d bytes", m, src.n)
+	}
+
+	root := t.TempDir()
+	content := strings.Repeat("line\n", prefetchLimit/4)
+	if err := os.WriteFile(filepath.Join(root, "big.txt"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "report.txt")
+	space := &DirectorySpace{RootPath: root, OutputFilePath: out, Config: cfg}
+	if _, err := RunExtractionWithOptions(space, ExtractOptions{MaxFileSize: 100}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Numbered as wide as the lines read, then cut at the size limit
+	big := newDelimiters(cfg).sections(string(data))["big.txt"]
+	if !regexp.MustCompile(`^ *1 \| line\n *2 \| line\n`).MatchString(big) || len(big) != 101 {
+		t.Errorf("big.txt = %q", big)
+	}
+	if !strings.Contains(string(data), "[Truncated: showing the first 100 bytes]\n") {
+		t.Errorf("no truncation notice:\n%s", data)
+	}
+}
+
+// neverEnding is an endless stream of one byte.
+type neverEnding byte
+
+func (b neverEnding) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(b)
+	}
+	return len(p), nil
+}
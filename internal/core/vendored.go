@@ -0,0 +1,262 @@
+// Package core implements detecting vendored third-party code.
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// VendoredDir is a directory of third-party code, such as node_modules or a
+// copy of a declared dependency.
+type VendoredDir struct {
+	Path   string // Relative to the root, slash-separated
+	Reason string // e.g. "node_modules" or "copy of dependency github.com/spf13/cobra"
+	Files  int    // Files included from it, in a report
+}
+
+// vendorDirNames always hold third-party code.
+var vendorDirNames = map[string]bool{"vendor": true, "node_modules": true}
+
+// thirdPartyDirNames conventionally hold copies of dependencies.
+var thirdPartyDirNames = map[string]bool{
+	"third_party": true, "thirdparty": true, "3rdparty": true, "external": true, "extern": true, "vendored": true,
+}
+
+// vendorDetector classifies directories under one root, remembering each
+// verdict.
+type vendorDetector struct {
+	root    string
+	deps    map[string]string // Lower-cased base name -> declared dependency
+	reasons map[string]string // Relative directory -> reason, "" if not vendored
+}
+
+func newVendorDetector(root string) *vendorDetector {
+	return &vendorDetector{root: root, deps: declaredDependencies(root), reasons: make(map[string]string)}
+}
+
+// vendoredDir returns the outermost vendored directory holding relPath, and
+// why it counts as vendored. dir is "" when relPath is first-party code.
+func (v *vendorDetector) vendoredDir(relPath string) (dir, reason string) {
+	parts := strings.Split(filepath.ToSlash(filepath.Dir(relPath)), "/")
+	for i := range parts {
+		if parts[i] == "." {
+			break
+		}
+		dir = strings.Join(parts[:i+1], "/")
+		if reason = v.dirReason(dir); reason != "" {
+			return dir, reason
+		}
+	}
+	return "", ""
+}
+
+// dirReason tells why the directory at relDir is vendored, or "".
+func (v *vendorDetector) dirReason(relDir string) string {
+	if reason, ok := v.reasons[relDir]; ok {
+		return reason
+	}
+	reason := ""
+	name := filepath.Base(relDir)
+	if vendorDirNames[name] {
+		reason = name
+	} else if dep, ok := v.deps[strings.ToLower(name)]; ok {
+		// A folder named like a dependency is only a copy when it sits with
+		// other third-party code or declares the same module itself
+		parent := filepath.Base(filepath.Dir(relDir))
+		if thirdPartyDirNames[strings.ToLower(parent)] || manifestName(filepath.Join(v.root, filepath.FromSlash(relDir))) == dep {
+			reason = "copy of dependency " + dep
+		}
+	}
+	v.reasons[relDir] = reason
+	return reason
+}
+
+// FindVendored walks root and lists the vendored directories in it,
+// skipping excluded and junk paths. Vendored directories are not descended
+// into, so their Files count stays zero.
+func FindVendored(root string, cfg ExtractionConfig) []VendoredDir {
+	v := newVendorDetector(root)
+	var dirs []VendoredDir
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == root {
+			return nil
+		}
+		relPath, _ := filepath.Rel(root, path)
+		if isExcluded(relPath, cfg.ExcludePatterns) || (cfg.SkipJunk && isExcluded(relPath, JunkPatterns)) {
+			return filepath.SkipDir
+		}
+		relDir := filepath.ToSlash(relPath)
+		if reason := v.dirReason(relDir); reason != "" {
+			dirs = append(dirs, VendoredDir{Path: relDir, Reason: reason})
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return dirs
+}
+
+// SelectedVendored returns the dirs of root the selection of cfg exports
+// files from.
+func SelectedVendored(root string, dirs []VendoredDir, cfg ExtractionConfig) []VendoredDir {
+	selections := cfg.checkedSet()
+	var selected []VendoredDir
+	for _, d := range dirs {
+		path := filepath.Join(root, filepath.FromSlash(d.Path))
+		covered := isPathSelected(path, root, selections)
+		if cfg.IncludeMode {
+			// Picking files inside the directory counts too
+			for _, sel := range cfg.ManualSelections {
+				covered = covered || strings.HasPrefix(sel, path+string(os.PathSeparator))
+			}
+		} else {
+			covered = !covered
+		}
+		if covered {
+			selected = append(selected, d)
+		}
+	}
+	return selected
+}
+
+// vendoredFiles groups the content files of a report that come from
+// vendored directories, most files first.
+func vendoredFiles(root string, files []contentFile) []VendoredDir {
+	v := newVendorDetector(root)
+	byDir := make(map[string]*VendoredDir)
+	var dirs []*VendoredDir
+	for _, f := range files {
+		relPath, err := filepath.Rel(root, f.Path)
+		if err != nil {
+			continue
+		}
+		dir, reason := v.vendoredDir(relPath)
+		if dir == "" {
+			continue
+		}
+		if byDir[dir] == nil {
+			byDir[dir] = &VendoredDir{Path: dir, Reason: reason}
+			dirs = append(dirs, byDir[dir])
+		}
+		byDir[dir].Files++
+	}
+	sort.SliceStable(dirs, func(i, j int) bool { return dirs[i].Files > dirs[j].Files })
+	out := make([]VendoredDir, len(dirs))
+	for i, d := range dirs {
+		out[i] = *d
+	}
+	return out
+}
+
+// declaredDependencies reads the dependencies declared in the go.mod,
+// package.json and requirements.txt of root, keyed by their lower-cased
+// base name: "cobra" for github.com/spf13/cobra, "core" for @babel/core.
+func declaredDependencies(root string) map[string]string {
+	deps := make(map[string]string)
+	add := func(dep string) {
+		base := dep[strings.LastIndex(dep, "/")+1:]
+		if goMajorVersion.MatchString(base) {
+			// github.com/x/y/v2 is a copy of y
+			trimmed := strings.TrimSuffix(dep, "/"+base)
+			base = trimmed[strings.LastIndex(trimmed, "/")+1:]
+		}
+		if base != "" {
+			deps[strings.ToLower(base)] = dep
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "go.mod")); err == nil {
+		inRequire := false
+		for line := range strings.SplitSeq(string(data), "\n") {
+			fields := strings.Fields(line)
+			switch {
+			case len(fields) == 0 || strings.HasPrefix(fields[0], "//"):
+			case fields[0] == "require" && len(fields) > 1 && fields[1] == "(":
+				inRequire = true
+			case fields[0] == ")":
+				inRequire = false
+			case fields[0] == "require" && len(fields) > 2:
+				add(fields[1])
+			case inRequire && len(fields) > 1:
+				add(fields[0])
+			}
+		}
+	}
+
+	if data, err := os.ReadFile(filepath.Join(root, "package.json")); err == nil {
+		var pkg struct {
+			Dependencies    map[string]string `json:"dependencies"`
+			DevDependencies map[string]string `json:"devDependencies"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			for dep := range pkg.Dependencies {
+				add(dep)
+			}
+			for dep := range pkg.DevDependencies {
+				add(dep)
+			}
+		}
+	}
+
+	if f, err := os.Open(filepath.Join(root, "requirements.txt")); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			if name := requirementName.FindString(strings.TrimSpace(scanner.Text())); name != "" {
+				add(name)
+			}
+		}
+	}
+	return deps
+}
+
+// goMajorVersion matches the major version suffix of a Go module path.
+var goMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// requirementName matches the package name leading a requirements.txt line.
+var requirementName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*`)
+
+// manifestName is the module or package a directory declares in its go.mod
+// or package.json, or "".
+func manifestName(dir string) string {
+	if data, err := os.ReadFile(filepath.Join(dir, "go.mod")); err == nil {
+		for line := range strings.SplitSeq(string(data), "\n") {
+			if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "module" {
+				return strings.Trim(fields[1], `"`)
+			}
+		}
+	}
+	if data, err := os.ReadFile(filepath.Join(dir, "package.json")); err == nil {
+		var pkg struct {
+			Name string `json:"name"`
+		}
+		if json.Unmarshal(data, &pkg) == nil {
+			return pkg.Name
+		}
+	}
+	return ""
+}
+
+// writeVendoredNote warns in the report header about vendored code among
+// the included files.
+func writeVendoredNote(w io.Writer, dirs []VendoredDir) error {
+	if len(dirs) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, "Note: Vendored code is included; consider structure-only listing or signatures instead:"); err != nil {
+		return err
+	}
+	for _, d := range dirs {
+		if _, err := fmt.Fprintf(w, "  %s (%d files, %s)\n", d.Path, d.Files, d.Reason); err != nil {
+			return err
+		}
+	}
+	return nil
+}
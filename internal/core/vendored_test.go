@@ -0,0 +1,80 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestVendoredDetection(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"go.mod":                             "module example.com/app\n\nrequire (\n\tgithub.com/spf13/cobra v1.8.0\n\tgithub.com/acme/yaml/v3 v3.0.1 // indirect\n)\n",
+		"main.go":                            "package main\n",
+		"web/node_modules/left-pad/index.js": "module.exports = 1\n",
+		"third_party/cobra/cobra.go":         "package cobra\n",
+		"libs/yaml/go.mod":                   "module github.com/acme/yaml/v3\n",
+		"libs/yaml/yaml.go":                  "package yaml\n",
+		"internal/cobra/cmd.go":              "package cobra\n", // Named like a dependency, but first-party
+	}
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	dirs := FindVendored(root, ExtractionConfig{})
+	var got []string
+	for _, d := range dirs {
+		got = append(got, d.Path+": "+d.Reason)
+	}
+	want := []string{
+		"libs/yaml: copy of dependency github.com/acme/yaml/v3",
+		"third_party/cobra: copy of dependency github.com/spf13/cobra",
+		"web/node_modules: node_modules",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("FindVendored = %q, want %q", got, want)
+	}
+
+	// Only the vendored directories the selection reaches are reported
+	cfg := ExtractionConfig{IncludeMode: true, ManualSelections: []string{
+		filepath.Join(root, "main.go"),
+		filepath.Join(root, "web", "node_modules", "left-pad", "index.js"),
+	}}
+	if selected := SelectedVendored(root, dirs, cfg); len(selected) != 1 || selected[0].Path != "web/node_modules" {
+		t.Errorf("SelectedVendored = %v, want web/node_modules", selected)
+	}
+	cfg = ExtractionConfig{ManualSelections: []string{filepath.Join(root, "web"), filepath.Join(root, "libs")}}
+	if selected := SelectedVendored(root, dirs, cfg); len(selected) != 1 || selected[0].Path != "third_party/cobra" {
+		t.Errorf("SelectedVendored in exclude mode = %v, want third_party/cobra", selected)
+	}
+
+	out := filepath.Join(t.TempDir(), "report.txt")
+	meta, err := RunExtraction(&DirectorySpace{RootPath: root, OutputFilePath: out})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(meta.Vendored) != 3 {
+		t.Errorf("meta.Vendored = %v, want 3 directories", meta.Vendored)
+	}
+	report, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, _, _ := strings.Cut(string(report), "\n---\n")
+	for _, line := range []string{"Note: Vendored code is included", "  libs/yaml (2 files, copy of dependency github.com/acme/yaml/v3)\n", "  web/node_modules (1 files, node_modules)"} {
+		if !strings.Contains(header, line) {
+			t.Errorf("header lacks %q:\n%s", line, header)
+		}
+	}
+	if strings.Contains(header, "internal/cobra") {
+		t.Errorf("first-party code was flagged:\n%s", header)
+	}
+}
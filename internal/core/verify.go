@@ -0,0 +1,106 @@
+// Package core implements checking that the structure and contents sections
+// of a report agree.
+package core
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ErrStructureMismatch is returned by a verified export whose structure
+// section does not list exactly the files whose contents it exports.
+var ErrStructureMismatch = errors.New("structure and contents sections disagree")
+
+// maxMismatches caps how many paths a mismatch error names.
+const maxMismatches = 5
+
+// listingWriter passes the structure section through to a writer while
+// remembering what it lists, so it can be checked against the contents.
+type listingWriter struct {
+	io.Writer
+	files      map[string]bool // Listed files by relative path, and whether marked exported
+	summarized []string        // Folders whose files are only counted
+}
+
+func newListingWriter(w io.Writer) *listingWriter {
+	return &listingWriter{Writer: w, files: make(map[string]bool)}
+}
+
+// listed notes a structure node written to w, if w is a listingWriter.
+func listed(w io.Writer, relPath string, isDir, exported bool) {
+	if lw, ok := w.(*listingWriter); ok && !isDir {
+		lw.files[relPath] = exported
+	}
+}
+
+// summarized notes that the files under dir are only counted in w.
+func summarized(w io.Writer, dir string) {
+	if lw, ok := w.(*listingWriter); ok {
+		lw.summarized = append(lw.summarized, dir)
+	}
+}
+
+// verifyAll makes every export check its sections, as if run with Verify.
+// The tests of this package set it.
+var verifyAll bool
+
+// verifying reports whether an export with opts checks its sections.
+func verifying(opts ExtractOptions) bool {
+	return opts.Verify || verifyAll
+}
+
+// verify checks that every file in files is listed as exported, or falls in
+// a summarized folder, and that every file listed as exported is in files.
+func (lw *listingWriter) verify(files []contentFile) error {
+	exported := make(map[string]bool, len(files))
+	var unlisted []string
+	for _, f := range files {
+		exported[f.RelPath] = true
+		if !lw.files[f.RelPath] && !lw.covers(f.RelPath) {
+			unlisted = append(unlisted, filepath.ToSlash(f.RelPath))
+		}
+	}
+	var missing []string
+	for relPath, marked := range lw.files {
+		if marked && !exported[relPath] {
+			missing = append(missing, filepath.ToSlash(relPath))
+		}
+	}
+
+	var problems []string
+	if len(unlisted) > 0 {
+		problems = append(problems, "exported but not listed: "+namePaths(unlisted))
+	}
+	if len(missing) > 0 {
+		problems = append(problems, "listed as exported but missing: "+namePaths(missing))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: %s", ErrStructureMismatch, strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// covers reports whether relPath is inside a summarized folder.
+func (lw *listingWriter) covers(relPath string) bool {
+	for _, dir := range lw.summarized {
+		if strings.HasPrefix(relPath, dir+string(os.PathSeparator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// namePaths lists the first few paths in order and counts the rest.
+func namePaths(paths []string) string {
+	slices.Sort(paths)
+	text := strings.Join(paths[:min(len(paths), maxMismatches)], ", ")
+	if n := len(paths) - maxMismatches; n > 0 {
+		text += fmt.Sprintf(" and %d more", n)
+	}
+	return text
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"errors"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStructureListsContents(t *testing.T) {
+	lw := newListingWriter(io.Discard)
+	if err := printTreeNode(lw, filepath.Join("a", "b.go"), false, true, ""); err != nil {
+		t.Fatal(err)
+	}
+	summarized(lw, "c")
+	files := []contentFile{{RelPath: filepath.Join("a", "b.go")}, {RelPath: filepath.Join("c", "d.go")}}
+	if err := lw.verify(files); err != nil {
+		t.Errorf("agreeing sections: %v", err)
+	}
+	if err := lw.verify(files[1:]); !errors.Is(err, ErrStructureMismatch) || !strings.Contains(err.Error(), "a/b.go") {
+		t.Errorf("file listed as exported but missing: %v", err)
+	}
+	if err := lw.verify(append(files, contentFile{RelPath: "e.go"})); !errors.Is(err, ErrStructureMismatch) || !strings.Contains(err.Error(), "exported but not listed: e.go") {
+		t.Errorf("file exported but not listed: %v", err)
+	}
+
+	// Random trees and settings: every export below is verified
+	rng := rand.New(rand.NewPCG(1, 2))
+	names := []string{"src", "lib", "test", "build", "a.go", "b_test.go", "c.txt", "d.min.js", "e.md"}
+	for i := range 60 {
+		root := t.TempDir()
+		var paths []string
+		for range 4 + rng.IntN(20) {
+			parts := make([]string, 1+rng.IntN(4))
+			for j := range parts {
+				parts[j] = names[rng.IntN(len(names))]
+			}
+			path := filepath.Join(append([]string{root}, parts...)...)
+			if os.MkdirAll(filepath.Dir(path), 0o755) != nil || os.WriteFile(path, []byte("package x\n"), 0o644) != nil {
+				continue // A file already took the name of a folder, or the other way around
+			}
+			paths = append(paths, path)
+		}
+		pick := func() []string {
+			var picked []string
+			for _, p := range paths {
+				if rng.IntN(4) == 0 {
+					picked = append(picked, filepath.Dir(p))
+				} else if rng.IntN(4) == 0 {
+					picked = append(picked, p)
+				}
+			}
+			return picked
+		}
+
+		cfg := DefaultExtractionConfig()
+		cfg.IncludeMode = rng.IntN(2) == 0
+		cfg.ManualSelections = pick()
+		cfg.ManualDeselections = pick()
+		cfg.AlwaysShowStructure = pick()
+		cfg.ExcludePatterns = append(cfg.ExcludePatterns, []string{"build", "*.txt", ""}[rng.IntN(3)])
+		cfg.ShowExcluded = rng.IntN(3) == 0
+		cfg.ShowContext = rng.IntN(2) == 0
+		cfg.ContextRules = []ContextRule{{Listing: rng.IntN(2) == 0, Signatures: rng.IntN(2) == 0}}
+		cfg.FullTreeMap = rng.IntN(4) == 0
+		cfg.StructureMaxDepth = rng.IntN(4)
+		cfg.TestsPolicy = []string{"", NestedRepoStructure, NestedRepoSkip}[rng.IntN(3)]
+		cfg.GeneratedPolicy = []string{"", NestedRepoStructure}[rng.IntN(2)]
+
+		space := &DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(t.TempDir(), "out.txt"), Config: cfg}
+		if _, err := RunExtractionWithOptions(space, ExtractOptions{Verify: true}); err != nil {
+			t.Fatalf("case %d with %+v: %v", i, cfg, err)
+		}
+	}
+}
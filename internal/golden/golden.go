@@ -0,0 +1,99 @@
+// Package golden implements snapshot testing against golden files. Run the
+// tests of a package with -update to rewrite its golden files from the
+// current output, then review the diff:
+//
+//	go test ./internal/core -run Golden -update
+package golden
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from the current output")
+
+// timestamp matches the RFC 3339 times reports are stamped with.
+var timestamp = regexp.MustCompile(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})`)
+
+// Normalize makes output comparable across runs and machines: timestamps
+// become <timestamp>, each of paths becomes <root>, <root1> and so on, and
+// Windows line endings become Unix ones.
+func Normalize(output []byte, paths ...string) []byte {
+	text := strings.ReplaceAll(string(output), "\r\n", "\n")
+	for i, path := range paths {
+		name := "<root>"
+		if len(paths) > 1 {
+			name = "<root" + string(rune('1'+i)) + ">"
+		}
+		text = strings.ReplaceAll(text, filepath.ToSlash(path), name)
+		text = strings.ReplaceAll(text, path, name)
+	}
+	return timestamp.ReplaceAll([]byte(text), []byte("<timestamp>"))
+}
+
+// Assert fails t unless got equals the golden file at path. With -update
+// it writes got to path instead, creating its directory.
+func Assert(t testing.TB, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("no golden file %s; run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s (run with -update to accept it)\n%s", path, diffLines(want, got))
+	}
+}
+
+// CopyFixture copies the fixture tree at dir into a temporary directory
+// named like it, outside any repository, and returns the copy's path. Reports
+// of the copy have a stable root name and no branch of the enclosing repo.
+func CopyFixture(t testing.TB, dir string) string {
+	t.Helper()
+	root := filepath.Join(t.TempDir(), filepath.Base(dir))
+	if err := os.CopyFS(root, os.DirFS(dir)); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// diffLines describes the first line where got departs from want, with a
+// little context, which is usually enough to see what changed.
+func diffLines(want, got []byte) string {
+	w := strings.Split(string(want), "\n")
+	g := strings.Split(string(got), "\n")
+	i := 0
+	for i < len(w) && i < len(g) && w[i] == g[i] {
+		i++
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "first difference at line %d:\n", i+1)
+	for j := max(0, i-2); j < i; j++ {
+		b.WriteString("  " + w[j] + "\n")
+	}
+	for j := i; j < min(len(w), i+3); j++ {
+		b.WriteString("- " + w[j] + "\n")
+	}
+	for j := i; j < min(len(g), i+3); j++ {
+		b.WriteString("+ " + g[j] + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,27 @@
+package golden
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	got := string(Normalize([]byte("Timestamp: 2026-01-02T03:04:05Z\r\nat 2026-01-02T03:04:05.123+02:00 in /tmp/a/project/x.go, /tmp/b\n"), "/tmp/a/project", "/tmp/b"))
+	if want := "Timestamp: <timestamp>\nat <timestamp> in <root1>/x.go, <root2>\n"; got != want {
+		t.Errorf("Normalize = %q, want %q", got, want)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	diff := diffLines([]byte("a\nb\nc\nd"), []byte("a\nb\nx\nd"))
+	if !strings.Contains(diff, "line 3") || !strings.Contains(diff, "- c\n") || !strings.Contains(diff, "+ x\n") {
+		t.Errorf("diff:\n%s", diff)
+	}
+}
+
+func TestCopyFixture(t *testing.T) {
+	if root := CopyFixture(t, filepath.Join("..", "core", "testdata", "golden", "project")); filepath.Base(root) != "project" {
+		t.Errorf("copied to %s", root)
+	}
+}
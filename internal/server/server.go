@@ -0,0 +1,263 @@
+// Package server implements a JSON-RPC 2.0 interface over stdio that lets
+// editor plugins edit a workspace's selection and export it.
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"pandabrew/internal/core"
+)
+
+// Standard JSON-RPC 2.0 error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeServerError    = -32000
+)
+
+// Server answers requests about a single workspace. Messages are one JSON
+// object per line in both directions.
+type Server struct {
+	sessions *core.SessionManager // Nil for a workspace that is never saved
+	session  *core.Session
+	space    *core.DirectorySpace
+	opts     core.ExtractOptions
+	Force    bool // Let export overwrite files PandaBrew did not write
+}
+
+// New returns a server for space. Selection changes are saved through sm,
+// where a later TUI picks them up. A TUI already running on the session
+// keeps its own copy: whichever of the two saves last wins.
+func New(sm *core.SessionManager, session *core.Session, space *core.DirectorySpace, opts core.ExtractOptions) *Server {
+	return &Server{sessions: sm, session: session, space: space, opts: opts}
+}
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// Serve handles requests from r until it is closed. Notifications (requests
+// without an id) are executed but not answered.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	enc := json.NewEncoder(w)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal(line, &req); err != nil {
+			if err := enc.Encode(response{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{codeParseError, err.Error()}}); err != nil {
+				return err
+			}
+			continue
+		}
+
+		result, err := s.handle(req)
+		if req.ID == nil {
+			continue
+		}
+		resp := response{JSONRPC: "2.0", ID: req.ID, Result: result}
+		if err != nil {
+			rerr, ok := err.(*rpcError)
+			if !ok {
+				rerr = &rpcError{codeServerError, err.Error()}
+			}
+			resp.Result, resp.Error = nil, rerr
+		}
+		if err := enc.Encode(resp); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// pathsParams are the params of select and deselect. Paths may be absolute
+// or relative to the workspace root.
+type pathsParams struct {
+	Paths []string `json:"paths"`
+}
+
+// exportParams are the params of export.
+type exportParams struct {
+	Output string `json:"output,omitempty"` // Defaults to the workspace output
+}
+
+// SelectionResult describes the current selection.
+type SelectionResult struct {
+	Root        string   `json:"root"`
+	IncludeMode bool     `json:"include_mode"`
+	Paths       []string `json:"paths"`                // Checked paths, relative to the root
+	Deselected  []string `json:"deselected,omitempty"` // Unchecked inside checked folders
+}
+
+// ExportResult describes a written report, or with DryRun set the report a
+// read-only server would have written to Output.
+type ExportResult struct {
+	Output string `json:"output"`
+	Files  int    `json:"files"`
+	Tokens int    `json:"tokens"`
+	DryRun bool   `json:"dry_run,omitempty"` // Nothing was written
+}
+
+// StatsResult summarizes what an export would contain.
+type StatsResult struct {
+	SelectedFiles  int             `json:"selected_files"`
+	TotalFiles     int             `json:"total_files"`
+	SelectedTokens int             `json:"selected_tokens"`
+	TotalTokens    int             `json:"total_tokens"`
+	Languages      []LanguageStats `json:"languages"`
+}
+
+// LanguageStats is the share of one language in the selection.
+type LanguageStats struct {
+	Language string `json:"language"`
+	Files    int    `json:"files"`
+	Tokens   int    `json:"tokens"`
+}
+
+// resolve cleans a path sent by the client, taking relative ones from the
+// workspace root, and refuses it if it leaves the root: clients only edit
+// and export within their workspace.
+func (s *Server) resolve(path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.space.RootPath, filepath.FromSlash(path))
+	}
+	path = filepath.Clean(path)
+	if rel, err := filepath.Rel(filepath.Clean(s.space.RootPath), path); err != nil || !filepath.IsLocal(rel) {
+		return "", &rpcError{codeInvalidParams, fmt.Sprintf("%s is outside the workspace root", path)}
+	}
+	return path, nil
+}
+
+func (s *Server) handle(req request) (any, error) {
+	if req.JSONRPC != "2.0" {
+		return nil, &rpcError{codeInvalidRequest, `jsonrpc must be "2.0"`}
+	}
+	switch req.Method {
+	case "select", "deselect":
+		var p pathsParams
+		if err := json.Unmarshal(req.Params, &p); err != nil || len(p.Paths) == 0 {
+			return nil, &rpcError{codeInvalidParams, "params must be {\"paths\": [...]}"}
+		}
+		paths := make([]string, len(p.Paths))
+		for i, path := range p.Paths {
+			var err error
+			if paths[i], err = s.resolve(path); err != nil {
+				return nil, err
+			}
+		}
+		for _, path := range paths {
+			if req.Method == "select" {
+				core.SelectPath(s.space, path)
+			} else {
+				core.DeselectPath(s.space, path)
+			}
+		}
+		if s.sessions != nil {
+			if err := s.sessions.Save(s.session); err != nil {
+				return nil, err
+			}
+		}
+		return s.selection(), nil
+	case "selection":
+		return s.selection(), nil
+	case "export":
+		var p exportParams
+		if len(req.Params) > 0 {
+			if err := json.Unmarshal(req.Params, &p); err != nil {
+				return nil, &rpcError{codeInvalidParams, err.Error()}
+			}
+		}
+		space := s.space
+		if p.Output != "" {
+			output, err := s.resolve(p.Output)
+			if err != nil {
+				return nil, err
+			}
+			copied := *s.space
+			copied.OutputFilePath = output
+			space = &copied
+		}
+		if !s.Force && !s.opts.DryRun {
+			for _, path := range core.FormatOutputPaths(space.OutputFilePath, core.OutputFormats(s.opts, space.Config)) {
+				if err := core.CheckOutputPath(path); err != nil {
+					return nil, &rpcError{codeServerError, fmt.Sprintf("%s: %s (start the server with --force to overwrite)", err, path)}
+				}
+			}
+		}
+		meta, err := core.RunExtractionWithOptions(space, s.opts)
+		if err != nil {
+			return nil, err
+		}
+		if !s.opts.DryRun {
+			// Remember the exported contents, to find selections that move
+			core.RecordSelectionHashes(s.space)
+			if s.sessions != nil {
+				if err := s.sessions.Save(s.session); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return ExportResult{Output: space.OutputFilePath, Files: meta.TotalFiles, Tokens: meta.TotalTokens, DryRun: s.opts.DryRun}, nil
+	case "stats":
+		stats, err := core.CollectFileStats(s.space)
+		if err != nil {
+			return nil, err
+		}
+		res := StatsResult{TotalFiles: len(stats), Languages: []LanguageStats{}}
+		for _, st := range stats {
+			res.TotalTokens += st.Tokens
+			if st.Selected {
+				res.SelectedFiles++
+				res.SelectedTokens += st.Tokens
+			}
+		}
+		for _, l := range core.SummarizeLanguages(stats) {
+			res.Languages = append(res.Languages, LanguageStats{Language: l.Language, Files: l.Files, Tokens: l.Tokens})
+		}
+		return res, nil
+	}
+	return nil, &rpcError{codeMethodNotFound, fmt.Sprintf("unknown method %q", req.Method)}
+}
+
+func (s *Server) selection() SelectionResult {
+	res := SelectionResult{Root: s.space.RootPath, IncludeMode: s.space.Config.IncludeMode, Paths: []string{}}
+	for _, sel := range s.space.Config.ManualSelections {
+		if rel, err := filepath.Rel(s.space.RootPath, sel); err == nil {
+			res.Paths = append(res.Paths, filepath.ToSlash(rel))
+		}
+	}
+	for _, desel := range s.space.Config.ManualDeselections {
+		if rel, err := filepath.Rel(s.space.RootPath, desel); err == nil {
+			res.Deselected = append(res.Deselected, filepath.ToSlash(rel))
+		}
+	}
+	return res
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestServe(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "b.go", "lib/c.go"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out := filepath.Join(t.TempDir(), "out.txt")
+	space := &core.DirectorySpace{RootPath: root, OutputFilePath: out, Config: core.DefaultExtractionConfig()}
+	srv := New(nil, nil, space, core.DefaultExtractOptions())
+
+	input := strings.Join([]string{
+		`{"jsonrpc":"2.0","id":1,"method":"select","params":{"paths":["."]}}`,
+		`{"jsonrpc":"2.0","method":"deselect","params":{"paths":["b.go"]}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"selection"}`,
+		`{"jsonrpc":"2.0","id":3,"method":"export"}`,
+		`{"jsonrpc":"2.0","id":4,"method":"nope"}`,
+		`not json`,
+	}, "\n")
+	var buf bytes.Buffer
+	if err := srv.Serve(strings.NewReader(input), &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	type rawResponse struct {
+		Result json.RawMessage `json:"result"`
+		Error  *rpcError       `json:"error"`
+	}
+	var responses []rawResponse
+	dec := json.NewDecoder(&buf)
+	for dec.More() {
+		var r rawResponse
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		responses = append(responses, r)
+	}
+	if len(responses) != 5 {
+		t.Fatalf("got %d responses, want 5 (the notification is not answered)", len(responses))
+	}
+
+	var sel SelectionResult
+	if err := json.Unmarshal(responses[1].Result, &sel); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(sel.Paths, ",") != "." || strings.Join(sel.Deselected, ",") != "b.go" {
+		t.Errorf("selection = %v without %v, want [.] without [b.go]", sel.Paths, sel.Deselected)
+	}
+
+	var export ExportResult
+	if err := json.Unmarshal(responses[2].Result, &export); err != nil {
+		t.Fatal(err)
+	}
+	if export.Output != out || export.Files != 2 {
+		t.Errorf("export = %+v", export)
+	}
+
+	if e := responses[3].Error; e == nil || e.Code != codeMethodNotFound {
+		t.Errorf("unknown method error = %+v", e)
+	}
+	if e := responses[4].Error; e == nil || e.Code != codeParseError {
+		t.Errorf("parse error = %+v", e)
+	}
+}
+
+func TestServeExportForeignOutput(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	foreign := filepath.Join(root, ".bashrc")
+	if err := os.WriteFile(foreign, []byte("export PATH=/bin\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := core.DefaultExtractionConfig()
+	cfg.ManualSelections = []string{root}
+	space := &core.DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(t.TempDir(), "out.txt"), Config: cfg}
+	request := `{"jsonrpc":"2.0","id":1,"method":"export","params":{"output":` + strconv.Quote(foreign) + `}}`
+
+	srv := New(nil, nil, space, core.DefaultExtractOptions())
+	var buf bytes.Buffer
+	if err := srv.Serve(strings.NewReader(request), &buf); err != nil {
+		t.Fatal(err)
+	}
+	var resp struct{ Error *rpcError }
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error == nil || !strings.Contains(resp.Error.Message, core.ErrForeignOutput.Error()) {
+		t.Errorf("error = %+v, want the foreign output refused", resp.Error)
+	}
+	if data, _ := os.ReadFile(foreign); string(data) != "export PATH=/bin\n" {
+		t.Errorf("foreign file overwritten:\n%s", data)
+	}
+
+	// With Force, as started with --force, it is replaced
+	srv.Force = true
+	buf.Reset()
+	if err := srv.Serve(strings.NewReader(request), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !core.IsReportFile(foreign) {
+		t.Errorf("forced export did not write the report: %s", buf.String())
+	}
+}
+
+func TestServeExportReadOnly(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := core.DefaultExtractionConfig()
+	cfg.ManualSelections = []string{root}
+	out := filepath.Join(t.TempDir(), "out.txt")
+	space := &core.DirectorySpace{RootPath: root, OutputFilePath: out, Config: cfg}
+	opts := core.DefaultExtractOptions()
+	opts.DryRun = true
+
+	var buf bytes.Buffer
+	if err := New(nil, nil, space, opts).Serve(strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"export"}`), &buf); err != nil {
+		t.Fatal(err)
+	}
+	var resp struct{ Result ExportResult }
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Result.DryRun || resp.Result.Output != out || resp.Result.Files != 1 {
+		t.Errorf("export = %+v, want a dry run of one file", resp.Result)
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("read-only export wrote %s", out)
+	}
+}
+
+func TestServeOutsideRoot(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	outside := t.TempDir()
+	space := &core.DirectorySpace{RootPath: root, OutputFilePath: filepath.Join(outside, "out.txt"), Config: core.DefaultExtractionConfig()}
+	srv := New(nil, nil, space, core.DefaultExtractOptions())
+
+	requests := []string{
+		`{"jsonrpc":"2.0","id":1,"method":"select","params":{"paths":["a.go","../secret"]}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"select","params":{"paths":[` + strconv.Quote(outside) + `]}}`,
+		`{"jsonrpc":"2.0","id":3,"method":"deselect","params":{"paths":["lib/../../x"]}}`,
+		`{"jsonrpc":"2.0","id":4,"method":"export","params":{"output":"../out.txt"}}`,
+		`{"jsonrpc":"2.0","id":5,"method":"export","params":{"output":` + strconv.Quote(filepath.Join(outside, "x.txt")) + `}}`,
+	}
+	var buf bytes.Buffer
+	if err := srv.Serve(strings.NewReader(strings.Join(requests, "\n")), &buf); err != nil {
+		t.Fatal(err)
+	}
+	dec := json.NewDecoder(&buf)
+	for _, request := range requests {
+		var resp struct{ Error *rpcError }
+		if err := dec.Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error == nil || resp.Error.Code != codeInvalidParams {
+			t.Errorf("%s: error = %+v, want invalid params", request, resp.Error)
+		}
+	}
+	// A refused request changes nothing, even for its paths inside the root
+	if len(space.Config.ManualSelections) != 0 || len(space.Config.ManualDeselections) != 0 {
+		t.Errorf("selection changed: %v without %v", space.Config.ManualSelections, space.Config.ManualDeselections)
+	}
+	if entries, _ := os.ReadDir(outside); len(entries) != 0 {
+		t.Errorf("export wrote outside the root: %v", entries)
+	}
+
+	// Paths inside the root are taken as before
+	buf.Reset()
+	request := `{"jsonrpc":"2.0","id":6,"method":"export","params":{"output":"out/report.txt"}}`
+	if err := srv.Serve(strings.NewReader(request), &buf); err != nil {
+		t.Fatal(err)
+	}
+	var resp struct{ Result ExportResult }
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(root, "out", "report.txt"); resp.Result.Output != want {
+		t.Errorf("output = %q, want %q", resp.Result.Output, want)
+	}
+}
@@ -0,0 +1,22 @@
+// Package tui implements the git branch indicator of the tab bar.
+package tui
+
+import (
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// BranchLoadedMsg carries the checked-out branch of a root.
+type BranchLoadedMsg struct {
+	Root   string
+	Branch core.GitBranch
+	OK     bool // False outside a git repository
+}
+
+func loadBranchCmd(root string) tea.Cmd {
+	return func() tea.Msg {
+		branch, ok := core.ReadGitBranch(root)
+		return BranchLoadedMsg{Root: root, Branch: branch, OK: ok}
+	}
+}
@@ -0,0 +1,94 @@
+// Package tui implements the quick re-export and copying reports to the clipboard.
+package tui
+
+import (
+	"io"
+	"os"
+
+	"pandabrew/internal/core"
+
+	"github.com/atotto/clipboard"
+	"github.com/aymanbagabas/go-osc52/v2"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// QuickExportMsg carries the result of a quick re-export.
+type QuickExportMsg struct {
+	Count   int
+	Tokens  int
+	Err     error
+	CopyErr error          // Set when the report could not be put on the clipboard
+	Summary *ExportSummary // Set when the export succeeded
+	OSC52   string         // Report still to copy by escape sequence, the system clipboard being unavailable
+
+	// SpaceID names the exported tab and Hashes the contents of its
+	// selected files as exported, as in ExportCompleteMsg.
+	SpaceID string
+	Hashes  map[string]core.FileHash
+}
+
+// quickExportCmd exports snapshot with opts and copies the report to the
+// clipboard.
+func quickExportCmd(snapshot *core.DirectorySpace, opts core.ExtractOptions) tea.Cmd {
+	return func() tea.Msg {
+		meta, err := core.RunExtractionWithOptions(snapshot, opts)
+		if err != nil {
+			return QuickExportMsg{Err: err}
+		}
+		msg := QuickExportMsg{
+			Count:   meta.TotalFiles,
+			Tokens:  meta.TotalTokens,
+			Summary: newExportSummary(snapshot, opts, meta.TotalFiles, meta.TotalTokens),
+			SpaceID: snapshot.ID,
+			Hashes:  core.SelectionHashes(snapshot),
+		}
+		report, err := os.ReadFile(outputPaths(snapshot, opts)[0])
+		if err != nil {
+			msg.CopyErr = err
+		} else if clipboard.WriteAll(string(report)) != nil {
+			msg.OSC52 = string(report)
+		}
+		return msg
+	}
+}
+
+// osc52CopyCmd copies text with the OSC 52 escape sequence, so copying also
+// works over SSH. It runs as an exec so the renderer has let go of the
+// terminal while the sequence is written, then reports the result as
+// another QuickExportMsg.
+func osc52CopyCmd(msg QuickExportMsg) tea.Cmd {
+	return tea.Exec(&osc52Copy{text: msg.OSC52}, func(err error) tea.Msg {
+		return QuickExportMsg{Count: msg.Count, Tokens: msg.Tokens, CopyErr: err}
+	})
+}
+
+// osc52Copy is a tea.ExecCommand writing an OSC 52 sequence to stderr.
+type osc52Copy struct {
+	text   string
+	stderr io.Writer
+}
+
+func (c *osc52Copy) Run() error {
+	out := c.stderr
+	if out == nil {
+		out = os.Stderr
+	}
+	_, err := osc52.New(c.text).WriteTo(out)
+	return err
+}
+
+func (c *osc52Copy) SetStdin(io.Reader)    {}
+func (c *osc52Copy) SetStdout(io.Writer)   {}
+func (c *osc52Copy) SetStderr(w io.Writer) { c.stderr = w }
+
+// lastExportSnapshot is the configuration of the last export of space, or
+// a copy of the current one when the tab has not been exported yet.
+func (m *AppModel) lastExportSnapshot(space *core.DirectorySpace) *core.DirectorySpace {
+	source := space
+	if last := m.LastExports[space.ID]; last != nil {
+		source = last
+	}
+	snapshot := *source
+	snapshot.Config = source.Config.Clone()
+	return &snapshot
+}
@@ -0,0 +1,102 @@
+package tui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestQuickExportChecksSnapshotOutput(t *testing.T) {
+	dir := t.TempDir()
+	foreign := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(foreign, []byte("not a report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	space := &core.DirectorySpace{ID: "a", RootPath: dir, OutputFilePath: filepath.Join(dir, "new.txt")}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.LastExports["a"] = &core.DirectorySpace{ID: "a", RootPath: dir, OutputFilePath: foreign}
+
+	// The live output is free, but the re-export would overwrite notes.txt
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+	m = updated.(AppModel)
+	if cmd != nil || len(m.Toasts) != 1 || !strings.HasPrefix(m.Toasts[0].Text, "Quick export skipped: ") {
+		t.Errorf("cmd %v, toasts %+v", cmd != nil, m.Toasts)
+	}
+}
+
+func TestQuickExportCopiesByOSC52(t *testing.T) {
+	m := InitialModel(&core.Session{}, nil)
+	updated, cmd := m.Update(QuickExportMsg{Count: 2, Tokens: 10, OSC52: "report"})
+	m = updated.(AppModel)
+	if cmd == nil || len(m.Toasts) != 0 {
+		t.Fatalf("cmd %v, toasts %+v; want the copy to run before any notice", cmd != nil, m.Toasts)
+	}
+
+	var out bytes.Buffer
+	c := &osc52Copy{text: "report"}
+	c.SetStderr(&out)
+	if err := c.Run(); err != nil || !strings.HasPrefix(out.String(), "\x1b]52;c;") {
+		t.Errorf("wrote %q, err %v", out.String(), err)
+	}
+}
+
+func TestQuickExportRecordsHashes(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(file, []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	space := &core.DirectorySpace{ID: "a", RootPath: dir, OutputFilePath: filepath.Join(t.TempDir(), "out.txt")}
+	space.Config.IncludeMode = true
+	space.Config.ManualSelections = []string{file}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	press := func() tea.Cmd {
+		updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("R")})
+		m = updated.(AppModel)
+		return cmd
+	}
+
+	// Not while another export runs
+	m.Loading = true
+	press()
+	if len(m.Toasts) != 1 || m.Toasts[0].Text != "Quick export skipped: busy" {
+		t.Fatalf("toasts %+v, want the quick export skipped", m.Toasts)
+	}
+
+	m.Loading = false
+	msg, ok := findMsg[QuickExportMsg](press())
+	if !ok || !m.Loading {
+		t.Fatalf("quick export not started (loading %v)", m.Loading)
+	}
+	updated, _ := m.Update(msg)
+	m = updated.(AppModel)
+	if m.Loading || space.Config.SelectionHashes[file].SHA256 == "" {
+		t.Errorf("loading %v, hashes %+v; want the exported contents recorded", m.Loading, space.Config.SelectionHashes)
+	}
+}
+
+// findMsg runs cmd, and the commands batched in it, for a message of type T.
+func findMsg[T tea.Msg](cmd tea.Cmd) (T, bool) {
+	var zero T
+	if cmd == nil {
+		return zero, false
+	}
+	switch msg := cmd().(type) {
+	case T:
+		return msg, true
+	case tea.BatchMsg:
+		for _, c := range msg {
+			if found, ok := findMsg[T](c); ok {
+				return found, true
+			}
+		}
+	}
+	return zero, false
+}
@@ -0,0 +1,59 @@
+// Package tui implements the terminal user interface logic.
+package tui
+
+import (
+	"fmt"
+
+	"pandabrew/internal/core"
+)
+
+// otherSpaces lists every open space except the active one, in tab order.
+func (m *AppModel) otherSpaces() []*core.DirectorySpace {
+	active := m.Session.GetActiveSpace()
+	var others []*core.DirectorySpace
+	for _, space := range m.Session.Spaces {
+		if space != active {
+			others = append(others, space)
+		}
+	}
+	return others
+}
+
+// spaceByID returns the open space with the given ID, or nil.
+func (m *AppModel) spaceByID(id string) *core.DirectorySpace {
+	for _, space := range m.Session.Spaces {
+		if space.ID == id {
+			return space
+		}
+	}
+	return nil
+}
+
+// compareRows lists the selection differences between the active space and
+// the comparison target, additions first.
+func (m *AppModel) compareRows() []string {
+	active := m.Session.GetActiveSpace()
+	target := m.spaceByID(m.CompareTargetID)
+	if active == nil || target == nil {
+		return nil
+	}
+	diff := core.DiffSelections(active, target)
+	var rows []string
+	for _, rel := range diff.Added {
+		rows = append(rows, "+ "+rel)
+	}
+	for _, rel := range diff.Removed {
+		rows = append(rows, "- "+rel)
+	}
+	return rows
+}
+
+// exportDiffRows lists the files an export would change, with line counts.
+func (m *AppModel) exportDiffRows() []string {
+	marks := map[string]string{"added": "+", "removed": "-", "changed": "~"}
+	var rows []string
+	for _, f := range m.ExportDiff.Files {
+		rows = append(rows, fmt.Sprintf("%s %-50s +%d -%d", marks[f.Status], f.Path, f.Added, f.Removed))
+	}
+	return rows
+}
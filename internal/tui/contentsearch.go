@@ -0,0 +1,69 @@
+// Package tui implements the content mode of the global search.
+package tui
+
+import (
+	"context"
+	"time"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	// maxContentMatches caps the lines a content search returns.
+	maxContentMatches = 200
+	// contentSearchDelay is how long typing must pause before searching.
+	contentSearchDelay = 250 * time.Millisecond
+)
+
+// contentSearchDueMsg fires once typing has paused; it is dropped if the
+// query changed again since.
+type contentSearchDueMsg struct {
+	Seq int
+}
+
+// ContentSearchMsg carries the lines matching a content search.
+type ContentSearchMsg struct {
+	Seq     int
+	Matches []core.ContentMatch
+	Err     error
+}
+
+func contentSearchDueCmd(seq int) tea.Cmd {
+	return tea.Tick(contentSearchDelay, func(time.Time) tea.Msg {
+		return contentSearchDueMsg{Seq: seq}
+	})
+}
+
+func contentSearchCmd(seq int, root string, cfg core.ExtractionConfig, query string) tea.Cmd {
+	return func() tea.Msg {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		matches, err := core.SearchContent(ctx, root, cfg, query, maxContentMatches)
+		return ContentSearchMsg{Seq: seq, Matches: matches, Err: err}
+	}
+}
+
+// queueContentSearch schedules a search for the current query, superseding
+// any search still waiting.
+func (m *AppModel) queueContentSearch() tea.Cmd {
+	m.ContentSearchSeq++
+	if m.GlobalSearchInput.Value() == "" {
+		m.ContentMatches = nil
+		m.GlobalSearchFiles = nil
+		return nil
+	}
+	return contentSearchDueCmd(m.ContentSearchSeq)
+}
+
+// setContentMatches shows matches as the results, one row per line. Rows
+// point at their file, so marking works as it does for file results.
+func (m *AppModel) setContentMatches(matches []core.ContentMatch) {
+	m.ContentMatches = matches
+	m.GlobalSearchFiles = make([]string, len(matches))
+	for i, match := range matches {
+		m.GlobalSearchFiles[i] = match.Path
+	}
+	m.GlobalSearchSelect = 0
+}
@@ -0,0 +1,208 @@
+// Package tui implements the control socket of a running TUI.
+package tui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// controlTimeout bounds how long a connection waits for the TUI to answer.
+const controlTimeout = 5 * time.Second
+
+// ControlSocketPath is where the TUI listens for commands:
+// $XDG_RUNTIME_DIR/pandabrew.sock, or without it a folder of the current
+// user in the temp dir, never the shared temp dir itself.
+func ControlSocketPath() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "pandabrew.sock")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("pandabrew-%d", os.Getuid()), "pandabrew.sock")
+}
+
+// privateDir creates dir for the current user alone, or checks that an
+// existing one is a real folder of the current user that no one else can
+// enter, so the socket is never reachable by other users, even before it
+// is listening.
+func privateDir(dir string) error {
+	if err := os.Mkdir(dir, 0o700); err != nil && !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+	info, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() || info.Mode().Perm()&0o077 != 0 || !ownedByUser(info) {
+		return fmt.Errorf("control socket folder %s is not private to this user", dir)
+	}
+	return nil
+}
+
+// ControlMsg is a command read from the control socket. The reply is a
+// single line: "ok", optionally followed by details, or "error: ...".
+type ControlMsg struct {
+	Command string
+	Args    []string
+
+	reply chan string
+}
+
+// ControlListener forwards control socket commands to a running program.
+type ControlListener struct {
+	ln   net.Listener
+	path string
+}
+
+// ListenControl starts accepting commands on the unix socket at path, in a
+// folder private to the user. It fails if another PandaBrew already listens
+// there; a stale socket left by a crash is replaced.
+func ListenControl(p *tea.Program, path string) (*ControlListener, error) {
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("control socket %s is in use by another instance", path)
+	}
+	if err := privateDir(filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+	_ = os.Remove(path)
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return // Closed
+			}
+			go serveControl(p, conn)
+		}
+	}()
+	return &ControlListener{ln: ln, path: path}, nil
+}
+
+// Close stops listening and removes the socket file.
+func (c *ControlListener) Close() error {
+	err := c.ln.Close()
+	_ = os.Remove(c.path)
+	return err
+}
+
+// serveControl answers one command per line until the client hangs up.
+func serveControl(p *tea.Program, conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		msg := ControlMsg{Command: fields[0], Args: fields[1:], reply: make(chan string, 1)}
+		p.Send(msg)
+
+		var reply string
+		select {
+		case reply = <-msg.reply:
+		case <-time.After(controlTimeout):
+			reply = "error: no answer from the TUI"
+		}
+		if _, err := fmt.Fprintln(conn, reply); err != nil {
+			return
+		}
+	}
+}
+
+// SendControl sends one command to the socket at path and returns the reply.
+func SendControl(path string, args []string) (string, error) {
+	conn, err := net.DialTimeout("unix", path, time.Second)
+	if err != nil {
+		return "", fmt.Errorf("no running PandaBrew TUI at %s: %w", path, err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(controlTimeout + time.Second))
+
+	if _, err := fmt.Fprintln(conn, strings.Join(args, " ")); err != nil {
+		return "", err
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	reply = strings.TrimSpace(reply)
+	if msg, ok := strings.CutPrefix(reply, "error: "); ok {
+		return "", errors.New(msg)
+	}
+	return reply, nil
+}
+
+// handleControl runs a control command against the active tab.
+func (m *AppModel) handleControl(msg ControlMsg) (string, tea.Cmd) {
+	space := m.Session.GetActiveSpace()
+	var state *TabState
+	if space != nil {
+		state = m.TabStates[space.ID]
+	}
+
+	switch msg.Command {
+	case "select", "deselect":
+		if space == nil {
+			return "error: no open tab", nil
+		}
+		if len(msg.Args) == 0 {
+			return fmt.Sprintf("error: usage: %s <path>...", msg.Command), nil
+		}
+		paths, err := core.ExpandPaths(space.RootPath, msg.Args)
+		if err != nil {
+			return "error: " + err.Error(), nil
+		}
+		for _, path := range paths {
+			if msg.Command == "select" {
+				core.SelectPath(space, path)
+			} else {
+				core.DeselectPath(space, path)
+			}
+		}
+		_ = m.Sessions.Save(m.Session)
+		return fmt.Sprintf("ok %d selected", len(space.Config.ManualSelections)), nil
+
+	case "export":
+		if space == nil {
+			return "error: no open tab", nil
+		}
+		if m.Loading {
+			return "error: busy", nil
+		}
+		if err := checkOutputPaths(space, m.exportOptions()); err != nil {
+			return "error: " + err.Error(), nil
+		}
+		return "ok exporting to " + space.OutputFilePath, m.startExport(space, state)
+
+	case "switch-tab":
+		visible := m.Session.VisibleSpaces()
+		if len(msg.Args) != 1 {
+			return "error: usage: switch-tab <n>", nil
+		}
+		n, err := strconv.Atoi(msg.Args[0])
+		if err != nil || n < 1 || n > len(visible) {
+			return fmt.Sprintf("error: no tab %s (1-%d)", msg.Args[0], len(visible)), nil
+		}
+		m.syncStateToSession()
+		m.Session.ActiveSpaceID = visible[n-1].ID
+		_ = m.Sessions.Save(m.Session)
+		return "ok " + visible[n-1].RootPath, m.loadActiveTreeCmd()
+	}
+	return fmt.Sprintf("error: unknown command %q (want select, deselect, export or switch-tab)", msg.Command), nil
+}
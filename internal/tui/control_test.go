@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestHandleControl(t *testing.T) {
+	dir := t.TempDir()
+	sm := core.NewSessionManager(filepath.Join(dir, "session.json"))
+	session := &core.Session{}
+	var roots []string
+	for _, name := range []string{"one", "two"} {
+		root := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Join(root, "src"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := sm.AddSpaceFromPath(session, root); err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+	}
+	m := InitialModel(session, sm)
+
+	if reply, _ := m.handleControl(ControlMsg{Command: "switch-tab", Args: []string{"1"}}); reply != "ok "+roots[0] {
+		t.Errorf("switch-tab reply = %q", reply)
+	}
+	if reply, _ := m.handleControl(ControlMsg{Command: "select", Args: []string{"src"}}); reply != "ok 1 selected" {
+		t.Errorf("select reply = %q", reply)
+	}
+	if got := session.Spaces[0].Config.ManualSelections; !slices.Equal(got, []string{filepath.Join(roots[0], "src")}) {
+		t.Errorf("selections = %v", got)
+	}
+	for _, args := range [][]string{{"switch-tab", "3"}, {"frobnicate"}} {
+		if reply, _ := m.handleControl(ControlMsg{Command: args[0], Args: args[1:]}); !strings.HasPrefix(reply, "error: ") {
+			t.Errorf("%v reply = %q, want an error", args, reply)
+		}
+	}
+}
+
+func TestControlSocket(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "proj")
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	sm := core.NewSessionManager(filepath.Join(dir, "session.json"))
+	session := &core.Session{}
+	if _, err := sm.AddSpaceFromPath(session, root); err != nil {
+		t.Fatal(err)
+	}
+
+	p := tea.NewProgram(InitialModel(session, sm), tea.WithInput(nil), tea.WithOutput(io.Discard))
+	go p.Run()
+	defer p.Kill()
+
+	sock := filepath.Join(dir, "private", "ctl.sock")
+	ctl, err := ListenControl(p, sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ctl.Close()
+	if _, err := ListenControl(p, sock); err == nil {
+		t.Error("a second listener took over a live socket")
+	}
+	shared := filepath.Join(dir, "shared")
+	if err := os.Mkdir(shared, 0o777); err != nil || os.Chmod(shared, 0o777) != nil {
+		t.Fatal(err)
+	}
+	if _, err := ListenControl(p, filepath.Join(shared, "ctl.sock")); err == nil {
+		t.Error("listened in a folder open to other users")
+	}
+	// A private folder of someone else's is refused too; only root can
+	// hand one over to test it
+	foreign := filepath.Join(dir, "foreign")
+	if err := os.Mkdir(foreign, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if os.Chown(foreign, os.Getuid()+1, -1) == nil {
+		if _, err := ListenControl(p, filepath.Join(foreign, "ctl.sock")); err == nil {
+			t.Error("listened in a folder of another user")
+		}
+	}
+	if info, err := os.Stat(filepath.Dir(sock)); err != nil || info.Mode().Perm() != 0o700 {
+		t.Errorf("socket folder: %v, %v", info, err)
+	}
+
+	reply, err := SendControl(sock, []string{"select", "src"})
+	if err != nil || reply != "ok 1 selected" {
+		t.Errorf("select = %q, %v", reply, err)
+	}
+	if _, err := SendControl(sock, []string{"switch-tab", "5"}); err == nil {
+		t.Error("switch-tab to a missing tab succeeded")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build unix
+
+// Package tui implements checking who owns the control socket folder.
+package tui
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+)
+
+// ownedByUser reports whether info is of a file the current user owns.
+func ownedByUser(info fs.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && int(st.Uid) == os.Getuid()
+}
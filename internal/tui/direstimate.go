@@ -0,0 +1,136 @@
+// Package tui implements estimating the size of the folder under the cursor
+// before it is selected.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// dirEstimateDelay is how long the cursor rests on a folder before it is
+// walked, so scrolling past folders starts no walks.
+const dirEstimateDelay = 300 * time.Millisecond
+
+// dirEstimate is the total of a folder, for the exclude and junk patterns
+// it was computed with.
+type dirEstimate struct {
+	patterns string
+	entry    core.IndexEntry
+	done     bool
+}
+
+// DirEstimateDueMsg fires once the cursor rested on Path.
+type DirEstimateDueMsg struct {
+	Path string
+}
+
+// DirEstimateMsg carries the total of the folder at Path.
+type DirEstimateMsg struct {
+	Path     string
+	Patterns string
+	Entry    core.IndexEntry
+	Err      error
+}
+
+// estimatePatterns identifies the patterns an estimate depends on.
+func estimatePatterns(cfg core.ExtractionConfig) string {
+	return fmt.Sprint(cfg.SkipJunk, cfg.ExcludePatterns)
+}
+
+// collapsedDirAtCursor returns the node under the cursor if it is a
+// collapsed folder below the root.
+func collapsedDirAtCursor(space *core.DirectorySpace, state *TabState) *TreeNode {
+	if space == nil || state == nil || state.CursorIndex >= len(state.VisibleNodes) {
+		return nil
+	}
+	node := state.VisibleNodes[state.CursorIndex]
+	if !node.IsDir || node.Expanded || node.FullPath == space.RootPath {
+		return nil
+	}
+	return node
+}
+
+// cursorDir returns the collapsed folder under the cursor whose total is
+// unknown: the index has none and no estimate matches the patterns.
+func (m AppModel) cursorDir(space *core.DirectorySpace, state *TabState) string {
+	node := collapsedDirAtCursor(space, state)
+	if node == nil {
+		return ""
+	}
+	if _, ok := m.Indexes[space.RootPath].Lookup(node.FullPath); ok {
+		return ""
+	}
+	if est, ok := m.DirEstimates[node.FullPath]; ok && est.patterns == estimatePatterns(space.Config) {
+		return ""
+	}
+	return node.FullPath
+}
+
+// scheduleDirEstimate arms the delay for the folder under the cursor.
+func (m AppModel) scheduleDirEstimate(space *core.DirectorySpace, state *TabState) tea.Cmd {
+	path := m.cursorDir(space, state)
+	if path == "" {
+		return nil
+	}
+	return tea.Tick(dirEstimateDelay, func(time.Time) tea.Msg { return DirEstimateDueMsg{Path: path} })
+}
+
+// startDirEstimate walks the folder at path if the cursor is still on it,
+// cancelling the walk of a folder left earlier.
+func (m *AppModel) startDirEstimate(space *core.DirectorySpace, state *TabState, path string) tea.Cmd {
+	if m.cursorDir(space, state) != path {
+		return nil
+	}
+	if m.EstimateCancel != nil {
+		m.EstimateCancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.EstimateCancel = cancel
+	patterns := estimatePatterns(space.Config)
+	m.DirEstimates[path] = dirEstimate{patterns: patterns}
+	root, cfg := space.RootPath, space.Config.Clone()
+	return func() tea.Msg {
+		entry, err := core.EstimateDir(ctx, root, path, cfg, nil)
+		return DirEstimateMsg{Path: path, Patterns: patterns, Entry: entry, Err: err}
+	}
+}
+
+// recordDirEstimate stores a finished estimate; a cancelled one is dropped
+// so the folder is walked again when revisited.
+func (m *AppModel) recordDirEstimate(msg DirEstimateMsg) {
+	if msg.Err != nil {
+		delete(m.DirEstimates, msg.Path)
+		return
+	}
+	m.DirEstimates[msg.Path] = dirEstimate{patterns: msg.Patterns, entry: msg.Entry, done: true}
+}
+
+// dirEstimateStatus describes the folder under the cursor for the status
+// bar, or is "" when the cursor is not on a collapsed folder.
+func (m AppModel) dirEstimateStatus(space *core.DirectorySpace, state *TabState) string {
+	node := collapsedDirAtCursor(space, state)
+	if node == nil {
+		return ""
+	}
+	entry, ok := m.Indexes[space.RootPath].Lookup(node.FullPath)
+	if !ok {
+		est, known := m.DirEstimates[node.FullPath]
+		switch {
+		case !known || est.patterns != estimatePatterns(space.Config):
+			return ""
+		case !est.done:
+			return node.Name + "/: counting…"
+		}
+		entry = est.entry
+	}
+	noun := "files"
+	if entry.Files == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("%s/: %d %s, ~%s tok", node.Name, entry.Files, noun, core.FormatTokens(entry.Tokens))
+}
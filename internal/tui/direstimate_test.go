@@ -0,0 +1,55 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestDirEstimate(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "vendor", "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.go", "lib/b.go"} {
+		if err := os.WriteFile(filepath.Join(root, "vendor", name), []byte("package x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	space := &core.DirectorySpace{ID: "a", RootPath: root}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	state := m.TabStates["a"]
+	vendor := &TreeNode{Name: "vendor", FullPath: filepath.Join(root, "vendor"), IsDir: true}
+	state.VisibleNodes = []*TreeNode{vendor}
+
+	if m.scheduleDirEstimate(space, state) == nil {
+		t.Fatal("no estimate scheduled for a collapsed folder")
+	}
+	cmd := m.startDirEstimate(space, state, vendor.FullPath)
+	if cmd == nil {
+		t.Fatal("estimate not started")
+	}
+	if status := m.dirEstimateStatus(space, state); !strings.Contains(status, "counting") {
+		t.Errorf("status while counting = %q", status)
+	}
+	if m.scheduleDirEstimate(space, state) != nil {
+		t.Error("estimate scheduled again while counting")
+	}
+	m.recordDirEstimate(cmd().(DirEstimateMsg))
+	if status := m.dirEstimateStatus(space, state); !strings.Contains(status, "2 files") {
+		t.Errorf("status = %q, want 2 files", status)
+	}
+
+	// New patterns make the estimate stale
+	space.Config.ExcludePatterns = []string{"lib"}
+	if m.scheduleDirEstimate(space, state) == nil {
+		t.Error("stale estimate not scheduled again")
+	}
+	vendor.Expanded = true
+	if m.scheduleDirEstimate(space, state) != nil || m.dirEstimateStatus(space, state) != "" {
+		t.Error("expanded folder estimated")
+	}
+}
@@ -0,0 +1,113 @@
+// Package tui implements moving focus through the sidebar like a form:
+// tab and shift+tab step through the text inputs, then the options.
+package tui
+
+import (
+	"pandabrew/internal/core"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// formInputs is the number of text inputs at the top of the sidebar,
+// ActiveInput 1 to 4.
+const formInputs = 4
+
+// sidebarOption is an option of the sidebar. It is changed by its hotkeys,
+// which a focused option also answers to with enter, space and the arrows.
+type sidebarOption struct {
+	name    string
+	check   func(cfg core.ExtractionConfig) bool   // Checkbox state; nil for selectors
+	value   func(cfg core.ExtractionConfig) string // Shown after the name, if set
+	back    key.Binding                            // Steps a selector back; unset for the others
+	forward key.Binding
+}
+
+// sidebarSections lists the options of the Options and Output sections of
+// the sidebar, in focus order.
+func (k keyMap) sidebarSections() [2][]sidebarOption {
+	return [2][]sidebarOption{
+		{
+			{name: "Include Mode", check: func(c core.ExtractionConfig) bool { return c.IncludeMode }, forward: k.ToggleI},
+			{name: "Show Context", check: func(c core.ExtractionConfig) bool { return c.ShowContext }, forward: k.ToggleC},
+			{name: "Show Excluded", check: func(c core.ExtractionConfig) bool { return c.ShowExcluded }, forward: k.ToggleX},
+			{name: "Dim Excluded", check: func(c core.ExtractionConfig) bool { return c.DimExcluded }, forward: k.DimExcluded},
+			{name: "Struct in View", check: func(c core.ExtractionConfig) bool { return c.StructureView }, forward: k.ToggleV},
+			{name: "Full Tree Map", check: func(c core.ExtractionConfig) bool { return c.FullTreeMap }, forward: k.ToggleMap},
+			{name: "Skip Junk", check: func(c core.ExtractionConfig) bool { return c.SkipJunk }, forward: k.ToggleJunk},
+			{name: "Git Info", check: func(c core.ExtractionConfig) bool { return c.GitInfo }, forward: k.ToggleGit},
+			{name: "Auto-select New", check: func(c core.ExtractionConfig) bool { return c.AutoSelectNew }, forward: k.AutoNew},
+			{
+				name:    "Generated",
+				check:   func(c core.ExtractionConfig) bool { return c.GeneratedPolicyFor() != core.NestedRepoFull },
+				value:   func(c core.ExtractionConfig) string { return c.GeneratedPolicyFor() },
+				forward: k.Generated,
+			},
+			{
+				name:    "Tests",
+				check:   func(c core.ExtractionConfig) bool { return c.TestsPolicyFor() != core.NestedRepoFull },
+				value:   func(c core.ExtractionConfig) string { return c.TestsPolicyFor() },
+				forward: k.Tests,
+			},
+		},
+		{
+			{name: "Format", value: func(c core.ExtractionConfig) string { return formatLabel(c.Formats) }, forward: k.Format},
+			{name: "Line Numbers", check: func(c core.ExtractionConfig) bool { return c.LineNumbers }, forward: k.LineNumbers},
+			{name: "Minify", check: func(c core.ExtractionConfig) bool { return c.MinifyContent }, forward: k.Minify},
+			{
+				name:    "Depth",
+				value:   func(c core.ExtractionConfig) string { return depthLabel(c.StructureMaxDepth) },
+				back:    k.DepthDown,
+				forward: k.DepthUp,
+			},
+		},
+	}
+}
+
+// sidebarOptions lists every option of the sidebar in focus order.
+func (k keyMap) sidebarOptions() []sidebarOption {
+	sections := k.sidebarSections()
+	return append(sections[0], sections[1]...)
+}
+
+// hotkey is the label of the keys changing the option.
+func (o sidebarOption) hotkey() string {
+	if len(o.back.Keys()) > 0 {
+		return o.back.Help().Key + " " + o.forward.Help().Key
+	}
+	return o.forward.Help().Key
+}
+
+// moveFormFocus moves focus delta stops through the text inputs and the
+// options, wrapping around at either end.
+func (m AppModel) moveFormFocus(state *TabState, delta int) {
+	stops := formInputs + len(m.keys.sidebarOptions())
+	pos := state.ActiveInput - 1
+	if state.ActiveOption > 0 {
+		pos = formInputs + state.ActiveOption - 1
+	}
+	pos = ((pos+delta)%stops + stops) % stops
+	if pos < formInputs {
+		focusInput(state, pos+1)
+		return
+	}
+	focusInput(state, 0)
+	state.ActiveOption = pos - formInputs + 1
+}
+
+// formStep is the direction tab (1) and shift+tab (-1) move focus in.
+func formStep(k string) int {
+	if k == "shift+tab" {
+		return -1
+	}
+	return 1
+}
+
+// optionKey is the key press that changes opt, backwards or forwards.
+func optionKey(opt sidebarOption, back bool) tea.KeyMsg {
+	b := opt.forward
+	if back && len(opt.back.Keys()) > 0 {
+		b = opt.back
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(b.Keys()[0])}
+}
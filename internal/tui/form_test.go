@@ -0,0 +1,57 @@
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFormNavigation(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r", OutputFilePath: "/out/r.txt"}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	state := m.TabStates["a"]
+	press := func(k tea.KeyMsg) {
+		model, _ := m.Update(k)
+		m = model.(AppModel)
+	}
+	tab, backTab := tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyShiftTab}
+
+	focusInput(state, 3)
+	state.InputInclude.SetValue("*.go")
+	press(tab)
+	if state.ActiveInput != 4 || !slices.Equal(space.Config.IncludePatterns, []string{"*.go"}) {
+		t.Fatalf("tab: input %d, include %v", state.ActiveInput, space.Config.IncludePatterns)
+	}
+	press(tab)
+	if state.ActiveInput != 0 || state.ActiveOption != 1 {
+		t.Fatalf("tab past Exclude: input %d, option %d", state.ActiveInput, state.ActiveOption)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if !space.Config.IncludeMode || state.ActiveOption != 1 {
+		t.Errorf("enter on Include Mode: %v, focus %d", space.Config.IncludeMode, state.ActiveOption)
+	}
+	press(backTab)
+	if state.ActiveInput != 4 || state.ActiveOption != 0 {
+		t.Errorf("shift+tab: input %d, option %d", state.ActiveInput, state.ActiveOption)
+	}
+
+	// The last option is the depth selector; the arrows step it
+	focusInput(state, 1)
+	press(backTab)
+	if state.ActiveOption != len(m.keys.sidebarOptions()) {
+		t.Fatalf("shift+tab from Root focused option %d", state.ActiveOption)
+	}
+	press(tea.KeyMsg{Type: tea.KeyLeft})
+	if space.Config.StructureMaxDepth != maxStructureDepth {
+		t.Errorf("left on Depth: %d", space.Config.StructureMaxDepth)
+	}
+	press(tab)
+	if state.ActiveInput != 1 {
+		t.Errorf("tab from the last option focused input %d", state.ActiveInput)
+	}
+}
@@ -3,6 +3,8 @@ package tui
 import (
 	"reflect"
 	"testing"
+	"unicode"
+	"unicode/utf8"
 
 	"pandabrew/internal/core"
 )
@@ -132,3 +134,48 @@ func sortIntsCopy(s []int) []int {
 	}
 	return c
 }
+
+func FuzzSimpleFuzzyMatch(f *testing.F) {
+	for _, seed := range [][2]string{
+		{"mg", "cmd/main.go"},
+		{"é", "café/README"},
+		{"i", "İstanbul.txt"},
+		{"ß", "STRASSE/straße.md"},
+		{"🐼", "docs/🐼.md"},
+		{"..", "../x/./y"},
+		{"K", "\u212a.go"}, // Kelvin sign
+	} {
+		f.Add(seed[0], seed[1])
+	}
+	f.Fuzz(func(t *testing.T, pattern, str string) {
+		matched, indices := SimpleFuzzyMatch(pattern, str)
+		if pattern == "" {
+			if !matched {
+				t.Fatal("empty pattern did not match")
+			}
+			return
+		}
+		if !matched {
+			return
+		}
+		pRunes := []rune(pattern)
+		if len(indices) != len(pRunes) {
+			t.Fatalf("%d indices for %d pattern runes", len(indices), len(pRunes))
+		}
+		starts := map[int]bool{}
+		for i := range str {
+			starts[i] = true
+		}
+		prev := -1
+		for i, idx := range indices {
+			if idx <= prev || !starts[idx] {
+				t.Fatalf("index %d of %v is not a rune of %q", idx, indices, str)
+			}
+			r, _ := utf8.DecodeRuneInString(str[idx:])
+			if unicode.ToLower(r) != unicode.ToLower(pRunes[i]) {
+				t.Fatalf("%q at %d does not match %q", r, idx, pRunes[i])
+			}
+			prev = idx
+		}
+	})
+}
@@ -0,0 +1,42 @@
+// Package tui implements recalling earlier searches in the search inputs.
+package tui
+
+import (
+	"pandabrew/internal/core"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// browseHistory replaces the contents of input with an older (or newer)
+// search from the history of space. Stepping past the newest entry brings
+// back what was typed before browsing started.
+func (m *AppModel) browseHistory(input *textinput.Model, space *core.DirectorySpace, older bool) {
+	if space == nil {
+		return
+	}
+	pos := m.HistoryPos - 1
+	if older {
+		pos = m.HistoryPos + 1
+	}
+	if pos >= len(space.SearchHistory) || pos < -1 {
+		return
+	}
+	if m.HistoryPos == -1 {
+		m.HistoryDraft = input.Value()
+	}
+	m.HistoryPos = pos
+	if pos == -1 {
+		input.SetValue(m.HistoryDraft)
+	} else {
+		input.SetValue(space.SearchHistory[pos])
+	}
+	input.CursorEnd()
+}
+
+// recordSearch adds query to the history of space and ends browsing.
+func (m *AppModel) recordSearch(space *core.DirectorySpace, query string) {
+	m.HistoryPos = -1
+	if space != nil {
+		core.RecordSearch(space, query)
+	}
+}
@@ -0,0 +1,38 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+func TestBrowseHistory(t *testing.T) {
+	m := InitialModel(&core.Session{}, nil)
+	space := &core.DirectorySpace{}
+	for _, q := range []string{"old", "mid", "old", "new"} {
+		m.recordSearch(space, q)
+	}
+	if want := []string{"new", "old", "mid"}; !slices.Equal(space.SearchHistory, want) {
+		t.Fatalf("history = %v, want %v", space.SearchHistory, want)
+	}
+
+	input := textinput.New()
+	input.SetValue("draft")
+	var seen []string
+	for range 4 {
+		m.browseHistory(&input, space, true)
+		seen = append(seen, input.Value())
+	}
+	if want := []string{"new", "old", "mid", "mid"}; !slices.Equal(seen, want) {
+		t.Errorf("older = %v, want %v", seen, want)
+	}
+	for range 3 {
+		m.browseHistory(&input, space, false)
+	}
+	if input.Value() != "draft" || m.HistoryPos != -1 {
+		t.Errorf("back to %q at %d, want the draft", input.Value(), m.HistoryPos)
+	}
+}
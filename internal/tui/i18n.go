@@ -0,0 +1,94 @@
+// Package tui implements translating the user interface.
+package tui
+
+import (
+	"embed"
+	"encoding/json"
+	"os"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+)
+
+// Translations live in locales/<language>.json, one object mapping each
+// English UI string to its translation. Strings missing from a catalog stay
+// in English, so a catalog can start small.
+//
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// catalog holds the translations of the active locale; nil means English.
+var catalog map[string]string
+
+// tr translates the English UI string msg into the active locale. Format
+// strings are translated before formatting, so their verbs must be kept.
+func tr(msg string) string {
+	if t, ok := catalog[msg]; ok && t != "" {
+		return t
+	}
+	return msg
+}
+
+// Locales lists the languages with a catalog, English included.
+func Locales() []string {
+	langs := []string{"en"}
+	entries, _ := localeFiles.ReadDir("locales")
+	for _, e := range entries {
+		langs = append(langs, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(langs[1:])
+	return langs
+}
+
+// DetectLocale returns the locale the environment asks for, from
+// PANDABREW_LANG, LC_ALL, LC_MESSAGES or LANG in that order, or "".
+func DetectLocale() string {
+	for _, name := range []string{"PANDABREW_LANG", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// SetLocale switches the UI to locale, such as "de", "pt_BR" or
+// "es_ES.UTF-8", and returns the language used: the locale's language when
+// it has a catalog, otherwise "en".
+func SetLocale(locale string) string {
+	catalog = nil
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, ".@"); i >= 0 {
+		lang = lang[:i]
+	}
+	lang = strings.ReplaceAll(lang, "-", "_")
+	// Prefer the regional catalog, then the plain language
+	for _, name := range []string{lang, strings.SplitN(lang, "_", 2)[0]} {
+		if name == "" || name == "en" || name == "c" || name == "posix" {
+			break
+		}
+		data, err := localeFiles.ReadFile(path.Join("locales", name+".json"))
+		if err != nil {
+			continue
+		}
+		var c map[string]string
+		if json.Unmarshal(data, &c) == nil {
+			catalog = c
+			return name
+		}
+	}
+	return "en"
+}
+
+// localizeKeys returns k with the help text of every binding translated.
+func localizeKeys(k keyMap) keyMap {
+	v := reflect.ValueOf(&k).Elem()
+	for i := range v.NumField() {
+		if b, ok := v.Field(i).Addr().Interface().(*key.Binding); ok {
+			b.SetHelp(b.Help().Key, tr(b.Help().Desc))
+		}
+	}
+	return k
+}
@@ -0,0 +1,67 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestLocales(t *testing.T) {
+	defer SetLocale("en")
+	verbs := regexp.MustCompile(`%[a-z]`)
+	trCalls := regexp.MustCompile(`\btr\(("(?:[^"\\]|\\.)*")\)`)
+	var used []string
+	sources, _ := filepath.Glob("*.go")
+	for _, name := range sources {
+		if strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, m := range trCalls.FindAllSubmatch(src, -1) {
+			if msg, err := strconv.Unquote(string(m[1])); err == nil {
+				used = append(used, msg)
+			}
+		}
+	}
+	for _, lang := range Locales()[1:] {
+		if SetLocale(lang) != lang {
+			t.Fatalf("catalog %s did not load", lang)
+		}
+		// Every text the code translates is in the catalog
+		for _, msg := range used {
+			if _, ok := catalog[msg]; !ok {
+				t.Errorf("%s: no translation of %q", lang, msg)
+			}
+		}
+		// Translations are format strings too; their verbs must line up
+		for msg, translated := range catalog {
+			if !slices.Equal(verbs.FindAllString(msg, -1), verbs.FindAllString(translated, -1)) {
+				t.Errorf("%s: %q changes the verbs of %q", lang, translated, msg)
+			}
+		}
+	}
+
+	if got := SetLocale("de_DE.UTF-8"); got != "de" || tr("Ready") != "Bereit" || tr("not in any catalog") != "not in any catalog" {
+		t.Errorf("de_DE.UTF-8 gave %s, Ready = %q", got, tr("Ready"))
+	}
+	m := InitialModel(&core.Session{}, nil)
+	if desc := m.keys.Quit.Help().Desc; desc != "beenden" {
+		t.Errorf("quit help = %q, want it translated", desc)
+	}
+	if keys.Quit.Help().Desc != "quit" {
+		t.Error("translating the key map changed the English one")
+	}
+
+	if got := SetLocale("C.UTF-8"); got != "en" || tr("Ready") != "Ready" {
+		t.Errorf("C.UTF-8 gave %s", got)
+	}
+}
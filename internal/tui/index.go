@@ -0,0 +1,72 @@
+// Package tui implements the terminal user interface logic.
+package tui
+
+import (
+	"context"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// --- Index Messages ---
+
+// IndexLoadedMsg carries a cached index read at startup.
+type IndexLoadedMsg struct {
+	Index *core.Index
+}
+
+// IndexProgressMsg reports how many files the background indexer has seen.
+type IndexProgressMsg struct {
+	Root  string
+	Files int
+	ch    <-chan tea.Msg
+}
+
+// IndexDoneMsg carries the finished (or cancelled) index build.
+type IndexDoneMsg struct {
+	Root  string
+	Index *core.Index
+	Err   error
+}
+
+func loadIndexCmd(root string) tea.Cmd {
+	return func() tea.Msg {
+		ix, err := core.LoadIndex(root)
+		if err != nil || ix == nil {
+			return nil // No usable cache; annotations appear after ctrl+b
+		}
+		return IndexLoadedMsg{Index: ix}
+	}
+}
+
+// buildIndexCmd starts the indexer in the background, caching the index on
+// disk when save is set. Progress is relayed through a channel; each
+// IndexProgressMsg re-arms waitForIndex.
+func buildIndexCmd(ctx context.Context, root string, cfg core.ExtractionConfig, save bool) tea.Cmd {
+	ch := make(chan tea.Msg, 1)
+	go func() {
+		defer close(ch)
+		ix, err := core.BuildIndex(ctx, root, cfg, func(files int) {
+			select {
+			case ch <- IndexProgressMsg{Root: root, Files: files, ch: ch}:
+			default: // UI is behind; drop this update
+			}
+		})
+		if err == nil && save {
+			err = core.SaveIndex(ix)
+		}
+		ch <- IndexDoneMsg{Root: root, Index: ix, Err: err}
+	}()
+	return waitForIndex(ch)
+}
+
+func waitForIndex(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		msg, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return msg
+	}
+}
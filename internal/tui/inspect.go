@@ -0,0 +1,40 @@
+// Package tui implements the inspector explaining why a node is or isn't
+// exported.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// InspectMsg carries the explanation of a node.
+type InspectMsg struct {
+	Explanation core.Explanation
+}
+
+func inspectCmd(space *core.DirectorySpace, path string) tea.Cmd {
+	snapshot := *space
+	snapshot.Config = space.Config.Clone()
+	return func() tea.Msg {
+		return InspectMsg{Explanation: core.ExplainPath(&snapshot, path)}
+	}
+}
+
+// renderInspectView lists the reasons behind the verdict on the inspected node.
+func (m AppModel) renderInspectView() string {
+	e := m.Inspection
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s is %s.\n", e.Path, e.Verdict)
+	for _, reason := range e.Reasons {
+		fmt.Fprintf(&body, "\n• %s", reason)
+	}
+	icon := iconInfo
+	if e.Verdict == core.VerdictLeftOut {
+		icon = iconWarn
+	}
+	return m.renderDialog(icon+" "+tr("Why?"), body.String(), tr("any key to close"))
+}
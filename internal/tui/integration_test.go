@@ -0,0 +1,128 @@
+package tui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/exp/teatest"
+)
+
+// fakeClock is a clock the test moves by hand; the program reads it from
+// its own goroutine.
+type fakeClock struct{ offset atomic.Int64 }
+
+var clockStart = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func (c *fakeClock) now() time.Time          { return clockStart.Add(time.Duration(c.offset.Load())) }
+func (c *fakeClock) advance(d time.Duration) { c.offset.Add(int64(d)) }
+
+// startApp runs a one-tab model on root in a test program and waits for
+// the tree to be drawn, which shows waitFor.
+func startApp(t *testing.T, root string, setup func(*AppModel), waitFor string) (*teatest.TestModel, *core.DirectorySpace) {
+	t.Helper()
+	space := &core.DirectorySpace{
+		ID:             "a",
+		RootPath:       root,
+		OutputFilePath: filepath.Join(t.TempDir(), "out.txt"),
+		Config:         core.DefaultExtractionConfig(),
+	}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	if setup != nil {
+		setup(&m)
+	}
+	tm := teatest.NewTestModel(t, m, teatest.WithInitialTermSize(120, 60))
+	waitForOutput(t, tm, waitFor)
+	return tm, space
+}
+
+func waitForOutput(t *testing.T, tm *teatest.TestModel, text string) {
+	t.Helper()
+	teatest.WaitFor(t, tm.Output(), func(out []byte) bool {
+		return bytes.Contains(out, []byte(text))
+	}, teatest.WithDuration(5*time.Second), teatest.WithCheckInterval(10*time.Millisecond))
+}
+
+func finalModel(t *testing.T, tm *teatest.TestModel) AppModel {
+	t.Helper()
+	if err := tm.Quit(); err != nil {
+		t.Fatal(err)
+	}
+	return tm.FinalModel(t, teatest.WithFinalTimeout(5*time.Second)).(AppModel)
+}
+
+func TestIntegrationSearchAndSelect(t *testing.T) {
+	root := "/proj"
+	fsys := fstest.MapFS{
+		"README.md":                   {Data: []byte("# proj\n")},
+		"cmd/main.go":                 {Data: []byte("package main\n")},
+		"internal/core/engine.go":     {Data: []byte("package core\n")},
+		"internal/core/engine_lib.go": {Data: []byte("package core\n")},
+	}
+	tm, space := startApp(t, root, func(m *AppModel) {
+		m.UseFileSystem(core.FileSystemAt(root, fsys))
+	}, "README.md")
+
+	// The match sits in a folder never opened: once the files are indexed,
+	// the next match expands the path to it
+	tm.Type("/")
+	tm.Type("engine_lib")
+	tm.Send(tea.KeyMsg{Type: tea.KeyEnter})
+	waitForOutput(t, tm, "in collapsed folders")
+	tm.Type("n")
+	waitForOutput(t, tm, "engine_lib.go")
+	tm.Send(tea.KeyMsg{Type: tea.KeySpace})
+	waitForOutput(t, tm, "1 selected")
+
+	m := finalModel(t, tm)
+	if want := []string{filepath.Join(root, "internal", "core", "engine_lib.go")}; !slices.Equal(space.Config.ManualSelections, want) {
+		t.Errorf("selections = %v, want %v", space.Config.ManualSelections, want)
+	}
+	if state := m.TabStates["a"]; state.SearchQuery != "engine_lib" {
+		t.Errorf("search query = %q", state.SearchQuery)
+	}
+}
+
+func TestIntegrationExport(t *testing.T) {
+	root := t.TempDir()
+	for name, data := range map[string]string{"main.go": "package main\n", "notes.txt": "notes\n"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	clock := &fakeClock{}
+	tm, space := startApp(t, root, func(m *AppModel) { m.Now = clock.now }, "notes.txt")
+
+	tm.Send(tea.KeyMsg{Type: tea.KeyDown})
+	tm.Send(tea.KeyMsg{Type: tea.KeySpace})
+	tm.Send(tea.KeyMsg{Type: tea.KeyCtrlE})
+	waitForOutput(t, tm, "Exported 1 files")
+
+	report, err := os.ReadFile(space.OutputFilePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(report, []byte("--- file: main.go ---")) || bytes.Contains(report, []byte("notes.txt ---")) {
+		t.Errorf("report:\n%s", report)
+	}
+
+	// Toasts expire by the injected clock, not the wall clock
+	clock.advance(time.Minute)
+	tm.Send(toastTickMsg(clock.now()))
+	m := finalModel(t, tm)
+	if len(m.Toasts) != 0 || m.LastExport == nil {
+		t.Errorf("toasts = %v, last export = %v", m.Toasts, m.LastExport)
+	}
+	if len(m.MessageLog) == 0 || !m.MessageLog[len(m.MessageLog)-1].Time.Equal(clockStart) {
+		t.Errorf("message log not stamped by the injected clock: %v", m.MessageLog)
+	}
+}
@@ -5,30 +5,66 @@ import "github.com/charmbracelet/bubbles/key"
 
 // --- Key Bindings ---
 type keyMap struct {
-	Up          key.Binding
-	Down        key.Binding
-	Left        key.Binding
-	Right       key.Binding
-	Select      key.Binding
-	Quit        key.Binding
-	Save        key.Binding
-	Export      key.Binding
-	Help        key.Binding
-	Tab         key.Binding
-	NewTab      key.Binding
-	CloseTab    key.Binding
-	Root        key.Binding
-	Output      key.Binding
-	Include     key.Binding
-	Exclude     key.Binding
-	ToggleI     key.Binding
-	ToggleC     key.Binding
-	ToggleX     key.Binding
-	ToggleV     key.Binding
-	Refresh     key.Binding
-	SelectAll   key.Binding
-	DeselectAll key.Binding
-	ToggleTheme key.Binding
+	Up           key.Binding
+	Down         key.Binding
+	Left         key.Binding
+	Right        key.Binding
+	Select       key.Binding
+	Quit         key.Binding
+	Save         key.Binding
+	Export       key.Binding
+	ExportDiff   key.Binding
+	QuickExport  key.Binding
+	Help         key.Binding
+	Tab          key.Binding
+	NewTab       key.Binding
+	CloseTab     key.Binding
+	DuplicateTab key.Binding
+	SwitchGroup  key.Binding
+	AssignGroup  key.Binding
+	Sessions     key.Binding
+	Root         key.Binding
+	Output       key.Binding
+	Include      key.Binding
+	Exclude      key.Binding
+	FormNext     key.Binding
+	ToggleI      key.Binding
+	ToggleC      key.Binding
+	ToggleX      key.Binding
+	DimExcluded  key.Binding
+	Unexclude    key.Binding
+	ExcludeThis  key.Binding
+	ToggleV      key.Binding
+	ToggleJunk   key.Binding
+	ToggleGit    key.Binding
+	AutoNew      key.Binding
+	Generated    key.Binding
+	Tests        key.Binding
+	Format       key.Binding
+	LineNumbers  key.Binding
+	Minify       key.Binding
+	DepthUp      key.Binding
+	DepthDown    key.Binding
+	NestedRepos  key.Binding
+	Stale        key.Binding
+	Preview      key.Binding
+	Inspect      key.Binding
+	ToPatterns   key.Binding
+	ToggleMap    key.Binding
+	Refresh      key.Binding
+	SelectAll    key.Binding
+	SelectFiles  key.Binding
+	SmartSelect  key.Binding
+	DeselectAll  key.Binding
+	UndoSelect   key.Binding
+	ToggleTheme  key.Binding
+	PickTheme    key.Binding
+	PickTask     key.Binding
+	Sidebar      key.Binding
+	MessageLog   key.Binding
+	BuildIndex   key.Binding
+	Offenders    key.Binding
+	CompareTabs  key.Binding
 	// Search Bindings
 	Search      key.Binding
 	NextMatch   key.Binding
@@ -47,13 +83,15 @@ func (k keyMap) ShortHelp() []key.Binding {
 func (k keyMap) FullHelp() [][]key.Binding {
 	return [][]key.Binding{
 		{k.Up, k.Down, k.Left, k.Right},
-		{k.Select, k.Tab, k.NewTab, k.CloseTab},
+		{k.Select, k.Preview, k.Inspect, k.Tab, k.NewTab, k.CloseTab, k.DuplicateTab},
+		{k.SwitchGroup, k.AssignGroup, k.Sessions},
 		{k.Search, k.NextMatch, k.PrevMatch, k.ClearSearch},
-		{k.GlobalSearch, k.GlobalSelect, k.Save, k.Export},
-		{k.Root, k.Output, k.Include, k.Exclude},
-		{k.ToggleI, k.ToggleC, k.ToggleX, k.ToggleV},
-		{k.Refresh, k.SelectAll, k.DeselectAll},
-		{k.ToggleTheme, k.Help, k.Quit},
+		{k.GlobalSearch, k.GlobalSelect, k.Save, k.Export, k.ExportDiff, k.QuickExport},
+		{k.Root, k.Output, k.Include, k.Exclude, k.FormNext, k.ToPatterns},
+		{k.ToggleI, k.ToggleC, k.ToggleX, k.DimExcluded, k.ExcludeThis, k.Unexclude, k.ToggleV, k.ToggleMap, k.ToggleJunk, k.ToggleGit, k.AutoNew, k.Generated, k.Tests},
+		{k.Format, k.LineNumbers, k.Minify, k.DepthDown, k.DepthUp, k.PickTask},
+		{k.Refresh, k.SelectAll, k.SelectFiles, k.SmartSelect, k.DeselectAll, k.UndoSelect, k.BuildIndex, k.Offenders, k.CompareTabs, k.NestedRepos, k.Stale},
+		{k.ToggleTheme, k.PickTheme, k.Sidebar, k.MessageLog, k.Help, k.Quit},
 	}
 }
 
@@ -90,6 +128,14 @@ var keys = keyMap{
 		key.WithKeys("ctrl+e"),
 		key.WithHelp("ctrl+e", "export"),
 	),
+	ExportDiff: key.NewBinding(
+		key.WithKeys("E"),
+		key.WithHelp("E", "preview export changes"),
+	),
+	QuickExport: key.NewBinding(
+		key.WithKeys("R"),
+		key.WithHelp("R", "re-export and copy"),
+	),
 	Help: key.NewBinding(
 		key.WithKeys("?"),
 		key.WithHelp("?", "toggle help"),
@@ -106,6 +152,22 @@ var keys = keyMap{
 		key.WithKeys("ctrl+w"),
 		key.WithHelp("ctrl+w", "close tab"),
 	),
+	DuplicateTab: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "duplicate tab"),
+	),
+	SwitchGroup: key.NewBinding(
+		key.WithKeys("G"),
+		key.WithHelp("G", "switch tab group"),
+	),
+	AssignGroup: key.NewBinding(
+		key.WithKeys("ctrl+g"),
+		key.WithHelp("ctrl+g", "move tab to group"),
+	),
+	Sessions: key.NewBinding(
+		key.WithKeys("ctrl+o"),
+		key.WithHelp("ctrl+o", "switch session"),
+	),
 	Refresh: key.NewBinding(
 		key.WithKeys("ctrl+r"),
 		key.WithHelp("ctrl+r", "refresh dir"),
@@ -126,6 +188,15 @@ var keys = keyMap{
 		key.WithKeys("g"),
 		key.WithHelp("g", "excl pattern"),
 	),
+	// FormNext is handled by the sidebar fields; in the tree tab switches tabs
+	FormNext: key.NewBinding(
+		key.WithKeys("tab", "shift+tab"),
+		key.WithHelp("tab/shift+tab", "next/prev sidebar field"),
+	),
+	ToPatterns: key.NewBinding(
+		key.WithKeys("P"),
+		key.WithHelp("P", "selection to patterns"),
+	),
 	ToggleI: key.NewBinding(
 		key.WithKeys("i"),
 		key.WithHelp("i", "toggle include mode"),
@@ -138,13 +209,97 @@ var keys = keyMap{
 		key.WithKeys("x"),
 		key.WithHelp("x", "toggle excluded"),
 	),
+	DimExcluded: key.NewBinding(
+		key.WithKeys("X"),
+		key.WithHelp("X", "dim excluded files in tree"),
+	),
+	ExcludeThis: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "exclude this path"),
+	),
+	Unexclude: key.NewBinding(
+		key.WithKeys("u"),
+		key.WithHelp("u", "drop pattern excluding file"),
+	),
 	ToggleV: key.NewBinding(
 		key.WithKeys("v"),
 		key.WithHelp("v", "toggle view structure"),
 	),
+	ToggleMap: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "toggle full tree map"),
+	),
+	ToggleJunk: key.NewBinding(
+		key.WithKeys("z"),
+		key.WithHelp("z", "toggle skip junk"),
+	),
+	ToggleGit: key.NewBinding(
+		key.WithKeys("B"),
+		key.WithHelp("B", "toggle git info"),
+	),
+	AutoNew: key.NewBinding(
+		key.WithKeys("A"),
+		key.WithHelp("A", "toggle auto-select new"),
+	),
+	Generated: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "cycle generated/license file policy"),
+	),
+	Tests: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "cycle test file policy"),
+	),
+	Format: key.NewBinding(
+		key.WithKeys("F"),
+		key.WithHelp("F", "cycle output format"),
+	),
+	LineNumbers: key.NewBinding(
+		key.WithKeys("#"),
+		key.WithHelp("#", "toggle line numbers"),
+	),
+	Minify: key.NewBinding(
+		key.WithKeys("M"),
+		key.WithHelp("M", "toggle minify"),
+	),
+	DepthDown: key.NewBinding(
+		key.WithKeys("["),
+		key.WithHelp("[", "shallower structure"),
+	),
+	DepthUp: key.NewBinding(
+		key.WithKeys("]"),
+		key.WithHelp("]", "deeper structure"),
+	),
+	Preview: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "preview file"),
+	),
+	Inspect: key.NewBinding(
+		key.WithKeys("I"),
+		key.WithHelp("I", "why is this (not) exported"),
+	),
+	Stale: key.NewBinding(
+		key.WithKeys("S"),
+		key.WithHelp("S", "review missing selections"),
+	),
+	NestedRepos: key.NewBinding(
+		key.WithKeys("U"),
+		key.WithHelp("U", "nested repos"),
+	),
 	SelectAll: key.NewBinding(
 		key.WithKeys("ctrl+a"),
-		key.WithHelp("ctrl+a", "select all"),
+		key.WithHelp("ctrl+a", "select root (new files too)"),
+	),
+	SelectFiles: key.NewBinding(
+		key.WithKeys("+"),
+		key.WithHelp("+", "select every listed file"),
+	),
+	SmartSelect: key.NewBinding(
+		key.WithKeys("*"),
+		key.WithHelp("*", "smart select"),
+	),
+	UndoSelect: key.NewBinding(
+		key.WithKeys("ctrl+z"),
+		key.WithHelp("ctrl+z", "undo bulk selection"),
 	),
 	DeselectAll: key.NewBinding(
 		key.WithKeys("ctrl+d"),
@@ -154,6 +309,34 @@ var keys = keyMap{
 		key.WithKeys("ctrl+t"),
 		key.WithHelp("ctrl+t", "switch theme"),
 	),
+	PickTheme: key.NewBinding(
+		key.WithKeys("alt+t"),
+		key.WithHelp("alt+t", "pick theme"),
+	),
+	PickTask: key.NewBinding(
+		key.WithKeys("K"),
+		key.WithHelp("K", "pick task prompt"),
+	),
+	Sidebar: key.NewBinding(
+		key.WithKeys("|"),
+		key.WithHelp("|", "show/hide sidebar"),
+	),
+	MessageLog: key.NewBinding(
+		key.WithKeys("ctrl+l"),
+		key.WithHelp("ctrl+l", "message log"),
+	),
+	BuildIndex: key.NewBinding(
+		key.WithKeys("ctrl+b"),
+		key.WithHelp("ctrl+b", "index sizes / cancel"),
+	),
+	Offenders: key.NewBinding(
+		key.WithKeys("O"),
+		key.WithHelp("O", "largest selected files"),
+	),
+	CompareTabs: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "compare tab selections"),
+	),
 	Search: key.NewBinding(
 		key.WithKeys("/"),
 		key.WithHelp("/", "search view"),
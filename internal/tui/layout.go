@@ -0,0 +1,123 @@
+// Package tui implements fitting the main view to the terminal size.
+package tui
+
+import (
+	"fmt"
+
+	"pandabrew/internal/core"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+const (
+	// minWidth and minHeight are the smallest terminal the UI is drawn in.
+	minWidth  = 60
+	minHeight = 16
+	// narrowWidth is the width below which the sidebar no longer fits next
+	// to the tree and moves above it, hidden until toggled.
+	narrowWidth = 100
+	// sidebarWidth is the width of the sidebar with its border.
+	sidebarWidth = 39
+	// minTreeRows is how many rows the tree keeps under a stacked sidebar.
+	minTreeRows = 6
+)
+
+// tooSmall reports whether the terminal is below the minimum size. The size
+// is unknown, and assumed large enough, until the first WindowSizeMsg.
+func (m AppModel) tooSmall() bool {
+	return m.Width > 0 && (m.Width < minWidth || m.Height < minHeight)
+}
+
+// narrow reports whether the sidebar is stacked above the tree.
+func (m AppModel) narrow() bool {
+	return m.Width > 0 && m.Width < narrowWidth
+}
+
+// sidebarShown reports whether the sidebar is drawn. It is by default on wide
+// terminals and not on narrow ones, SidebarToggled flips that, and a focused
+// field always shows it.
+func (m AppModel) sidebarShown(state *TabState) bool {
+	if state.ActiveInput != 0 || state.ActiveOption != 0 {
+		return true
+	}
+	return m.narrow() == m.SidebarToggled
+}
+
+// renderBody lays out the sidebar and the tree in height rows: side by side
+// on wide terminals, stacked on narrow ones.
+func (m AppModel) renderBody(state *TabState, space *core.DirectorySpace, height int) string {
+	if !m.sidebarShown(state) {
+		return m.renderTree(state, space, height, m.Width)
+	}
+	if !m.narrow() {
+		// The sidebar lists every option and outgrows short terminals; cut it
+		// to the body rather than let the frame grow past the screen, which
+		// scrolls the tabs off the top
+		sidebar := lipgloss.NewStyle().MaxHeight(height).Render(m.renderSidebar(state, space, height))
+		return lipgloss.JoinHorizontal(lipgloss.Top, sidebar, m.renderTree(state, space, height, max(0, m.Width-sidebarWidth)))
+	}
+
+	sidebar := m.renderSidebar(state, space, 0)
+	sidebarHeight := stackedSidebarHeight(sidebar, height)
+	sidebar = lipgloss.NewStyle().MaxHeight(sidebarHeight).Render(sidebar)
+	tree := m.renderTree(state, space, height-sidebarHeight, m.Width)
+	return lipgloss.JoinVertical(lipgloss.Left, sidebar, tree)
+}
+
+// stackedSidebarHeight is how much of height a sidebar stacked above the
+// tree takes, leaving the tree at least minTreeRows.
+func stackedSidebarHeight(sidebar string, height int) int {
+	return min(lipgloss.Height(sidebar), max(0, height-minTreeRows))
+}
+
+// renderHeader draws the tabs, and under them the vendored code banner when
+// the selection has some.
+func (m AppModel) renderHeader(space *core.DirectorySpace) string {
+	tabs := m.renderTabs()
+	if warning := m.vendoredWarning(space); warning != "" {
+		tabs = lipgloss.JoinVertical(lipgloss.Left, tabs, m.renderBanner(warning))
+	}
+	return tabs
+}
+
+// treeRows is how many tree rows View shows for the active tab, laid out
+// as renderBody lays it out.
+func (m AppModel) treeRows(state *TabState, space *core.DirectorySpace) int {
+	height := max(0, m.Height-lipgloss.Height(m.renderHeader(space))-lipgloss.Height(m.renderFooter(space, state)))
+	if m.sidebarShown(state) && m.narrow() {
+		height -= stackedSidebarHeight(m.renderSidebar(state, space, 0), height)
+	}
+	return max(0, height-2)
+}
+
+// followCursor scrolls the tree of the active tab just enough to keep the
+// cursor in view, so that View only draws the window Update left.
+func (m AppModel) followCursor() {
+	space := m.Session.GetActiveSpace()
+	if space == nil || m.Width == 0 || m.tooSmall() {
+		return
+	}
+	state := m.TabStates[space.ID]
+	if state == nil {
+		return
+	}
+	if rows := m.treeRows(state, space); len(state.VisibleNodes) > rows {
+		state.ScrollOffset = state.scrollWindow(rows)
+	}
+}
+
+// renderTooSmallView replaces the UI on terminals below the minimum size.
+func (m AppModel) renderTooSmallView() string {
+	text := lipgloss.NewStyle().
+		Foreground(m.Styles.ColorPeach).
+		Background(m.Styles.ColorBase).
+		Align(lipgloss.Center).
+		Width(m.Width).
+		Render(iconWarn + " " + tr("Terminal too small") + "\n\n" + fmt.Sprintf(tr("%d×%d, needs at least %d×%d"), m.Width, m.Height, minWidth, minHeight))
+	return lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		text,
+		lipgloss.WithWhitespaceBackground(m.Styles.ColorBase),
+	)
+}
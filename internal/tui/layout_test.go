@@ -0,0 +1,66 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+func TestLayoutFitsShortTerminals(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r/proj"}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Width, m.Height = 120, minHeight
+
+	// The sidebar is taller than the terminal, but the tabs stay on top
+	view := m.View()
+	if h := lipgloss.Height(view); h != m.Height {
+		t.Errorf("view is %d rows, want %d", h, m.Height)
+	}
+	if first, _, _ := strings.Cut(view, "\n"); !strings.Contains(first, "PandaBrew") || !strings.Contains(first, "proj") {
+		t.Errorf("first row %q, want the tabs", first)
+	}
+}
+
+func TestNarrowLayout(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: t.TempDir()}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	resize := func(w, h int) {
+		updated, _ := m.Update(tea.WindowSizeMsg{Width: w, Height: h})
+		m = updated.(AppModel)
+	}
+	fits := func() bool {
+		for _, line := range strings.Split(m.View(), "\n") {
+			if lipgloss.Width(line) > m.Width {
+				return false
+			}
+		}
+		return true
+	}
+
+	resize(50, 30)
+	if !strings.Contains(m.View(), "Terminal too small") {
+		t.Error("no warning below the minimum size")
+	}
+
+	resize(120, 40)
+	if !strings.Contains(m.View(), "Configuration") || !fits() {
+		t.Error("sidebar missing beside the tree on a wide terminal")
+	}
+
+	resize(80, 40)
+	if strings.Contains(m.View(), "Configuration") || !fits() {
+		t.Error("sidebar not hidden on a narrow terminal")
+	}
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("|")})
+	m = updated.(AppModel)
+	if !strings.Contains(m.View(), "Configuration") || !fits() {
+		t.Error("toggled sidebar not stacked above the tree")
+	}
+	if h := lipgloss.Height(m.View()); h != 40 {
+		t.Errorf("stacked layout is %d rows, want 40", h)
+	}
+}
@@ -0,0 +1,98 @@
+// Package tui implements the terminal user interface logic.
+package tui
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxLogEntries caps the message log so long sessions don't grow unbounded.
+const maxLogEntries = 200
+
+// maxVisibleToasts limits how many notifications are stacked on screen.
+const maxVisibleToasts = 4
+
+// Severity classifies a notification.
+type Severity int
+
+const (
+	SeverityInfo Severity = iota
+	SeverityWarn
+	SeverityError
+)
+
+// lifetime returns how long a toast of this severity stays on screen.
+func (s Severity) lifetime() time.Duration {
+	switch s {
+	case SeverityError:
+		return 10 * time.Second
+	case SeverityWarn:
+		return 6 * time.Second
+	default:
+		return 4 * time.Second
+	}
+}
+
+// LogEntry is a single timestamped status message.
+type LogEntry struct {
+	Time     time.Time
+	Severity Severity
+	Text     string
+}
+
+// Toast is a transient notification rendered above the status bar.
+type Toast struct {
+	Severity  Severity
+	Text      string
+	ExpiresAt time.Time
+}
+
+// toastTickMsg drives auto-dismissal of expired toasts.
+type toastTickMsg time.Time
+
+func toastTickCmd() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return toastTickMsg(t)
+	})
+}
+
+// notify queues a toast and records the message in the log.
+func (m *AppModel) notify(severity Severity, text string) {
+	if text == "" {
+		return
+	}
+	now := m.Now()
+	m.Toasts = append(m.Toasts, Toast{
+		Severity:  severity,
+		Text:      text,
+		ExpiresAt: now.Add(severity.lifetime()),
+	})
+	if len(m.Toasts) > maxVisibleToasts {
+		m.Toasts = m.Toasts[len(m.Toasts)-maxVisibleToasts:]
+	}
+
+	m.MessageLog = append(m.MessageLog, LogEntry{Time: now, Severity: severity, Text: text})
+	if len(m.MessageLog) > maxLogEntries {
+		m.MessageLog = m.MessageLog[len(m.MessageLog)-maxLogEntries:]
+	}
+}
+
+// pruneToasts drops every toast that has outlived its severity's lifetime.
+func (m *AppModel) pruneToasts(now time.Time) {
+	active := m.Toasts[:0]
+	for _, t := range m.Toasts {
+		if now.Before(t.ExpiresAt) {
+			active = append(active, t)
+		}
+	}
+	m.Toasts = active
+}
+
+// latestToast returns the newest visible toast, if any.
+func (m AppModel) latestToast() (Toast, bool) {
+	if len(m.Toasts) == 0 {
+		return Toast{}, false
+	}
+	return m.Toasts[len(m.Toasts)-1], true
+}
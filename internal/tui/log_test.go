@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestMessageLog(t *testing.T) {
+	m := InitialModel(&core.Session{}, nil)
+	m.Width, m.Height = 120, 40
+	press := func(k tea.KeyMsg) {
+		updated, _ := m.Update(k)
+		m = updated.(AppModel)
+	}
+	for i := range maxLogEntries + 5 {
+		m.notify(SeverityInfo, fmt.Sprintf("message %d", i))
+	}
+	if len(m.MessageLog) != maxLogEntries || m.MessageLog[0].Text != "message 5" {
+		t.Fatalf("log holds %d entries from %q", len(m.MessageLog), m.MessageLog[0].Text)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyCtrlL})
+	if !m.ShowMessageLog || !strings.Contains(m.View(), fmt.Sprintf("message %d", maxLogEntries+4)) {
+		t.Fatal("message log not opened on the newest entry")
+	}
+	press(tea.KeyMsg{Type: tea.KeyUp})
+	press(tea.KeyMsg{Type: tea.KeyUp})
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	if m.MessageLogOffset != 1 {
+		t.Errorf("offset = %d after two ups and a down", m.MessageLogOffset)
+	}
+	for range maxLogEntries {
+		press(tea.KeyMsg{Type: tea.KeyUp})
+	}
+	if m.MessageLogOffset != maxLogEntries-1 {
+		t.Errorf("scrolled past the oldest entry: offset %d", m.MessageLogOffset)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.ShowMessageLog {
+		t.Error("Esc did not close the message log")
+	}
+
+	// Reopening starts from the newest entry again
+	press(tea.KeyMsg{Type: tea.KeyCtrlL})
+	if m.MessageLogOffset != 0 {
+		t.Errorf("reopened at offset %d", m.MessageLogOffset)
+	}
+}
+
+func TestToasts(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r"}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Width, m.Height = 120, 40
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	m.Now = func() time.Time { return now }
+	tick := func(d time.Duration) {
+		now = now.Add(d)
+		updated, _ := m.Update(toastTickMsg(now))
+		m = updated.(AppModel)
+	}
+
+	// Toasts stack rather than replace each other, and stay by severity
+	m.notify(SeverityError, "Error: disk full")
+	m.notify(SeverityWarn, "Export cancelled")
+	m.notify(SeverityInfo, "Loaded 14 items")
+	if view := m.View(); !strings.Contains(view, "disk full") || !strings.Contains(view, "Loaded 14 items") {
+		t.Fatalf("toasts not stacked on screen:\n%s", view)
+	}
+	tick(5 * time.Second)
+	if len(m.Toasts) != 2 || m.Toasts[0].Severity != SeverityError {
+		t.Errorf("after 5s: %+v", m.Toasts)
+	}
+	tick(2 * time.Second)
+	if toast, ok := m.latestToast(); !ok || len(m.Toasts) != 1 || toast.Text != "Error: disk full" {
+		t.Errorf("after 7s: %+v", m.Toasts)
+	}
+	tick(4 * time.Second)
+	if len(m.Toasts) != 0 || len(m.MessageLog) != 3 {
+		t.Errorf("after 11s: toasts %+v, log %d entries", m.Toasts, len(m.MessageLog))
+	}
+
+	// Only the newest few are kept on screen
+	for i := range maxVisibleToasts + 2 {
+		m.notify(SeverityInfo, fmt.Sprintf("toast %d", i))
+	}
+	if len(m.Toasts) != maxVisibleToasts || m.Toasts[0].Text != "toast 2" {
+		t.Errorf("stack = %+v", m.Toasts)
+	}
+}
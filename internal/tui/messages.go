@@ -2,7 +2,6 @@
 package tui
 
 import (
-	"io/fs"
 	"os"
 	"path/filepath"
 
@@ -20,9 +19,9 @@ type DirLoadedMsg struct {
 	Err     error
 }
 
-func loadDirectoryCmd(path string) tea.Cmd {
+func loadDirectoryCmd(dirs *core.DirCache, path string) tea.Cmd {
 	return func() tea.Msg {
-		entries, err := core.ListDir(path)
+		entries, err := dirs.List(path)
 		return DirLoadedMsg{Path: path, Entries: entries, Err: err}
 	}
 }
@@ -35,27 +34,61 @@ type ExportProgressMsg struct {
 
 // ExportCompleteMsg carries the result of an extraction operation.
 type ExportCompleteMsg struct {
-	Count  int
-	Tokens int
-	Err    error
+	Count   int
+	Tokens  int
+	Err     error
+	Summary *ExportSummary // Set on success
+	DryRun  bool           // Nothing was written
+	Output  string         // File names of the reports, for messages
+
+	// SpaceID names the exported tab and Hashes the contents of its
+	// selected files as exported, for Update to record in its config.
+	SpaceID string
+	Hashes  map[string]core.FileHash
 }
 
-func runExportCmd(space *core.DirectorySpace) tea.Cmd {
+// runExportCmd exports space, which must be a snapshot the model no longer
+// changes: the export runs alongside Update.
+func runExportCmd(space *core.DirectorySpace, opts core.ExtractOptions) tea.Cmd {
 	return func() tea.Msg {
-		meta, err := core.RunExtraction(space)
-		return ExportCompleteMsg{
-			Count:  meta.TotalFiles,
-			Tokens: meta.TotalTokens,
-			Err:    err,
+		meta, err := core.RunExtractionWithOptions(space, opts)
+		msg := ExportCompleteMsg{
+			Count:   meta.TotalFiles,
+			Tokens:  meta.TotalTokens,
+			Err:     err,
+			DryRun:  opts.DryRun,
+			Output:  outputNames(space, opts),
+			SpaceID: space.ID,
+		}
+		if err == nil && !opts.DryRun {
+			msg.Summary = newExportSummary(space, opts, meta.TotalFiles, meta.TotalTokens)
+			msg.Hashes = core.SelectionHashes(space)
 		}
+		return msg
+	}
+}
+
+// ExportDiffMsg carries how a fresh export would differ from the last one.
+type ExportDiffMsg struct {
+	Diff core.ReportDiff
+	Err  error
+}
+
+func previewExportCmd(space *core.DirectorySpace, opts core.ExtractOptions) tea.Cmd {
+	snapshot := *space
+	snapshot.Config = space.Config.Clone()
+	return func() tea.Msg {
+		diff, err := core.PreviewExtraction(&snapshot, opts)
+		return ExportDiffMsg{Diff: diff, Err: err}
 	}
 }
 
 // NewTabValidatedMsg confirms the new tab path is valid.
 type NewTabValidatedMsg struct {
-	Path  string
-	Valid bool
-	Error string
+	Path    string
+	Valid   bool
+	Error   string
+	Project *core.ProjectType // Detected project type, offered as defaults
 }
 
 func validateNewTabCmd(path string) tea.Cmd {
@@ -97,10 +130,14 @@ func validateNewTabCmd(path string) tea.Cmd {
 			}
 		}
 
-		return NewTabValidatedMsg{
+		msg := NewTabValidatedMsg{
 			Path:  absPath,
 			Valid: true,
 		}
+		if pt, ok := core.DetectProjectType(absPath); ok {
+			msg.Project = &pt
+		}
+		return msg
 	}
 }
 
@@ -113,25 +150,30 @@ type AllFilesLoadedMsg struct {
 }
 
 // findAllFilesCmd walks the directory tree efficiently to find all files.
-func findAllFilesCmd(root string) tea.Cmd {
+func findAllFilesCmd(fsys core.FileSystem, root string) tea.Cmd {
 	return func() tea.Msg {
 		var files []string
-		_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		var walk func(dir string)
+		walk = func(dir string) {
+			entries, err := fsys.ReadDir(dir)
 			if err != nil {
-				return nil
+				return
 			}
-			// Skip typical heavy directories to improve performance
-			if d.IsDir() {
-				name := d.Name()
-				if name == ".git" || name == "node_modules" || name == "vendor" || name == "target" || name == "dist" || name == "build" || name == ".idea" || name == ".vscode" {
-					return filepath.SkipDir
+			for _, e := range entries {
+				path := filepath.Join(dir, e.Name())
+				if !e.IsDir() {
+					files = append(files, path)
+					continue
+				}
+				// Skip typical heavy directories to improve performance
+				switch e.Name() {
+				case ".git", "node_modules", "vendor", "target", "dist", "build", ".idea", ".vscode":
+				default:
+					walk(path)
 				}
-			} else {
-				// Only add files
-				files = append(files, path)
 			}
-			return nil
-		})
+		}
+		walk(root)
 		return AllFilesLoadedMsg{RootPath: root, Files: files}
 	}
 }
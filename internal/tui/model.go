@@ -2,8 +2,10 @@
 package tui
 
 import (
+	"context"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"pandabrew/internal/core"
 
@@ -21,6 +23,7 @@ import (
 // AppModel is the single source of truth for the UI state.
 type AppModel struct {
 	Session    *core.Session
+	Sessions   *core.SessionManager // Where Session is persisted
 	TabStates  map[string]*TabState
 	Spinner    spinner.Model
 	Progress   progress.Model
@@ -30,6 +33,114 @@ type AppModel struct {
 	ShowHelp   bool
 	ShowNewTab bool
 
+	// ShowConfirmOverwrite asks before truncating a file PandaBrew didn't write.
+	ShowConfirmOverwrite bool
+
+	// ProjectSuggestion holds the detected project type of a new tab while
+	// asking whether to apply its defaults.
+	ProjectSuggestion *core.ProjectType
+
+	// Inspection explains the node the inspector was opened on, if any.
+	Inspection *core.Explanation
+
+	// ActiveTooltip holds the hotkey of the option being explained, if any.
+	ActiveTooltip string
+
+	// Notifications
+	Toasts []Toast
+
+	// Message Log State
+	ShowMessageLog   bool
+	MessageLog       []LogEntry
+	MessageLogOffset int // Entries scrolled up from the newest
+
+	// Largest Offenders Panel
+	ShowOffenders   bool
+	Offenders       []core.FileStat
+	OffendersCursor int
+
+	// Selection Comparison
+	ShowCompare     bool
+	CompareTargetID string // Empty while picking the tab to compare against
+	CompareCursor   int
+
+	// Nested Repository Policies
+	ShowNestedRepos   bool
+	NestedRepos       []string // Relative paths of the active space's nested repositories
+	NestedReposCursor int
+
+	// File Preview (rendered into Viewport)
+	ShowPreview        bool
+	PreviewPath        string
+	PreviewLines       []string
+	PreviewQuery       string
+	PreviewMatches     []int // Line indexes containing PreviewQuery
+	PreviewMatchPtr    int
+	PreviewSearching   bool
+	PreviewSearchInput textinput.Model
+
+	// Configuration of the last export per space ID, for the quick re-export
+	LastExports map[string]*core.DirectorySpace
+
+	// Export Preview (diff against the previous report)
+	ShowExportDiff   bool
+	ExportDiff       core.ReportDiff
+	ExportDiffCursor int
+
+	// Stale Selection Review
+	ShowStale    bool
+	Stale        []core.StaleSelection
+	StaleChoice  []int // Chosen suggestion per row
+	StaleCursor  int
+	StaleChecked map[string]bool // Space IDs already reviewed on load
+	StaleKept    map[string]bool // Missing paths the user chose to keep
+
+	// Workspace Groups
+	ShowGroups     bool
+	GroupsCursor   int
+	ShowGroupInput bool
+	GroupInput     textinput.Model
+
+	// Session Switcher (the last row starts a new session)
+	ShowSessions     bool
+	SessionNames     []string
+	SessionsCursor   int
+	ShowSessionInput bool
+	SessionInput     textinput.Model
+
+	// Theme Picker (previews the theme under the cursor)
+	ShowThemes        bool
+	ThemesCursor      int
+	ThemeBefore       string // Restored on Esc, with TransparentBefore
+	TransparentBefore bool
+
+	// Task Picker (the first row clears the task)
+	ShowTasks   bool
+	TasksCursor int
+
+	// Size Index State
+	Indexes      map[string]*core.Index        // Per root path
+	Branches     map[string]core.GitBranch     // Per root path, git repositories only
+	Vendored     map[string][]core.VendoredDir // Per root path
+	IndexCancel  context.CancelFunc            // Non-nil while the indexer runs
+	IndexRoot    string
+	IndexedFiles int
+
+	// DirEstimates totals collapsed folders the cursor rested on, by path,
+	// while no index covers them. EstimateCancel stops the running walk.
+	DirEstimates   map[string]dirEstimate
+	EstimateCancel context.CancelFunc
+
+	// StartupWarnings lists what checking the saved spaces found, shown in
+	// a dialog until a key is pressed
+	StartupWarnings []string
+
+	// Selection Scan State: the folder last selected is walked in the
+	// background while ScanCancel is non-nil
+	ScanCancel   context.CancelFunc
+	ScanPath     string
+	ScannedFiles int
+
 	// Global Search State
 	ShowGlobalSearch     bool
 	GlobalSearchInput    textinput.Model
@@ -37,15 +148,37 @@ type AppModel struct {
 	GlobalSearchFiles    []string            // Currently filtered files
 	GlobalSearchSelect   int                 // Selected index in the filtered list
 	GlobalSearchSelected map[string]bool     // Multi-select state (path -> isSelected)
+	GlobalSearchContent  bool                // Search file contents instead of paths
+	ContentMatches       []core.ContentMatch // Lines behind GlobalSearchFiles in content mode
+	ContentSearchSeq     int                 // Latest queued content search
+	ContentBackend       string
+
+	// Search History (shared by tree and global search)
+	HistoryPos   int // Entry shown while browsing, -1 when not browsing
+	HistoryDraft string
 
 	NewTabInput     textinput.Model
-	StatusMessage   string
 	Width, Height   int
+	SidebarToggled  bool // Sidebar flipped from its default for the width
 	keys            keyMap
 	ExportProgress  float64
 	ExportTotal     int
 	ExportProcessed int
+	LastExport      *ExportSummary      // Printed after quitting; nil until an export succeeds
+	StartupActions  []StartupAction     // Left of the --on-start script
+	ReadOnly        bool                // Exports are dry runs and the file index is not cached
+	SelectionUndo   []selectionUndo     // Selections replaced by bulk actions, newest last
+	ExportOptions   core.ExtractOptions // How every export reads and writes, as set by the command line
 	Styles          Styles
+
+	// FS is where the tree, the file list of global search and the startup
+	// checks read the disk, and Dirs keeps the tree listings across
+	// expansions and tab switches. Tests swap them with UseFileSystem, and
+	// Now for a fake clock. Everything else reads the disk: see
+	// UseFileSystem.
+	FS   core.FileSystem
+	Dirs *core.DirCache
+	Now  func() time.Time
 }
 
 // TabState holds the UI state for a specific directory space (tab).
@@ -53,12 +186,17 @@ type TabState struct {
 	TreeRoot     *TreeNode
 	VisibleNodes []*TreeNode
 	CursorIndex  int
+	ScrollOffset int // First visible row of the tree
 
 	// Search State
 	InputSearch  textinput.Model
 	SearchQuery  string
 	MatchIndices []int
 	MatchPtr     int
+	// Collapsed directory -> number of matching descendants
+	HiddenMatches map[*TreeNode]int
+	hiddenPaths   map[*TreeNode][]hiddenMatch
+	IndexedFiles  []string // Every file under the root, for matches in unloaded folders
 
 	// State Restoration Targets
 	TargetExpandedPaths map[string]bool
@@ -70,7 +208,8 @@ type TabState struct {
 	InputInclude textinput.Model
 	InputExclude textinput.Model
 
-	ActiveInput int
+	ActiveInput  int
+	ActiveOption int // 1-based index into keyMap.sidebarOptions, 0 when none is focused
 }
 
 // TreeNode represents the VISUAL state of a file.
@@ -82,27 +221,21 @@ type TreeNode struct {
 	Children []*TreeNode
 	Parent   *TreeNode
 	IsLast   bool
+
+	Loaded        bool // Children have been listed at least once
+	NewlyIncluded bool // Appeared on the last refresh and is part of the export
 }
 
 // --- Init ---
 
-func InitialModel(session *core.Session) AppModel {
+func InitialModel(session *core.Session, sm *core.SessionManager) AppModel {
 	if session.Theme == "" {
 		session.Theme = "mocha"
 	}
 
-	palette := GetTheme(session.Theme)
+	palette := themePalette(session.Theme, session.Transparent)
 	styles := DefaultStyles(palette)
 
-	s := spinner.New()
-	s.Spinner = spinner.Dot
-	s.Style = lipgloss.NewStyle().Foreground(styles.ColorMauve)
-
-	prog := progress.New(
-		progress.WithDefaultGradient(),
-		progress.WithWidth(40),
-	)
-
 	newTabInput := textinput.New()
 	newTabInput.Placeholder = "Enter directory path..."
 	newTabInput.CharLimit = 200
@@ -114,6 +247,24 @@ func InitialModel(session *core.Session) AppModel {
 	newTabInput.Cursor.Style = lipgloss.NewStyle().Foreground(styles.ColorMauve)
 	newTabInput.Cursor.TextStyle = lipgloss.NewStyle().Background(styles.ColorBase)
 
+	groupInput := textinput.New()
+	groupInput.Placeholder = "Group name (empty to ungroup)..."
+	groupInput.CharLimit = 40
+	groupInput.Width = 40
+	updateInputStyle(&groupInput, styles)
+
+	sessionInput := textinput.New()
+	sessionInput.Placeholder = "Session name..."
+	sessionInput.CharLimit = 40
+	sessionInput.Width = 40
+	updateInputStyle(&sessionInput, styles)
+
+	previewSearchInput := textinput.New()
+	previewSearchInput.Placeholder = "Search file..."
+	previewSearchInput.CharLimit = 100
+	previewSearchInput.Width = 40
+	updateInputStyle(&previewSearchInput, styles)
+
 	// Global Search Input
 	globalSearchInput := textinput.New()
 	globalSearchInput.Placeholder = "Type to search files..."
@@ -134,15 +285,31 @@ func InitialModel(session *core.Session) AppModel {
 
 	model := AppModel{
 		Session:              session,
+		Sessions:             sm,
 		TabStates:            make(map[string]*TabState),
-		Spinner:              s,
-		Progress:             prog,
+		Spinner:              newSpinner(palette),
+		Progress:             newProgress(palette),
 		Help:                 h,
 		NewTabInput:          newTabInput,
+		GroupInput:           groupInput,
+		SessionInput:         sessionInput,
+		PreviewSearchInput:   previewSearchInput,
 		GlobalSearchInput:    globalSearchInput,
 		GlobalSearchCache:    make(map[string][]string),
 		GlobalSearchSelected: make(map[string]bool),
-		keys:                 keys,
+		HistoryPos:           -1,
+		LastExports:          make(map[string]*core.DirectorySpace),
+		ExportOptions:        core.DefaultExtractOptions(),
+		FS:                   core.OSFileSystem,
+		Dirs:                 core.NewDirCache(),
+		Now:                  time.Now,
+		Indexes:              make(map[string]*core.Index),
+		DirEstimates:         make(map[string]dirEstimate),
+		Branches:             make(map[string]core.GitBranch),
+		Vendored:             make(map[string][]core.VendoredDir),
+		StaleChecked:         make(map[string]bool),
+		StaleKept:            make(map[string]bool),
+		keys:                 localizeKeys(keys),
 		Styles:               styles,
 	}
 
@@ -185,9 +352,12 @@ func newTabState(space *core.DirectorySpace, styles Styles) *TabState {
 		InputExclude:        newInput(".git, node_modules", strings.Join(space.Config.ExcludePatterns, ", ")),
 		InputSearch:         searchInput,
 		CursorIndex:         0,
+		ScrollOffset:        space.ScrollOffset,
+		SearchQuery:         space.SearchQuery,
 		TargetExpandedPaths: make(map[string]bool),
 		TargetCursorPath:    space.CursorPath,
 	}
+	ts.InputSearch.SetValue(space.SearchQuery)
 
 	for _, p := range space.ExpandedPaths {
 		ts.TargetExpandedPaths[p] = true
@@ -204,12 +374,29 @@ func newTabState(space *core.DirectorySpace, styles Styles) *TabState {
 	return ts
 }
 
+// UseFileSystem reads the tree, lists the files for global search and
+// checks the spaces against fsys instead of the disk. It covers browsing
+// only: exports and their previews, the size index, content search, branch
+// switching and the detection of vendored code and nested repositories
+// still read the disk, so tests of those need a real directory.
+func (m *AppModel) UseFileSystem(fsys core.FileSystem) {
+	m.FS = fsys
+	m.Dirs = core.NewDirCacheFS(fsys)
+}
+
 func (m AppModel) Init() tea.Cmd {
+	cmds := []tea.Cmd{m.Spinner.Tick, toastTickCmd(), checkSpacesCmd(m.FS, m.Session)}
+	for _, space := range m.Session.Spaces {
+		cmds = append(cmds, loadIndexCmd(space.RootPath), loadBranchCmd(space.RootPath), loadVendoredCmd(space))
+	}
 	activeSpace := m.Session.GetActiveSpace()
 	if activeSpace != nil {
-		return tea.Batch(m.Spinner.Tick, loadDirectoryCmd(activeSpace.RootPath))
+		cmds = append(cmds, loadDirectoryCmd(m.Dirs, activeSpace.RootPath))
+	}
+	if len(m.StartupActions) > 0 {
+		cmds = append(cmds, func() tea.Msg { return StartupActionsMsg{} })
 	}
-	return m.Spinner.Tick
+	return tea.Batch(cmds...)
 }
 
 func (ts *TabState) rebuildVisibleList() {
@@ -242,6 +429,7 @@ func (ts *TabState) rebuildVisibleList() {
 
 func (ts *TabState) PerformSearch() {
 	ts.MatchIndices = []int{}
+	ts.HiddenMatches = nil
 	if ts.SearchQuery == "" {
 		return
 	}
@@ -256,4 +444,5 @@ func (ts *TabState) PerformSearch() {
 	if ts.MatchPtr >= len(ts.MatchIndices) {
 		ts.MatchPtr = 0
 	}
+	ts.countHiddenMatches(query)
 }
@@ -0,0 +1,79 @@
+// Package tui implements the nested repository policy dialog and the
+// policies for generated and test files.
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// NestedReposLoadedMsg carries the repositories nested in a space.
+type NestedReposLoadedMsg struct {
+	SpaceID string
+	Repos   []string
+}
+
+func loadNestedReposCmd(space *core.DirectorySpace) tea.Cmd {
+	id, root, cfg := space.ID, space.RootPath, space.Config.Clone()
+	return func() tea.Msg {
+		return NestedReposLoadedMsg{SpaceID: id, Repos: core.FindNestedRepos(root, cfg)}
+	}
+}
+
+// nestedRepoRows renders each nested repository with its policy.
+func (m AppModel) nestedRepoRows() []string {
+	space := m.Session.GetActiveSpace()
+	if space == nil {
+		return nil
+	}
+	var rows []string
+	for _, repo := range m.NestedRepos {
+		rows = append(rows, fmt.Sprintf("%-10s %s", space.Config.NestedRepoPolicyFor(repo), repo))
+	}
+	return rows
+}
+
+// cycleNestedRepoPolicy moves repo to the next policy. Policies equal to the
+// space default are not stored.
+func cycleNestedRepoPolicy(space *core.DirectorySpace, repo string) string {
+	cfg := &space.Config
+	cur := slices.Index(core.NestedRepoPolicies, cfg.NestedRepoPolicyFor(repo))
+	next := core.NestedRepoPolicies[(cur+1)%len(core.NestedRepoPolicies)]
+
+	if cfg.NestedRepos == nil {
+		cfg.NestedRepos = make(map[string]string)
+	}
+	cfg.NestedRepos[repo] = next
+	if def := cfg.NestedRepoPolicy; next == def || (def == "" && next == core.NestedRepoFull) {
+		delete(cfg.NestedRepos, repo)
+	}
+	return next
+}
+
+// cycleGeneratedPolicy moves the policy for generated, minified and license
+// files to the next one, storing the default as empty.
+func cycleGeneratedPolicy(space *core.DirectorySpace) string {
+	cur := slices.Index(core.NestedRepoPolicies, space.Config.GeneratedPolicyFor())
+	next := core.NestedRepoPolicies[(cur+1)%len(core.NestedRepoPolicies)]
+	space.Config.GeneratedPolicy = next
+	if next == core.NestedRepoFull {
+		space.Config.GeneratedPolicy = ""
+	}
+	return next
+}
+
+// cycleTestsPolicy moves the policy for test files to the next one, storing
+// the default as empty.
+func cycleTestsPolicy(space *core.DirectorySpace) string {
+	cur := slices.Index(core.NestedRepoPolicies, space.Config.TestsPolicyFor())
+	next := core.NestedRepoPolicies[(cur+1)%len(core.NestedRepoPolicies)]
+	space.Config.TestsPolicy = next
+	if next == core.NestedRepoFull {
+		space.Config.TestsPolicy = ""
+	}
+	return next
+}
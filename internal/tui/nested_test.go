@@ -0,0 +1,36 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestCycleNestedRepoPolicy(t *testing.T) {
+	space := &core.DirectorySpace{}
+	var got []string
+	for range core.NestedRepoPolicies {
+		got = append(got, cycleNestedRepoPolicy(space, "sub"))
+	}
+	if want := []string{core.NestedRepoStructure, core.NestedRepoSkip, core.NestedRepoFull}; !slices.Equal(got, want) {
+		t.Errorf("cycle = %v, want %v", got, want)
+	}
+	if len(space.Config.NestedRepos) != 0 {
+		t.Errorf("the default policy was stored: %v", space.Config.NestedRepos)
+	}
+}
+
+func TestCycleGeneratedPolicy(t *testing.T) {
+	space := &core.DirectorySpace{}
+	var got []string
+	for range core.NestedRepoPolicies {
+		got = append(got, cycleGeneratedPolicy(space))
+	}
+	if want := []string{core.NestedRepoStructure, core.NestedRepoSkip, core.NestedRepoFull}; !slices.Equal(got, want) {
+		t.Errorf("policies = %v, want %v", got, want)
+	}
+	if space.Config.GeneratedPolicy != "" {
+		t.Errorf("the default policy was stored: %q", space.Config.GeneratedPolicy)
+	}
+}
@@ -0,0 +1,37 @@
+// Package tui implements highlighting files that appear on a refresh.
+package tui
+
+import (
+	"fmt"
+
+	"pandabrew/internal/core"
+)
+
+// markNewlyIncluded flags the nodes that appeared on a reload and are part
+// of the export. With AutoSelectNew, new files that match a fully checked
+// group of siblings are selected first. It reports how many nodes were
+// flagged and how many of those were auto-selected.
+func markNewlyIncluded(space *core.DirectorySpace, added []*TreeNode) (included, selected int) {
+	for _, node := range added {
+		if space.Config.AutoSelectNew && !node.IsDir && core.ShouldAutoSelect(space, node.FullPath) {
+			core.SelectPath(space, node.FullPath)
+			selected++
+		}
+		if core.IsIncluded(space, node.FullPath) {
+			node.NewlyIncluded = true
+			included++
+		}
+	}
+	return included, selected
+}
+
+// notifyNewlyIncluded reports the outcome of markNewlyIncluded.
+func (m *AppModel) notifyNewlyIncluded(included, selected int) {
+	switch {
+	case selected > 0:
+		m.notify(SeverityInfo, fmt.Sprintf(tr("%d new files included, %d auto-selected"), included, selected))
+		_ = m.Sessions.Save(m.Session)
+	case included > 0:
+		m.notify(SeverityInfo, fmt.Sprintf(tr("%d new files included"), included))
+	}
+}
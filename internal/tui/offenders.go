@@ -0,0 +1,47 @@
+// Package tui implements the terminal user interface logic.
+package tui
+
+import (
+	"slices"
+	"sort"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// maxOffenders is how many files the largest-offenders panel lists.
+const maxOffenders = 20
+
+// OffendersLoadedMsg carries the largest selected files of a space.
+type OffendersLoadedMsg struct {
+	SpaceID string
+	Files   []core.FileStat
+	Err     error
+}
+
+// loadOffendersCmd ranks the selected files of a snapshot of the space by
+// estimated tokens.
+func loadOffendersCmd(space *core.DirectorySpace) tea.Cmd {
+	snapshot := *space
+	snapshot.Config.ManualSelections = slices.Clone(space.Config.ManualSelections)
+	return func() tea.Msg {
+		stats, err := core.CollectFileStats(&snapshot)
+		if err != nil {
+			return OffendersLoadedMsg{SpaceID: snapshot.ID, Err: err}
+		}
+		var selected []core.FileStat
+		for _, s := range stats {
+			if s.Selected {
+				selected = append(selected, s)
+			}
+		}
+		sort.SliceStable(selected, func(i, j int) bool {
+			return selected[i].Tokens > selected[j].Tokens
+		})
+		if len(selected) > maxOffenders {
+			selected = selected[:maxOffenders]
+		}
+		return OffendersLoadedMsg{SpaceID: snapshot.ID, Files: selected}
+	}
+}
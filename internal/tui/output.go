@@ -0,0 +1,102 @@
+// Package tui implements the output options of the sidebar: report format,
+// line numbers, minifying and structure depth.
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"pandabrew/internal/core"
+)
+
+// maxStructureDepth is the deepest structure limit the sidebar steps to
+// before wrapping back to unlimited.
+const maxStructureDepth = 10
+
+// outputFormatChoices are the format sets the sidebar cycles through. The
+// first, text alone, is stored as no formats.
+var outputFormatChoices = [][]string{
+	nil,
+	{core.FormatMarkdown},
+	{core.FormatJSON},
+	core.ReportFormats,
+}
+
+// cycleOutputFormat moves space to the next format set and returns it.
+// Sets not offered by the sidebar, such as ones from a project config,
+// restart the cycle.
+func cycleOutputFormat(space *core.DirectorySpace) []string {
+	cur := slices.IndexFunc(outputFormatChoices, func(f []string) bool {
+		return slices.Equal(f, space.Config.Formats) || (len(f) == 0 && len(space.Config.Formats) == 0)
+	})
+	next := outputFormatChoices[(cur+1)%len(outputFormatChoices)]
+	space.Config.Formats = slices.Clone(next)
+	return next
+}
+
+// formatLabel names a format set for the sidebar.
+func formatLabel(formats []string) string {
+	if len(formats) == 0 {
+		return core.FormatText
+	}
+	if slices.Equal(formats, core.ReportFormats) {
+		return "all"
+	}
+	return strings.Join(formats, "+")
+}
+
+// stepStructureDepth moves the structure depth limit of space by delta,
+// where 0 (unlimited) sits past the deepest limit.
+func stepStructureDepth(space *core.DirectorySpace, delta int) int {
+	depth := space.Config.StructureMaxDepth
+	if depth <= 0 {
+		depth = maxStructureDepth + 1
+	}
+	depth += delta
+	switch {
+	case depth < 1:
+		depth = maxStructureDepth + 1
+	case depth > maxStructureDepth+1:
+		depth = 1
+	}
+	if depth > maxStructureDepth {
+		depth = 0
+	}
+	space.Config.StructureMaxDepth = depth
+	return depth
+}
+
+// depthLabel shows a structure depth limit, ∞ for none.
+func depthLabel(depth int) string {
+	if depth <= 0 {
+		return "∞"
+	}
+	return strconv.Itoa(depth)
+}
+
+// outputPaths lists the files an export of space with opts writes.
+func outputPaths(space *core.DirectorySpace, opts core.ExtractOptions) []string {
+	return core.FormatOutputPaths(space.OutputFilePath, core.OutputFormats(opts, space.Config))
+}
+
+// outputNames joins the file names of outputPaths for messages.
+func outputNames(space *core.DirectorySpace, opts core.ExtractOptions) string {
+	var names []string
+	for _, path := range outputPaths(space, opts) {
+		names = append(names, filepath.Base(path))
+	}
+	return strings.Join(names, ", ")
+}
+
+// checkOutputPaths returns the first error of core.CheckOutputPath over the
+// files an export of space writes.
+func checkOutputPaths(space *core.DirectorySpace, opts core.ExtractOptions) error {
+	for _, path := range outputPaths(space, opts) {
+		if err := core.CheckOutputPath(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
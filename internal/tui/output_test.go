@@ -0,0 +1,33 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestOutputOptions(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r", OutputFilePath: "/out/r.txt"}
+	var labels []string
+	for range outputFormatChoices {
+		labels = append(labels, formatLabel(cycleOutputFormat(space)))
+	}
+	if !slices.Equal(labels, []string{"markdown", "json", "all", "txt"}) || space.Config.Formats != nil {
+		t.Errorf("format cycle = %v, ending on %v", labels, space.Config.Formats)
+	}
+	space.Config.Formats = []string{core.FormatJSON}
+	if got := outputNames(space, core.ExtractOptions{}); got != "r.json" {
+		t.Errorf("outputNames = %q", got)
+	}
+	if got := outputNames(space, core.ExtractOptions{Formats: []string{core.FormatText, core.FormatMarkdown}}); got != "r.txt, r.md" {
+		t.Errorf("outputNames with --format = %q", got)
+	}
+
+	if stepStructureDepth(space, -1) != maxStructureDepth || stepStructureDepth(space, 1) != 0 {
+		t.Errorf("unlimited depth should sit past the deepest limit")
+	}
+	if stepStructureDepth(space, 1) != 1 || stepStructureDepth(space, -1) != 0 {
+		t.Errorf("depth should wrap between 1 and unlimited")
+	}
+}
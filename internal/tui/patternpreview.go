@@ -0,0 +1,103 @@
+// Package tui implements showing the effect of include and exclude patterns
+// on the tree: a preview while they are typed, greyed-out excluded files
+// with DimExcluded, and excluding or un-excluding a node in one key.
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"pandabrew/internal/core"
+)
+
+// Effects of the patterns on a tree node, as previewed.
+const (
+	patternNone = iota
+	patternExcluded
+	patternIncluded
+)
+
+// patternPreview returns the config the tree previews while a pattern input
+// is focused: that of space with the patterns being typed. ok is false when
+// no pattern input is focused.
+func patternPreview(state *TabState, space *core.DirectorySpace) (cfg core.ExtractionConfig, ok bool) {
+	if state.ActiveInput != 3 && state.ActiveInput != 4 {
+		return cfg, false
+	}
+	cfg = space.Config
+	cfg.IncludePatterns = splitClean(state.InputInclude.Value())
+	cfg.ExcludePatterns = splitClean(state.InputExclude.Value())
+	return cfg, true
+}
+
+// patternEffect tells whether cfg excludes path, naming the pattern that
+// does, or, in include mode, an include pattern picks it.
+func patternEffect(cfg core.ExtractionConfig, root, path string) (effect int, pattern string) {
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == "." {
+		return patternNone, ""
+	}
+	if pattern, _ := cfg.ExcludedBy(rel); pattern != "" {
+		return patternExcluded, pattern
+	}
+	if cfg.IncludeMode && cfg.MatchesInclude(rel) {
+		return patternIncluded, ""
+	}
+	return patternNone, ""
+}
+
+// treeEffect is the effect shown on a tree node: that of the patterns being
+// typed, or else, with DimExcluded, whether the saved ones exclude it.
+func treeEffect(state *TabState, space *core.DirectorySpace, path string) (effect int, pattern string) {
+	if cfg, ok := patternPreview(state, space); ok {
+		return patternEffect(cfg, space.RootPath, path)
+	}
+	if !space.Config.DimExcluded {
+		return patternNone, ""
+	}
+	if effect, pattern = patternEffect(space.Config, space.RootPath, path); effect != patternExcluded {
+		return patternNone, ""
+	}
+	return effect, pattern
+}
+
+// excludeNode appends the exclude pattern of node to space, leaving the
+// patterns alone when one already excludes it.
+func (m *AppModel) excludeNode(space *core.DirectorySpace, state *TabState, node *TreeNode) {
+	rel, err := filepath.Rel(space.RootPath, node.FullPath)
+	if err != nil || rel == "." {
+		m.notify(SeverityWarn, tr("The root can't be excluded"))
+		return
+	}
+	if pattern, _ := space.Config.ExcludedBy(rel); pattern != "" {
+		m.notify(SeverityInfo, fmt.Sprintf(tr("%s is already excluded by %q"), node.Name, pattern))
+		return
+	}
+	pattern := core.ExcludePatternFor(filepath.ToSlash(rel), node.IsDir)
+	space.Config.ExcludePatterns = append(slices.Clone(space.Config.ExcludePatterns), pattern)
+	state.InputExclude.SetValue(strings.Join(space.Config.ExcludePatterns, ", "))
+	_ = m.Sessions.Save(m.Session)
+
+	text := fmt.Sprintf(tr("Excluded %q (u undoes)"), pattern)
+	if !node.IsDir && !strings.Contains(pattern, "/") {
+		text += tr("; it also matches files of that name in subfolders")
+	}
+	m.notify(SeverityInfo, text)
+}
+
+// unexclude drops the exclude pattern that hides path from the export and
+// returns it. junk is set instead when a junk pattern hides it, which only
+// turning SkipJunk off undoes.
+func unexclude(space *core.DirectorySpace, path string) (pattern string, junk bool) {
+	rel, err := filepath.Rel(space.RootPath, path)
+	if err != nil || rel == "." {
+		return "", false
+	}
+	pattern, junk = space.Config.ExcludedBy(rel)
+	if pattern != "" && !junk {
+		space.Config.ExcludePatterns = slices.DeleteFunc(slices.Clone(space.Config.ExcludePatterns), func(p string) bool { return p == pattern })
+	}
+	return pattern, junk
+}
@@ -0,0 +1,82 @@
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestPatternPreview(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r", Config: core.ExtractionConfig{IncludeMode: true}}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	state := m.TabStates["a"]
+
+	state.InputExclude.SetValue("node_modules")
+	if _, ok := patternPreview(state, space); ok {
+		t.Fatal("previewing without a focused pattern input")
+	}
+	focusInput(state, 4)
+	state.InputInclude.SetValue("cmd/**")
+	cfg, ok := patternPreview(state, space)
+	if !ok {
+		t.Fatal("no preview while editing the exclude patterns")
+	}
+	for path, want := range map[string]int{
+		"/r/web/node_modules/x/index.js": patternExcluded,
+		"/r/cmd/main.go":                 patternIncluded,
+		"/r/README.md":                   patternNone,
+		"/r":                             patternNone,
+	} {
+		if got, _ := patternEffect(cfg, space.RootPath, path); got != want {
+			t.Errorf("patternEffect(%s) = %d, want %d", path, got, want)
+		}
+	}
+	if len(space.Config.ExcludePatterns) != 0 {
+		t.Errorf("previewing changed the config: %v", space.Config.ExcludePatterns)
+	}
+}
+
+func TestDimExcluded(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r", Config: core.ExtractionConfig{
+		ExcludePatterns: []string{"dist", "*.log"},
+		SkipJunk:        true,
+	}}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	state := m.TabStates["a"]
+
+	if effect, _ := treeEffect(state, space, "/r/dist/app.js"); effect != patternNone {
+		t.Errorf("excluded file dimmed with DimExcluded off")
+	}
+	space.Config.DimExcluded = true
+	if effect, pattern := treeEffect(state, space, "/r/dist/app.js"); effect != patternExcluded || pattern != "dist" {
+		t.Errorf("treeEffect = %d, %q; want excluded by dist", effect, pattern)
+	}
+
+	if pattern, junk := unexclude(space, "/r/dist/app.js"); pattern != "dist" || junk {
+		t.Errorf("unexclude = %q, %v", pattern, junk)
+	}
+	if !slices.Equal(space.Config.ExcludePatterns, []string{"*.log"}) {
+		t.Errorf("exclude patterns = %v", space.Config.ExcludePatterns)
+	}
+	if pattern, junk := unexclude(space, "/r/.DS_Store"); !junk || pattern == "" {
+		t.Errorf("junk file: unexclude = %q, %v", pattern, junk)
+	}
+}
+
+func TestExcludeNode(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r"}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	state := m.TabStates["a"]
+
+	m.excludeNode(space, state, &TreeNode{Name: "build", FullPath: "/r/web/build", IsDir: true})
+	m.excludeNode(space, state, &TreeNode{Name: "out.js", FullPath: "/r/web/build/out.js"})
+	if !slices.Equal(space.Config.ExcludePatterns, []string{"web/build/**"}) || state.InputExclude.Value() != "web/build/**" {
+		t.Fatalf("exclude patterns = %v, input %q", space.Config.ExcludePatterns, state.InputExclude.Value())
+	}
+	if pattern, _ := unexclude(space, "/r/web/build/out.js"); pattern != "web/build/**" || len(space.Config.ExcludePatterns) != 0 {
+		t.Errorf("unexclude = %q, leaving %v", pattern, space.Config.ExcludePatterns)
+	}
+}
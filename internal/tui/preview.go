@@ -0,0 +1,164 @@
+// Package tui implements the file preview overlay and its search.
+package tui
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// maxPreviewBytes is how much of a file the preview reads.
+const maxPreviewBytes = 512 << 10
+
+// PreviewLoadedMsg carries the lines of the previewed file.
+type PreviewLoadedMsg struct {
+	Path      string
+	Lines     []string
+	Truncated bool
+	Err       error
+}
+
+func loadPreviewCmd(path string) tea.Cmd {
+	return func() tea.Msg {
+		f, err := os.Open(path)
+		if err != nil {
+			return PreviewLoadedMsg{Path: path, Err: err}
+		}
+		defer f.Close()
+		data, err := io.ReadAll(io.LimitReader(f, maxPreviewBytes+1))
+		if err != nil {
+			return PreviewLoadedMsg{Path: path, Err: err}
+		}
+		if bytes.IndexByte(data, 0) >= 0 {
+			return PreviewLoadedMsg{Path: path, Lines: []string{"(binary file)"}}
+		}
+		truncated := len(data) > maxPreviewBytes
+		if truncated {
+			data = data[:maxPreviewBytes]
+		}
+		text := strings.ReplaceAll(string(data), "\t", "    ")
+		return PreviewLoadedMsg{Path: path, Lines: strings.Split(text, "\n"), Truncated: truncated}
+	}
+}
+
+// showText opens the preview overlay on text that is not a file, such as a
+// diff. title takes the place of the file name.
+func (m *AppModel) showText(title, text string) {
+	m.openPreview(title, strings.Split(strings.ReplaceAll(strings.TrimSuffix(text, "\n"), "\t", "    "), "\n"))
+}
+
+// openPreview shows lines in the preview overlay, sized to the window.
+func (m *AppModel) openPreview(path string, lines []string) {
+	m.ShowPreview = true
+	m.PreviewPath = path
+	m.PreviewLines = lines
+	m.Viewport.Width = min(m.Width-14, 110)
+	m.Viewport.Height = max(1, m.Height-14)
+	m.Viewport.SetYOffset(0)
+	m.searchPreview(m.PreviewQuery)
+}
+
+// searchPreview finds the lines containing query, case-insensitively, and
+// scrolls to the first match at or below the current position.
+func (m *AppModel) searchPreview(query string) {
+	m.PreviewQuery = query
+	m.PreviewMatches = nil
+	m.PreviewMatchPtr = 0
+	if query == "" {
+		m.refreshPreview()
+		return
+	}
+	q := strings.ToLower(query)
+	for i, line := range m.PreviewLines {
+		if strings.Contains(strings.ToLower(line), q) {
+			m.PreviewMatches = append(m.PreviewMatches, i)
+		}
+	}
+	for i, line := range m.PreviewMatches {
+		if line >= m.Viewport.YOffset {
+			m.PreviewMatchPtr = i
+			break
+		}
+	}
+	m.refreshPreview()
+	m.scrollToPreviewMatch()
+}
+
+// stepPreviewMatch moves to the next (delta 1) or previous (delta -1) match,
+// wrapping around.
+func (m *AppModel) stepPreviewMatch(delta int) {
+	if len(m.PreviewMatches) == 0 {
+		return
+	}
+	n := len(m.PreviewMatches)
+	m.PreviewMatchPtr = (m.PreviewMatchPtr + delta + n) % n
+	m.refreshPreview()
+	m.scrollToPreviewMatch()
+}
+
+func (m *AppModel) scrollToPreviewMatch() {
+	if len(m.PreviewMatches) == 0 {
+		return
+	}
+	line := m.PreviewMatches[m.PreviewMatchPtr]
+	m.Viewport.SetYOffset(max(0, line-m.Viewport.Height/2))
+}
+
+// refreshPreview renders the previewed lines with line numbers into the
+// viewport, highlighting every match and marking the current one.
+func (m *AppModel) refreshPreview() {
+	base := lipgloss.NewStyle().Foreground(m.Styles.ColorText).Background(m.Styles.ColorBase)
+	gutter := lipgloss.NewStyle().Foreground(m.Styles.ColorSubtext).Background(m.Styles.ColorBase)
+	hit := lipgloss.NewStyle().Foreground(m.Styles.ColorInk).Background(m.Styles.ColorYellow)
+	current := hit.Background(m.Styles.ColorPeach).Bold(true)
+
+	currentLine := -1
+	if len(m.PreviewMatches) > 0 {
+		currentLine = m.PreviewMatches[m.PreviewMatchPtr]
+	}
+	width := len(fmt.Sprint(len(m.PreviewLines)))
+	q := strings.ToLower(m.PreviewQuery)
+
+	var sb strings.Builder
+	for i, line := range m.PreviewLines {
+		if i > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(gutter.Render(fmt.Sprintf("%*d │ ", width, i+1)))
+		style := hit
+		if i == currentLine {
+			style = current
+		}
+		sb.WriteString(ansi.Truncate(highlightAll(line, q, base, style), max(0, m.Viewport.Width-width-3), "…"))
+	}
+	m.Viewport.SetContent(sb.String())
+}
+
+// highlightAll renders line with every case-insensitive occurrence of the
+// lower-cased query in match style.
+func highlightAll(line, query string, base, match lipgloss.Style) string {
+	if query == "" {
+		return base.Render(line)
+	}
+	lower := strings.ToLower(line)
+	if len(lower) != len(line) {
+		return base.Render(line) // Case folding changed byte offsets
+	}
+	var sb strings.Builder
+	for {
+		idx := strings.Index(lower, query)
+		if idx < 0 {
+			sb.WriteString(base.Render(line))
+			return sb.String()
+		}
+		sb.WriteString(base.Render(line[:idx]))
+		sb.WriteString(match.Render(line[idx : idx+len(query)]))
+		line, lower = line[idx+len(query):], lower[idx+len(query):]
+	}
+}
@@ -0,0 +1,32 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestSearchPreview(t *testing.T) {
+	m := InitialModel(&core.Session{}, nil)
+	m.Viewport.Width, m.Viewport.Height = 80, 2
+	m.PreviewLines = []string{"alpha", "Beta", "gamma", "beta beta"}
+
+	m.searchPreview("BETA")
+	if !slices.Equal(m.PreviewMatches, []int{1, 3}) {
+		t.Fatalf("matches = %v, want [1 3]", m.PreviewMatches)
+	}
+	m.stepPreviewMatch(1)
+	m.stepPreviewMatch(1)
+	if m.PreviewMatchPtr != 0 {
+		t.Errorf("stepping did not wrap: ptr = %d", m.PreviewMatchPtr)
+	}
+	m.stepPreviewMatch(-1)
+	if m.PreviewMatchPtr != 1 || m.Viewport.YOffset == 0 {
+		t.Errorf("ptr = %d, offset = %d", m.PreviewMatchPtr, m.Viewport.YOffset)
+	}
+	m.searchPreview("")
+	if len(m.PreviewMatches) != 0 {
+		t.Errorf("clearing the query kept matches: %v", m.PreviewMatches)
+	}
+}
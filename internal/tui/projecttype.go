@@ -0,0 +1,28 @@
+// Package tui implements applying the defaults of a detected project type.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"pandabrew/internal/core"
+)
+
+// applyProjectSuggestion applies the pending project defaults to the new
+// tab and mirrors them in its inputs. With edit set, the include patterns
+// are focused so the user can refine them.
+func (m *AppModel) applyProjectSuggestion(space *core.DirectorySpace, state *TabState, edit bool) {
+	pt := m.ProjectSuggestion
+	if space == nil || state == nil || pt == nil {
+		return
+	}
+	pt.Apply(space)
+	state.InputOutput.SetValue(space.OutputFilePath)
+	state.InputInclude.SetValue(strings.Join(space.Config.IncludePatterns, ", "))
+	state.InputExclude.SetValue(strings.Join(space.Config.ExcludePatterns, ", "))
+	_ = m.Sessions.Save(m.Session)
+	m.notify(SeverityInfo, fmt.Sprintf(tr("Applied %s project defaults"), pt.Name))
+	if edit {
+		focusInput(state, 3)
+	}
+}
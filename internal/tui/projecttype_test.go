@@ -0,0 +1,31 @@
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestApplyProjectSuggestion(t *testing.T) {
+	m := InitialModel(&core.Session{}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	space := &core.DirectorySpace{RootPath: "/r", Config: core.DefaultExtractionConfig()}
+	state := newTabState(space, m.Styles)
+	m.ProjectSuggestion = &core.ProjectType{
+		Name:            "Go",
+		IncludePatterns: []string{"go.mod"},
+		ExcludePatterns: []string{".git", "bin"},
+		OutputPath:      "/widget.txt",
+		Selections:      []string{"/r/cmd"},
+	}
+
+	m.applyProjectSuggestion(space, state, true)
+	if space.OutputFilePath != "/widget.txt" || !slices.Equal(space.Config.ManualSelections, []string{"/r/cmd"}) {
+		t.Errorf("space = %+v, want the suggested output and selection", space)
+	}
+	if state.InputExclude.Value() != ".git, bin" || state.ActiveInput != 3 {
+		t.Errorf("exclude input %q, focus %d, want the new patterns and the include input focused", state.InputExclude.Value(), state.ActiveInput)
+	}
+}
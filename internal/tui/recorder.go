@@ -0,0 +1,584 @@
+// Package tui implements recording a TUI run and replaying it for bug reports.
+package tui
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// recordingVersion is written in the first line of every recording. Version
+// 1 recordings, without the session, can no longer be replayed.
+const recordingVersion = 2
+
+// maxRecordedEntries caps the tree recorded for each tab.
+const maxRecordedEntries = 20000
+
+// maxReplayGap caps the pause between two replayed events, so a recording
+// of a user thinking for a minute replays in seconds.
+const maxReplayGap = 2 * time.Second
+
+// RecordedEvent is one line of a recording: a key press or a resize, or the
+// state of the model right after one.
+type RecordedEvent struct {
+	At       int64          `json:"at_ms"` // Since the recording started
+	Key      *RecordedKey   `json:"key,omitempty"`
+	Size     []int          `json:"size,omitempty"` // Width, height
+	Snapshot *ModelSnapshot `json:"snapshot,omitempty"`
+}
+
+// RecordedKey is a key press. Text typed into inputs is anonymized: letters
+// become "a" or "A" and digits "0", keeping only its shape.
+type RecordedKey struct {
+	Type  tea.KeyType `json:"type"`
+	Runes string      `json:"runes,omitempty"`
+	Alt   bool        `json:"alt,omitempty"`
+}
+
+// ModelSnapshot is the state of the UI a replay is checked against. Paths
+// are anonymized like the recorded tree.
+type ModelSnapshot struct {
+	Tabs       int    `json:"tabs"`
+	Tab        int    `json:"tab"` // Active tab among the visible ones, -1 without
+	Visible    int    `json:"visible"`
+	Cursor     int    `json:"cursor"`
+	CursorPath string `json:"cursor_path,omitempty"`
+	Selected   int    `json:"selected"`
+	Deselected int    `json:"deselected,omitempty"`
+	Overlay    string `json:"overlay,omitempty"`
+	Input      int    `json:"input,omitempty"`
+	Option     int    `json:"option,omitempty"`
+}
+
+// recordingHeader is the first line of a recording.
+type recordingHeader struct {
+	Version int              `json:"pandabrew_recording"`
+	Started time.Time        `json:"started"`
+	Session *RecordedSession `json:"session,omitempty"`
+}
+
+// RecordedSession is the state a recording starts from: the visible tabs
+// with their files, so that a replay on another machine starts where the
+// recording did. Names are anonymized.
+type RecordedSession struct {
+	Active int             `json:"active"` // Among Spaces
+	Spaces []RecordedSpace `json:"spaces"`
+}
+
+// RecordedSpace is a tab of a RecordedSession. Paths are relative to its
+// root and slash-separated.
+type RecordedSpace struct {
+	IncludeMode  bool            `json:"include_mode"`
+	Selections   []string        `json:"selections,omitempty"`
+	Deselections []string        `json:"deselections,omitempty"`
+	Expanded     []string        `json:"expanded,omitempty"`
+	Cursor       string          `json:"cursor,omitempty"`
+	Scroll       int             `json:"scroll,omitempty"`
+	Tree         []RecordedEntry `json:"tree"`
+}
+
+// RecordedEntry is a file or folder of a recorded tree. Excluded folders
+// are recorded without their contents.
+type RecordedEntry struct {
+	Path     string `json:"path"`
+	Dir      bool   `json:"dir,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+	Excluded bool   `json:"excluded,omitempty"`
+}
+
+// Recorder runs an AppModel and writes every key press and resize to a
+// recording, each followed by a snapshot of the resulting state.
+type Recorder struct {
+	model AppModel
+	enc   *json.Encoder
+	start time.Time
+	names *nameAnonymizer
+	Err   error // First write error; recording stops at it
+}
+
+// NewRecorder records m to w, starting with the header line, which holds
+// the session and the files of its visible tabs.
+func NewRecorder(m AppModel, w io.Writer) *Recorder {
+	r := &Recorder{model: m, enc: json.NewEncoder(w), start: time.Now(), names: newNameAnonymizer()}
+	r.write(recordingHeader{Version: recordingVersion, Started: r.start.UTC(), Session: r.names.session(m.Session, m.Dirs)})
+	return r
+}
+
+// Model returns the recorded model as it is now.
+func (r *Recorder) Model() AppModel {
+	return r.model
+}
+
+func (r *Recorder) Init() tea.Cmd {
+	return r.model.Init()
+}
+
+func (r *Recorder) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	event := RecordedEvent{At: time.Since(r.start).Milliseconds()}
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		key := recordKey(msg, r.model.typing())
+		event.Key = &key
+	case tea.WindowSizeMsg:
+		event.Size = []int{msg.Width, msg.Height}
+	}
+
+	next, cmd := r.model.Update(msg)
+	r.model = next.(AppModel)
+	if event.Key != nil || event.Size != nil {
+		r.write(event)
+		snapshot := r.model.snapshot(r.names.path)
+		r.write(RecordedEvent{At: event.At, Snapshot: &snapshot})
+	}
+	return r, cmd
+}
+
+func (r *Recorder) View() string {
+	return r.model.View()
+}
+
+func (r *Recorder) write(v any) {
+	if r.Err == nil {
+		r.Err = r.enc.Encode(v)
+	}
+}
+
+// recordKey turns msg into a RecordedKey, anonymizing it while typing.
+func recordKey(msg tea.KeyMsg, typing bool) RecordedKey {
+	key := RecordedKey{Type: msg.Type, Runes: string(msg.Runes), Alt: msg.Alt}
+	if typing {
+		key.Runes = strings.Map(func(r rune) rune {
+			switch {
+			case unicode.IsUpper(r):
+				return 'A'
+			case unicode.IsLetter(r):
+				return 'a'
+			case unicode.IsDigit(r):
+				return '0'
+			}
+			return r
+		}, key.Runes)
+	}
+	return key
+}
+
+// msg is the key press the recorded key replays.
+func (k RecordedKey) msg() tea.KeyMsg {
+	return tea.KeyMsg{Type: k.Type, Runes: []rune(k.Runes), Alt: k.Alt}
+}
+
+// typing reports whether a text input has focus, so keys may be typed text.
+func (m AppModel) typing() bool {
+	focused := m.NewTabInput.Focused() || m.GlobalSearchInput.Focused() || m.GroupInput.Focused() ||
+		m.SessionInput.Focused() || m.PreviewSearchInput.Focused()
+	for _, ts := range m.TabStates {
+		focused = focused || ts.InputRoot.Focused() || ts.InputOutput.Focused() ||
+			ts.InputInclude.Focused() || ts.InputExclude.Focused() || ts.InputSearch.Focused()
+	}
+	return focused
+}
+
+// overlay names the dialog drawn over the main view, or "".
+func (m AppModel) overlay() string {
+	for _, o := range []struct {
+		shown bool
+		name  string
+	}{
+		{m.ShowNewTab, "new-tab"},
+		{m.ShowGlobalSearch, "global-search"},
+		{m.ShowConfirmOverwrite, "confirm-overwrite"},
+		{m.ProjectSuggestion != nil, "project-suggestion"},
+		{len(m.StartupWarnings) > 0, "startup-warnings"},
+		{m.Inspection != nil, "inspect"},
+		{m.ShowMessageLog, "message-log"},
+		{m.ShowOffenders, "offenders"},
+		{m.ShowCompare, "compare"},
+		{m.ShowNestedRepos, "nested-repos"},
+		{m.ShowPreview, "preview"},
+		{m.ShowExportDiff, "export-diff"},
+		{m.ShowStale, "stale"},
+		{m.ShowSessions, "sessions"},
+		{m.ShowSessionInput, "session-input"},
+		{m.ShowThemes, "themes"},
+		{m.ShowTasks, "tasks"},
+		{m.ShowGroups, "groups"},
+		{m.ShowGroupInput, "group-input"},
+		{m.ShowHelp, "help"},
+	} {
+		if o.shown {
+			return o.name
+		}
+	}
+	return ""
+}
+
+// snapshot captures the state a replay is compared on, with paths turned
+// into their recorded form by anonymize.
+func (m AppModel) snapshot(anonymize func(root, path string) string) ModelSnapshot {
+	visible := m.Session.VisibleSpaces()
+	s := ModelSnapshot{Tabs: len(visible), Tab: -1, Overlay: m.overlay()}
+	space := m.Session.GetActiveSpace()
+	if space == nil {
+		return s
+	}
+	for i, v := range visible {
+		if v.ID == space.ID {
+			s.Tab = i
+		}
+	}
+	s.Selected = len(space.Config.ManualSelections)
+	s.Deselected = len(space.Config.ManualDeselections)
+	if state := m.TabStates[space.ID]; state != nil {
+		s.Visible = len(state.VisibleNodes)
+		s.Cursor = state.CursorIndex
+		s.Input, s.Option = state.ActiveInput, state.ActiveOption
+		if state.CursorIndex >= 0 && state.CursorIndex < len(state.VisibleNodes) {
+			s.CursorPath = anonymize(space.RootPath, state.VisibleNodes[state.CursorIndex].FullPath)
+		}
+	}
+	return s
+}
+
+// nameAnonymizer replaces the names of recorded paths. Files and folders of
+// the recorded trees become their position among their siblings, as in
+// "00003.go", which keeps the order the tree shows them in. Any other name,
+// such as of a file created while recording, becomes a hash keyed by a
+// random key that is never written, so names can't be recovered by hashing
+// guesses.
+type nameAnonymizer struct {
+	key   []byte
+	known map[string]string // Path of a recorded entry to its anonymized path
+}
+
+func newNameAnonymizer() *nameAnonymizer {
+	key := make([]byte, 32)
+	_, _ = rand.Read(key)
+	return &nameAnonymizer{key: key, known: make(map[string]string)}
+}
+
+// hash anonymizes a name not in the recorded trees, keeping its extension.
+func (a *nameAnonymizer) hash(name string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(name))
+	return hex.EncodeToString(mac.Sum(nil))[:12] + filepath.Ext(name)
+}
+
+// path anonymizes path, relative to root and slash-separated.
+func (a *nameAnonymizer) path(root, path string) string {
+	if rel, err := filepath.Rel(root, path); err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return "."
+	}
+	var rest []string
+	for p := path; p != root; p = filepath.Dir(p) {
+		if token, ok := a.known[p]; ok {
+			return strings.Join(append([]string{token}, rest...), "/")
+		}
+		rest = append([]string{a.hash(filepath.Base(p))}, rest...)
+	}
+	return strings.Join(rest, "/")
+}
+
+// paths anonymizes each of paths under root, dropping those outside it.
+func (a *nameAnonymizer) paths(root string, paths []string) []string {
+	var out []string
+	for _, p := range paths {
+		if rel, err := filepath.Rel(root, p); err == nil && filepath.IsLocal(rel) {
+			out = append(out, a.path(root, p))
+		}
+	}
+	return out
+}
+
+// session records the visible tabs of session, listing their folders
+// through dirs.
+func (a *nameAnonymizer) session(session *core.Session, dirs *core.DirCache) *RecordedSession {
+	rs := &RecordedSession{}
+	active := session.GetActiveSpace()
+	for i, space := range session.VisibleSpaces() {
+		if space == active {
+			rs.Active = i
+		}
+		// The tree first, so the paths below use its names
+		recorded := RecordedSpace{Tree: a.tree(dirs, space.RootPath, space.Config)}
+		recorded.IncludeMode = space.Config.IncludeMode
+		recorded.Selections = a.paths(space.RootPath, space.Config.ManualSelections)
+		recorded.Deselections = a.paths(space.RootPath, space.Config.ManualDeselections)
+		recorded.Expanded = a.paths(space.RootPath, space.ExpandedPaths)
+		if space.CursorPath != "" {
+			recorded.Cursor = a.path(space.RootPath, space.CursorPath)
+		}
+		recorded.Scroll = space.ScrollOffset
+		rs.Spaces = append(rs.Spaces, recorded)
+	}
+	return rs
+}
+
+// tree lists what is under root in the order the tree shows it. Excluded
+// folders and .git are listed without their contents.
+func (a *nameAnonymizer) tree(dirs *core.DirCache, root string, cfg core.ExtractionConfig) []RecordedEntry {
+	entries := []RecordedEntry{}
+	var walk func(dir, token string)
+	walk = func(dir, token string) {
+		list, err := dirs.List(dir)
+		if err != nil {
+			return
+		}
+		for i, e := range list {
+			if len(entries) >= maxRecordedEntries {
+				return
+			}
+			name := fmt.Sprintf("%05d", i+1)
+			if !e.IsDir {
+				name += filepath.Ext(e.Name)
+			}
+			if token != "" {
+				name = token + "/" + name
+			}
+			a.known[e.FullPath] = name
+			rel, _ := filepath.Rel(root, e.FullPath)
+			entry := RecordedEntry{Path: name, Dir: e.IsDir, Excluded: cfg.Excludes(rel)}
+			if !e.IsDir {
+				entry.Size = e.Size
+			}
+			entries = append(entries, entry)
+			if e.IsDir && !entry.Excluded && e.Name != ".git" {
+				walk(e.FullPath, name)
+			}
+		}
+	}
+	walk(root, "")
+	return entries
+}
+
+// Recording is a recorded TUI run, loaded for replay.
+type Recording struct {
+	Started time.Time
+	Session *RecordedSession
+	Events  []RecordedEvent
+}
+
+// LoadRecording reads a recording written by a Recorder.
+func LoadRecording(r io.Reader) (*Recording, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 64<<20) // The header holds whole trees
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("empty recording")
+	}
+	var header recordingHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.Version == 0 {
+		return nil, fmt.Errorf("not a PandaBrew recording")
+	}
+	if header.Version > recordingVersion {
+		return nil, fmt.Errorf("recording version %d is newer than this PandaBrew supports", header.Version)
+	}
+	if header.Version < recordingVersion || header.Session == nil {
+		return nil, fmt.Errorf("recording version %d holds no session to replay from; record it again", header.Version)
+	}
+
+	rec := &Recording{Started: header.Started, Session: header.Session}
+	for line := 2; scanner.Scan(); line++ {
+		var event RecordedEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		rec.Events = append(rec.Events, event)
+	}
+	return rec, scanner.Err()
+}
+
+// Restore recreates the recorded tabs under dir, one folder per tab, and
+// returns a session of them. Files are sparse, of their recorded size, so
+// the tree and its sizes match what was recorded but contents do not.
+func (rec *Recording) Restore(dir string) (*core.Session, error) {
+	session := &core.Session{}
+	for i, rs := range rec.Session.Spaces {
+		root := filepath.Join(dir, fmt.Sprintf("tab%d", i+1))
+		if err := os.MkdirAll(root, 0o755); err != nil {
+			return nil, err
+		}
+		abs := func(rels []string) []string {
+			var paths []string
+			for _, rel := range rels {
+				if !filepath.IsLocal(filepath.FromSlash(rel)) {
+					continue
+				}
+				paths = append(paths, filepath.Join(root, filepath.FromSlash(rel)))
+			}
+			return paths
+		}
+
+		cfg := core.DefaultExtractionConfig()
+		cfg.ExcludePatterns, cfg.SkipJunk = nil, false // Names no longer match them
+		cfg.IncludeMode = rs.IncludeMode
+		cfg.ManualSelections, cfg.ManualDeselections = abs(rs.Selections), abs(rs.Deselections)
+		for _, e := range rs.Tree {
+			path := filepath.Join(root, filepath.FromSlash(e.Path))
+			if !filepath.IsLocal(filepath.FromSlash(e.Path)) {
+				return nil, fmt.Errorf("recorded path outside the tree: %s", e.Path)
+			}
+			if e.Excluded {
+				cfg.ExcludePatterns = append(cfg.ExcludePatterns, core.ExcludePatternFor(e.Path, e.Dir))
+			}
+			if err := restoreEntry(path, e); err != nil {
+				return nil, err
+			}
+		}
+		space := &core.DirectorySpace{
+			ID:             fmt.Sprint(i + 1),
+			RootPath:       root,
+			OutputFilePath: filepath.Join(dir, fmt.Sprintf("report%d.txt", i+1)),
+			Config:         cfg,
+			ExpandedPaths:  abs(rs.Expanded),
+			ScrollOffset:   rs.Scroll,
+		}
+		if cursor := abs([]string{rs.Cursor}); rs.Cursor != "" && len(cursor) == 1 {
+			space.CursorPath = cursor[0]
+		}
+		session.Spaces = append(session.Spaces, space)
+		if i == rec.Session.Active {
+			session.ActiveSpaceID = space.ID
+		}
+	}
+	return session, nil
+}
+
+// restoreEntry creates the file or folder e at path.
+func restoreEntry(path string, e RecordedEntry) error {
+	if e.Dir {
+		return os.MkdirAll(path, 0o755)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(e.Size); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// replayStepMsg replays the event at Index of the recording.
+type replayStepMsg struct{ Index int }
+
+// Replayer runs an AppModel through the key presses and resizes of a
+// recording at their recorded pace, checking each resulting state against
+// the recorded snapshot. Keys pressed meanwhile are ignored, except ctrl+c.
+// Exports are dry runs, so a replay writes no report.
+type Replayer struct {
+	model      AppModel
+	rec        *Recording
+	Mismatches []string // One per step whose state differs from the recording
+	Done       bool
+}
+
+// NewReplayer replays rec on m, which should be opened on the session
+// rec.Restore returns, and is made read-only.
+func NewReplayer(m AppModel, rec *Recording) *Replayer {
+	m.ReadOnly = true
+	return &Replayer{model: m, rec: rec}
+}
+
+// Model returns the replayed model as it is now.
+func (r *Replayer) Model() AppModel {
+	return r.model
+}
+
+func (r *Replayer) Init() tea.Cmd {
+	return tea.Batch(r.model.Init(), r.next(0, 0))
+}
+
+// next schedules the first input event at or after index, waiting as long
+// as the recording did since the event at previous milliseconds.
+func (r *Replayer) next(index int, previous int64) tea.Cmd {
+	for index < len(r.rec.Events) && r.rec.Events[index].Snapshot != nil {
+		index++
+	}
+	if index == len(r.rec.Events) {
+		return func() tea.Msg { return replayStepMsg{Index: index} }
+	}
+	gap := min(time.Duration(r.rec.Events[index].At-previous)*time.Millisecond, maxReplayGap)
+	return tea.Tick(max(gap, time.Millisecond), func(time.Time) tea.Msg { return replayStepMsg{Index: index} })
+}
+
+func (r *Replayer) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		if msg.Type == tea.KeyCtrlC {
+			return r, tea.Quit
+		}
+		if !r.Done {
+			return r, nil
+		}
+	case replayStepMsg:
+		return r, r.step(msg.Index)
+	}
+	next, cmd := r.model.Update(msg)
+	r.model = next.(AppModel)
+	return r, cmd
+}
+
+// step replays the event at index and checks the state after it.
+func (r *Replayer) step(index int) tea.Cmd {
+	if index >= len(r.rec.Events) {
+		r.Done = true
+		text := "Replay finished, every state matched"
+		severity := SeverityInfo
+		if len(r.Mismatches) > 0 {
+			text = fmt.Sprintf("Replay finished with %d mismatched states", len(r.Mismatches))
+			severity = SeverityWarn
+		}
+		r.model.notify(severity, text)
+		return nil
+	}
+
+	event := r.rec.Events[index]
+	var msg tea.Msg
+	if event.Key != nil {
+		msg = event.Key.msg()
+	} else {
+		msg = tea.WindowSizeMsg{Width: event.Size[0], Height: event.Size[1]}
+	}
+	next, cmd := r.model.Update(msg)
+	r.model = next.(AppModel)
+
+	if index+1 < len(r.rec.Events) {
+		if want := r.rec.Events[index+1].Snapshot; want != nil {
+			if got := r.model.snapshot(restoredPath); got != *want {
+				r.Mismatches = append(r.Mismatches, fmt.Sprintf("at %.1fs: got %+v, recorded %+v", float64(event.At)/1000, got, *want))
+			}
+		}
+	}
+	return tea.Batch(cmd, r.next(index+1, event.At))
+}
+
+func (r *Replayer) View() string {
+	return r.model.View()
+}
+
+// restoredPath is the recorded form of a path in a restored tree, whose
+// names are anonymized already.
+func restoredPath(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return "."
+	}
+	return filepath.ToSlash(rel)
+}
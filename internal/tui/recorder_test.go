@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRecordReplay(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"alpha.go", "beta.go", "secret_plans.md"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("package x\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	open := func(session *core.Session) AppModel {
+		m := InitialModel(session, nil)
+		next, _ := m.Update(loadDirectoryCmd(m.Dirs, session.GetActiveSpace().RootPath)())
+		return next.(AppModel)
+	}
+	space := &core.DirectorySpace{ID: "a", RootPath: root, Config: core.DefaultExtractionConfig()}
+	space.Config.ManualSelections = []string{filepath.Join(root, "beta.go")}
+	keys := []tea.Msg{
+		tea.WindowSizeMsg{Width: 120, Height: 40},
+		tea.KeyMsg{Type: tea.KeyDown},
+		tea.KeyMsg{Type: tea.KeyDown},
+		tea.KeyMsg{Type: tea.KeyDown},
+		tea.KeyMsg{Type: tea.KeyCtrlN},
+		tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("Secret9")},
+		tea.KeyMsg{Type: tea.KeyEsc},
+	}
+
+	var buf bytes.Buffer
+	recorder := NewRecorder(open(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}), &buf)
+	for _, msg := range keys {
+		recorder.Update(msg)
+	}
+	if recorder.Err != nil {
+		t.Fatal(recorder.Err)
+	}
+	for _, leak := range []string{"Secret", "secret_plans", "alpha", root} {
+		if strings.Contains(buf.String(), leak) {
+			t.Fatalf("recording leaks %q:\n%s", leak, buf.String())
+		}
+	}
+	if !strings.Contains(buf.String(), `"runes":"Aaaaaa0"`) || !strings.Contains(buf.String(), `"cursor_path":"00003.md"`) {
+		t.Errorf("typed text or names not kept in shape:\n%s", buf.String())
+	}
+
+	// A replay recreates the tree elsewhere and runs on it read-only
+	replay := func(rec *Recording) *Replayer {
+		session, err := rec.Restore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		r := NewReplayer(open(session), rec)
+		for i, event := range rec.Events {
+			if event.Snapshot == nil {
+				r.Update(replayStepMsg{Index: i})
+			}
+		}
+		r.Update(replayStepMsg{Index: len(rec.Events)})
+		return r
+	}
+	rec, err := LoadRecording(bytes.NewReader(buf.Bytes()))
+	if err != nil || len(rec.Events) != 2*len(keys) {
+		t.Fatalf("loaded %d events, %v", len(rec.Events), err)
+	}
+	r := replay(rec)
+	if !r.Done || len(r.Mismatches) != 0 {
+		t.Errorf("replay mismatched: %v", r.Mismatches)
+	}
+	m := r.Model()
+	if s := m.snapshot(restoredPath); s.Cursor != 3 || s.Overlay != "" || s.Selected != 1 {
+		t.Errorf("replayed state = %+v", s)
+	}
+	replayed := m.Session.GetActiveSpace()
+	if info, err := os.Stat(filepath.Join(replayed.RootPath, "00002.go")); err != nil || info.Size() != int64(len("package x\n")) || !m.ReadOnly {
+		t.Errorf("restored file: %v, %v; read-only %v", info, err, m.ReadOnly)
+	}
+
+	rec.Events[3].Snapshot.Cursor = 0
+	if r := replay(rec); len(r.Mismatches) != 1 {
+		t.Errorf("altered snapshot gave %d mismatches, want 1", len(r.Mismatches))
+	}
+	if _, err := LoadRecording(strings.NewReader("{}\n")); err == nil {
+		t.Error("loaded a file that is not a recording")
+	}
+	if _, err := LoadRecording(strings.NewReader(`{"pandabrew_recording":1}` + "\n")); err == nil {
+		t.Error("loaded a recording without a session")
+	}
+}
@@ -0,0 +1,209 @@
+// Package tui implements tree search across collapsed and unloaded directories.
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// hiddenMatch is a search match that is not in the visible tree.
+type hiddenMatch struct {
+	path  string
+	isDir bool
+}
+
+// nameMatches reports whether name contains the lower-cased query.
+func nameMatches(name, query string) bool {
+	return strings.Contains(strings.ToLower(name), query)
+}
+
+// countHiddenMatches finds the matches that are not visible, from the loaded
+// tree and from IndexedFiles, and files each under the visible collapsed
+// directory that holds it.
+func (ts *TabState) countHiddenMatches(query string) {
+	ts.HiddenMatches = make(map[*TreeNode]int)
+	ts.hiddenPaths = make(map[*TreeNode][]hiddenMatch)
+
+	visible := make(map[string]*TreeNode, len(ts.VisibleNodes))
+	for _, n := range ts.VisibleNodes {
+		visible[n.FullPath] = n
+	}
+	seen := make(map[string]bool)
+	add := func(path string, isDir bool) {
+		if seen[path] || visible[path] != nil {
+			return
+		}
+		seen[path] = true
+		for p := filepath.Dir(path); p != filepath.Dir(p); p = filepath.Dir(p) {
+			if holder := visible[p]; holder != nil {
+				ts.hiddenPaths[holder] = append(ts.hiddenPaths[holder], hiddenMatch{path, isDir})
+				return
+			}
+		}
+	}
+
+	var walk func(*TreeNode)
+	walk = func(n *TreeNode) {
+		for _, c := range n.Children {
+			if nameMatches(c.Name, query) {
+				add(c.FullPath, c.IsDir)
+			}
+			walk(c)
+		}
+	}
+	if ts.TreeRoot != nil {
+		walk(ts.TreeRoot)
+	}
+	for _, f := range ts.IndexedFiles {
+		if nameMatches(filepath.Base(f), query) {
+			add(f, false)
+		}
+	}
+
+	for holder, paths := range ts.hiddenPaths {
+		slices.SortFunc(paths, compareTreeOrder)
+		ts.HiddenMatches[holder] = len(paths)
+	}
+}
+
+// hiddenMatchCount totals the matches inside collapsed directories.
+func (ts *TabState) hiddenMatchCount() int {
+	n := 0
+	for _, c := range ts.HiddenMatches {
+		n += c
+	}
+	return n
+}
+
+// compareTreeOrder orders paths the way the tree lists them: depth first,
+// folders before files, then by name.
+func compareTreeOrder(a, b hiddenMatch) int {
+	as := strings.Split(a.path, string(filepath.Separator))
+	bs := strings.Split(b.path, string(filepath.Separator))
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if as[i] == bs[i] {
+			continue
+		}
+		aDir := i < len(as)-1 || a.isDir
+		bDir := i < len(bs)-1 || b.isDir
+		if aDir != bDir {
+			if aDir {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(as[i], bs[i])
+	}
+	return len(as) - len(bs)
+}
+
+// jumpToMatch moves the cursor to the next (or previous) match. Collapsed
+// directories holding matches are stops too: landing on one expands the
+// path to its first match, or its last when moving backwards. The returned
+// command loads the directories on that path that were never opened.
+func (ts *TabState) jumpToMatch(dirs *core.DirCache, forward bool) tea.Cmd {
+	if ts.SearchQuery == "" || len(ts.VisibleNodes) == 0 {
+		return nil
+	}
+	query := strings.ToLower(ts.SearchQuery)
+
+	// A matching directory that is itself collapsed is entered next
+	if cur := ts.VisibleNodes[ts.CursorIndex]; forward && ts.HiddenMatches[cur] > 0 {
+		return ts.revealMatch(dirs, cur, true)
+	}
+
+	n := len(ts.VisibleNodes)
+	step := 1
+	if !forward {
+		step = n - 1
+	}
+	for i, k := (ts.CursorIndex+step)%n, 0; k < n; i, k = (i+step)%n, k+1 {
+		node := ts.VisibleNodes[i]
+		if ts.HiddenMatches[node] > 0 && (!forward || !nameMatches(node.Name, query)) {
+			return ts.revealMatch(dirs, node, forward)
+		}
+		if nameMatches(node.Name, query) {
+			ts.setCursor(i)
+			return nil
+		}
+	}
+	return nil
+}
+
+// revealMatch expands dir down to its first or last hidden match and moves
+// the cursor there. When part of that path is not loaded yet, the expansion
+// is left to the restoration targets and a load of dir is returned.
+func (ts *TabState) revealMatch(dirs *core.DirCache, dir *TreeNode, first bool) tea.Cmd {
+	paths := ts.hiddenPaths[dir]
+	if len(paths) == 0 {
+		return nil
+	}
+	target := paths[len(paths)-1].path
+	if first {
+		target = paths[0].path
+	}
+
+	var node *TreeNode
+	var find func(*TreeNode)
+	find = func(n *TreeNode) {
+		for _, c := range n.Children {
+			if node != nil {
+				return
+			}
+			if c.FullPath == target {
+				node = c
+			}
+			find(c)
+		}
+	}
+	find(dir)
+
+	if node == nil {
+		dir.Expanded = true
+		for p := filepath.Dir(target); p != dir.FullPath && p != filepath.Dir(p); p = filepath.Dir(p) {
+			ts.TargetExpandedPaths[p] = true
+		}
+		ts.TargetCursorPath = target
+		return loadDirectoryCmd(dirs, dir.FullPath)
+	}
+
+	for p := node.Parent; p != nil && p != dir.Parent; p = p.Parent {
+		p.Expanded = true
+	}
+	ts.rebuildVisibleList()
+	if i := slices.Index(ts.VisibleNodes, node); i >= 0 {
+		ts.setCursor(i)
+	}
+	return nil
+}
+
+// scrollWindow returns the first tree row shown in a window of rows lines.
+// The window only moves from ScrollOffset as far as needed to keep the
+// cursor in view, so it stays put across redraws and tab switches.
+// followCursor stores the result; View only reads it.
+func (ts *TabState) scrollWindow(rows int) int {
+	total := len(ts.VisibleNodes)
+	if rows <= 0 || total <= rows {
+		return 0
+	}
+	offset := ts.ScrollOffset
+	if ts.CursorIndex < offset {
+		offset = ts.CursorIndex
+	} else if ts.CursorIndex >= offset+rows {
+		offset = ts.CursorIndex - rows + 1
+	}
+	return max(0, min(offset, total-rows))
+}
+
+// setCursor moves the cursor to a visible index and syncs MatchPtr.
+func (ts *TabState) setCursor(i int) {
+	ts.CursorIndex = i
+	if p := slices.Index(ts.MatchIndices, i); p >= 0 {
+		ts.MatchPtr = p
+	}
+}
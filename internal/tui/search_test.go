@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestSearchCollapsedDirs(t *testing.T) {
+	root := &TreeNode{Name: "root", FullPath: "/r", IsDir: true, Expanded: true}
+	add := func(parent *TreeNode, name string, dir bool) *TreeNode {
+		n := &TreeNode{Name: name, FullPath: parent.FullPath + "/" + name, IsDir: dir, Parent: parent}
+		parent.Children = append(parent.Children, n)
+		return n
+	}
+	src := add(root, "src", true)
+	lib := add(src, "lib", true)
+	add(lib, "match_a.go", false)
+	add(src, "other.go", false)
+	add(src, "match_b.go", false)
+	add(root, "match_c.go", false)
+
+	ts := &TabState{TreeRoot: root, SearchQuery: "match"}
+	ts.rebuildVisibleList()
+	if got := ts.HiddenMatches[src]; got != 2 {
+		t.Fatalf("badge on src = %d, want 2", got)
+	}
+
+	ts.jumpToMatch(core.NewDirCache(), true)
+	if node := ts.VisibleNodes[ts.CursorIndex]; node.Name != "match_a.go" || !lib.Expanded {
+		t.Fatalf("jumped to %s, want match_a.go inside an expanded lib", node.Name)
+	}
+	if len(ts.HiddenMatches) != 0 {
+		t.Errorf("badges left after expanding: %v", ts.HiddenMatches)
+	}
+	ts.jumpToMatch(core.NewDirCache(), true)
+	ts.jumpToMatch(core.NewDirCache(), true)
+	if node := ts.VisibleNodes[ts.CursorIndex]; node.Name != "match_c.go" {
+		t.Errorf("third jump landed on %s", node.Name)
+	}
+	ts.jumpToMatch(core.NewDirCache(), false)
+	if node := ts.VisibleNodes[ts.CursorIndex]; node.Name != "match_b.go" {
+		t.Errorf("jump back landed on %s", node.Name)
+	}
+
+	// Matches in folders that were never opened come from the file index
+	deep := add(root, "deep", true)
+	ts.IndexedFiles = []string{"/r/deep/x/match_d.go", "/r/deep/other.go"}
+	ts.TargetExpandedPaths = make(map[string]bool)
+	ts.rebuildVisibleList()
+	if got := ts.HiddenMatches[deep]; got != 1 {
+		t.Fatalf("badge on deep = %d, want 1", got)
+	}
+	ts.CursorIndex = slices.Index(ts.VisibleNodes, deep)
+	if cmd := ts.jumpToMatch(core.NewDirCache(), true); cmd == nil {
+		t.Fatal("no load issued for an unloaded match")
+	}
+	if ts.TargetCursorPath != "/r/deep/x/match_d.go" || !ts.TargetExpandedPaths["/r/deep/x"] || !deep.Expanded {
+		t.Errorf("targets = %q %v, deep expanded %v", ts.TargetCursorPath, ts.TargetExpandedPaths, deep.Expanded)
+	}
+}
@@ -0,0 +1,53 @@
+// Package tui implements selecting every listed file, as opposed to the
+// root folder.
+package tui
+
+import (
+	"context"
+	"fmt"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SelectFilesMsg carries every file listed under the root of a space.
+type SelectFilesMsg struct {
+	SpaceID string
+	Paths   []string
+	Err     error
+}
+
+func selectFilesCmd(space *core.DirectorySpace) tea.Cmd {
+	id, root, cfg := space.ID, space.RootPath, space.Config.Clone()
+	return func() tea.Msg {
+		paths, err := core.ListedFiles(context.Background(), root, cfg)
+		return SelectFilesMsg{SpaceID: id, Paths: paths, Err: err}
+	}
+}
+
+// applySelectFiles replaces the selection of the space with the listed
+// files. Unlike selecting the root, files created later stay unselected.
+// Checked files are left out in exclude mode, so the space switches to
+// include mode. The replaced selection can be restored with UndoSelect.
+func (m *AppModel) applySelectFiles(msg SelectFilesMsg) {
+	space := m.spaceByID(msg.SpaceID)
+	switch {
+	case space == nil:
+		return
+	case msg.Err != nil:
+		m.notify(SeverityError, tr("Listing files failed: ")+msg.Err.Error())
+		return
+	}
+	m.pushSelectionUndo(space, "selecting every listed file")
+	switched := !space.Config.IncludeMode
+	space.Config.IncludeMode = true
+	space.Config.ManualSelections = msg.Paths
+	space.Config.ManualDeselections = nil
+	_ = m.Sessions.Save(m.Session)
+	text := fmt.Sprintf(tr("✓ Selected %d listed files; new files stay unselected, ctrl+z undoes"), len(msg.Paths))
+	if switched {
+		text += tr(" (switched to include mode)")
+	}
+	m.notify(SeverityInfo, text)
+}
@@ -0,0 +1,46 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSelectFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "b.log"} {
+		if err := os.WriteFile(filepath.Join(root, name), []byte("x\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	space := &core.DirectorySpace{ID: "a", RootPath: root, Config: core.ExtractionConfig{ExcludePatterns: []string{"*.log"}, ManualSelections: []string{root}}}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+	m = updated.(AppModel)
+	if !m.Loading || cmd == nil {
+		t.Fatal("listing files did not start")
+	}
+	updated, _ = m.Update(selectFilesCmd(space)())
+	m = updated.(AppModel)
+	if want := []string{filepath.Join(root, "a.go")}; !slices.Equal(space.Config.ManualSelections, want) || !space.Config.IncludeMode {
+		t.Errorf("selections = %v in include mode %v, want %v", space.Config.ManualSelections, space.Config.IncludeMode, want)
+	}
+	if last := m.MessageLog[len(m.MessageLog)-1].Text; !strings.Contains(last, "switched to include mode") {
+		t.Errorf("mode change not told: %q", last)
+	}
+
+	// ctrl+z brings back the exclude-mode selection
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlZ})
+	m = updated.(AppModel)
+	if space.Config.IncludeMode || !slices.Equal(space.Config.ManualSelections, []string{root}) {
+		t.Errorf("undone config = %+v", space.Config)
+	}
+}
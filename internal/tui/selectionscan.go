@@ -0,0 +1,121 @@
+// Package tui implements scanning a folder in the background once it is
+// selected, so big toggles never block the interface.
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// largeSelectionFiles is the file count beyond which a selected folder is
+// reported as a warning.
+const largeSelectionFiles = 2000
+
+// SelectionScanProgressMsg reports how many files the scan of Path has seen.
+type SelectionScanProgressMsg struct {
+	Path  string
+	Files int
+	ch    <-chan tea.Msg
+}
+
+// SelectionScanDoneMsg carries the totals of a selected folder.
+type SelectionScanDoneMsg struct {
+	Path     string
+	Patterns string
+	Entry    core.IndexEntry
+	Err      error
+}
+
+// scanSelectionCmd walks the folder at path in the background. Progress is
+// relayed through a channel like the indexer's.
+func scanSelectionCmd(ctx context.Context, root, path string, cfg core.ExtractionConfig) tea.Cmd {
+	patterns := estimatePatterns(cfg)
+	ch := make(chan tea.Msg, 1)
+	go func() {
+		defer close(ch)
+		entry, err := core.EstimateDir(ctx, root, path, cfg, func(files int) {
+			select {
+			case ch <- SelectionScanProgressMsg{Path: path, Files: files, ch: ch}:
+			default: // UI is behind; drop this update
+			}
+		})
+		ch <- SelectionScanDoneMsg{Path: path, Patterns: patterns, Entry: entry, Err: err}
+	}()
+	return waitForIndex(ch)
+}
+
+// scanSelection reports the size of the folder just selected, from the
+// index or an earlier estimate when one is current and by walking it
+// otherwise. Deselecting the folder being scanned stops the scan.
+func (m *AppModel) scanSelection(space *core.DirectorySpace, node *TreeNode) tea.Cmd {
+	if !node.IsDir {
+		return nil
+	}
+	if !core.IsChecked(space, node.FullPath) {
+		if m.ScanPath == node.FullPath {
+			m.cancelSelectionScan()
+		}
+		return nil
+	}
+
+	entry, ok := m.Indexes[space.RootPath].Lookup(node.FullPath)
+	if est, cached := m.DirEstimates[node.FullPath]; !ok && cached && est.done && est.patterns == estimatePatterns(space.Config) {
+		entry, ok = est.entry, true
+	}
+	if ok {
+		m.notifySelectionSize(space, node.Name, entry)
+		return nil
+	}
+
+	m.cancelSelectionScan()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.ScanCancel = cancel
+	m.ScanPath = node.FullPath
+	m.ScannedFiles = 0
+	return scanSelectionCmd(ctx, space.RootPath, node.FullPath, space.Config.Clone())
+}
+
+func (m *AppModel) cancelSelectionScan() {
+	if m.ScanCancel != nil {
+		m.ScanCancel()
+	}
+	m.ScanCancel = nil
+	m.ScanPath = ""
+}
+
+// finishSelectionScan records the totals of a scan and reports them.
+func (m *AppModel) finishSelectionScan(space *core.DirectorySpace, msg SelectionScanDoneMsg) {
+	if m.ScanPath == msg.Path {
+		m.ScanCancel = nil
+		m.ScanPath = ""
+	}
+	switch {
+	case errors.Is(msg.Err, context.Canceled):
+	case msg.Err != nil:
+		m.notify(SeverityError, tr("Scan failed: ")+msg.Err.Error())
+	default:
+		m.DirEstimates[msg.Path] = dirEstimate{patterns: msg.Patterns, entry: msg.Entry, done: true}
+		m.notifySelectionSize(space, filepath.Base(msg.Path), msg.Entry)
+	}
+}
+
+// notifySelectionSize reports the totals of a selected folder, warning
+// when a large one is exported.
+func (m *AppModel) notifySelectionSize(space *core.DirectorySpace, name string, entry core.IndexEntry) {
+	text := fmt.Sprintf("%s/: %d files (~%s tokens)", name, entry.Files, core.FormatTokens(entry.Tokens))
+	if space == nil || !space.Config.IncludeMode {
+		m.notify(SeverityInfo, tr("Left out ")+text)
+		return
+	}
+	if entry.Files >= largeSelectionFiles {
+		m.notify(SeverityWarn, tr("Large selection ")+text)
+		return
+	}
+	m.notify(SeverityInfo, tr("Selected ")+text)
+}
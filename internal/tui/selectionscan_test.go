@@ -0,0 +1,50 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestSelectionScan(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "vendor"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "vendor", "a.go"), []byte("package x\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	space := &core.DirectorySpace{ID: "a", RootPath: root, Config: core.ExtractionConfig{IncludeMode: true}}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	vendor := &TreeNode{Name: "vendor", FullPath: filepath.Join(root, "vendor"), IsDir: true}
+
+	toggleSelection(space, vendor.FullPath)
+	cmd := m.scanSelection(space, vendor)
+	if cmd == nil || m.ScanPath != vendor.FullPath {
+		t.Fatal("selecting a folder started no scan")
+	}
+	done, ok := cmd().(SelectionScanDoneMsg)
+	if !ok || done.Entry.Files != 1 {
+		t.Fatalf("scan result = %+v", done)
+	}
+	m.finishSelectionScan(space, done)
+	if m.ScanCancel != nil || !m.DirEstimates[vendor.FullPath].done {
+		t.Error("finished scan not recorded")
+	}
+
+	// The recorded totals answer the next toggle without a walk
+	if m.scanSelection(space, vendor) != nil {
+		t.Error("scanned again despite a current estimate")
+	}
+
+	// Deselecting the folder being scanned stops the scan
+	delete(m.DirEstimates, vendor.FullPath)
+	m.scanSelection(space, vendor)
+	toggleSelection(space, vendor.FullPath)
+	m.scanSelection(space, vendor)
+	if m.ScanCancel != nil || m.ScanPath != "" {
+		t.Error("scan kept running after deselecting")
+	}
+}
@@ -0,0 +1,51 @@
+// Package tui implements the terminal user interface logic.
+package tui
+
+import (
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// switchSession saves the current session and replaces it with the named
+// one, rebuilding every tab. The current theme and background are kept.
+func (m *AppModel) switchSession(name string) tea.Cmd {
+	sm, err := core.NewNamedSessionManager(name)
+	if err != nil {
+		m.notify(SeverityError, tr("Error: ")+err.Error())
+		return nil
+	}
+	sm.Ephemeral = m.Sessions.Ephemeral
+	session, err := sm.Load()
+	if err != nil {
+		m.notify(SeverityError, tr("Error: ")+err.Error())
+		return nil
+	}
+
+	m.syncStateToSession()
+	_ = m.Sessions.Save(m.Session)
+
+	session.Theme, session.Transparent = m.Session.Theme, m.Session.Transparent
+	m.Session, m.Sessions = session, sm
+	m.TabStates = make(map[string]*TabState)
+	for _, space := range session.Spaces {
+		m.TabStates[space.ID] = newTabState(space, m.Styles)
+	}
+	_ = sm.Save(session)
+
+	cmds := []tea.Cmd{checkSpacesCmd(m.FS, session)}
+	for _, space := range session.Spaces {
+		if m.Indexes[space.RootPath] == nil {
+			cmds = append(cmds, loadIndexCmd(space.RootPath))
+		}
+		if _, ok := m.Branches[space.RootPath]; !ok {
+			cmds = append(cmds, loadBranchCmd(space.RootPath))
+		}
+		if _, ok := m.Vendored[space.RootPath]; !ok {
+			cmds = append(cmds, loadVendoredCmd(space))
+		}
+	}
+	cmds = append(cmds, m.loadActiveTreeCmd())
+	m.notify(SeverityInfo, tr("Switched to session: ")+sm.Name)
+	return tea.Batch(cmds...)
+}
@@ -0,0 +1,53 @@
+// Package tui implements the smart select action.
+package tui
+
+import (
+	"fmt"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SmartSelectMsg carries the starting selection proposed for a space.
+type SmartSelectMsg struct {
+	SpaceID string
+	Paths   []string
+	Err     error
+}
+
+func smartSelectCmd(space *core.DirectorySpace) tea.Cmd {
+	id, root, cfg := space.ID, space.RootPath, space.Config.Clone()
+	return func() tea.Msg {
+		paths, err := core.SmartSelection(root, cfg)
+		return SmartSelectMsg{SpaceID: id, Paths: paths, Err: err}
+	}
+}
+
+// applySmartSelection replaces the selection of the space with the proposed
+// one, switching to include mode so it reads as picked files. The replaced
+// selection can be restored with UndoSelect.
+func (m *AppModel) applySmartSelection(msg SmartSelectMsg) {
+	space := m.spaceByID(msg.SpaceID)
+	switch {
+	case space == nil:
+		return
+	case msg.Err != nil:
+		m.notify(SeverityError, tr("Smart select failed: ")+msg.Err.Error())
+		return
+	case len(msg.Paths) == 0:
+		m.notify(SeverityWarn, tr("Smart select found no source files"))
+		return
+	}
+	m.pushSelectionUndo(space, "smart select")
+	switched := !space.Config.IncludeMode
+	space.Config.IncludeMode = true
+	space.Config.ManualSelections = msg.Paths
+	space.Config.ManualDeselections = nil
+	_ = m.Sessions.Save(m.Session)
+	text := fmt.Sprintf(tr("✓ Smart-selected %d items; refine with space, ctrl+z undoes"), len(msg.Paths))
+	if switched {
+		text += tr(" (switched to include mode)")
+	}
+	m.notify(SeverityInfo, text)
+}
@@ -0,0 +1,34 @@
+package tui
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestApplySmartSelection(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r", Config: core.ExtractionConfig{ManualSelections: []string{"/r/old.go"}}}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+
+	m.applySmartSelection(SmartSelectMsg{SpaceID: "a"})
+	if !slices.Equal(space.Config.ManualSelections, []string{"/r/old.go"}) {
+		t.Errorf("an empty proposal replaced the selection: %v", space.Config.ManualSelections)
+	}
+	m.applySmartSelection(SmartSelectMsg{SpaceID: "a", Paths: []string{"/r/cmd", "/r/go.mod"}})
+	if !space.Config.IncludeMode || !slices.Equal(space.Config.ManualSelections, []string{"/r/cmd", "/r/go.mod"}) {
+		t.Errorf("config = %+v, want the proposal in include mode", space.Config)
+	}
+
+	// The replaced selection comes back with its mode
+	m.undoSelection(space)
+	if space.Config.IncludeMode || !slices.Equal(space.Config.ManualSelections, []string{"/r/old.go"}) {
+		t.Errorf("undone config = %+v", space.Config)
+	}
+	m.undoSelection(space)
+	if last := m.MessageLog[len(m.MessageLog)-1]; last.Text != "Nothing to undo" {
+		t.Errorf("second undo: %q", last.Text)
+	}
+}
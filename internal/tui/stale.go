@@ -0,0 +1,85 @@
+// Package tui implements the review dialog for selections that no longer exist.
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// StaleSelectionsMsg carries the missing selections of a space.
+type StaleSelectionsMsg struct {
+	SpaceID string
+	Stale   []core.StaleSelection
+	Manual  bool // Requested with a key rather than on load
+}
+
+func loadStaleSelectionsCmd(space *core.DirectorySpace, manual bool) tea.Cmd {
+	snapshot := *space
+	snapshot.Config = space.Config.Clone()
+	return func() tea.Msg {
+		return StaleSelectionsMsg{SpaceID: snapshot.ID, Stale: core.FindStaleSelections(&snapshot), Manual: manual}
+	}
+}
+
+// staleRows renders each missing selection with its chosen new location.
+func (m AppModel) staleRows() []string {
+	space := m.Session.GetActiveSpace()
+	if space == nil {
+		return nil
+	}
+	rel := func(path string) string {
+		if r, err := filepath.Rel(space.RootPath, path); err == nil {
+			return filepath.ToSlash(r)
+		}
+		return path
+	}
+	var rows []string
+	for i, s := range m.Stale {
+		target := "(no match)"
+		if len(s.Suggestions) > 0 {
+			choice := s.Suggestions[m.StaleChoice[i]]
+			target = rel(choice)
+			if choice == s.Moved {
+				target += " (same content)"
+			}
+			if len(s.Suggestions) > 1 {
+				target += fmt.Sprintf(" [%d/%d]", m.StaleChoice[i]+1, len(s.Suggestions))
+			}
+		}
+		rows = append(rows, fmt.Sprintf("%-36s → %s", rel(s.Path), target))
+	}
+	return rows
+}
+
+// resolveStale removes the row under the cursor and closes the dialog once
+// every missing selection has been dealt with.
+func (m *AppModel) resolveStale() {
+	m.Stale = slices.Delete(m.Stale, m.StaleCursor, m.StaleCursor+1)
+	m.StaleChoice = slices.Delete(m.StaleChoice, m.StaleCursor, m.StaleCursor+1)
+	m.StaleCursor = min(m.StaleCursor, max(0, len(m.Stale)-1))
+	if len(m.Stale) == 0 {
+		m.ShowStale = false
+	}
+}
+
+// remapMoved remaps every missing selection whose content was found at a new
+// path and returns how many were remapped.
+func (m *AppModel) remapMoved(space *core.DirectorySpace) int {
+	n := 0
+	for i := 0; i < len(m.Stale); {
+		if moved := m.Stale[i].Moved; moved != "" {
+			core.RemapSelection(space, m.Stale[i].Path, moved)
+			m.StaleCursor = i
+			m.resolveStale()
+			n++
+			continue
+		}
+		i++
+	}
+	return n
+}
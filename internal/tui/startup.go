@@ -0,0 +1,73 @@
+// Package tui implements the actions an --on-start script runs.
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// StartupCommands are the commands an --on-start script may use: the
+// control socket commands and quit.
+var StartupCommands = []string{"select", "deselect", "export", "switch-tab", "quit"}
+
+// StartupAction is one command of an --on-start script.
+type StartupAction struct {
+	Command string
+	Args    []string
+}
+
+// String renders the action as written in a script.
+func (a StartupAction) String() string {
+	return strings.Join(append([]string{a.Command}, a.Args...), " ")
+}
+
+// ParseStartupActions splits a script such as "select internal/**; export;
+// quit" into its actions. Commands are separated by semicolons and their
+// arguments by spaces; empty commands are skipped.
+func ParseStartupActions(script string) ([]StartupAction, error) {
+	var actions []StartupAction
+	for part := range strings.SplitSeq(script, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if !slices.Contains(StartupCommands, fields[0]) {
+			return nil, fmt.Errorf("unknown command %q (want one of %s)", fields[0], strings.Join(StartupCommands, ", "))
+		}
+		actions = append(actions, StartupAction{Command: fields[0], Args: fields[1:]})
+	}
+	return actions, nil
+}
+
+// StartupActionsMsg starts the --on-start script once the program runs.
+type StartupActionsMsg struct{}
+
+// runStartupActions runs the queued startup actions in order. An export
+// pauses the queue until it completes; a failing action drops the rest.
+func (m *AppModel) runStartupActions() tea.Cmd {
+	var cmds []tea.Cmd
+	for len(m.StartupActions) > 0 {
+		action := m.StartupActions[0]
+		m.StartupActions = m.StartupActions[1:]
+
+		if action.Command == "quit" {
+			m.syncStateToSession()
+			_ = m.Sessions.Save(m.Session)
+			return tea.Batch(append(cmds, tea.Quit)...)
+		}
+		reply, cmd := m.handleControl(ControlMsg{Command: action.Command, Args: action.Args})
+		if msg, failed := strings.CutPrefix(reply, "error: "); failed {
+			m.notify(SeverityError, fmt.Sprintf(tr("On start %q: %s"), action, msg))
+			m.StartupActions = nil
+			break
+		}
+		cmds = append(cmds, cmd)
+		if action.Command == "export" {
+			break // Resumed by ExportCompleteMsg
+		}
+	}
+	return tea.Batch(cmds...)
+}
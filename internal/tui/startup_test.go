@@ -0,0 +1,68 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStartupActions(t *testing.T) {
+	if _, err := ParseStartupActions("select a; launch"); err == nil {
+		t.Error("unknown command accepted")
+	}
+	actions, err := ParseStartupActions("select internal/** ;; export; quit")
+	if err != nil || len(actions) != 3 || actions[0].String() != "select internal/**" {
+		t.Fatalf("actions = %v, %v", actions, err)
+	}
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "internal", "x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "internal", "x", "a.go"), []byte("package x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	space := &core.DirectorySpace{
+		ID:             "a",
+		RootPath:       root,
+		OutputFilePath: filepath.Join(t.TempDir(), "out.txt"),
+		Config:         core.ExtractionConfig{IncludeMode: true},
+	}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	m.StartupActions = actions
+
+	updated, _ := m.Update(StartupActionsMsg{})
+	m = updated.(AppModel)
+	if want := []string{filepath.Join(root, "internal")}; !slices.Equal(space.Config.ManualSelections, want) {
+		t.Errorf("selections = %v, want %v", space.Config.ManualSelections, want)
+	}
+	if !m.Loading || len(m.StartupActions) != 1 {
+		t.Fatalf("queue did not pause on the export: %v", m.StartupActions)
+	}
+
+	updated, cmd := m.Update(runExportCmd(space, m.exportOptions())())
+	m = updated.(AppModel)
+	if len(m.StartupActions) != 0 || m.LastExport == nil || !quits(cmd) {
+		t.Errorf("quit did not follow the export: %v", m.StartupActions)
+	}
+}
+
+// quits reports whether cmd, or a command batched in it, quits the program.
+func quits(cmd tea.Cmd) bool {
+	if cmd == nil {
+		return false
+	}
+	switch msg := cmd().(type) {
+	case tea.QuitMsg:
+		return true
+	case tea.BatchMsg:
+		return slices.ContainsFunc(msg, quits)
+	}
+	return false
+}
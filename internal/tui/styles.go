@@ -13,6 +13,7 @@ const (
 	iconJSON       = "\ue60b" // nf-seti-json
 	iconYAML       = "\ue6a5" // nf-seti-yml
 	iconGit        = "\ue702" // nf-dev-git
+	iconBranch     = "\ue725" // nf-dev-git_branch
 	iconDocker     = "\uf308" // nf-dev-docker
 	iconJS         = "\ue74e" // nf-seti-javascript
 	iconTS         = "\ue628" // nf-seti-typescript
@@ -39,6 +40,10 @@ const (
 	iconHelp     = "\uf059" // nf-fa-question_circle
 	iconGear     = "\uf013" // nf-fa-cog
 	iconFilter   = "\uf0b0" // nf-fa-filter
+	iconSort     = "\uf0dc" // nf-fa-sort
+	iconInfo     = "\uf05a" // nf-fa-info_circle
+	iconWarn     = "\uf071" // nf-fa-warning
+	iconError    = "\uf057" // nf-fa-times_circle
 
 	treeSpace = "  "
 )
@@ -46,7 +51,8 @@ const (
 // Styles holds all the lipgloss styles for the UI
 type Styles struct {
 	// Colors (Exposed for conditional rendering in utils)
-	ColorBase     lipgloss.Color
+	ColorBase     lipgloss.Color // Background; empty with a transparent theme
+	ColorInk      lipgloss.Color // Text on accent backgrounds
 	ColorSurface  lipgloss.Color
 	ColorText     lipgloss.Color
 	ColorSubtext  lipgloss.Color
@@ -80,8 +86,14 @@ type Styles struct {
 
 // DefaultStyles generates the style sheet based on the provided palette
 func DefaultStyles(p ThemePalette) Styles {
+	// Base inks text on accents; as a background it may be left to the terminal
+	ink, bg := p.Base, p.Base
+	if p.Transparent {
+		bg = ""
+	}
 	s := Styles{
-		ColorBase:     p.Base,
+		ColorBase:     bg,
+		ColorInk:      ink,
 		ColorSurface:  p.Surface,
 		ColorText:     p.Text,
 		ColorSubtext:  p.Subtext,
@@ -102,7 +114,7 @@ func DefaultStyles(p ThemePalette) Styles {
 
 	s.TabActive = lipgloss.NewStyle().
 		Padding(0, 2).
-		Foreground(p.Base).
+		Foreground(ink).
 		Background(p.Mauve).
 		Bold(true)
 
@@ -112,14 +124,14 @@ func DefaultStyles(p ThemePalette) Styles {
 	s.Sidebar = lipgloss.NewStyle().
 		Width(38).
 		Padding(1, 2).
-		Background(p.Base).
+		Background(bg).
 		Border(lipgloss.RoundedBorder(), false, true, false, false).
 		BorderForeground(p.Mauve).
-		BorderBackground(p.Base)
+		BorderBackground(bg)
 
 	s.SectionHeader = lipgloss.NewStyle().
 		Foreground(p.Mauve).
-		Background(p.Base).
+		Background(bg).
 		Bold(true).
 		Underline(true).
 		Width(34). // Fix: Force width to match Sidebar content area (38 - 4 padding)
@@ -127,33 +139,33 @@ func DefaultStyles(p ThemePalette) Styles {
 
 	s.InputLabel = lipgloss.NewStyle().
 		Foreground(p.Blue).
-		Background(p.Base).
+		Background(bg).
 		Bold(true).
 		Width(34)
 
 	s.InputBox = lipgloss.NewStyle().
-		Background(p.Base)
+		Background(bg)
 
 	s.InputBoxFocused = lipgloss.NewStyle().
 		Foreground(p.Mauve).
-		Background(p.Base)
+		Background(bg)
 
 	// Main Content Area
 	// Removed MarginLeft(1) to allow background color to propagate from the sidebar.
 	// Padding(1, 2) provides the visual separation while maintaining the background.
 	s.Main = lipgloss.NewStyle().
 		Padding(1, 2).
-		Background(p.Base)
+		Background(bg)
 
 	// Status Bar Styles
 	s.StatusLeft = lipgloss.NewStyle().
-		Foreground(p.Base).
+		Foreground(ink).
 		Background(p.Mauve).
 		Padding(0, 2).
 		Bold(true)
 
 	s.StatusMiddle = lipgloss.NewStyle().
-		Foreground(p.Base).
+		Foreground(ink).
 		Background(p.Blue).
 		Padding(0, 2)
 
@@ -171,28 +183,28 @@ func DefaultStyles(p ThemePalette) Styles {
 
 	// Tree Row (Standard) - uses Base background to fill gaps in file names
 	s.TreeRow = lipgloss.NewStyle().
-		Background(p.Base).
+		Background(bg).
 		Foreground(p.Text) // Explicitly set foreground to prevent partial resets
 
 	// Option Styles (Checkboxes)
 	s.Option = lipgloss.NewStyle().
 		Foreground(p.Subtext).
-		Background(p.Base)
+		Background(bg)
 
 	s.OptionSelected = lipgloss.NewStyle().
 		Foreground(p.Green).
-		Background(p.Base).
+		Background(bg).
 		Bold(true)
 
 	// Help Styles - ensure they work on base background
 	s.HelpKey = lipgloss.NewStyle().
 		Foreground(p.Mauve).
-		Background(p.Base).
+		Background(bg).
 		Bold(true)
 
 	s.HelpDesc = lipgloss.NewStyle().
 		Foreground(p.Text).
-		Background(p.Base)
+		Background(bg)
 
 	return s
 }
@@ -0,0 +1,40 @@
+// Package tui implements the summary of the last export printed on exit.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"pandabrew/internal/core"
+)
+
+// ExportSummary describes the last export written during a TUI run, for
+// the shell once the TUI has quit.
+type ExportSummary struct {
+	Paths    []string // Files written, one per output format
+	Files    int
+	Tokens   int
+	Selected int // Selected paths of the exported tab
+}
+
+// newExportSummary summarizes an export of space with opts that included files
+// totalling tokens.
+func newExportSummary(space *core.DirectorySpace, opts core.ExtractOptions, files, tokens int) *ExportSummary {
+	return &ExportSummary{
+		Paths:    outputPaths(space, opts),
+		Files:    files,
+		Tokens:   tokens,
+		Selected: len(space.Config.ManualSelections),
+	}
+}
+
+// String is a line of totals followed by the written files, one per line,
+// so scripts can take the path from the last line.
+func (s ExportSummary) String() string {
+	noun := "files"
+	if s.Files == 1 {
+		noun = "file"
+	}
+	return fmt.Sprintf("Exported %d %s (~%s tokens, %d selected) to:\n%s",
+		s.Files, noun, core.FormatTokens(s.Tokens), s.Selected, strings.Join(s.Paths, "\n"))
+}
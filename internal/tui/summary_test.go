@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestExportSummary(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "out.txt")
+	space := &core.DirectorySpace{
+		ID:             "a",
+		RootPath:       root,
+		OutputFilePath: out,
+		Config:         core.ExtractionConfig{IncludeMode: true, ManualSelections: []string{filepath.Join(root, "a.go")}},
+	}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	if m.LastExport != nil {
+		t.Fatal("summary before any export")
+	}
+
+	// Exports follow the options of the command line
+	m.ExportOptions.FileMode = 0o600
+	updated, _ := m.Update(runExportCmd(space, m.exportOptions())())
+	m = updated.(AppModel)
+	if m.LastExport == nil {
+		t.Fatal("no summary after the export")
+	}
+	if info, err := os.Stat(out); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("report mode: %v, %v", info, err)
+	}
+	if _, ok := space.Config.SelectionHashes[filepath.Join(root, "a.go")]; !ok {
+		t.Errorf("exported contents not recorded: %v", space.Config.SelectionHashes)
+	}
+	lines := strings.Split(m.LastExport.String(), "\n")
+	if !strings.HasPrefix(lines[0], "Exported 1 file (~") || !strings.HasSuffix(lines[0], "1 selected) to:") || lines[len(lines)-1] != out {
+		t.Errorf("summary = %q", m.LastExport.String())
+	}
+}
@@ -1,16 +1,23 @@
 // Package tui implements the terminal user interface logic.
 package tui
 
+import (
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// syncStateToSession copies the view state of every tab into its space, so
+// it survives a restart or a session switch.
 func (m AppModel) syncStateToSession() {
-	space := m.Session.GetActiveSpace()
-	if space == nil {
-		return
-	}
-	state := m.TabStates[space.ID]
-	if state == nil {
-		return
+	for _, space := range m.Session.Spaces {
+		if state := m.TabStates[space.ID]; state != nil {
+			syncTabState(space, state)
+		}
 	}
+}
 
+func syncTabState(space *core.DirectorySpace, state *TabState) {
 	// 1. Save Expanded Paths
 	if state.TreeRoot != nil {
 		space.ExpandedPaths = CollectExpandedPaths(state.TreeRoot)
@@ -20,4 +27,23 @@ func (m AppModel) syncStateToSession() {
 	if len(state.VisibleNodes) > 0 && state.CursorIndex >= 0 && state.CursorIndex < len(state.VisibleNodes) {
 		space.CursorPath = state.VisibleNodes[state.CursorIndex].FullPath
 	}
+
+	// 3. Save Scroll and Search; focus is not kept, so a restart never
+	// opens with keys going to a text field
+	space.ScrollOffset = state.ScrollOffset
+	space.SearchQuery = state.SearchQuery
+}
+
+// loadActiveTreeCmd loads the root of the active tab if it has not been
+// loaded yet, e.g. after switching tabs or groups.
+func (m AppModel) loadActiveTreeCmd() tea.Cmd {
+	space := m.Session.GetActiveSpace()
+	if space == nil {
+		return nil
+	}
+	state := m.TabStates[space.ID]
+	if state != nil && len(state.TreeRoot.Children) == 0 {
+		return loadDirectoryCmd(m.Dirs, space.RootPath)
+	}
+	return nil
 }
@@ -0,0 +1,54 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestTabStateSurvivesRebuild(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r"}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	state := m.TabStates["a"]
+	for i := range 30 {
+		name := string(rune('a'+i%26)) + strings.Repeat("x", i/26)
+		state.VisibleNodes = append(state.VisibleNodes, &TreeNode{Name: name, FullPath: filepath.Join("/r", name)})
+	}
+
+	// The window follows the cursor only as far as needed
+	state.CursorIndex = 20
+	if got := state.scrollWindow(10); got != 11 {
+		t.Fatalf("window starts at %d, want 11", got)
+	}
+	state.ScrollOffset = 11
+	state.CursorIndex = 15
+	if got := state.scrollWindow(10); got != 11 {
+		t.Errorf("window moved to %d while the cursor was in view", got)
+	}
+
+	// Update moves the window, so View draws what it left
+	m.Width, m.Height = 120, 20
+	state.CursorIndex = 29
+	updated, _ := m.Update(toastTickMsg{})
+	m = updated.(AppModel)
+	view := m.View()
+	if state.ScrollOffset <= 11 || !strings.Contains(view, state.VisibleNodes[29].Name) {
+		t.Errorf("offset %d after moving the cursor down", state.ScrollOffset)
+	}
+	if offset := state.ScrollOffset; m.View() != view || state.ScrollOffset != offset {
+		t.Error("drawing changed the view or the offset")
+	}
+
+	state.SearchQuery = "b"
+	focusInput(state, 3)
+	m.syncStateToSession()
+	rebuilt := newTabState(space, m.Styles)
+	if rebuilt.ScrollOffset != state.ScrollOffset || rebuilt.SearchQuery != "b" || rebuilt.InputSearch.Value() != "b" || rebuilt.ActiveInput != 0 {
+		t.Errorf("rebuilt state: offset %d, query %q, focus %d", rebuilt.ScrollOffset, rebuilt.SearchQuery, rebuilt.ActiveInput)
+	}
+	if rebuilt.TargetCursorPath != state.VisibleNodes[29].FullPath {
+		t.Errorf("cursor target %q, want %q", rebuilt.TargetCursorPath, state.VisibleNodes[29].FullPath)
+	}
+}
@@ -0,0 +1,82 @@
+// Package tui implements the picker of the task preset wrapping a report.
+package tui
+
+import (
+	"fmt"
+
+	"pandabrew/internal/core"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openTaskPicker shows the task presets with the cursor on the one the
+// active tab uses. The first row stands for no task.
+func (m *AppModel) openTaskPicker() {
+	space := m.Session.GetActiveSpace()
+	if space == nil {
+		return
+	}
+	m.ShowTasks = true
+	m.TasksCursor = 0
+	for i, t := range core.TaskPresets {
+		if t.Name == space.Config.Task {
+			m.TasksCursor = i + 1
+		}
+	}
+}
+
+// updateTaskPicker handles a key in the task picker; enter sets the task of
+// the active tab.
+func (m *AppModel) updateTaskPicker(msg tea.KeyMsg) {
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		if m.TasksCursor > 0 {
+			m.TasksCursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.TasksCursor < len(core.TaskPresets) {
+			m.TasksCursor++
+		}
+	case msg.String() == "enter", key.Matches(msg, m.keys.Select):
+		m.ShowTasks = false
+		space := m.Session.GetActiveSpace()
+		if space == nil {
+			return
+		}
+		space.Config.Task = ""
+		title := tr("No task")
+		if m.TasksCursor > 0 {
+			task := core.TaskPresets[m.TasksCursor-1]
+			space.Config.Task, title = task.Name, task.Title
+		}
+		_ = m.Sessions.Save(m.Session)
+		m.notify(SeverityInfo, tr("Task: ")+title)
+	case key.Matches(msg, m.keys.PickTask), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+		m.ShowTasks = false
+	}
+}
+
+// renderTaskPickerView lists the task presets with their instructions.
+func (m AppModel) renderTaskPickerView() string {
+	current := ""
+	if space := m.Session.GetActiveSpace(); space != nil {
+		current = space.Config.Task
+	}
+	marker := func(name string) string {
+		if name == current {
+			return "● "
+		}
+		return "  "
+	}
+	rows := []string{marker("") + tr("No task")}
+	for _, t := range core.TaskPresets {
+		rows = append(rows, fmt.Sprintf("%s%-14s %s", marker(t.Name), t.Title, t.Instructions))
+	}
+	return m.renderListDialog(
+		iconGear+" "+tr("Task"),
+		rows, m.TasksCursor,
+		"",
+		tr("enter wraps the report in the task's instructions • Esc to go back"),
+	)
+}
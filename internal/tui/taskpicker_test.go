@@ -0,0 +1,39 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestTaskPicker(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: t.TempDir(), Config: core.DefaultExtractionConfig()}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	press := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			updated, _ := m.Update(k)
+			m = updated.(AppModel)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	press(runes("K"), tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyEnter})
+	if m.ShowTasks || space.Config.Task != core.TaskPresets[1].Name {
+		t.Fatalf("task = %q, picker shown %v", space.Config.Task, m.ShowTasks)
+	}
+
+	// The picker opens on the current task; the first row clears it
+	press(runes("K"))
+	if m.TasksCursor != 2 || !strings.Contains(m.View(), core.TaskPresets[1].Title) {
+		t.Errorf("cursor = %d", m.TasksCursor)
+	}
+	press(tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyEnter})
+	if space.Config.Task != "" {
+		t.Errorf("task = %q after choosing none", space.Config.Task)
+	}
+}
@@ -1,7 +1,11 @@
 // Package tui implements the terminal user interface logic.
 package tui
 
-import "github.com/charmbracelet/lipgloss"
+import (
+	"github.com/charmbracelet/bubbles/progress"
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/lipgloss"
+)
 
 // ThemePalette defines the semantic colors for the UI
 type ThemePalette struct {
@@ -17,6 +21,8 @@ type ThemePalette struct {
 	Yellow   lipgloss.Color // Warning/JSON
 	Peach    lipgloss.Color // HTML/Orange
 	Lavender lipgloss.Color // Secondary Accent
+
+	Transparent bool // Leave Base unpainted so the terminal background shows
 }
 
 var (
@@ -79,30 +85,172 @@ var (
 		Peach:    lipgloss.Color("#fe640b"),
 		Lavender: lipgloss.Color("#7287fd"),
 	}
+
+	ThemeGruvbox = ThemePalette{
+		Base:     lipgloss.Color("#282828"),
+		Surface:  lipgloss.Color("#3c3836"),
+		Overlay:  lipgloss.Color("#7c6f64"),
+		Text:     lipgloss.Color("#ebdbb2"),
+		Subtext:  lipgloss.Color("#bdae93"),
+		Mauve:    lipgloss.Color("#d3869b"),
+		Red:      lipgloss.Color("#fb4934"),
+		Blue:     lipgloss.Color("#83a598"),
+		Green:    lipgloss.Color("#b8bb26"),
+		Yellow:   lipgloss.Color("#fabd2f"),
+		Peach:    lipgloss.Color("#fe8019"),
+		Lavender: lipgloss.Color("#8ec07c"),
+	}
+
+	ThemeNord = ThemePalette{
+		Base:     lipgloss.Color("#2e3440"),
+		Surface:  lipgloss.Color("#3b4252"),
+		Overlay:  lipgloss.Color("#616e88"),
+		Text:     lipgloss.Color("#eceff4"),
+		Subtext:  lipgloss.Color("#d8dee9"),
+		Mauve:    lipgloss.Color("#88c0d0"),
+		Red:      lipgloss.Color("#bf616a"),
+		Blue:     lipgloss.Color("#81a1c1"),
+		Green:    lipgloss.Color("#a3be8c"),
+		Yellow:   lipgloss.Color("#ebcb8b"),
+		Peach:    lipgloss.Color("#d08770"),
+		Lavender: lipgloss.Color("#b48ead"),
+	}
+
+	ThemeTokyoNight = ThemePalette{
+		Base:     lipgloss.Color("#1a1b26"),
+		Surface:  lipgloss.Color("#292e42"),
+		Overlay:  lipgloss.Color("#565f89"),
+		Text:     lipgloss.Color("#c0caf5"),
+		Subtext:  lipgloss.Color("#a9b1d6"),
+		Mauve:    lipgloss.Color("#bb9af7"),
+		Red:      lipgloss.Color("#f7768e"),
+		Blue:     lipgloss.Color("#7aa2f7"),
+		Green:    lipgloss.Color("#9ece6a"),
+		Yellow:   lipgloss.Color("#e0af68"),
+		Peach:    lipgloss.Color("#ff9e64"),
+		Lavender: lipgloss.Color("#7dcfff"),
+	}
+
+	ThemeDracula = ThemePalette{
+		Base:     lipgloss.Color("#282a36"),
+		Surface:  lipgloss.Color("#44475a"),
+		Overlay:  lipgloss.Color("#6272a4"),
+		Text:     lipgloss.Color("#f8f8f2"),
+		Subtext:  lipgloss.Color("#bfbfcf"),
+		Mauve:    lipgloss.Color("#bd93f9"),
+		Red:      lipgloss.Color("#ff5555"),
+		Blue:     lipgloss.Color("#8be9fd"),
+		Green:    lipgloss.Color("#50fa7b"),
+		Yellow:   lipgloss.Color("#f1fa8c"),
+		Peach:    lipgloss.Color("#ffb86c"),
+		Lavender: lipgloss.Color("#ff79c6"),
+	}
+
+	ThemeSolarized = ThemePalette{
+		Base:     lipgloss.Color("#002b36"),
+		Surface:  lipgloss.Color("#073642"),
+		Overlay:  lipgloss.Color("#586e75"),
+		Text:     lipgloss.Color("#93a1a1"),
+		Subtext:  lipgloss.Color("#839496"),
+		Mauve:    lipgloss.Color("#6c71c4"),
+		Red:      lipgloss.Color("#dc322f"),
+		Blue:     lipgloss.Color("#268bd2"),
+		Green:    lipgloss.Color("#859900"),
+		Yellow:   lipgloss.Color("#b58900"),
+		Peach:    lipgloss.Color("#cb4b16"),
+		Lavender: lipgloss.Color("#2aa198"),
+	}
 )
 
+// namedTheme is a palette with the name it is saved under and its title.
+type namedTheme struct {
+	Name    string // e.g. "tokyonight"
+	Title   string // e.g. "Tokyo Night"
+	Palette ThemePalette
+}
+
+// themes are the built-in palettes, in the order ctrl+t cycles them.
+var themes = []namedTheme{
+	{"mocha", "Mocha", ThemeMocha},
+	{"latte", "Latte", ThemeLatte},
+	{"frappe", "Frappe", ThemeFrappe},
+	{"macchiato", "Macchiato", ThemeMacchiato},
+	{"gruvbox", "Gruvbox", ThemeGruvbox},
+	{"nord", "Nord", ThemeNord},
+	{"tokyonight", "Tokyo Night", ThemeTokyoNight},
+	{"dracula", "Dracula", ThemeDracula},
+	{"solarized", "Solarized", ThemeSolarized},
+}
+
+// ThemeNames lists the names the built-in themes are selected by.
+func ThemeNames() []string {
+	names := make([]string, len(themes))
+	for i, t := range themes {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// themeIndex is the position of the named theme in themes, or -1.
+func themeIndex(name string) int {
+	for i, t := range themes {
+		if t.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// GetTheme returns the named palette, or Mocha for unknown names.
 func GetTheme(name string) ThemePalette {
-	switch name {
-	case "latte":
-		return ThemeLatte
-	case "frappe":
-		return ThemeFrappe
-	case "macchiato":
-		return ThemeMacchiato
-	default:
-		return ThemeMocha
+	if i := themeIndex(name); i >= 0 {
+		return themes[i].Palette
 	}
+	return ThemeMocha
+}
+
+// themePalette returns the named palette, leaving the background to the
+// terminal when transparent is set.
+func themePalette(name string, transparent bool) ThemePalette {
+	p := GetTheme(name)
+	p.Transparent = transparent
+	return p
 }
 
+// GetNextTheme returns the theme after current in the cycle.
 func GetNextTheme(current string) string {
-	switch current {
-	case "mocha":
-		return "latte"
-	case "latte":
-		return "frappe"
-	case "frappe":
-		return "macchiato"
-	default:
-		return "mocha"
+	return themes[(themeIndex(current)+1)%len(themes)].Name
+}
+
+// themeTitle is the display name of the named theme.
+func themeTitle(name string) string {
+	if i := themeIndex(name); i >= 0 {
+		return themes[i].Title
 	}
+	return name
+}
+
+// newProgress builds the export progress bar in the colors of p: a gradient
+// from the primary to the info accent over an overlay-colored track.
+func newProgress(p ThemePalette) progress.Model {
+	prog := progress.New(
+		progress.WithGradient(string(p.Mauve), string(p.Blue)),
+		progress.WithWidth(40),
+	)
+	prog.EmptyColor = string(p.Overlay)
+	prog.PercentageStyle = lipgloss.NewStyle().Foreground(p.Subtext)
+	return prog
+}
+
+// spinnerStyle colors the activity spinner with the primary accent of p.
+func spinnerStyle(p ThemePalette) lipgloss.Style {
+	return lipgloss.NewStyle().Foreground(p.Mauve)
+}
+
+// newSpinner builds the activity spinner in the colors of p.
+func newSpinner(p ThemePalette) spinner.Model {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = spinnerStyle(p)
+	return s
 }
@@ -0,0 +1,30 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestThemeSwitchRecolorsProgress(t *testing.T) {
+	m := InitialModel(&core.Session{Theme: "mocha"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	if m.Progress.EmptyColor != string(ThemeMocha.Overlay) {
+		t.Fatalf("progress track = %s, want the mocha overlay", m.Progress.EmptyColor)
+	}
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlT})
+	m = updated.(AppModel)
+	if m.Session.Theme != "latte" {
+		t.Fatalf("theme = %s, want latte", m.Session.Theme)
+	}
+	if m.Progress.EmptyColor != string(ThemeLatte.Overlay) {
+		t.Errorf("progress track = %s, want the latte overlay", m.Progress.EmptyColor)
+	}
+	if fg := m.Spinner.Style.GetForeground(); fg != ThemeLatte.Mauve {
+		t.Errorf("spinner color = %v, want the latte accent", fg)
+	}
+}
@@ -0,0 +1,110 @@
+// Package tui implements the theme picker and restyling on theme change.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// applyTheme switches the session to the named theme and restyles every
+// component drawn with the old palette. It does not save the session.
+func (m *AppModel) applyTheme(name string) {
+	m.Session.Theme = name
+	palette := themePalette(name, m.Session.Transparent)
+	m.Styles = DefaultStyles(palette)
+
+	m.Help.Styles.FullKey = m.Styles.HelpKey
+	m.Help.Styles.ShortKey = m.Styles.HelpKey
+	m.Help.Styles.FullDesc = m.Styles.HelpDesc
+	m.Help.Styles.ShortDesc = m.Styles.HelpDesc
+	m.Spinner.Style = spinnerStyle(palette)
+	m.Progress = newProgress(palette)
+
+	updateInputStyle(&m.NewTabInput, m.Styles)
+	updateInputStyle(&m.GlobalSearchInput, m.Styles)
+	updateInputStyle(&m.GroupInput, m.Styles)
+	updateInputStyle(&m.SessionInput, m.Styles)
+	updateInputStyle(&m.PreviewSearchInput, m.Styles)
+	for _, ts := range m.TabStates {
+		updateInputStyle(&ts.InputRoot, m.Styles)
+		updateInputStyle(&ts.InputOutput, m.Styles)
+		updateInputStyle(&ts.InputInclude, m.Styles)
+		updateInputStyle(&ts.InputExclude, m.Styles)
+		updateInputStyle(&ts.InputSearch, m.Styles)
+	}
+}
+
+// openThemePicker shows the theme picker on the current theme, remembering
+// it so Esc can go back.
+func (m *AppModel) openThemePicker() {
+	m.ShowThemes = true
+	m.ThemeBefore, m.TransparentBefore = m.Session.Theme, m.Session.Transparent
+	m.ThemesCursor = max(0, themeIndex(m.Session.Theme))
+}
+
+// updateThemePicker handles a key in the theme picker. Moving the cursor
+// previews the theme under it and b toggles the terminal background; enter
+// keeps them and Esc restores the old ones.
+func (m *AppModel) updateThemePicker(msg tea.KeyMsg) {
+	switch {
+	case key.Matches(msg, m.keys.Up):
+		if m.ThemesCursor > 0 {
+			m.ThemesCursor--
+		}
+	case key.Matches(msg, m.keys.Down):
+		if m.ThemesCursor < len(themes)-1 {
+			m.ThemesCursor++
+		}
+	case msg.String() == "enter", key.Matches(msg, m.keys.Select):
+		m.ShowThemes = false
+		_ = m.Sessions.Save(m.Session)
+		m.notify(SeverityInfo, tr("Theme: ")+themeTitle(m.Session.Theme))
+		return
+	case msg.String() == "b":
+		m.Session.Transparent = !m.Session.Transparent
+	case key.Matches(msg, m.keys.PickTheme), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+		m.ShowThemes = false
+		m.Session.Transparent = m.TransparentBefore
+		m.applyTheme(m.ThemeBefore)
+		return
+	default:
+		return
+	}
+	m.applyTheme(themes[m.ThemesCursor].Name)
+}
+
+// renderThemePickerView lists the themes with a swatch of their accents,
+// drawn in the theme under the cursor.
+func (m AppModel) renderThemePickerView() string {
+	var rows []string
+	for _, t := range themes {
+		marker := "  "
+		if t.Name == m.ThemeBefore {
+			marker = "● "
+		}
+		rows = append(rows, fmt.Sprintf("%s%-14s", marker, t.Title)+themeSwatch(t.Palette))
+	}
+	background := tr("theme")
+	if m.Session.Transparent {
+		background = tr("terminal")
+	}
+	return m.renderListDialog(
+		iconGear+" "+tr("Theme"),
+		rows, m.ThemesCursor,
+		"",
+		fmt.Sprintf(tr("↑/↓ to preview • b background: %s • enter to keep • Esc to go back"), background),
+	)
+}
+
+// themeSwatch draws one block per accent color of p on its own background.
+func themeSwatch(p ThemePalette) string {
+	var b strings.Builder
+	for _, c := range []lipgloss.Color{p.Mauve, p.Lavender, p.Blue, p.Green, p.Yellow, p.Peach, p.Red, p.Text} {
+		b.WriteString(lipgloss.NewStyle().Foreground(c).Background(p.Base).Render("██"))
+	}
+	return b.String()
+}
@@ -0,0 +1,62 @@
+package tui
+
+import (
+	"path/filepath"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestThemePicker(t *testing.T) {
+	if len(ThemeNames()) != len(themes) || GetNextTheme("solarized") != "mocha" || GetTheme("nord") != ThemeNord {
+		t.Fatalf("themes do not cycle: %v", ThemeNames())
+	}
+	m := InitialModel(&core.Session{Theme: "mocha"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	press := func(msg tea.KeyMsg) {
+		updated, _ := m.Update(msg)
+		m = updated.(AppModel)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t"), Alt: true})
+	if !m.ShowThemes {
+		t.Fatal("picker did not open")
+	}
+	press(tea.KeyMsg{Type: tea.KeyDown})
+	if m.Session.Theme != "latte" || m.Styles.ColorBase != ThemeLatte.Base {
+		t.Errorf("moving the cursor did not preview latte: %s", m.Session.Theme)
+	}
+	press(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.ShowThemes || m.Session.Theme != "mocha" || m.Styles.ColorBase != ThemeMocha.Base {
+		t.Errorf("Esc kept %s", m.Session.Theme)
+	}
+
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("t"), Alt: true})
+	for range themeIndex("dracula") {
+		press(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	press(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.ShowThemes || m.Session.Theme != "dracula" {
+		t.Errorf("enter kept %s, want dracula", m.Session.Theme)
+	}
+}
+
+func TestTransparentBackground(t *testing.T) {
+	m := InitialModel(&core.Session{Theme: "nord", Transparent: true}, nil)
+	if m.Styles.ColorBase != "" || m.Styles.ColorInk != ThemeNord.Base {
+		t.Fatalf("background %q, ink %q", m.Styles.ColorBase, m.Styles.ColorInk)
+	}
+
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	m.openThemePicker()
+	m.updateThemePicker(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if m.Session.Transparent || m.Styles.ColorBase != ThemeNord.Base {
+		t.Errorf("b left the background at %q", m.Styles.ColorBase)
+	}
+	m.updateThemePicker(tea.KeyMsg{Type: tea.KeyEsc})
+	if !m.Session.Transparent || m.Styles.ColorBase != "" {
+		t.Errorf("Esc did not restore the terminal background")
+	}
+}
@@ -0,0 +1,69 @@
+// Package tui implements the terminal user interface logic.
+package tui
+
+// optionTooltip describes a sidebar option in plain language.
+type optionTooltip struct {
+	Title   string
+	Body    string
+	Example string
+}
+
+// optionTooltips maps an option hotkey to its explanation.
+// Shown when the hotkey is pressed while the help overlay is open.
+var optionTooltips = map[string]optionTooltip{
+	"i": {
+		Title: "Include Mode",
+		Body: "Decides what a checked item means. When on, only checked files and folders " +
+			"are exported. When off, everything is exported EXCEPT the checked items.",
+		Example: "Check src/ with Include Mode on to export just src/. " +
+			"Turn it off to export the whole project minus src/.",
+	},
+	"c": {
+		Title: "Show Context",
+		Body: "Lists the siblings of exported items in the Project Structure section " +
+			"(marked [EXCLUDED]) without including their contents, so the reader can see " +
+			"what lives next to the selected files. The radius, Go imports and sibling " +
+			"signatures are tunable via --context-radius or context_rules in the session.",
+		Example: "Selecting src/main.go also lists src/utils.go and src/lib/ in the tree.",
+	},
+	"x": {
+		Title: "Show Excluded",
+		Body: "Prints every file and folder in the Project Structure section, including " +
+			"items matched by exclude patterns. Contents are still limited to the selection.",
+		Example: "node_modules/ appears in the tree as [EXCLUDED] but none of its files are dumped.",
+	},
+	"X": {
+		Title: "Dim Excluded",
+		Body: "Greys out the files and folders of the tree that exclude or junk patterns " +
+			"keep out of the report, naming the pattern responsible. Press u on one to " +
+			"drop its exclude pattern.",
+		Example: "dist/ shows as \"(excluded: dist)\"; u removes dist from the exclude patterns.",
+	},
+	"v": {
+		Title: "Struct in View",
+		Body: "Mirrors the folders expanded in the tree view into the Project Structure " +
+			"section. Whatever you can see in the TUI is listed in the report.",
+		Example: "Expand docs/ before exporting to list its files even if none are selected.",
+	},
+	"m": {
+		Title: "Full Tree Map",
+		Body: "Lists the complete project layout in the Project Structure section, " +
+			"whatever is selected. Exclude patterns still apply, and only selected " +
+			"files have their contents included.",
+		Example: "Select just src/api.go and the report still maps every folder and file " +
+			"outside node_modules/ for orientation.",
+	},
+	"z": {
+		Title: "Skip Junk",
+		Body: "Leaves out editor and OS droppings everywhere in the tree: .DS_Store, " +
+			"Thumbs.db, swap files, .idea/, .vscode/, __pycache__ and *.pyc. " +
+			"They are skipped even when Show Excluded is on.",
+		Example: "A stray src/.main.go.swp no longer shows up next to src/main.go.",
+	}, "A": {
+		Title: "Auto-select New",
+		Body: "After a refresh (ctrl+r), files that appeared inside selected folders or match " +
+			"include patterns are marked (new). With this on, a new file is also selected " +
+			"when every other file of its type in the same folder is already checked.",
+		Example: "With src/a.go and src/b.go checked, a new src/c.go is checked on the next refresh.",
+	},
+}
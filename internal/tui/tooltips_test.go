@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestOptionTooltips(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r"}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	m.Width, m.Height = 120, 40
+	press := func(s string) {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+		m = updated.(AppModel)
+	}
+
+	// From the help overlay an option's hotkey explains it, leaving it be
+	press("?")
+	press("i")
+	if m.ActiveTooltip != "i" || space.Config.IncludeMode {
+		t.Fatalf("tooltip %q, include mode %v", m.ActiveTooltip, space.Config.IncludeMode)
+	}
+	if view := m.View(); !strings.Contains(view, "Include Mode") || !strings.Contains(view, "src/") {
+		t.Errorf("tooltip lacks its title or example:\n%s", view)
+	}
+	press("x")
+	if m.ActiveTooltip != "" || space.Config.ShowExcluded {
+		t.Errorf("any key should only close the tooltip: %q, show excluded %v", m.ActiveTooltip, space.Config.ShowExcluded)
+	}
+
+	// Outside the help overlay the hotkey toggles the option as usual
+	press("?")
+	press("i")
+	if m.ActiveTooltip != "" || !space.Config.IncludeMode {
+		t.Errorf("tooltip %q, include mode %v", m.ActiveTooltip, space.Config.IncludeMode)
+	}
+}
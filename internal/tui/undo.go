@@ -0,0 +1,57 @@
+// Undoing the actions that replace a whole selection at once.
+
+package tui
+
+import (
+	"fmt"
+	"slices"
+
+	"pandabrew/internal/core"
+)
+
+// maxSelectionUndo caps how many replaced selections are kept.
+const maxSelectionUndo = 20
+
+// selectionUndo is a selection replaced by a bulk action, kept so the
+// action can be undone.
+type selectionUndo struct {
+	SpaceID      string
+	Action       string // What replaced it, for the notification
+	IncludeMode  bool
+	Selections   []string
+	Deselections []string
+}
+
+// pushSelectionUndo keeps the selection of space before action replaces it.
+func (m *AppModel) pushSelectionUndo(space *core.DirectorySpace, action string) {
+	m.SelectionUndo = append(m.SelectionUndo, selectionUndo{
+		SpaceID:      space.ID,
+		Action:       action,
+		IncludeMode:  space.Config.IncludeMode,
+		Selections:   slices.Clone(space.Config.ManualSelections),
+		Deselections: slices.Clone(space.Config.ManualDeselections),
+	})
+	if len(m.SelectionUndo) > maxSelectionUndo {
+		m.SelectionUndo = slices.Delete(m.SelectionUndo, 0, 1)
+	}
+}
+
+// undoSelection restores the selection of space that the last bulk action
+// on it replaced.
+func (m *AppModel) undoSelection(space *core.DirectorySpace) {
+	i := len(m.SelectionUndo) - 1
+	for i >= 0 && m.SelectionUndo[i].SpaceID != space.ID {
+		i--
+	}
+	if i < 0 {
+		m.notify(SeverityWarn, tr("Nothing to undo"))
+		return
+	}
+	u := m.SelectionUndo[i]
+	m.SelectionUndo = slices.Delete(m.SelectionUndo, i, i+1)
+	space.Config.IncludeMode = u.IncludeMode
+	space.Config.ManualSelections = u.Selections
+	space.Config.ManualDeselections = u.Deselections
+	_ = m.Sessions.Save(m.Session)
+	m.notify(SeverityInfo, fmt.Sprintf(tr("Undid %s"), u.Action))
+}
@@ -2,8 +2,11 @@
 package tui
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	"pandabrew/internal/core"
@@ -16,6 +19,14 @@ import (
 
 // Update handles incoming messages and updates the model.
 func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	if next, ok := model.(AppModel); ok {
+		next.followCursor()
+	}
+	return model, cmd
+}
+
+func (m AppModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	var cmds []tea.Cmd
 
@@ -26,6 +37,170 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	}
 
 	switch msg := msg.(type) {
+	case ControlMsg:
+		reply, cmd := m.handleControl(msg)
+		msg.reply <- reply
+		return m, cmd
+
+	case toastTickMsg:
+		m.pruneToasts(m.Now())
+		return m, toastTickCmd()
+
+	case NestedReposLoadedMsg:
+		m.Loading = false
+		if space != nil && space.ID == msg.SpaceID {
+			if len(msg.Repos) == 0 {
+				m.notify(SeverityInfo, tr("No nested repositories found"))
+				return m, nil
+			}
+			m.NestedRepos = msg.Repos
+			m.NestedReposCursor = 0
+			m.ShowNestedRepos = true
+		}
+		return m, nil
+
+	case PreviewLoadedMsg:
+		m.Loading = false
+		if msg.Err != nil {
+			m.notify(SeverityError, tr("Could not preview: ")+msg.Err.Error())
+			return m, nil
+		}
+		m.openPreview(msg.Path, msg.Lines)
+		if msg.Truncated {
+			m.notify(SeverityWarn, tr("Preview shows the first 512KB only"))
+		}
+		return m, nil
+
+	case ExportDiffMsg:
+		m.Loading = false
+		if msg.Err != nil {
+			m.notify(SeverityError, tr("Could not preview export: ")+msg.Err.Error())
+			return m, nil
+		}
+		m.ExportDiff = msg.Diff
+		m.ExportDiffCursor = 0
+		m.ShowExportDiff = true
+		return m, nil
+
+	case StaleSelectionsMsg:
+		m.Loading = false
+		if space == nil || space.ID != msg.SpaceID {
+			return m, nil
+		}
+		m.Stale = nil
+		for _, s := range msg.Stale {
+			if !m.StaleKept[s.Path] {
+				m.Stale = append(m.Stale, s)
+			}
+		}
+		if len(m.Stale) == 0 {
+			if msg.Manual {
+				m.notify(SeverityInfo, tr("Every selection still exists"))
+			}
+			return m, nil
+		}
+		m.StaleChoice = make([]int, len(m.Stale))
+		m.StaleCursor = 0
+		m.ShowStale = true
+		moved := 0
+		for _, s := range m.Stale {
+			if s.Moved != "" {
+				moved++
+			}
+		}
+		if moved > 0 {
+			m.notify(SeverityInfo, fmt.Sprintf(tr("%d missing selections moved: press a to remap them"), moved))
+		}
+		return m, nil
+
+	case OffendersLoadedMsg:
+		m.Loading = false
+		if msg.Err != nil {
+			m.notify(SeverityError, tr("Could not rank files: ")+msg.Err.Error())
+			return m, nil
+		}
+		if space != nil && space.ID == msg.SpaceID {
+			m.Offenders = msg.Files
+			m.OffendersCursor = 0
+			m.ShowOffenders = true
+		}
+		return m, nil
+
+	// --- Index Messages ---
+	case IndexLoadedMsg:
+		m.Indexes[msg.Index.RootPath] = msg.Index
+		return m, nil
+
+	case BranchLoadedMsg:
+		if msg.OK {
+			m.Branches[msg.Root] = msg.Branch
+		} else {
+			delete(m.Branches, msg.Root)
+		}
+		return m, nil
+
+	case SpaceCheckedMsg:
+		m.applySpaceCheck(msg.Check)
+		return m, nil
+
+	case SelectionScanProgressMsg:
+		if msg.Path == m.ScanPath {
+			m.ScannedFiles = msg.Files
+		}
+		return m, waitForIndex(msg.ch)
+
+	case SelectionScanDoneMsg:
+		m.finishSelectionScan(space, msg)
+		return m, nil
+
+	case DirEstimateDueMsg:
+		return m, m.startDirEstimate(space, state, msg.Path)
+
+	case DirEstimateMsg:
+		m.recordDirEstimate(msg)
+		return m, nil
+
+	case InspectMsg:
+		m.Loading = false
+		m.Inspection = &msg.Explanation
+		return m, nil
+
+	case SmartSelectMsg:
+		m.Loading = false
+		m.applySmartSelection(msg)
+		return m, nil
+
+	case SelectFilesMsg:
+		m.Loading = false
+		m.applySelectFiles(msg)
+		return m, nil
+
+	case VendoredLoadedMsg:
+		m.Vendored[msg.Root] = msg.Dirs
+		return m, nil
+
+	case IndexProgressMsg:
+		m.IndexedFiles = msg.Files
+		return m, waitForIndex(msg.ch)
+
+	case IndexDoneMsg:
+		m.IndexCancel = nil
+		m.IndexRoot = ""
+		switch {
+		case errors.Is(msg.Err, context.Canceled):
+			m.notify(SeverityWarn, tr("Indexing cancelled"))
+		case msg.Index == nil:
+			m.notify(SeverityError, tr("Indexing failed: ")+msg.Err.Error())
+		default:
+			m.Indexes[msg.Root] = msg.Index
+			total, _ := msg.Index.Lookup(msg.Root)
+			m.notify(SeverityInfo, fmt.Sprintf(tr("Indexed %d files (~%s tokens)"), total.Files, core.FormatTokens(total.Tokens)))
+			if msg.Err != nil {
+				m.notify(SeverityWarn, tr("Could not cache index: ")+msg.Err.Error())
+			}
+		}
+		return m, nil
+
 	case tea.WindowSizeMsg:
 		m.Width = msg.Width
 		m.Height = msg.Height
@@ -33,21 +208,22 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	case NewTabValidatedMsg:
 		if msg.Valid {
-			sm := core.NewSessionManager("")
+			sm := m.Sessions
 			newSpace, err := sm.AddSpaceFromPath(m.Session, msg.Path)
 			if err == nil {
 				m.TabStates[newSpace.ID] = newTabState(newSpace, m.Styles)
-				m.StatusMessage = fmt.Sprintf("✓ Opened new tab: %s", filepath.Base(msg.Path))
+				m.notify(SeverityInfo, fmt.Sprintf(tr("✓ Opened new tab: %s"), filepath.Base(msg.Path)))
 				m.ShowNewTab = false
 				m.NewTabInput.Blur()
 				m.NewTabInput.SetValue("")
-				cmds = append(cmds, loadDirectoryCmd(newSpace.RootPath))
+				cmds = append(cmds, loadDirectoryCmd(m.Dirs, newSpace.RootPath), loadIndexCmd(newSpace.RootPath), loadBranchCmd(newSpace.RootPath), loadVendoredCmd(newSpace))
 				_ = sm.Save(m.Session)
+				m.ProjectSuggestion = msg.Project
 			} else {
-				m.StatusMessage = "Error: " + err.Error()
+				m.notify(SeverityError, tr("Error: ")+err.Error())
 			}
 		} else {
-			m.StatusMessage = "Invalid path: " + msg.Error
+			m.notify(SeverityError, tr("Invalid path: ")+msg.Error)
 			m.ShowNewTab = false
 			m.NewTabInput.Blur()
 			m.NewTabInput.SetValue("")
@@ -57,12 +233,42 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// --- Global Search Messages ---
 	case AllFilesLoadedMsg:
 		m.GlobalSearchCache[msg.RootPath] = msg.Files
-		m.GlobalSearchFiles = msg.Files // Initial show all (or could be empty)
-		if m.GlobalSearchInput.Value() != "" {
-			m.filterGlobalSearch()
+		if !m.GlobalSearchContent {
+			m.GlobalSearchFiles = msg.Files // Initial show all (or could be empty)
+			if m.GlobalSearchInput.Value() != "" {
+				m.filterGlobalSearch()
+			}
+		}
+		m.notify(SeverityInfo, fmt.Sprintf(tr("Indexed %d files"), len(msg.Files)))
+		for _, sp := range m.Session.Spaces {
+			if ts := m.TabStates[sp.ID]; ts != nil && sp.RootPath == msg.RootPath {
+				ts.IndexedFiles = msg.Files
+				if ts.SearchQuery != "" {
+					ts.PerformSearch()
+					if ts == state && ts.hiddenMatchCount() > 0 {
+						m.notify(SeverityInfo, fmt.Sprintf(tr("%d matches in collapsed folders"), ts.hiddenMatchCount()))
+					}
+				}
+			}
 		}
-		m.StatusMessage = fmt.Sprintf("Indexed %d files", len(msg.Files))
 
+	case contentSearchDueMsg:
+		if msg.Seq == m.ContentSearchSeq && m.ShowGlobalSearch && space != nil {
+			m.Loading = true
+			return m, contentSearchCmd(msg.Seq, space.RootPath, space.Config, m.GlobalSearchInput.Value())
+		}
+		return m, nil
+
+	case ContentSearchMsg:
+		if msg.Seq != m.ContentSearchSeq {
+			return m, nil // Superseded by a newer query
+		}
+		m.Loading = false
+		if msg.Err != nil {
+			m.notify(SeverityError, tr("Content search failed: ")+msg.Err.Error())
+		}
+		m.setContentMatches(msg.Matches)
+		return m, nil
 	}
 
 	// Handle New Tab Input Mode
@@ -78,7 +284,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				path := m.NewTabInput.Value()
 				if path != "" {
-					m.StatusMessage = "Validating path..."
+					m.notify(SeverityInfo, tr("Validating path..."))
 					return m, validateNewTabCmd(path)
 				}
 				m.ShowNewTab = false
@@ -99,33 +305,84 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.ShowGlobalSearch = false
 				m.GlobalSearchInput.Blur()
 				m.GlobalSearchInput.SetValue("")
+				m.HistoryPos = -1
 				// Clear selections on cancel
 				m.GlobalSearchSelected = make(map[string]bool)
 				return m, nil
 			case "up", "ctrl+k":
+				m.GlobalSearchInput.Blur() // The results take the keys until typing resumes
 				if m.GlobalSearchSelect > 0 {
 					m.GlobalSearchSelect--
 				} else if len(m.GlobalSearchFiles) > 0 {
 					// Loop to bottom
 					m.GlobalSearchSelect = len(m.GlobalSearchFiles) - 1
 				}
+				return m, nil
 			case "down", "ctrl+j":
+				m.GlobalSearchInput.Blur()
 				if m.GlobalSearchSelect < len(m.GlobalSearchFiles)-1 {
 					m.GlobalSearchSelect++
 				} else if len(m.GlobalSearchFiles) > 0 {
 					// Loop to top
 					m.GlobalSearchSelect = 0
 				}
+				return m, nil
+
+			// Space types into the query, or toggles in place once the
+			// results were moved through
+			case " ":
+				if m.GlobalSearchInput.Focused() {
+					break
+				}
+				if len(m.GlobalSearchFiles) > 0 && m.GlobalSearchSelect < len(m.GlobalSearchFiles) {
+					m.toggleGlobalSearchMark(m.GlobalSearchFiles[m.GlobalSearchSelect])
+				}
+				return m, nil
+
+			// Recall earlier searches
+			case "ctrl+p", "ctrl+n":
+				m.browseHistory(&m.GlobalSearchInput, space, msg.String() == "ctrl+p")
+				if m.GlobalSearchContent {
+					return m, m.queueContentSearch()
+				}
+				m.filterGlobalSearch()
+				m.GlobalSearchSelect = 0
+				return m, nil
+
+			// Switch between file name and content search
+			case "ctrl+f":
+				m.GlobalSearchContent = !m.GlobalSearchContent
+				m.GlobalSearchSelect = 0
+				if m.GlobalSearchContent {
+					m.ContentBackend = core.ContentSearchBackend()
+					return m, m.queueContentSearch()
+				}
+				m.ContentSearchSeq++ // Drop any content search in flight
+				m.ContentMatches = nil
+				m.Loading = false
+				if space == nil {
+					m.GlobalSearchFiles = nil
+					return m, nil
+				}
+				cached, ok := m.GlobalSearchCache[space.RootPath]
+				m.GlobalSearchFiles = cached
+				m.filterGlobalSearch()
+				if !ok {
+					return m, findAllFilesCmd(m.FS, space.RootPath)
+				}
+				return m, nil
+
+			// Check every filtered result, or uncheck them if all are checked
+			case "ctrl+a":
+				if space != nil {
+					m.markAllGlobalSearch(space)
+				}
+				return m, nil
 
 			// MULTI-SELECT TRIGGER (LazyVim style: Tab toggles and moves down)
 			case "tab":
 				if len(m.GlobalSearchFiles) > 0 && m.GlobalSearchSelect < len(m.GlobalSearchFiles) {
-					currentFile := m.GlobalSearchFiles[m.GlobalSearchSelect]
-					if m.GlobalSearchSelected[currentFile] {
-						delete(m.GlobalSearchSelected, currentFile)
-					} else {
-						m.GlobalSearchSelected[currentFile] = true
-					}
+					m.toggleGlobalSearchMark(m.GlobalSearchFiles[m.GlobalSearchSelect])
 					// Move down automatically for smooth selection flow
 					if m.GlobalSearchSelect < len(m.GlobalSearchFiles)-1 {
 						m.GlobalSearchSelect++
@@ -139,12 +396,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// MULTI-SELECT BACKWARD (Shift+Tab: Tab toggles and moves up)
 			case "shift+tab":
 				if len(m.GlobalSearchFiles) > 0 && m.GlobalSearchSelect < len(m.GlobalSearchFiles) {
-					currentFile := m.GlobalSearchFiles[m.GlobalSearchSelect]
-					if m.GlobalSearchSelected[currentFile] {
-						delete(m.GlobalSearchSelected, currentFile)
-					} else {
-						m.GlobalSearchSelected[currentFile] = true
-					}
+					m.toggleGlobalSearchMark(m.GlobalSearchFiles[m.GlobalSearchSelect])
 					// Move UP automatically
 					if m.GlobalSearchSelect > 0 {
 						m.GlobalSearchSelect--
@@ -158,22 +410,30 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "enter":
 				m.ShowGlobalSearch = false
 				m.GlobalSearchInput.Blur()
+				if query := m.GlobalSearchInput.Value(); query != "" {
+					m.recordSearch(space, query)
+					_ = m.Sessions.Save(m.Session)
+				}
 
 				// BATCH SELECTION LOGIC
 				if len(m.GlobalSearchSelected) > 0 {
 					// Apply all marked files
-					count := 0
+					added, removed := 0, 0
 					for path := range m.GlobalSearchSelected {
+						if core.IsChecked(space, path) {
+							removed++
+						} else {
+							added++
+						}
 						toggleSelection(space, path)
-						count++
 					}
-					m.StatusMessage = fmt.Sprintf("Toggled %d files from search", count)
-					sm := core.NewSessionManager("")
+					m.notify(SeverityInfo, fmt.Sprintf(tr("Selected %d and deselected %d files from search"), added, removed))
+					sm := m.Sessions
 					_ = sm.Save(m.Session)
 
 					// Force refresh to update checkboxes in tree
 					if state != nil {
-						cmds = append(cmds, loadDirectoryCmd(space.RootPath))
+						cmds = append(cmds, loadDirectoryCmd(m.Dirs, space.RootPath))
 					}
 
 					// Clear map
@@ -192,24 +452,460 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						}
 						state.TargetExpandedPaths[space.RootPath] = true
 
-						m.StatusMessage = fmt.Sprintf("Jumping to %s...", filepath.Base(selectedPath))
+						m.notify(SeverityInfo, fmt.Sprintf(tr("Jumping to %s..."), filepath.Base(selectedPath)))
 						m.Loading = true
-						cmds = append(cmds, loadDirectoryCmd(space.RootPath))
+						cmds = append(cmds, loadDirectoryCmd(m.Dirs, space.RootPath))
 					}
 				}
 				return m, tea.Batch(cmds...)
 			}
 		}
 
+		var focusCmd tea.Cmd
+		if _, ok := msg.(tea.KeyMsg); ok && !m.GlobalSearchInput.Focused() {
+			focusCmd = m.GlobalSearchInput.Focus()
+		}
 		oldValue := m.GlobalSearchInput.Value()
 		m.GlobalSearchInput, cmd = m.GlobalSearchInput.Update(msg)
+		cmd = tea.Batch(focusCmd, cmd)
 		if m.GlobalSearchInput.Value() != oldValue {
+			if m.GlobalSearchContent {
+				return m, tea.Batch(cmd, m.queueContentSearch())
+			}
 			m.filterGlobalSearch()
 			m.GlobalSearchSelect = 0
 		}
 		return m, cmd
 	}
 
+	// Handle Overwrite Confirmation
+	if m.ShowConfirmOverwrite {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			m.ShowConfirmOverwrite = false
+			switch msg.String() {
+			case "y", "Y":
+				if space != nil {
+					return m, m.startExport(space, state)
+				}
+			default:
+				m.notify(SeverityWarn, tr("Export cancelled"))
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Project Defaults Prompt
+	if m.ProjectSuggestion != nil {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.String() {
+			case "y", "Y", "enter":
+				m.applyProjectSuggestion(space, state, false)
+			case "e", "E":
+				m.applyProjectSuggestion(space, state, true)
+			default:
+				m.notify(SeverityInfo, tr("Kept the default settings"))
+			}
+			m.ProjectSuggestion = nil
+			return m, nil
+		}
+	}
+
+	// Handle Startup Warnings
+	if len(m.StartupWarnings) > 0 {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			m.StartupWarnings = nil
+			return m, nil
+		}
+	}
+
+	// Handle Inspector
+	if m.Inspection != nil {
+		if _, ok := msg.(tea.KeyMsg); ok {
+			m.Inspection = nil
+			return m, nil
+		}
+	}
+
+	// Handle Message Log Overlay
+	if m.ShowMessageLog {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.MessageLogOffset < len(m.MessageLog)-1 {
+					m.MessageLogOffset++
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.MessageLogOffset > 0 {
+					m.MessageLogOffset--
+				}
+			case key.Matches(msg, m.keys.MessageLog), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				m.ShowMessageLog = false
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Largest Offenders Panel
+	if m.ShowOffenders {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.OffendersCursor > 0 {
+					m.OffendersCursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.OffendersCursor < len(m.Offenders)-1 {
+					m.OffendersCursor++
+				}
+			case key.Matches(msg, m.keys.Select), msg.String() == "d", msg.String() == "x":
+				if space != nil && len(m.Offenders) > 0 {
+					file := m.Offenders[m.OffendersCursor]
+					core.DeselectPath(space, filepath.Join(space.RootPath, filepath.FromSlash(file.Path)))
+					_ = m.Sessions.Save(m.Session)
+					m.Offenders = slices.Delete(m.Offenders, m.OffendersCursor, m.OffendersCursor+1)
+					m.OffendersCursor = min(m.OffendersCursor, max(0, len(m.Offenders)-1))
+					m.notify(SeverityInfo, fmt.Sprintf(tr("Deselected %s (~%s tokens)"), file.Path, core.FormatTokens(file.Tokens)))
+				}
+			case key.Matches(msg, m.keys.Offenders), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				m.ShowOffenders = false
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Session Switcher
+	if m.ShowSessions {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.SessionsCursor > 0 {
+					m.SessionsCursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.SessionsCursor < len(m.SessionNames) {
+					m.SessionsCursor++
+				}
+			case msg.String() == "enter", key.Matches(msg, m.keys.Select):
+				m.ShowSessions = false
+				if m.SessionsCursor == len(m.SessionNames) {
+					m.ShowSessionInput = true
+					m.SessionInput.SetValue("")
+					m.SessionInput.Focus()
+					return m, textinput.Blink
+				}
+				if name := m.SessionNames[m.SessionsCursor]; name != m.Sessions.Name {
+					return m, m.switchSession(name)
+				}
+			case key.Matches(msg, m.keys.Sessions), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				m.ShowSessions = false
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Theme Picker
+	if m.ShowThemes {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			m.updateThemePicker(msg)
+			return m, nil
+		}
+	}
+
+	// Handle Task Picker
+	if m.ShowTasks {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			m.updateTaskPicker(msg)
+			return m, nil
+		}
+	}
+
+	// Handle New Session Input
+	if m.ShowSessionInput {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.String() {
+			case "esc":
+				m.ShowSessionInput = false
+				m.SessionInput.Blur()
+				return m, nil
+			case "enter":
+				m.ShowSessionInput = false
+				m.SessionInput.Blur()
+				if name := strings.TrimSpace(m.SessionInput.Value()); name != "" {
+					return m, m.switchSession(name)
+				}
+				return m, nil
+			}
+		}
+		m.SessionInput, cmd = m.SessionInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle Group Switcher (row 0 shows every tab)
+	if m.ShowGroups {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			groups := m.Session.Groups()
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.GroupsCursor > 0 {
+					m.GroupsCursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.GroupsCursor < len(groups) {
+					m.GroupsCursor++
+				}
+			case msg.String() == "enter", key.Matches(msg, m.keys.Select):
+				m.syncStateToSession()
+				name := ""
+				if m.GroupsCursor > 0 {
+					name = groups[m.GroupsCursor-1]
+				}
+				m.Session.SwitchGroup(name)
+				m.ShowGroups = false
+				_ = m.Sessions.Save(m.Session)
+				if name == "" {
+					m.notify(SeverityInfo, tr("Showing all tabs"))
+				} else {
+					m.notify(SeverityInfo, tr("Switched to group: ")+name)
+				}
+				return m, m.loadActiveTreeCmd()
+			case key.Matches(msg, m.keys.SwitchGroup), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				m.ShowGroups = false
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Group Assignment Input
+	if m.ShowGroupInput {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch msg.String() {
+			case "esc":
+				m.ShowGroupInput = false
+				m.GroupInput.Blur()
+				return m, nil
+			case "enter":
+				m.ShowGroupInput = false
+				m.GroupInput.Blur()
+				if space != nil {
+					space.Group = strings.TrimSpace(m.GroupInput.Value())
+					// Follow the tab if a group filter is active
+					if m.Session.ActiveGroup != "" {
+						m.Session.SwitchGroup(space.Group)
+					}
+					_ = m.Sessions.Save(m.Session)
+					if space.Group == "" {
+						m.notify(SeverityInfo, tr("✓ Removed tab from its group"))
+					} else {
+						m.notify(SeverityInfo, tr("✓ Moved tab to group: ")+space.Group)
+					}
+				}
+				return m, nil
+			}
+		}
+		m.GroupInput, cmd = m.GroupInput.Update(msg)
+		return m, cmd
+	}
+
+	// Handle File Preview
+	if m.ShowPreview {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			if m.PreviewSearching {
+				switch msg.String() {
+				case "esc":
+					m.PreviewSearching = false
+					m.PreviewSearchInput.Blur()
+				case "enter":
+					m.PreviewSearching = false
+					m.PreviewSearchInput.Blur()
+					m.searchPreview(m.PreviewSearchInput.Value())
+					if m.PreviewQuery != "" && len(m.PreviewMatches) == 0 {
+						m.notify(SeverityWarn, tr("No matches found"))
+					}
+				default:
+					m.PreviewSearchInput, cmd = m.PreviewSearchInput.Update(msg)
+					return m, cmd
+				}
+				return m, nil
+			}
+			switch {
+			case key.Matches(msg, m.keys.Search):
+				m.PreviewSearching = true
+				m.PreviewSearchInput.SetValue(m.PreviewQuery)
+				m.PreviewSearchInput.CursorEnd()
+				m.PreviewSearchInput.Focus()
+				return m, textinput.Blink
+			case key.Matches(msg, m.keys.NextMatch):
+				m.stepPreviewMatch(1)
+			case key.Matches(msg, m.keys.PrevMatch):
+				m.stepPreviewMatch(-1)
+			case key.Matches(msg, m.keys.Preview), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				m.ShowPreview = false
+			default:
+				m.Viewport, cmd = m.Viewport.Update(msg)
+				return m, cmd
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Stale Selection Review
+	if m.ShowStale {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.StaleCursor > 0 {
+					m.StaleCursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.StaleCursor < len(m.Stale)-1 {
+					m.StaleCursor++
+				}
+			case msg.String() == "tab":
+				if n := len(m.Stale[m.StaleCursor].Suggestions); n > 0 {
+					m.StaleChoice[m.StaleCursor] = (m.StaleChoice[m.StaleCursor] + 1) % n
+				}
+			case msg.String() == "enter", key.Matches(msg, m.keys.Select):
+				stale := m.Stale[m.StaleCursor]
+				if len(stale.Suggestions) == 0 {
+					m.notify(SeverityWarn, tr("No match to remap to: remove (d) or keep (s) it"))
+					break
+				}
+				to := stale.Suggestions[m.StaleChoice[m.StaleCursor]]
+				if space != nil {
+					core.RemapSelection(space, stale.Path, to)
+					_ = m.Sessions.Save(m.Session)
+				}
+				m.notify(SeverityInfo, fmt.Sprintf(tr("Remapped %s → %s"), filepath.Base(stale.Path), to))
+				m.resolveStale()
+			case msg.String() == "d", msg.String() == "x":
+				stale := m.Stale[m.StaleCursor]
+				if space != nil {
+					core.RemoveSelection(space, stale.Path)
+					_ = m.Sessions.Save(m.Session)
+				}
+				m.notify(SeverityInfo, tr("Removed missing selection ")+filepath.Base(stale.Path))
+				m.resolveStale()
+			case msg.String() == "s":
+				m.StaleKept[m.Stale[m.StaleCursor].Path] = true
+				m.resolveStale()
+			case msg.String() == "a":
+				if space != nil {
+					if n := m.remapMoved(space); n > 0 {
+						_ = m.Sessions.Save(m.Session)
+						m.notify(SeverityInfo, fmt.Sprintf(tr("Remapped %d moved selections"), n))
+					} else {
+						m.notify(SeverityWarn, tr("No missing selection was found by content"))
+					}
+				}
+			case key.Matches(msg, m.keys.Stale), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				// Whatever is left is kept for now
+				for _, s := range m.Stale {
+					m.StaleKept[s.Path] = true
+				}
+				m.ShowStale = false
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Export Preview
+	if m.ShowExportDiff {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.ExportDiffCursor > 0 {
+					m.ExportDiffCursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.ExportDiffCursor < len(m.ExportDiff.Files)-1 {
+					m.ExportDiffCursor++
+				}
+			case msg.String() == "u":
+				if m.ExportDiff.Unified != "" {
+					m.showText("Changes since the last export", m.ExportDiff.Unified)
+				}
+			case msg.String() == "enter", key.Matches(msg, m.keys.Export):
+				m.ShowExportDiff = false
+				if space != nil {
+					return m, m.requestExport(space, state)
+				}
+			case key.Matches(msg, m.keys.ExportDiff), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				m.ShowExportDiff = false
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Nested Repository Policies
+	if m.ShowNestedRepos {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.NestedReposCursor > 0 {
+					m.NestedReposCursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.NestedReposCursor < len(m.NestedRepos)-1 {
+					m.NestedReposCursor++
+				}
+			case msg.String() == "enter", key.Matches(msg, m.keys.Select):
+				if space != nil && m.NestedReposCursor < len(m.NestedRepos) {
+					repo := m.NestedRepos[m.NestedReposCursor]
+					policy := cycleNestedRepoPolicy(space, repo)
+					_ = m.Sessions.Save(m.Session)
+					m.notify(SeverityInfo, fmt.Sprintf("%s: %s", repo, policy))
+				}
+			case key.Matches(msg, m.keys.NestedRepos), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				m.ShowNestedRepos = false
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Selection Comparison
+	if m.ShowCompare {
+		if msg, ok := msg.(tea.KeyMsg); ok {
+			rows := len(m.Session.Spaces) - 1
+			if m.CompareTargetID != "" {
+				rows = len(m.compareRows())
+			}
+			switch {
+			case key.Matches(msg, m.keys.Up):
+				if m.CompareCursor > 0 {
+					m.CompareCursor--
+				}
+			case key.Matches(msg, m.keys.Down):
+				if m.CompareCursor < rows-1 {
+					m.CompareCursor++
+				}
+			case msg.String() == "enter", key.Matches(msg, m.keys.Select):
+				if m.CompareTargetID == "" {
+					if others := m.otherSpaces(); m.CompareCursor < len(others) {
+						m.CompareTargetID = others[m.CompareCursor].ID
+						m.CompareCursor = 0
+					}
+				}
+			case key.Matches(msg, m.keys.CompareTabs), key.Matches(msg, m.keys.ClearSearch), key.Matches(msg, m.keys.Quit):
+				m.ShowCompare = false
+			}
+			return m, nil
+		}
+	}
+
+	// Handle Option Tooltips (opened from the help overlay)
+	if keyMsg, ok := msg.(tea.KeyMsg); ok {
+		if m.ActiveTooltip != "" {
+			m.ActiveTooltip = ""
+			return m, nil
+		}
+		if m.ShowHelp {
+			if _, ok := optionTooltips[keyMsg.String()]; ok {
+				m.ActiveTooltip = keyMsg.String()
+				return m, nil
+			}
+		}
+	}
+
 	// Handle Regular Inputs
 	if state != nil && state.ActiveInput > 0 {
 		switch msg := msg.(type) {
@@ -218,41 +914,59 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "esc":
 				state.ActiveInput = 0
 				state.InputSearch.SetValue("")
+				m.HistoryPos = -1
 				blurAll(state)
 				return m, nil
+			case "up", "down":
+				if state.ActiveInput == 5 {
+					m.browseHistory(&state.InputSearch, space, msg.String() == "up")
+					return m, nil
+				}
+			case "tab", "shift+tab":
+				if state.ActiveInput > formInputs {
+					break // The search field is not part of the form
+				}
+				if cmd := m.applyInputs(space, state); cmd != nil {
+					cmds = append(cmds, cmd)
+				}
+				_ = m.Sessions.Save(m.Session)
+				m.moveFormFocus(state, formStep(msg.String()))
+				return m, tea.Batch(append(cmds, textinput.Blink)...)
 			case "enter":
 				state.ActiveInput = 0
 				blurAll(state)
 
-				if state.InputRoot.Value() != space.RootPath {
-					space.RootPath = state.InputRoot.Value()
-					state.TreeRoot = &TreeNode{
-						Name:     filepath.Base(space.RootPath),
-						FullPath: space.RootPath,
-						IsDir:    true,
-						Expanded: true,
-					}
-					state.rebuildVisibleList()
-					m.Loading = true
-					cmds = append(cmds, loadDirectoryCmd(space.RootPath))
+				if cmd := m.applyInputs(space, state); cmd != nil {
+					cmds = append(cmds, cmd)
 				}
-				space.OutputFilePath = state.InputOutput.Value()
-				space.Config.IncludePatterns = splitClean(state.InputInclude.Value())
-				space.Config.ExcludePatterns = splitClean(state.InputExclude.Value())
 
 				if state.InputSearch.Value() != "" {
 					state.SearchQuery = state.InputSearch.Value()
+					m.recordSearch(space, state.SearchQuery)
+					if files, ok := m.GlobalSearchCache[space.RootPath]; ok {
+						state.IndexedFiles = files
+					} else {
+						cmds = append(cmds, findAllFilesCmd(m.FS, space.RootPath))
+					}
 					state.PerformSearch()
+					hidden := state.hiddenMatchCount()
 					if len(state.MatchIndices) > 0 {
 						state.CursorIndex = state.MatchIndices[0]
 						state.MatchPtr = 0
-						m.StatusMessage = fmt.Sprintf("Found %d matches", len(state.MatchIndices))
+						m.notify(SeverityInfo, fmt.Sprintf(tr("Found %d matches"), len(state.MatchIndices)+hidden))
+					} else if hidden > 0 {
+						state.CursorIndex = 0
+						if cmd := state.jumpToMatch(m.Dirs, true); cmd != nil {
+							m.Loading = true
+							cmds = append(cmds, cmd)
+						}
+						m.notify(SeverityInfo, fmt.Sprintf(tr("Found %d matches in collapsed folders"), hidden))
 					} else {
-						m.StatusMessage = "No matches found"
+						m.notify(SeverityWarn, tr("No matches found"))
 					}
 				}
 
-				sm := core.NewSessionManager("")
+				sm := m.Sessions
 				_ = sm.Save(m.Session)
 				return m, tea.Batch(cmds...)
 			}
@@ -273,15 +987,38 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return m, cmd
 	}
 
+	// Handle a focused sidebar option, which answers to the keys of a form
+	if keyMsg, ok := msg.(tea.KeyMsg); ok && state != nil && state.ActiveOption > 0 {
+		opt := m.keys.sidebarOptions()[state.ActiveOption-1]
+		switch keyMsg.String() {
+		case "tab", "shift+tab":
+			m.moveFormFocus(state, formStep(keyMsg.String()))
+			return m, textinput.Blink
+		case "esc":
+			state.ActiveOption = 0
+			return m, nil
+		case "enter", " ", "right":
+			return m.Update(optionKey(opt, false))
+		case "left":
+			return m.Update(optionKey(opt, true))
+		}
+	}
+
 	switch msg := msg.(type) {
 
 	case DirLoadedMsg:
+		userInitiated := m.Loading
 		m.Loading = false
 		if msg.Err != nil {
-			m.StatusMessage = "Error: " + msg.Err.Error()
+			m.notify(SeverityError, tr("Error: ")+msg.Err.Error())
 		} else {
 			if state != nil {
-				m.populateChildren(state, msg.Path, msg.Entries)
+				added := m.populateChildren(state, msg.Path, msg.Entries)
+				m.notifyNewlyIncluded(markNewlyIncluded(space, added))
+				if msg.Path == space.RootPath && !m.StaleChecked[space.ID] {
+					m.StaleChecked[space.ID] = true
+					cmds = append(cmds, loadStaleSelectionsCmd(space, false))
+				}
 
 				var newCmds []tea.Cmd
 				var checkChildren func(node *TreeNode)
@@ -290,7 +1027,7 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 						if child.IsDir && state.TargetExpandedPaths[child.FullPath] {
 							if !child.Expanded {
 								child.Expanded = true
-								newCmds = append(newCmds, loadDirectoryCmd(child.FullPath))
+								newCmds = append(newCmds, loadDirectoryCmd(m.Dirs, child.FullPath))
 							}
 							if len(child.Children) > 0 {
 								checkChildren(child)
@@ -331,7 +1068,9 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					}
 				}
 
-				m.StatusMessage = fmt.Sprintf("Loaded %d items", len(msg.Entries))
+				if userInitiated {
+					m.notify(SeverityInfo, fmt.Sprintf(tr("Loaded %d items"), len(msg.Entries)))
+				}
 			}
 		}
 
@@ -347,47 +1086,73 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.ExportProgress = 0
 		m.ExportTotal = 0
 		m.ExportProcessed = 0
+		// The exported tab, which need not be the active one by now
+		exported := m.spaceByID(msg.SpaceID)
+		switch {
+		case msg.Err != nil:
+			m.notify(SeverityError, tr("Failed: ")+msg.Err.Error())
+		case msg.DryRun:
+			m.notify(SeverityInfo, fmt.Sprintf(tr("Read-only: would export %d files (~%d tokens) to %s"),
+				msg.Count, msg.Tokens, msg.Output))
+		default:
+			m.LastExport = msg.Summary
+			m.notify(SeverityInfo, fmt.Sprintf(tr("✓ Exported %d files (~%d tokens) to %s"),
+				msg.Count, msg.Tokens, msg.Output))
+			if exported != nil {
+				exported.Config.SelectionHashes = msg.Hashes
+			}
+		}
+		if exported != nil {
+			cmds = append(cmds, loadBranchCmd(exported.RootPath)) // Match the branch in the report
+			_ = m.Sessions.Save(m.Session)                        // Keep the selection hashes recorded by the export
+		}
 		if msg.Err != nil {
-			m.StatusMessage = "Failed: " + msg.Err.Error()
-		} else {
-			m.StatusMessage = fmt.Sprintf("✓ Exported %d files (~%d tokens) to %s",
-				msg.Count, msg.Tokens, filepath.Base(space.OutputFilePath))
+			m.StartupActions = nil // Later steps would rely on the export
+		}
+		cmds = append(cmds, m.runStartupActions())
+
+	case StartupActionsMsg:
+		cmds = append(cmds, m.runStartupActions())
+
+	case QuickExportMsg:
+		m.Loading = false
+		if msg.Summary != nil {
+			m.LastExport = msg.Summary
+			if exported := m.spaceByID(msg.SpaceID); exported != nil {
+				exported.Config.SelectionHashes = msg.Hashes
+				_ = m.Sessions.Save(m.Session)
+			}
+		}
+		switch {
+		case msg.OSC52 != "":
+			cmds = append(cmds, osc52CopyCmd(msg))
+		case msg.Err != nil:
+			m.notify(SeverityError, tr("Failed: ")+msg.Err.Error())
+		case msg.CopyErr != nil:
+			m.notify(SeverityWarn, fmt.Sprintf(tr("Exported %d files (~%d tokens), copy failed: %v"), msg.Count, msg.Tokens, msg.CopyErr))
+		default:
+			m.notify(SeverityInfo, fmt.Sprintf(tr("✓ Exported %d files (~%d tokens) and copied"), msg.Count, msg.Tokens))
 		}
 
 	case tea.KeyMsg:
 		switch {
 		case key.Matches(msg, m.keys.ToggleTheme):
-			nextTheme := GetNextTheme(m.Session.Theme)
-			m.Session.Theme = nextTheme
+			m.applyTheme(GetNextTheme(m.Session.Theme))
+			_ = m.Sessions.Save(m.Session)
+			m.notify(SeverityInfo, tr("Theme: ")+themeTitle(m.Session.Theme))
 
-			palette := GetTheme(nextTheme)
-			m.Styles = DefaultStyles(palette)
+		case key.Matches(msg, m.keys.PickTheme):
+			m.openThemePicker()
 
-			m.Help.Styles.FullKey = m.Styles.HelpKey
-			m.Help.Styles.ShortKey = m.Styles.HelpKey
-			m.Help.Styles.FullDesc = m.Styles.HelpDesc
-			m.Help.Styles.ShortDesc = m.Styles.HelpDesc
-			m.Spinner.Style = lipgloss.NewStyle().Foreground(m.Styles.ColorMauve)
+		case key.Matches(msg, m.keys.PickTask):
+			m.openTaskPicker()
 
-			updateInputStyle(&m.NewTabInput, m.Styles)
-			updateInputStyle(&m.GlobalSearchInput, m.Styles)
-			for _, ts := range m.TabStates {
-				updateInputStyle(&ts.InputRoot, m.Styles)
-				updateInputStyle(&ts.InputOutput, m.Styles)
-				updateInputStyle(&ts.InputInclude, m.Styles)
-				updateInputStyle(&ts.InputExclude, m.Styles)
-				updateInputStyle(&ts.InputSearch, m.Styles)
-			}
-
-			sm := core.NewSessionManager("")
-			_ = sm.Save(m.Session)
-
-			displayTheme := strings.ToUpper(string(nextTheme[0])) + nextTheme[1:]
-			m.StatusMessage = "Theme: " + displayTheme
+		case key.Matches(msg, m.keys.Sidebar):
+			m.SidebarToggled = !m.SidebarToggled
 
 		case key.Matches(msg, m.keys.Quit):
 			m.syncStateToSession()
-			sm := core.NewSessionManager("")
+			sm := m.Sessions
 			_ = sm.Save(m.Session)
 			return m, tea.Quit
 
@@ -397,37 +1162,123 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					state.SearchQuery = ""
 					state.MatchIndices = []int{}
 					state.InputSearch.SetValue("")
-					m.StatusMessage = "Search cleared"
+					m.notify(SeverityInfo, tr("Search cleared"))
 				}
 			}
 
 		case key.Matches(msg, m.keys.SelectAll):
 			if space != nil {
+				m.pushSelectionUndo(space, "selecting the root")
 				selectAll(space)
-				sm := core.NewSessionManager("")
+				sm := m.Sessions
 				_ = sm.Save(m.Session)
-				m.StatusMessage = "✓ Selected All"
+				m.notify(SeverityInfo, tr("✓ Selected the root; new files are included too"))
+			}
+
+		case key.Matches(msg, m.keys.SelectFiles):
+			if space != nil {
+				m.Loading = true
+				m.notify(SeverityInfo, tr("Listing files..."))
+				cmds = append(cmds, selectFilesCmd(space))
+			}
+
+		case key.Matches(msg, m.keys.SmartSelect):
+			if space != nil {
+				m.Loading = true
+				m.notify(SeverityInfo, tr("Picking a starting selection..."))
+				cmds = append(cmds, smartSelectCmd(space))
+			}
+
+		case key.Matches(msg, m.keys.UndoSelect):
+			if space != nil {
+				m.undoSelection(space)
 			}
 
 		case key.Matches(msg, m.keys.DeselectAll):
 			if space != nil {
+				m.pushSelectionUndo(space, "deselecting all")
 				deselectAll(space)
-				sm := core.NewSessionManager("")
+				sm := m.Sessions
 				_ = sm.Save(m.Session)
-				m.StatusMessage = "✓ Deselected All"
+				m.notify(SeverityInfo, tr("✓ Deselected All"))
 			}
 		case key.Matches(msg, m.keys.Help):
 			m.ShowHelp = !m.ShowHelp
 
+		case key.Matches(msg, m.keys.MessageLog):
+			m.ShowMessageLog = true
+			m.MessageLogOffset = 0
+
+		case key.Matches(msg, m.keys.Offenders):
+			if space != nil {
+				m.Loading = true
+				m.notify(SeverityInfo, tr("Ranking selected files..."))
+				cmds = append(cmds, loadOffendersCmd(space))
+			}
+
+		case key.Matches(msg, m.keys.Preview):
+			if state != nil && len(state.VisibleNodes) > 0 {
+				node := state.VisibleNodes[state.CursorIndex]
+				if node.IsDir {
+					m.notify(SeverityWarn, tr("Select a file to preview"))
+					break
+				}
+				m.Loading = true
+				cmds = append(cmds, loadPreviewCmd(node.FullPath))
+			}
+
+		case key.Matches(msg, m.keys.Stale):
+			if space != nil {
+				m.Loading = true
+				cmds = append(cmds, loadStaleSelectionsCmd(space, true))
+			}
+
+		case key.Matches(msg, m.keys.NestedRepos):
+			if space != nil {
+				m.Loading = true
+				cmds = append(cmds, loadNestedReposCmd(space))
+			}
+
+		case key.Matches(msg, m.keys.CompareTabs):
+			others := m.otherSpaces()
+			if len(others) == 0 {
+				m.notify(SeverityWarn, tr("Open another tab to compare selections"))
+				break
+			}
+			m.ShowCompare = true
+			m.CompareTargetID = ""
+			m.CompareCursor = 0
+			if len(others) == 1 {
+				m.CompareTargetID = others[0].ID
+			}
+
+		case key.Matches(msg, m.keys.BuildIndex):
+			if m.IndexCancel != nil {
+				m.IndexCancel()
+			} else if space != nil {
+				ctx, cancel := context.WithCancel(context.Background())
+				m.IndexCancel = cancel
+				m.IndexRoot = space.RootPath
+				m.IndexedFiles = 0
+				m.notify(SeverityInfo, fmt.Sprintf(tr("Indexing %s..."), filepath.Base(space.RootPath)))
+				cmds = append(cmds, buildIndexCmd(ctx, space.RootPath, space.Config, !m.ReadOnly))
+			}
+
 		case key.Matches(msg, m.keys.Refresh):
 			if state != nil && state.TreeRoot != nil {
 				m.Loading = true
-				m.StatusMessage = "Refreshing view..."
-				cmds = append(cmds, loadDirectoryCmd(space.RootPath))
+				m.notify(SeverityInfo, tr("Refreshing view..."))
+				m.Dirs.Invalidate() // Refresh must also pick up edited files
+				delete(m.GlobalSearchCache, space.RootPath)
+				state.IndexedFiles = nil
+				if state.SearchQuery != "" {
+					cmds = append(cmds, findAllFilesCmd(m.FS, space.RootPath))
+				}
+				cmds = append(cmds, loadDirectoryCmd(m.Dirs, space.RootPath), loadBranchCmd(space.RootPath), loadVendoredCmd(space))
 				expanded := CollectExpandedPaths(state.TreeRoot)
 				for _, p := range expanded {
 					if p != space.RootPath {
-						cmds = append(cmds, loadDirectoryCmd(p))
+						cmds = append(cmds, loadDirectoryCmd(m.Dirs, p))
 					}
 				}
 			}
@@ -444,57 +1295,92 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Reset selections on new search
 				m.GlobalSearchSelected = make(map[string]bool)
 
-				if cached, ok := m.GlobalSearchCache[space.RootPath]; ok {
+				if m.GlobalSearchContent {
+					m.ContentBackend = core.ContentSearchBackend()
+					m.setContentMatches(nil)
+					cmds = append(cmds, m.queueContentSearch())
+				} else if cached, ok := m.GlobalSearchCache[space.RootPath]; ok {
 					m.GlobalSearchFiles = cached
 					m.filterGlobalSearch()
 				} else {
 					m.GlobalSearchFiles = []string{}
-					m.StatusMessage = "Indexing files..."
-					cmds = append(cmds, findAllFilesCmd(space.RootPath))
+					m.notify(SeverityInfo, tr("Indexing files..."))
+					cmds = append(cmds, findAllFilesCmd(m.FS, space.RootPath))
 				}
 				return m, tea.Batch(append(cmds, textinput.Blink)...)
 			}
 
 		case key.Matches(msg, m.keys.CloseTab):
 			if space != nil && len(m.Session.Spaces) > 1 {
-				sm := core.NewSessionManager("")
+				sm := m.Sessions
 				if err := sm.RemoveSpace(m.Session, space.ID); err != nil {
-					m.StatusMessage = "Error: " + err.Error()
+					m.notify(SeverityError, tr("Error: ")+err.Error())
 				} else {
 					delete(m.TabStates, space.ID)
-					m.StatusMessage = fmt.Sprintf("✓ Closed tab: %s", filepath.Base(space.RootPath))
-					newSpace := m.Session.GetActiveSpace()
-					if newSpace != nil {
-						newState := m.TabStates[newSpace.ID]
-						if newState != nil && len(newState.TreeRoot.Children) == 0 {
-							cmds = append(cmds, loadDirectoryCmd(newSpace.RootPath))
-						}
+					m.notify(SeverityInfo, fmt.Sprintf(tr("✓ Closed tab: %s"), filepath.Base(space.RootPath)))
+					if cmd := m.loadActiveTreeCmd(); cmd != nil {
+						cmds = append(cmds, cmd)
 					}
 				}
 			} else {
-				m.StatusMessage = "Cannot close the last tab"
+				m.notify(SeverityWarn, tr("Cannot close the last tab"))
+			}
+
+		case key.Matches(msg, m.keys.Sessions):
+			m.ShowSessions = true
+			m.SessionNames = core.ListSessions()
+			m.SessionsCursor = max(0, slices.Index(m.SessionNames, m.Sessions.Name))
+
+		case key.Matches(msg, m.keys.SwitchGroup):
+			if len(m.Session.Groups()) == 0 {
+				m.notify(SeverityWarn, tr("No groups yet: move a tab into one with ctrl+g"))
+				break
+			}
+			m.ShowGroups = true
+			m.GroupsCursor = 0
+			if i := slices.Index(m.Session.Groups(), m.Session.ActiveGroup); i >= 0 {
+				m.GroupsCursor = i + 1
+			}
+
+		case key.Matches(msg, m.keys.AssignGroup):
+			if space != nil {
+				m.ShowGroupInput = true
+				m.GroupInput.SetValue(space.Group)
+				m.GroupInput.CursorEnd()
+				m.GroupInput.Focus()
+				return m, textinput.Blink
+			}
+
+		case key.Matches(msg, m.keys.DuplicateTab):
+			if space != nil {
+				m.syncStateToSession()
+				sm := m.Sessions
+				newSpace, err := sm.DuplicateSpace(m.Session, space.ID)
+				if err != nil {
+					m.notify(SeverityError, tr("Error: ")+err.Error())
+				} else {
+					m.TabStates[newSpace.ID] = newTabState(newSpace, m.Styles)
+					m.notify(SeverityInfo, fmt.Sprintf(tr("✓ Duplicated tab: %s"), filepath.Base(space.RootPath)))
+					cmds = append(cmds, loadDirectoryCmd(m.Dirs, newSpace.RootPath))
+				}
 			}
 
 		case key.Matches(msg, m.keys.Tab):
-			if len(m.Session.Spaces) > 1 {
+			if visible := m.Session.VisibleSpaces(); len(visible) > 1 {
 				m.syncStateToSession()
 				currIdx := 0
-				for i, s := range m.Session.Spaces {
+				for i, s := range visible {
 					if s.ID == space.ID {
 						currIdx = i
 						break
 					}
 				}
-				nextIdx := (currIdx + 1) % len(m.Session.Spaces)
-				m.Session.ActiveSpaceID = m.Session.Spaces[nextIdx].ID
-				newSpace := m.Session.GetActiveSpace()
-				if newSpace != nil {
-					newState := m.TabStates[newSpace.ID]
-					if newState != nil && len(newState.TreeRoot.Children) == 0 {
-						cmds = append(cmds, loadDirectoryCmd(newSpace.RootPath))
-					}
+				nextIdx := (currIdx + 1) % len(visible)
+				m.Session.ActiveSpaceID = visible[nextIdx].ID
+				if cmd := m.loadActiveTreeCmd(); cmd != nil {
+					cmds = append(cmds, cmd)
 				}
-				sm := core.NewSessionManager("")
+				sm := m.Sessions
 				_ = sm.Save(m.Session)
 			}
 
@@ -525,22 +1411,12 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 			return m, textinput.Blink
 
-		case key.Matches(msg, m.keys.NextMatch):
-			if state != nil && len(state.MatchIndices) > 0 {
-				state.MatchPtr++
-				if state.MatchPtr >= len(state.MatchIndices) {
-					state.MatchPtr = 0
-				}
-				state.CursorIndex = state.MatchIndices[state.MatchPtr]
-			}
-
-		case key.Matches(msg, m.keys.PrevMatch):
-			if state != nil && len(state.MatchIndices) > 0 {
-				state.MatchPtr--
-				if state.MatchPtr < 0 {
-					state.MatchPtr = len(state.MatchIndices) - 1
+		case key.Matches(msg, m.keys.NextMatch), key.Matches(msg, m.keys.PrevMatch):
+			if state != nil {
+				if cmd := state.jumpToMatch(m.Dirs, key.Matches(msg, m.keys.NextMatch)); cmd != nil {
+					m.Loading = true
+					cmds = append(cmds, cmd)
 				}
-				state.CursorIndex = state.MatchIndices[state.MatchPtr]
 			}
 
 		case key.Matches(msg, m.keys.ToggleI):
@@ -559,6 +1435,83 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if space != nil {
 				space.Config.StructureView = !space.Config.StructureView
 			}
+		case key.Matches(msg, m.keys.ToggleMap):
+			if space != nil {
+				space.Config.FullTreeMap = !space.Config.FullTreeMap
+			}
+
+		case key.Matches(msg, m.keys.ToggleJunk):
+			if space != nil {
+				space.Config.SkipJunk = !space.Config.SkipJunk
+			}
+		case key.Matches(msg, m.keys.ToPatterns):
+			if space != nil && state != nil {
+				m.selectionToPatterns(space, state)
+			}
+
+		case key.Matches(msg, m.keys.ToggleGit):
+			if space != nil {
+				space.Config.GitInfo = !space.Config.GitInfo
+			}
+		case key.Matches(msg, m.keys.AutoNew):
+			if space != nil {
+				space.Config.AutoSelectNew = !space.Config.AutoSelectNew
+			}
+		case key.Matches(msg, m.keys.Generated):
+			if space != nil {
+				cycleGeneratedPolicy(space)
+			}
+		case key.Matches(msg, m.keys.Tests):
+			if space != nil {
+				cycleTestsPolicy(space)
+			}
+		case key.Matches(msg, m.keys.Inspect):
+			if space != nil && state != nil && state.CursorIndex < len(state.VisibleNodes) {
+				m.Loading = true
+				cmds = append(cmds, inspectCmd(space, state.VisibleNodes[state.CursorIndex].FullPath))
+			}
+		case key.Matches(msg, m.keys.DimExcluded):
+			if space != nil {
+				space.Config.DimExcluded = !space.Config.DimExcluded
+			}
+		case key.Matches(msg, m.keys.ExcludeThis):
+			if space != nil && state != nil && state.CursorIndex < len(state.VisibleNodes) {
+				m.excludeNode(space, state, state.VisibleNodes[state.CursorIndex])
+			}
+		case key.Matches(msg, m.keys.Unexclude):
+			if space != nil && state != nil && state.CursorIndex < len(state.VisibleNodes) {
+				node := state.VisibleNodes[state.CursorIndex]
+				switch pattern, junk := unexclude(space, node.FullPath); {
+				case pattern == "":
+					m.notify(SeverityInfo, fmt.Sprintf(tr("%s is not excluded"), node.Name))
+				case junk:
+					m.notify(SeverityWarn, fmt.Sprintf(tr("%s is junk (%s); turn off Skip Junk (z) to include it"), node.Name, pattern))
+				default:
+					state.InputExclude.SetValue(strings.Join(space.Config.ExcludePatterns, ", "))
+					m.notify(SeverityInfo, fmt.Sprintf(tr("Removed exclude pattern %q"), pattern))
+					_ = m.Sessions.Save(m.Session)
+				}
+			}
+		case key.Matches(msg, m.keys.Format):
+			if space != nil {
+				cycleOutputFormat(space)
+			}
+		case key.Matches(msg, m.keys.LineNumbers):
+			if space != nil {
+				space.Config.LineNumbers = !space.Config.LineNumbers
+			}
+		case key.Matches(msg, m.keys.Minify):
+			if space != nil {
+				space.Config.MinifyContent = !space.Config.MinifyContent
+			}
+		case key.Matches(msg, m.keys.DepthDown):
+			if space != nil {
+				stepStructureDepth(space, -1)
+			}
+		case key.Matches(msg, m.keys.DepthUp):
+			if space != nil {
+				stepStructureDepth(space, 1)
+			}
 
 		case key.Matches(msg, m.keys.Up):
 			if state != nil {
@@ -583,8 +1536,10 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			if state != nil && len(state.VisibleNodes) > 0 {
 				node := state.VisibleNodes[state.CursorIndex]
 				toggleSelection(space, node.FullPath)
-				sm := core.NewSessionManager("")
+				node.NewlyIncluded = false
+				sm := m.Sessions
 				_ = sm.Save(m.Session)
+				cmds = append(cmds, m.scanSelection(space, node))
 			}
 
 		case key.Matches(msg, m.keys.Right):
@@ -594,8 +1549,8 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					node.Expanded = !node.Expanded
 					if node.Expanded && len(node.Children) == 0 {
 						m.Loading = true
-						m.StatusMessage = fmt.Sprintf("Loading %s...", node.Name)
-						cmds = append(cmds, loadDirectoryCmd(node.FullPath))
+						m.notify(SeverityInfo, fmt.Sprintf(tr("Loading %s..."), node.Name))
+						cmds = append(cmds, loadDirectoryCmd(m.Dirs, node.FullPath))
 					} else {
 						state.rebuildVisibleList()
 					}
@@ -620,28 +1575,54 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		case key.Matches(msg, m.keys.Save):
 			m.syncStateToSession()
-			sm := core.NewSessionManager("")
+			sm := m.Sessions
 			if err := sm.Save(m.Session); err != nil {
-				m.StatusMessage = iconSave + " Error: " + err.Error()
+				m.notify(SeverityError, iconSave+" "+tr("Error: ")+err.Error())
 			} else {
-				m.StatusMessage = iconSave + " Session Saved"
+				m.notify(SeverityInfo, iconSave+" "+tr("Session Saved"))
 			}
 
 		case key.Matches(msg, m.keys.Export):
 			if space != nil {
-				space.Config.AlwaysShowStructure = []string{}
-				if space.Config.StructureView && state != nil && state.TreeRoot != nil {
-					space.Config.AlwaysShowStructure = CollectExpandedPaths(state.TreeRoot)
+				cmds = append(cmds, m.requestExport(space, state))
+			}
+
+		case key.Matches(msg, m.keys.QuickExport):
+			if space != nil {
+				if m.ReadOnly {
+					m.notify(SeverityWarn, tr("Quick export skipped: read-only"))
+					break
 				}
+				if m.Loading {
+					m.notify(SeverityWarn, tr("Quick export skipped: busy"))
+					break
+				}
+				// The snapshot may write elsewhere than the tab does now
+				snapshot, opts := m.lastExportSnapshot(space), m.exportOptions()
+				if err := checkOutputPaths(snapshot, opts); err != nil {
+					m.notify(SeverityError, tr("Quick export skipped: ")+err.Error())
+					break
+				}
+				m.Loading = true
+				cmds = append(cmds, quickExportCmd(snapshot, opts))
+			}
 
+		case key.Matches(msg, m.keys.ExportDiff):
+			if space != nil {
+				m.snapshotStructure(space, state)
 				m.Loading = true
-				m.ExportProgress = 0
-				m.StatusMessage = "Starting export..."
-				cmds = append(cmds, runExportCmd(space))
+				m.notify(SeverityInfo, tr("Comparing with the last export..."))
+				cmds = append(cmds, previewExportCmd(space, m.exportOptions()))
 			}
 		}
 	}
 
+	if _, ok := msg.(tea.KeyMsg); ok {
+		if cmd := m.scheduleDirEstimate(space, state); cmd != nil {
+			cmds = append(cmds, cmd)
+		}
+	}
+
 	if m.Loading {
 		m.Spinner, cmd = m.Spinner.Update(msg)
 		cmds = append(cmds, cmd)
@@ -650,6 +1631,102 @@ func (m AppModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// applyInputs copies the root, output and pattern inputs of the sidebar into
+// space, reloading the tree when the root changed.
+func (m *AppModel) applyInputs(space *core.DirectorySpace, state *TabState) tea.Cmd {
+	var cmd tea.Cmd
+	if state.InputRoot.Value() != space.RootPath {
+		space.RootPath = state.InputRoot.Value()
+		state.TreeRoot = &TreeNode{
+			Name:     filepath.Base(space.RootPath),
+			FullPath: space.RootPath,
+			IsDir:    true,
+			Expanded: true,
+		}
+		state.rebuildVisibleList()
+		m.Loading = true
+		cmd = loadDirectoryCmd(m.Dirs, space.RootPath)
+	}
+	space.OutputFilePath = state.InputOutput.Value()
+	space.Config.IncludePatterns = splitClean(state.InputInclude.Value())
+	space.Config.ExcludePatterns = splitClean(state.InputExclude.Value())
+	return cmd
+}
+
+// requestExport starts the export, or asks first when the output path holds
+// a file PandaBrew did not write.
+func (m *AppModel) requestExport(space *core.DirectorySpace, state *TabState) tea.Cmd {
+	if m.ReadOnly {
+		return m.startExport(space, state) // Nothing gets overwritten
+	}
+	if err := checkOutputPaths(space, m.exportOptions()); err != nil {
+		if errors.Is(err, core.ErrForeignOutput) {
+			m.ShowConfirmOverwrite = true
+			return nil
+		}
+		m.notify(SeverityError, tr("Error: ")+err.Error())
+		return nil
+	}
+	return m.startExport(space, state)
+}
+
+// snapshotStructure copies the expanded folders of the tree into the config
+// when the report mirrors the view.
+func (m *AppModel) snapshotStructure(space *core.DirectorySpace, state *TabState) {
+	space.Config.AlwaysShowStructure = []string{}
+	if space.Config.StructureView && state != nil && state.TreeRoot != nil {
+		space.Config.AlwaysShowStructure = CollectExpandedPaths(state.TreeRoot)
+	}
+}
+
+// startExport snapshots the view-derived config and kicks off the export.
+func (m *AppModel) startExport(space *core.DirectorySpace, state *TabState) tea.Cmd {
+	m.snapshotStructure(space, state)
+	last := *space
+	last.Config = space.Config.Clone()
+	m.LastExports[space.ID] = &last
+	snapshot := last
+	snapshot.Config = space.Config.Clone()
+
+	m.Loading = true
+	m.ExportProgress = 0
+	m.notify(SeverityInfo, tr("Starting export..."))
+	return runExportCmd(&snapshot, m.exportOptions())
+}
+
+// exportOptions are the ExportOptions of the next export, a dry run when
+// the model is read-only.
+func (m *AppModel) exportOptions() core.ExtractOptions {
+	opts := m.ExportOptions
+	opts.DryRun = opts.DryRun || m.ReadOnly
+	return opts
+}
+
+// toggleGlobalSearchMark stages (or unstages) a change to the selection of
+// path, to be applied when the search is confirmed.
+func (m *AppModel) toggleGlobalSearchMark(path string) {
+	if m.GlobalSearchSelected[path] {
+		delete(m.GlobalSearchSelected, path)
+	} else {
+		m.GlobalSearchSelected[path] = true
+	}
+}
+
+// markAllGlobalSearch stages every filtered result as checked. When they all
+// already show as checked, it stages them as unchecked instead.
+func (m *AppModel) markAllGlobalSearch(space *core.DirectorySpace) {
+	checked := func(path string) bool {
+		return core.IsChecked(space, path) != m.GlobalSearchSelected[path]
+	}
+	// Check all unless nothing is left unchecked
+	want := slices.ContainsFunc(m.GlobalSearchFiles, func(path string) bool { return !checked(path) })
+	for _, path := range m.GlobalSearchFiles {
+		if checked(path) != want {
+			m.toggleGlobalSearchMark(path)
+		}
+	}
+}
+
 func (m *AppModel) filterGlobalSearch() {
 	space := m.Session.GetActiveSpace()
 	if space == nil {
@@ -691,3 +1768,34 @@ func updateInputStyle(t *textinput.Model, s Styles) {
 	t.Cursor.Style = lipgloss.NewStyle().Foreground(s.ColorMauve)
 	t.Cursor.TextStyle = lipgloss.NewStyle().Background(s.ColorBase)
 }
+
+// selectionToPatterns replaces the manual selections with equivalent include
+// patterns, keeping the selections no pattern can express.
+func (m *AppModel) selectionToPatterns(space *core.DirectorySpace, state *TabState) {
+	if !space.Config.IncludeMode {
+		m.notify(SeverityWarn, tr("Patterns only apply in include mode"))
+		return
+	}
+	if len(space.Config.ManualSelections) == 0 {
+		m.notify(SeverityWarn, tr("Nothing selected"))
+		return
+	}
+	patterns, kept := core.SelectionToPatterns(space)
+	added := 0
+	for _, p := range patterns {
+		if !slices.Contains(space.Config.IncludePatterns, p) {
+			space.Config.IncludePatterns = append(space.Config.IncludePatterns, p)
+			added++
+		}
+	}
+	space.Config.ManualSelections = kept
+	space.Config.ManualDeselections = nil // Left out by the patterns
+	state.InputInclude.SetValue(strings.Join(space.Config.IncludePatterns, ", "))
+	_ = m.Sessions.Save(m.Session)
+
+	msg := fmt.Sprintf(tr("✓ Added %d include patterns"), added)
+	if len(kept) > 0 {
+		msg += fmt.Sprintf(tr(", kept %d selections"), len(kept))
+	}
+	m.notify(SeverityInfo, msg)
+}
@@ -0,0 +1,212 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestGlobalSearchTyping(t *testing.T) {
+	space := &core.DirectorySpace{ID: "a", RootPath: "/r"}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.GlobalSearchCache["/r"] = []string{"/r/a b.go", "/r/ab.go", "/r/c.go"}
+	m.ShowGlobalSearch = true
+	m.GlobalSearchInput.Focus()
+	m.filterGlobalSearch()
+
+	send := func(keys ...tea.KeyMsg) {
+		for _, k := range keys {
+			updated, _ := m.Update(k)
+			m = updated.(AppModel)
+		}
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	// Space is part of the query while typing
+	send(runes("a"), tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}, runes("b"))
+	if got := m.GlobalSearchInput.Value(); got != "a b" || len(m.GlobalSearchSelected) != 0 {
+		t.Fatalf("query %q, marked %v", got, m.GlobalSearchSelected)
+	}
+
+	// Once the results are moved through, it marks the one under the cursor
+	send(tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if len(m.GlobalSearchSelected) != 1 || m.GlobalSearchInput.Value() != "a b" {
+		t.Fatalf("space after moving: query %q, marked %v", m.GlobalSearchInput.Value(), m.GlobalSearchSelected)
+	}
+
+	// Typing again goes back to the query
+	send(runes("."), tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if got := m.GlobalSearchInput.Value(); got != "a b. " || len(m.GlobalSearchSelected) != 1 {
+		t.Errorf("query %q, marked %v", got, m.GlobalSearchSelected)
+	}
+}
+
+func TestContentSearchToggleWithoutTab(t *testing.T) {
+	m := InitialModel(&core.Session{}, nil)
+	m.ShowGlobalSearch = true
+	m.GlobalSearchInput.Focus()
+
+	// Toggling back to file names must not need an open tab
+	for range 2 {
+		updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+		m = updated.(AppModel)
+	}
+	if m.GlobalSearchContent || m.GlobalSearchFiles != nil {
+		t.Errorf("content mode %v, results %v", m.GlobalSearchContent, m.GlobalSearchFiles)
+	}
+}
+
+func TestConfirmOverwrite(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(out, []byte("notes of my own\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	space := &core.DirectorySpace{
+		ID:             "a",
+		RootPath:       root,
+		OutputFilePath: out,
+		Config:         core.ExtractionConfig{IncludeMode: true, ManualSelections: []string{filepath.Join(root, "a.go")}},
+	}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	m.Width, m.Height = 120, 40
+	press := func(k tea.KeyMsg) tea.Cmd {
+		updated, cmd := m.Update(k)
+		m = updated.(AppModel)
+		return cmd
+	}
+	exportKey := tea.KeyMsg{Type: tea.KeyCtrlE}
+
+	// Any key but y keeps the file PandaBrew did not write
+	press(exportKey)
+	if !m.ShowConfirmOverwrite || m.Loading {
+		t.Fatal("export of a foreign output file did not ask first")
+	}
+	press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	if data, _ := os.ReadFile(out); m.ShowConfirmOverwrite || string(data) != "notes of my own\n" {
+		t.Fatalf("declined overwrite: prompt %v, output %q", m.ShowConfirmOverwrite, data)
+	}
+	if last := m.MessageLog[len(m.MessageLog)-1].Text; last != "Export cancelled" {
+		t.Errorf("last message = %q", last)
+	}
+
+	// y exports over it, and the report is then PandaBrew's own
+	press(exportKey)
+	cmd := press(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if cmd == nil || m.ShowConfirmOverwrite {
+		t.Fatal("confirmed overwrite did not export")
+	}
+	updated, _ := m.Update(cmd())
+	m = updated.(AppModel)
+	if data, _ := os.ReadFile(out); !strings.Contains(string(data), "--- file: a.go ---") {
+		t.Fatalf("output = %q", data)
+	}
+	press(exportKey)
+	if m.ShowConfirmOverwrite {
+		t.Error("asked to overwrite a report PandaBrew wrote")
+	}
+}
+
+func TestExportCompleteAfterTabSwitch(t *testing.T) {
+	var spaces []*core.DirectorySpace
+	for _, id := range []string{"a", "b"} {
+		root := t.TempDir()
+		file := filepath.Join(root, id+".go")
+		if err := os.WriteFile(file, []byte("package "+id+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		spaces = append(spaces, &core.DirectorySpace{
+			ID:             id,
+			RootPath:       root,
+			OutputFilePath: filepath.Join(t.TempDir(), id+"-report.txt"),
+			Config:         core.ExtractionConfig{IncludeMode: true, ManualSelections: []string{file}},
+		})
+	}
+	m := InitialModel(&core.Session{Spaces: spaces, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	m.Width, m.Height = 120, 40
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	m = updated.(AppModel)
+	msg, ok := findMsg[ExportCompleteMsg](cmd)
+	if !ok {
+		t.Fatal("export did not start")
+	}
+	// The export of a completes once b is the active tab
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m = updated.(AppModel)
+	if m.Session.ActiveSpaceID != "b" {
+		t.Fatalf("active tab = %q, want b", m.Session.ActiveSpaceID)
+	}
+	updated, _ = m.Update(msg)
+	m = updated.(AppModel)
+
+	if len(spaces[0].Config.SelectionHashes) != 1 || len(spaces[1].Config.SelectionHashes) != 0 {
+		t.Errorf("hashes recorded on a: %v, on b: %v", spaces[0].Config.SelectionHashes, spaces[1].Config.SelectionHashes)
+	}
+	if last := m.MessageLog[len(m.MessageLog)-1].Text; !strings.Contains(last, "a-report.txt") {
+		t.Errorf("last message = %q, want the output of a", last)
+	}
+}
+
+func TestMarkAllGlobalSearch(t *testing.T) {
+	m := InitialModel(&core.Session{}, nil)
+	space := &core.DirectorySpace{RootPath: "/r", Config: core.ExtractionConfig{ManualSelections: []string{"/r/b.go", "/r/lib"}}}
+	m.GlobalSearchFiles = []string{"/r/a.go", "/r/b.go", "/r/c.go", "/r/lib/d.go"}
+
+	m.markAllGlobalSearch(space)
+	if !m.GlobalSearchSelected["/r/a.go"] || m.GlobalSearchSelected["/r/b.go"] || !m.GlobalSearchSelected["/r/c.go"] {
+		t.Fatalf("check all staged %v", m.GlobalSearchSelected)
+	}
+	// A file in a checked folder already shows as checked, not as staged
+	if m.GlobalSearchSelected["/r/lib/d.go"] {
+		t.Fatalf("check all staged lib/d.go, checked by its folder")
+	}
+	m.markAllGlobalSearch(space)
+	if len(m.GlobalSearchSelected) != 2 || !m.GlobalSearchSelected["/r/b.go"] || !m.GlobalSearchSelected["/r/lib/d.go"] {
+		t.Errorf("uncheck all staged %v, want b.go and lib/d.go for removal", m.GlobalSearchSelected)
+	}
+}
+
+func TestReadOnlyExport(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "a.go"), []byte("package a\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out := filepath.Join(t.TempDir(), "out.txt")
+	if err := os.WriteFile(out, []byte("notes of my own\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	space := &core.DirectorySpace{
+		ID:             "a",
+		RootPath:       root,
+		OutputFilePath: out,
+		Config:         core.ExtractionConfig{IncludeMode: true, ManualSelections: []string{filepath.Join(root, "a.go")}},
+	}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	m.Sessions = core.NewSessionManager(filepath.Join(t.TempDir(), "session.json"))
+	m.ReadOnly = true
+
+	// A foreign output file needs no confirmation, since it stays untouched
+	cmd := m.requestExport(space, m.TabStates["a"])
+	if cmd == nil || m.ShowConfirmOverwrite {
+		t.Fatal("read-only export asked to overwrite")
+	}
+	updated, _ := m.Update(cmd())
+	m = updated.(AppModel)
+	if data, _ := os.ReadFile(out); string(data) != "notes of my own\n" {
+		t.Errorf("output written: %q", data)
+	}
+	if m.LastExport != nil || len(m.MessageLog) == 0 || !strings.HasPrefix(m.MessageLog[len(m.MessageLog)-1].Text, "Read-only: would export 1 files") {
+		t.Errorf("last export = %v, log = %v", m.LastExport, m.MessageLog)
+	}
+}
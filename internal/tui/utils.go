@@ -5,27 +5,29 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"unicode"
 
 	"pandabrew/internal/core"
 
 	"github.com/charmbracelet/lipgloss"
 )
 
-// SimpleFuzzyMatch checks if 'pattern' is a subsequence of 'str'.
-// It returns true and the indices of the matched characters.
+// SimpleFuzzyMatch checks if 'pattern' is a subsequence of 'str', ignoring
+// case rune by rune. It returns true and the byte offsets in str of the
+// matched runes.
 func SimpleFuzzyMatch(pattern, str string) (bool, []int) {
 	if pattern == "" {
 		return true, nil
 	}
-	pattern = strings.ToLower(pattern)
-	strLower := strings.ToLower(str)
+	pRunes := []rune(pattern)
+	for i, r := range pRunes {
+		pRunes[i] = unicode.ToLower(r)
+	}
 
 	indices := []int{}
 	pIdx := 0
-	pRunes := []rune(pattern)
-
-	for i, r := range strLower {
-		if pIdx < len(pRunes) && r == pRunes[pIdx] {
+	for i, r := range str {
+		if pIdx < len(pRunes) && unicode.ToLower(r) == pRunes[pIdx] {
 			indices = append(indices, i)
 			pIdx++
 		}
@@ -137,48 +139,48 @@ func isCodeFile(ext string) bool {
 func getSelectionIcon(node *TreeNode, space *core.DirectorySpace, s Styles) (string, lipgloss.Style) {
 	style := lipgloss.NewStyle()
 
-	isExact := slices.Contains(space.Config.ManualSelections, node.FullPath)
-	if isExact {
-		return iconCheckSquare, style.Foreground(s.ColorGreen).Bold(true)
-	}
-
-	for _, sVal := range space.Config.ManualSelections {
-		if strings.HasPrefix(node.FullPath, sVal+string(filepath.Separator)) {
-			return iconDot, style.Foreground(s.ColorGreen)
-		}
-	}
-
+	// A folder checked with holes in it, or unchecked with picks in it, is
+	// partly selected
+	checked := core.IsChecked(space, node.FullPath)
 	if node.IsDir {
+		below := space.Config.ManualSelections
+		if checked {
+			below = space.Config.ManualDeselections
+		}
 		prefix := node.FullPath + string(filepath.Separator)
-		for _, sVal := range space.Config.ManualSelections {
+		for _, sVal := range below {
 			if strings.HasPrefix(sVal, prefix) {
 				return iconCircle, style.Foreground(s.ColorYellow)
 			}
 		}
 	}
 
-	return iconSquare, style.Foreground(s.ColorSubtext)
+	switch {
+	case !checked:
+		return iconSquare, style.Foreground(s.ColorSubtext)
+	case slices.Contains(space.Config.ManualSelections, node.FullPath):
+		return iconCheckSquare, style.Foreground(s.ColorGreen).Bold(true)
+	default:
+		return iconDot, style.Foreground(s.ColorGreen)
+	}
 }
 
+// toggleSelection checks or unchecks path. Unchecking a path inside a
+// checked folder records a deselection, so the folder keeps the rest.
 func toggleSelection(space *core.DirectorySpace, path string) {
 	if path == "" {
 		return
 	}
-	found := false
-	for i, existing := range space.Config.ManualSelections {
-		if existing == path {
-			space.Config.ManualSelections = append(space.Config.ManualSelections[:i], space.Config.ManualSelections[i+1:]...)
-			found = true
-			break
-		}
-	}
-	if !found {
-		space.Config.ManualSelections = append(space.Config.ManualSelections, path)
+	if core.IsChecked(space, path) {
+		core.UncheckPath(space, path)
+	} else {
+		core.CheckPath(space, path)
 	}
 }
 
 func focusInput(state *TabState, idx int) {
 	state.ActiveInput = idx
+	state.ActiveOption = 0
 	blurAll(state)
 	switch idx {
 	case 1:
@@ -217,7 +219,10 @@ func splitClean(s string) []string {
 	return res
 }
 
-func (m *AppModel) populateChildren(state *TabState, parentPath string, entries []core.DirEntry) {
+// populateChildren replaces the children of the node at parentPath with
+// entries, keeping the state of those already shown. On a reload it returns
+// the entries that were not there before.
+func (m *AppModel) populateChildren(state *TabState, parentPath string, entries []core.DirEntry) []*TreeNode {
 	var targetNode *TreeNode
 	var find func(*TreeNode) *TreeNode
 
@@ -237,7 +242,7 @@ func (m *AppModel) populateChildren(state *TabState, parentPath string, entries
 		targetNode = find(state.TreeRoot)
 	}
 	if targetNode == nil {
-		return
+		return nil
 	}
 
 	existingState := make(map[string]*TreeNode)
@@ -245,7 +250,7 @@ func (m *AppModel) populateChildren(state *TabState, parentPath string, entries
 		existingState[child.FullPath] = child
 	}
 
-	var children []*TreeNode
+	var children, added []*TreeNode
 	for _, e := range entries {
 		newNode := &TreeNode{
 			Name:     e.Name,
@@ -257,20 +262,27 @@ func (m *AppModel) populateChildren(state *TabState, parentPath string, entries
 		if old, ok := existingState[e.FullPath]; ok {
 			newNode.Expanded = old.Expanded
 			newNode.Children = old.Children
+			newNode.Loaded = old.Loaded
 			for _, gc := range newNode.Children {
 				gc.Parent = newNode
 			}
+		} else if targetNode.Loaded {
+			added = append(added, newNode)
 		}
 
 		children = append(children, newNode)
 	}
 	targetNode.Children = children
+	targetNode.Loaded = true
+	return added
 }
 
 func selectAll(space *core.DirectorySpace) {
 	space.Config.ManualSelections = []string{space.RootPath}
+	space.Config.ManualDeselections = nil
 }
 
 func deselectAll(space *core.DirectorySpace) {
 	space.Config.ManualSelections = []string{}
+	space.Config.ManualDeselections = nil
 }
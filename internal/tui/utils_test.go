@@ -0,0 +1,64 @@
+package tui
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestExcludeFromSelection(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.go", "big.go", "lib/c.go"} {
+		path := filepath.Join(root, "src", name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("package x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Deselecting a file inside a selected folder keeps its siblings selected
+	space := &core.DirectorySpace{RootPath: root}
+	space.Config.IncludeMode = true
+	space.Config.ManualSelections = []string{filepath.Join(root, "src")}
+	core.DeselectPath(space, filepath.Join(root, "src", "big.go"))
+
+	if want := []string{filepath.Join(root, "src")}; !reflect.DeepEqual(space.Config.ManualSelections, want) {
+		t.Errorf("selections = %v, want %v", space.Config.ManualSelections, want)
+	}
+	if want := []string{filepath.Join(root, "src", "big.go")}; !reflect.DeepEqual(space.Config.ManualDeselections, want) {
+		t.Errorf("deselections = %v, want %v", space.Config.ManualDeselections, want)
+	}
+	if core.IsIncluded(space, filepath.Join(root, "src", "big.go")) || !core.IsIncluded(space, filepath.Join(root, "src", "lib", "c.go")) {
+		t.Error("deselection not honored")
+	}
+}
+
+func TestToggleInsideSelectedFolder(t *testing.T) {
+	space := &core.DirectorySpace{RootPath: "/r", Config: core.ExtractionConfig{IncludeMode: true}}
+	styles := InitialModel(&core.Session{}, nil).Styles
+	src := &TreeNode{Name: "src", FullPath: "/r/src", IsDir: true}
+	file := &TreeNode{Name: "a.go", FullPath: "/r/src/a.go"}
+
+	toggleSelection(space, src.FullPath)
+	toggleSelection(space, file.FullPath)
+	if !slices.Equal(space.Config.ManualDeselections, []string{file.FullPath}) || core.IsChecked(space, file.FullPath) {
+		t.Fatalf("deselections = %v", space.Config.ManualDeselections)
+	}
+	if icon, _ := getSelectionIcon(src, space, styles); icon != iconCircle {
+		t.Errorf("folder with a deselected file shows %q, want partial", icon)
+	}
+	if icon, _ := getSelectionIcon(file, space, styles); icon != iconSquare {
+		t.Errorf("deselected file shows %q, want unchecked", icon)
+	}
+
+	toggleSelection(space, file.FullPath)
+	if len(space.Config.ManualDeselections) != 0 || len(space.Config.ManualSelections) != 1 || !core.IsChecked(space, file.FullPath) {
+		t.Errorf("re-selecting left %v and %v", space.Config.ManualSelections, space.Config.ManualDeselections)
+	}
+}
@@ -0,0 +1,84 @@
+// Package tui implements checking the saved spaces against the disk after
+// the first frame, so large sessions open without delay.
+package tui
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// SpaceCheckedMsg carries what checking a saved space found on disk.
+type SpaceCheckedMsg struct {
+	Check core.SpaceCheck
+}
+
+// checkSpaceCmd checks a copy of space against fsys in the background.
+func checkSpaceCmd(fsys core.FileSystem, space *core.DirectorySpace) tea.Cmd {
+	snapshot := *space
+	snapshot.Config = space.Config.Clone()
+	snapshot.ExpandedPaths = slices.Clone(space.ExpandedPaths)
+	return func() tea.Msg {
+		return SpaceCheckedMsg{Check: core.CheckSpaceFS(fsys, &snapshot)}
+	}
+}
+
+// checkSpacesCmd checks every space of the session, each reporting as soon
+// as it is done.
+func checkSpacesCmd(fsys core.FileSystem, session *core.Session) tea.Cmd {
+	var cmds []tea.Cmd
+	for _, space := range session.Spaces {
+		cmds = append(cmds, checkSpaceCmd(fsys, space))
+	}
+	return tea.Batch(cmds...)
+}
+
+// applySpaceCheck drops what the check found gone from its space and tab,
+// records its warnings in the message log and lists them in the startup
+// warnings dialog.
+func (m *AppModel) applySpaceCheck(check core.SpaceCheck) {
+	space := m.spaceByID(check.SpaceID)
+	if space == nil {
+		return // Closed or switched away meanwhile
+	}
+	check.Apply(space)
+	if state := m.TabStates[space.ID]; state != nil {
+		for _, p := range check.GoneExpanded {
+			delete(state.TargetExpandedPaths, p)
+		}
+		if check.CursorGone {
+			state.TargetCursorPath = ""
+		}
+	}
+
+	for _, w := range check.Warnings {
+		severity := SeverityWarn
+		if strings.HasPrefix(w, "CRITICAL") {
+			severity = SeverityError
+		}
+		m.notify(severity, w)
+		m.StartupWarnings = append(m.StartupWarnings, filepath.Base(space.RootPath)+": "+w)
+	}
+}
+
+// renderStartupWarningsView lists what checking the saved spaces found.
+func (m AppModel) renderStartupWarningsView() string {
+	var body strings.Builder
+	rootMissing := false
+	for i, w := range m.StartupWarnings {
+		if i > 0 {
+			body.WriteString("\n")
+		}
+		fmt.Fprintf(&body, "• %s", w)
+		rootMissing = rootMissing || strings.Contains(w, "CRITICAL")
+	}
+	if rootMissing {
+		body.WriteString("\n\n" + tr("A missing root shows an empty tree; if the project moved, open it from its new place with ctrl+n."))
+	}
+	return m.renderDialog(iconWarn+" "+tr("Startup Warnings"), body.String(), tr("any key to close"))
+}
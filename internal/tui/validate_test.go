@@ -0,0 +1,43 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestStartupSpaceCheck(t *testing.T) {
+	root := t.TempDir()
+	gone := filepath.Join(root, "gone")
+	space := &core.DirectorySpace{
+		ID:            "a",
+		RootPath:      root,
+		Config:        core.ExtractionConfig{ManualSelections: []string{gone}},
+		ExpandedPaths: []string{gone},
+	}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	if !m.TabStates["a"].TargetExpandedPaths[gone] {
+		t.Fatal("expanded paths checked before the first frame")
+	}
+
+	msg := checkSpaceCmd(core.OSFileSystem, space)().(SpaceCheckedMsg)
+	updated, _ := m.Update(msg)
+	m = updated.(AppModel)
+	if m.TabStates["a"].TargetExpandedPaths[gone] || len(space.ExpandedPaths) != 0 {
+		t.Error("missing expanded folder kept")
+	}
+	if len(m.MessageLog) != 1 || m.MessageLog[0].Severity != SeverityWarn || !strings.Contains(m.MessageLog[0].Text, gone) {
+		t.Errorf("message log = %+v", m.MessageLog)
+	}
+	if len(m.StartupWarnings) != 1 || !strings.Contains(m.View(), "Startup Warnings") {
+		t.Fatalf("startup warnings not shown: %v", m.StartupWarnings)
+	}
+	updated, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m = updated.(AppModel); len(m.StartupWarnings) != 0 {
+		t.Error("startup warnings not dismissed")
+	}
+}
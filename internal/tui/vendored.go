@@ -0,0 +1,39 @@
+// Package tui implements the vendored code warning banner.
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// VendoredLoadedMsg carries the vendored directories under a root.
+type VendoredLoadedMsg struct {
+	Root string
+	Dirs []core.VendoredDir
+}
+
+func loadVendoredCmd(space *core.DirectorySpace) tea.Cmd {
+	root, cfg := space.RootPath, space.Config.Clone()
+	return func() tea.Msg {
+		return VendoredLoadedMsg{Root: root, Dirs: core.FindVendored(root, cfg)}
+	}
+}
+
+// vendoredWarning describes the vendored directories the selection of
+// space exports, or returns "" when there are none.
+func (m AppModel) vendoredWarning(space *core.DirectorySpace) string {
+	selected := core.SelectedVendored(space.RootPath, m.Vendored[space.RootPath], space.Config)
+	if len(selected) == 0 {
+		return ""
+	}
+	paths := make([]string, len(selected))
+	for i, d := range selected {
+		paths[i] = d.Path
+	}
+	return fmt.Sprintf("Selection includes vendored code (%s); consider structure-only or signatures instead",
+		strings.Join(paths, ", "))
+}
@@ -0,0 +1,22 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+)
+
+func TestVendoredWarning(t *testing.T) {
+	m := InitialModel(&core.Session{}, nil)
+	space := &core.DirectorySpace{RootPath: "/r", Config: core.ExtractionConfig{IncludeMode: true, ManualSelections: []string{"/r/main.go"}}}
+	m.Vendored["/r"] = []core.VendoredDir{{Path: "vendor", Reason: "vendor"}}
+
+	if got := m.vendoredWarning(space); got != "" {
+		t.Errorf("warned without vendored selections: %q", got)
+	}
+	space.Config.ManualSelections = append(space.Config.ManualSelections, "/r/vendor/lib/lib.go")
+	if got := m.vendoredWarning(space); !strings.Contains(got, "(vendor)") {
+		t.Errorf("warning = %q, want it to name vendor", got)
+	}
+}
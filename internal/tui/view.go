@@ -6,20 +6,75 @@ import (
 	"path/filepath"
 	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"pandabrew/internal/core"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textinput"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
 )
 
 // View renders the UI.
 func (m AppModel) View() string {
-	if m.ShowNewTab {
+	if m.tooSmall() {
+		return m.renderTooSmallView()
+	} else if m.ShowNewTab {
 		return m.renderNewTabView()
 	} else if m.ShowGlobalSearch {
 		return m.renderGlobalSearchView()
+	} else if m.ShowConfirmOverwrite {
+		return m.renderConfirmOverwriteView()
+	} else if m.ProjectSuggestion != nil {
+		return m.renderProjectSuggestionView()
+	} else if len(m.StartupWarnings) > 0 {
+		return m.renderStartupWarningsView()
+	} else if m.Inspection != nil {
+		return m.renderInspectView()
+	} else if m.ShowMessageLog {
+		return m.renderMessageLogView()
+	} else if m.ShowOffenders {
+		return m.renderOffendersView()
+	} else if m.ShowCompare {
+		return m.renderCompareView()
+	} else if m.ShowNestedRepos {
+		return m.renderListDialog(
+			iconFolder+" "+tr("Nested Repositories"),
+			m.nestedRepoRows(), m.NestedReposCursor,
+			tr("No nested repositories."),
+			tr("enter cycles full → structure → skip • Esc to close"),
+		)
+	} else if m.ShowPreview {
+		return m.renderPreviewView()
+	} else if m.ShowExportDiff {
+		return m.renderListDialog(
+			iconInfo+" "+tr("Export Preview: ")+m.ExportDiff.Summary(),
+			m.exportDiffRows(), m.ExportDiffCursor,
+			tr("No file contents changed."),
+			tr("enter exports • u shows the diff • Esc cancels"),
+		)
+	} else if m.ShowStale {
+		return m.renderListDialog(
+			iconWarn+" "+tr("Missing Selections"),
+			m.staleRows(), m.StaleCursor,
+			tr("Every selection still exists."),
+			tr("enter remaps • a remaps moved files • tab next match • d removes • s keeps • Esc keeps the rest"),
+		)
+	} else if m.ShowSessions {
+		return m.renderSessionsView()
+	} else if m.ShowThemes {
+		return m.renderThemePickerView()
+	} else if m.ShowTasks {
+		return m.renderTaskPickerView()
+	} else if m.ShowSessionInput {
+		return m.renderInputDialog(iconFolder+" New Session", "Sessions keep separate sets of tabs (--session on the CLI):", m.SessionInput)
+	} else if m.ShowGroups {
+		return m.renderGroupsView()
+	} else if m.ShowGroupInput {
+		return m.renderGroupInputView()
+	} else if m.ActiveTooltip != "" {
+		return m.renderTooltipView()
 	} else if m.ShowHelp {
 		return m.renderHelpView()
 	}
@@ -30,7 +85,7 @@ func (m AppModel) View() string {
 	if space == nil {
 		emptyMsg := lipgloss.NewStyle().
 			Foreground(m.Styles.ColorSubtext).
-			Render("No workspace open. Press ctrl+n to create a new tab.")
+			Render(tr("No workspace open. Press ctrl+n to create a new tab."))
 
 		content = lipgloss.Place(
 			m.Width, m.Height,
@@ -40,7 +95,7 @@ func (m AppModel) View() string {
 		)
 	} else {
 		state := m.TabStates[space.ID]
-		tabs := m.renderTabs()
+		tabs := m.renderHeader(space)
 		footer := m.renderFooter(space, state)
 
 		headerHeight := lipgloss.Height(tabs)
@@ -48,10 +103,7 @@ func (m AppModel) View() string {
 
 		middleHeight := max(0, m.Height-headerHeight-footerHeight)
 
-		sidebar := m.renderSidebar(state, space, middleHeight)
-		tree := m.renderTree(state, space, middleHeight)
-
-		body := lipgloss.JoinHorizontal(lipgloss.Top, sidebar, tree)
+		body := m.overlayToasts(m.renderBody(state, space, middleHeight))
 		content = lipgloss.JoinVertical(lipgloss.Left, tabs, body, footer)
 	}
 
@@ -75,8 +127,20 @@ func (m AppModel) renderTabs() string {
 		Render("ʕ•ᴥ•ʔっ☕ PandaBrew")
 	tabs = append(tabs, branding)
 
-	for _, s := range m.Session.Spaces {
-		name := iconFolder + " " + filepath.Base(s.RootPath)
+	if group := m.Session.ActiveGroup; group != "" {
+		tabs = append(tabs, lipgloss.NewStyle().
+			Foreground(m.Styles.ColorSubtext).
+			Background(m.Styles.ColorBase).
+			Italic(true).
+			Padding(0, 1).
+			Render("["+group+"]"))
+	}
+
+	for _, s := range m.Session.VisibleSpaces() {
+		name := iconFolder + " " + truncateCells(filepath.Base(s.RootPath), maxTabNameCells)
+		if branch, ok := m.Branches[s.RootPath]; ok {
+			name += " " + iconBranch + " " + truncateCells(branch.String(), maxTabNameCells)
+		}
 		style := m.Styles.Tab
 		if s.ID == m.Session.ActiveSpaceID {
 			style = m.Styles.TabActive
@@ -88,11 +152,15 @@ func (m AppModel) renderTabs() string {
 		Foreground(m.Styles.ColorSubtext).
 		Background(m.Styles.ColorBase).
 		Padding(0, 2).
-		Render(iconKeyboard + " ? Help • Tab Switch • ^N New • ^W Close")
+		Render(iconKeyboard + " " + tr("? Help • Tab Switch • ^N New • ^W Close"))
 
 	tabs = append(tabs, helpTab)
 
 	tabBar := lipgloss.JoinHorizontal(lipgloss.Top, tabs...)
+	if m.Width > 0 {
+		// Too many tabs are cut rather than wrapped onto a second line
+		tabBar = ansi.Truncate(tabBar, m.Width, "…")
+	}
 
 	return lipgloss.NewStyle().
 		Width(m.Width).
@@ -101,7 +169,7 @@ func (m AppModel) renderTabs() string {
 }
 
 func (m AppModel) renderSidebar(state *TabState, space *core.DirectorySpace, height int) string {
-	header := m.Styles.SectionHeader.Render(iconGear + " Configuration")
+	header := m.Styles.SectionHeader.Render(iconGear + " " + tr("Configuration"))
 
 	inputs := lipgloss.JoinVertical(lipgloss.Left,
 		m.renderInput("Root", state.InputRoot, state.ActiveInput == 1, "r"),
@@ -109,24 +177,31 @@ func (m AppModel) renderSidebar(state *TabState, space *core.DirectorySpace, hei
 		m.renderInput("Output", state.InputOutput, state.ActiveInput == 2, "o"),
 		"",
 		m.renderInput("Include", state.InputInclude, state.ActiveInput == 3, "f"),
-		"",
+		m.renderPatternIssues(state.InputInclude.Value()),
 		m.renderInput("Exclude", state.InputExclude, state.ActiveInput == 4, "g"),
+		m.renderPatternIssues(state.InputExclude.Value()),
 	)
 
-	optionsHeader := m.Styles.SectionHeader.Render(iconFilter + " Options")
-	options := lipgloss.JoinVertical(lipgloss.Left,
-		m.renderCheckbox("Include Mode", space.Config.IncludeMode, "i"),
-		m.renderCheckbox("Show Context", space.Config.ShowContext, "c"),
-		m.renderCheckbox("Show Excluded", space.Config.ShowExcluded, "x"),
-		m.renderCheckbox("Struct in View", space.Config.StructureView, "v"),
-	)
+	// Options render in focus order, numbered like ActiveOption
+	var sections [2][]string
+	focus := 0
+	for i, opts := range m.keys.sidebarSections() {
+		for _, opt := range opts {
+			focus++
+			sections[i] = append(sections[i], m.renderOption(opt, space.Config, state.ActiveOption == focus))
+		}
+	}
+	optionsHeader := m.Styles.SectionHeader.Render(iconFilter + " " + tr("Options"))
+	options := lipgloss.JoinVertical(lipgloss.Left, sections[0]...)
+	outputHeader := m.Styles.SectionHeader.Render(iconExport + " " + tr("Output"))
+	output := lipgloss.JoinVertical(lipgloss.Left, sections[1]...)
 
 	selectionCount := lipgloss.NewStyle().
 		Foreground(m.Styles.ColorGreen).
 		Bold(true).
 		Background(m.Styles.ColorBase).
 		Width(34).
-		Render(fmt.Sprintf("%s Selected: %d", iconCheckSquare, len(space.Config.ManualSelections)))
+		Render(fmt.Sprintf("%s "+tr("Selected: %d"), iconCheckSquare, len(space.Config.ManualSelections)))
 
 	content := lipgloss.JoinVertical(lipgloss.Left,
 		header,
@@ -137,6 +212,8 @@ func (m AppModel) renderSidebar(state *TabState, space *core.DirectorySpace, hei
 		optionsHeader,
 		options,
 		"",
+		outputHeader,
+		output,
 		"",
 		selectionCount,
 	)
@@ -147,7 +224,20 @@ func (m AppModel) renderSidebar(state *TabState, space *core.DirectorySpace, hei
 		Render(content)
 }
 
-func (m AppModel) renderCheckbox(label string, checked bool, hotkey string) string {
+// renderOption draws a sidebar option as a checkbox or, without one, as a
+// selector stepping through its values.
+func (m AppModel) renderOption(opt sidebarOption, cfg core.ExtractionConfig, focused bool) string {
+	if opt.check == nil {
+		return m.renderSelector(opt.name, opt.value(cfg), opt.hotkey(), focused)
+	}
+	label := tr(opt.name)
+	if opt.value != nil {
+		label += ": " + opt.value(cfg)
+	}
+	return m.renderCheckbox(label, opt.check(cfg), opt.hotkey(), focused)
+}
+
+func (m AppModel) renderCheckbox(label string, checked bool, hotkey string, focused bool) string {
 	icon := iconSquare
 	style := m.Styles.Option
 
@@ -155,13 +245,26 @@ func (m AppModel) renderCheckbox(label string, checked bool, hotkey string) stri
 		icon = iconCheckSquare
 		style = m.Styles.OptionSelected
 	}
+	if focused {
+		style = style.Background(m.Styles.ColorSurface)
+	}
 
 	labelWithKey := fmt.Sprintf("%s %s (%s)", icon, label, hotkey)
 	return style.Width(34).Render(labelWithKey)
 }
 
+// renderSelector draws an option that steps through a list of values.
+func (m AppModel) renderSelector(label, value, hotkey string, focused bool) string {
+	style := m.Styles.Option
+	if focused {
+		style = style.Background(m.Styles.ColorSurface)
+	}
+	text := fmt.Sprintf("%s %s: ‹ %s › (%s)", iconSort, label, value, hotkey)
+	return style.Width(34).Render(text)
+}
+
 func (m AppModel) renderInput(label string, input textinput.Model, focused bool, hotkey string) string {
-	labelWithKey := fmt.Sprintf("%s (%s):", label, hotkey)
+	labelWithKey := fmt.Sprintf("%s (%s):", tr(label), hotkey)
 	labelStyle := m.Styles.InputLabel.Render(labelWithKey)
 
 	inputView := input.View()
@@ -179,27 +282,39 @@ func (m AppModel) renderInput(label string, input textinput.Model, focused bool,
 	)
 }
 
-func (m AppModel) renderTree(state *TabState, space *core.DirectorySpace, height int) string {
+// renderPatternIssues shows a problem of a pattern input under it: an
+// invalid pattern in red, or else the first warning in yellow. Without
+// problems it is the blank line separating the inputs.
+func (m AppModel) renderPatternIssues(value string) string {
+	issues := core.CheckPatterns(splitClean(value))
+	if len(issues) == 0 {
+		return ""
+	}
+	issue, icon, color := issues[0], iconWarn, m.Styles.ColorYellow
+	if i := slices.IndexFunc(issues, func(i core.PatternIssue) bool { return i.Invalid }); i >= 0 {
+		issue, icon, color = issues[i], iconError, m.Styles.ColorRed
+	}
+	text := fmt.Sprintf("%s %s: %s", icon, issue.Pattern, issue.Message)
+	if len(issues) > 1 {
+		text += fmt.Sprintf(" (+%d more)", len(issues)-1)
+	}
+	return lipgloss.NewStyle().
+		Foreground(color).
+		Background(m.Styles.ColorBase).
+		Width(34).
+		Render(text)
+}
+
+func (m AppModel) renderTree(state *TabState, space *core.DirectorySpace, height, width int) string {
 	var treeRows []string
 	availableRows := max(0, height-2)
-	startRow := 0
+	startRow := state.scrollWindow(availableRows)
 	totalNodes := len(state.VisibleNodes)
 
-	if totalNodes > availableRows {
-		bottomThreshold := totalNodes - availableRows
-		if state.CursorIndex <= availableRows/2 {
-			startRow = 0
-		} else if state.CursorIndex >= bottomThreshold+availableRows/2 {
-			startRow = bottomThreshold
-		} else {
-			startRow = state.CursorIndex - availableRows/2
-		}
-	}
-
 	endRow := min(startRow+availableRows, totalNodes)
-	sidebarWidth := 39
-	treeWidth := max(0, m.Width-sidebarWidth)
+	treeWidth := width
 	contentWidth := treeWidth
+	index := m.Indexes[space.RootPath]
 
 	for i := startRow; i < endRow; i++ {
 		node := state.VisibleNodes[i]
@@ -249,42 +364,23 @@ func (m AppModel) renderTree(state *TabState, space *core.DirectorySpace, height
 		if isSelected {
 			nameStyle = nameStyle.Foreground(m.Styles.ColorMauve).Bold(true)
 		}
+		effect, excludedBy := treeEffect(state, space, node.FullPath)
+		if effect == patternExcluded {
+			nameStyle = nameStyle.Foreground(m.Styles.ColorSubtext).Strikethrough(true)
+		}
 
-		var styledName string
 		var matchCounter string
-
-		if state.SearchQuery != "" {
-			lowerName := strings.ToLower(node.Name)
-			lowerQuery := strings.ToLower(state.SearchQuery)
-			idx := strings.Index(lowerName, lowerQuery)
-
-			if idx >= 0 {
-				highlightStyle := nameStyle.
-					Background(m.Styles.ColorYellow).
-					Foreground(m.Styles.ColorBase).
-					Bold(true)
-
-				start := idx
-				end := idx + len(lowerQuery)
-				end = min(end, len(node.Name))
-
-				prefix := node.Name[:start]
-				match := node.Name[start:end]
-				suffix := node.Name[end:]
-
-				styledName = nameStyle.Render(prefix) + highlightStyle.Render(match) + nameStyle.Render(suffix)
-
-				for mIdx, matchedNodeIdx := range state.MatchIndices {
-					if matchedNodeIdx == i {
-						matchCounter = fmt.Sprintf(" (%d/%d)", mIdx+1, len(state.MatchIndices))
-						break
-					}
+		if state.SearchQuery != "" && strings.Contains(strings.ToLower(node.Name), strings.ToLower(state.SearchQuery)) {
+			for mIdx, matchedNodeIdx := range state.MatchIndices {
+				if matchedNodeIdx == i {
+					matchCounter = fmt.Sprintf(" (%d/%d)", mIdx+1, len(state.MatchIndices))
+					break
 				}
-			} else {
-				styledName = nameStyle.Render(node.Name)
 			}
-		} else {
-			styledName = nameStyle.Render(node.Name)
+		}
+
+		if n := state.HiddenMatches[node]; n > 0 {
+			matchCounter += fmt.Sprintf(" (%d)", n)
 		}
 
 		var styledMatchCounter string
@@ -294,6 +390,54 @@ func (m AppModel) renderTree(state *TabState, space *core.DirectorySpace, height
 				Background(rowBgColor).
 				Render(matchCounter)
 		}
+		switch effect {
+		case patternExcluded:
+			styledMatchCounter += lipgloss.NewStyle().
+				Foreground(m.Styles.ColorSubtext).
+				Background(rowBgColor).
+				Italic(true).
+				Render(" (excluded: " + excludedBy + ")")
+		case patternIncluded:
+			styledMatchCounter += lipgloss.NewStyle().
+				Foreground(m.Styles.ColorGreen).
+				Background(rowBgColor).
+				Italic(true).
+				Render(" (matched)")
+		}
+		if node.NewlyIncluded {
+			styledMatchCounter += lipgloss.NewStyle().
+				Foreground(m.Styles.ColorGreen).
+				Background(rowBgColor).
+				Italic(true).
+				Render(" (new)")
+		}
+
+		// Size annotation from the background index, right-aligned
+		var styledAnnotation string
+		if entry, ok := index.Lookup(node.FullPath); ok {
+			styledAnnotation = lipgloss.NewStyle().
+				Foreground(m.Styles.ColorSubtext).
+				Background(rowBgColor).
+				PaddingRight(2).
+				Render(core.FormatTokens(entry.Tokens) + " tok")
+		}
+
+		// Long or wide names are cut by display cells so the row never wraps
+		used := lipgloss.Width(leftPad) + lipgloss.Width(styledIndent) + lipgloss.Width(styledCheck) +
+			lipgloss.Width(styledIcon) + lipgloss.Width(styledMatchCounter) + lipgloss.Width(styledAnnotation)
+		name := node.Name
+		if contentWidth > 0 && used+cellWidth(name) > contentWidth {
+			name = truncateCells(name, contentWidth-used)
+		}
+
+		styledName := nameStyle.Render(name)
+		if start, end := foldIndex(name, state.SearchQuery); start >= 0 {
+			highlightStyle := nameStyle.
+				Background(m.Styles.ColorYellow).
+				Foreground(m.Styles.ColorInk).
+				Bold(true)
+			styledName = nameStyle.Render(name[:start]) + highlightStyle.Render(name[start:end]) + nameStyle.Render(name[end:])
+		}
 
 		leftContent := lipgloss.JoinHorizontal(lipgloss.Top,
 			leftPad,
@@ -304,14 +448,16 @@ func (m AppModel) renderTree(state *TabState, space *core.DirectorySpace, height
 			styledMatchCounter,
 		)
 
-		currentWidth := lipgloss.Width(leftContent)
-		fillWidth := max(0, contentWidth-currentWidth)
-		filler := lipgloss.NewStyle().
-			Background(rowBgColor).
-			Width(fillWidth).
-			Render(" ")
+		currentWidth := lipgloss.Width(leftContent) + lipgloss.Width(styledAnnotation)
+		var filler string
+		if fillWidth := contentWidth - currentWidth; fillWidth > 0 {
+			filler = lipgloss.NewStyle().
+				Background(rowBgColor).
+				Width(fillWidth).
+				Render("")
+		}
 
-		line := lipgloss.JoinHorizontal(lipgloss.Top, leftContent, filler)
+		line := lipgloss.JoinHorizontal(lipgloss.Top, leftContent, filler, styledAnnotation)
 		treeRows = append(treeRows, line)
 	}
 
@@ -328,42 +474,82 @@ func (m AppModel) renderTree(state *TabState, space *core.DirectorySpace, height
 func (m AppModel) renderFooter(space *core.DirectorySpace, state *TabState) string {
 	if state.ActiveInput == 5 {
 		searchLabel := lipgloss.NewStyle().
-			Foreground(m.Styles.ColorBase).
+			Foreground(m.Styles.ColorInk).
 			Background(m.Styles.ColorYellow).
 			Bold(true).
 			Padding(0, 1).
-			Render("SEARCH /")
+			Render(tr("SEARCH /"))
+
+		var historyHint string
+		if len(space.SearchHistory) > 0 {
+			historyHint = lipgloss.NewStyle().
+				Foreground(m.Styles.ColorSubtext).
+				Background(m.Styles.ColorSurface).
+				Italic(true).
+				Padding(0, 1).
+				Render(tr("↑/↓ history"))
+		}
 
 		searchInput := lipgloss.NewStyle().
 			Background(m.Styles.ColorSurface).
 			Padding(0, 1).
-			Width(m.Width - lipgloss.Width(searchLabel)).
+			Width(m.Width - lipgloss.Width(searchLabel) - lipgloss.Width(historyHint)).
 			Render(state.InputSearch.View())
 
-		return lipgloss.JoinHorizontal(lipgloss.Top, searchLabel, searchInput)
+		return lipgloss.JoinHorizontal(lipgloss.Top, searchLabel, searchInput, historyHint)
 	}
 
-	var sections []string
-
 	var leftSection string
 	if m.Loading && m.ExportTotal > 0 {
 		progressBar := m.Progress.ViewAs(m.ExportProgress)
-		leftSection = fmt.Sprintf("Exporting: %d/%d %s", m.ExportProcessed, m.ExportTotal, progressBar)
+		leftSection = fmt.Sprintf(tr("Exporting: %d/%d %s"), m.ExportProcessed, m.ExportTotal, progressBar)
 	} else if m.Loading {
-		leftSection = fmt.Sprintf("%s %s", m.Spinner.View(), m.StatusMessage)
+		activity := tr("Working...")
+		if toast, ok := m.latestToast(); ok {
+			activity = toast.Text
+		}
+		leftSection = fmt.Sprintf("%s %s", m.Spinner.View(), activity)
+	} else if m.ScanCancel != nil {
+		leftSection = fmt.Sprintf("%s "+tr("Scanning %s: %d files"), m.Spinner.View(), filepath.Base(m.ScanPath), m.ScannedFiles)
+	} else if m.IndexCancel != nil {
+		leftSection = fmt.Sprintf("%s "+tr("Indexing %s: %d files (ctrl+b to cancel)"),
+			m.Spinner.View(), filepath.Base(m.IndexRoot), m.IndexedFiles)
 	} else {
-		leftSection = m.StatusMessage
+		leftSection = tr("Ready")
 	}
-	sections = append(sections, m.Styles.StatusLeft.Render(leftSection))
+	left := m.Styles.StatusLeft.Render(leftSection)
 
-	middleSection := fmt.Sprintf("%s %d selected", iconCheckSquare, len(space.Config.ManualSelections))
-	sections = append(sections, m.Styles.StatusMiddle.Render(middleSection))
+	middleSection := fmt.Sprintf("%s "+tr("%d selected"), iconCheckSquare, len(space.Config.ManualSelections))
+	if index := m.Indexes[space.RootPath]; index != nil {
+		middleSection += " • ~" + core.FormatTokens(index.EstimateSelection(space.Config)) + " tok"
+	}
+	if status := m.dirEstimateStatus(space, state); status != "" {
+		middleSection += " • " + iconFolder + " " + status
+	}
 
-	rightSection := fmt.Sprintf("%s help • %s save • %s export • %s theme • / search • q quit",
+	rightSection := fmt.Sprintf(tr("%s help • %s save • %s export • %s theme • / search • q quit"),
 		iconHelp, iconSave, iconExport, iconGear)
-	sections = append(sections, m.Styles.StatusRight.Render(rightSection))
+	right := m.Styles.StatusRight.Render(rightSection)
 
-	footer := lipgloss.JoinHorizontal(lipgloss.Top, sections...)
+	// On narrow terminals the key hints go first, then the status is cut
+	if m.Width > 0 {
+		padding := m.Styles.StatusMiddle.GetHorizontalFrameSize()
+		if lipgloss.Width(left)+padding+cellWidth(middleSection)+lipgloss.Width(right) > m.Width {
+			right = ""
+		}
+		if room := m.Width - lipgloss.Width(left) - padding; cellWidth(middleSection) > room {
+			middleSection = truncateCells(middleSection, room)
+		}
+	}
+	middle := ""
+	if middleSection != "" {
+		middle = m.Styles.StatusMiddle.Render(middleSection)
+	}
+
+	footer := lipgloss.JoinHorizontal(lipgloss.Top, left, middle, right)
+	if m.Width > 0 {
+		footer = ansi.Truncate(footer, m.Width, "")
+	}
 
 	return lipgloss.NewStyle().
 		Width(m.Width).
@@ -382,7 +568,7 @@ func (m AppModel) renderGlobalSearchView() string {
 		Background(m.Styles.ColorBase).
 		Width(contentWidth).
 		Align(lipgloss.Center).
-		Render(iconFolder + " Global File Search")
+		Render(iconFolder + " " + m.globalSearchTitle())
 
 	inputBox := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
@@ -396,10 +582,17 @@ func (m AppModel) renderGlobalSearchView() string {
 
 	var results []string
 	if len(m.GlobalSearchFiles) == 0 && m.GlobalSearchInput.Value() != "" {
-		results = append(results, lipgloss.NewStyle().Foreground(m.Styles.ColorSubtext).Render("No results found."))
+		empty := "No results found."
+		if m.GlobalSearchContent && m.Loading {
+			empty = "Searching..."
+		}
+		results = append(results, lipgloss.NewStyle().Foreground(m.Styles.ColorSubtext).Render(empty))
 	} else {
 		// Calculate available height for results
 		resultsHeight := max(1, modalHeight-8) // approx height for header/footer
+		if m.GlobalSearchContent {
+			resultsHeight = max(1, resultsHeight-4) // Room for the context of the current line
+		}
 
 		start := 0
 		end := 0
@@ -443,7 +636,7 @@ func (m AppModel) renderGlobalSearchView() string {
 			}
 
 			// Marker Logic
-			isAlreadySelected := slices.Contains(space.Config.ManualSelections, file)
+			isAlreadySelected := core.IsChecked(space, file)
 			isStaged := m.GlobalSearchSelected[file]
 
 			marker := ""
@@ -458,6 +651,8 @@ func (m AppModel) renderGlobalSearchView() string {
 			} else if isAlreadySelected {
 				// Already selected
 				marker = lipgloss.NewStyle().Foreground(m.Styles.ColorGreen).Bold(true).Render(iconCheckSquare + " ")
+			} else {
+				marker = lipgloss.NewStyle().Foreground(m.Styles.ColorSubtext).Render(iconSquare + " ")
 			}
 
 			// Icon Logic
@@ -477,19 +672,28 @@ func (m AppModel) renderGlobalSearchView() string {
 
 			// Render Path and Highlight Matches
 			var styledName string
-			if matched, indices := SimpleFuzzyMatch(query, filepath.ToSlash(relPath)); matched && query != "" {
+			if m.GlobalSearchContent && i < len(m.ContentMatches) {
+				hit := m.ContentMatches[i]
+				location := lipgloss.NewStyle().Foreground(m.Styles.ColorSubtext).Background(rowBg).
+					Render(fmt.Sprintf("%s:%d ", displayPath, hit.Line))
+				highlightStyle := style.Foreground(m.Styles.ColorYellow).Bold(true).Background(rowBg)
+				text := highlightAll(strings.TrimSpace(hit.Text), strings.ToLower(query), style, highlightStyle)
+				styledName = ansi.Truncate(prefixStr+location+text, contentWidth, "…")
+			} else if matched, indices := SimpleFuzzyMatch(query, filepath.ToSlash(relPath)); matched && query != "" {
 				var sb strings.Builder
 				lastIdx := 0
 
 				// Highlight style must also have the row background
 				highlightStyle := style.Foreground(m.Styles.ColorYellow).Bold(true).Background(rowBg)
 
+				slashPath := filepath.ToSlash(relPath)
 				for _, idx := range indices {
-					sb.WriteString(style.Render(relPath[lastIdx:idx]))
-					sb.WriteString(highlightStyle.Render(string(relPath[idx])))
-					lastIdx = idx + 1
+					_, size := utf8.DecodeRuneInString(slashPath[idx:])
+					sb.WriteString(style.Render(slashPath[lastIdx:idx]))
+					sb.WriteString(highlightStyle.Render(slashPath[idx : idx+size]))
+					lastIdx = idx + size
 				}
-				sb.WriteString(style.Render(relPath[lastIdx:]))
+				sb.WriteString(style.Render(slashPath[lastIdx:]))
 
 				styledName = prefixStr + sb.String()
 			} else {
@@ -514,7 +718,19 @@ func (m AppModel) renderGlobalSearchView() string {
 		Height(modalHeight - 8).
 		MarginTop(1).
 		Render(resultsList)
+	if m.GlobalSearchContent {
+		resultsBox = lipgloss.NewStyle().
+			Width(contentWidth).
+			Height(modalHeight - 12).
+			MarginTop(1).
+			Render(resultsList)
+		resultsBox = lipgloss.JoinVertical(lipgloss.Left, resultsBox, m.renderContentContext(contentWidth))
+	}
 
+	hint := "Tab Mark (Space after ↑↓) • Ctrl+A All • Ctrl+F Files/Content • Ctrl+P/N History • Enter Jump"
+	if n := len(m.GlobalSearchSelected); n > 0 {
+		hint = fmt.Sprintf("%d marked • Tab Mark (Space after ↑↓) • Ctrl+A All • Enter to Apply • Esc", n)
+	}
 	hints := lipgloss.NewStyle().
 		Foreground(m.Styles.ColorSubtext).
 		Italic(true).
@@ -522,7 +738,7 @@ func (m AppModel) renderGlobalSearchView() string {
 		Width(contentWidth).
 		Align(lipgloss.Center).
 		MarginTop(1).
-		Render("Tab to Mark • Enter to Batch Select • Esc to Cancel")
+		Render(hint)
 
 	content := lipgloss.JoinVertical(
 		lipgloss.Left,
@@ -551,6 +767,49 @@ func (m AppModel) renderGlobalSearchView() string {
 	)
 }
 
+// globalSearchTitle names the search mode, and the engine in content mode.
+func (m AppModel) globalSearchTitle() string {
+	if m.GlobalSearchContent {
+		return "Content Search (" + m.ContentBackend + ")"
+	}
+	return "Global File Search"
+}
+
+// renderContentContext shows the lines around the current content match.
+func (m AppModel) renderContentContext(width int) string {
+	dim := lipgloss.NewStyle().Foreground(m.Styles.ColorSubtext).Background(m.Styles.ColorBase)
+	if m.GlobalSearchSelect >= len(m.ContentMatches) {
+		return lipgloss.NewStyle().Width(width).Height(3).MarginTop(1).Render("")
+	}
+	hit := m.ContentMatches[m.GlobalSearchSelect]
+	text := lipgloss.NewStyle().Foreground(m.Styles.ColorText).Background(m.Styles.ColorBase)
+	match := text.Foreground(m.Styles.ColorInk).Background(m.Styles.ColorYellow)
+	query := strings.ToLower(m.GlobalSearchInput.Value())
+	gutter := len(fmt.Sprint(hit.Line + 1))
+	row := func(n int, line string, current bool) string {
+		line = strings.ReplaceAll(line, "\t", "    ")
+		body := dim.Render(line)
+		if current {
+			body = highlightAll(line, query, text, match)
+		}
+		return ansi.Truncate(dim.Render(fmt.Sprintf("%*d │ ", gutter, n))+body, width, "…")
+	}
+	var rows []string
+	if hit.Line > 1 {
+		rows = append(rows, row(hit.Line-1, hit.Before, false))
+	}
+	rows = append(rows, row(hit.Line, hit.Text, true))
+	if hit.After != "" {
+		rows = append(rows, row(hit.Line+1, hit.After, false))
+	}
+	return lipgloss.NewStyle().
+		Width(width).
+		Height(3).
+		MarginTop(1).
+		Background(m.Styles.ColorBase).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+}
+
 func (m AppModel) renderHelpView() string {
 	groups := m.keys.FullHelp()
 	const itemWidth = 38
@@ -641,7 +900,7 @@ func (m AppModel) renderHelpView() string {
 		Italic(true).
 		Width(totalWidth).
 		Align(lipgloss.Center).
-		Render("Press ? to close")
+		Render("Press ? to close • Press an option's key to explain it")
 	spacerBeforeHint := lipgloss.NewStyle().
 		Background(m.Styles.ColorBase).
 		Width(totalWidth).
@@ -657,7 +916,192 @@ func (m AppModel) renderHelpView() string {
 	)
 }
 
+func (m AppModel) renderConfirmOverwriteView() string {
+	path := ""
+	if space := m.Session.GetActiveSpace(); space != nil {
+		path = space.OutputFilePath
+	}
+	return m.renderDialog(
+		iconWarn+" "+tr("Overwrite File?"),
+		fmt.Sprintf(tr("%s already exists and was not created by PandaBrew. Exporting will replace its contents."), path),
+		tr("y to overwrite • any other key to cancel"),
+	)
+}
+
+func (m AppModel) renderProjectSuggestionView() string {
+	pt := m.ProjectSuggestion
+	selections := make([]string, len(pt.Selections))
+	for i, s := range pt.Selections {
+		selections[i] = filepath.Base(s) + "/"
+	}
+	if len(selections) == 0 {
+		selections = []string{tr("(nothing)")}
+	}
+	body := fmt.Sprintf(tr("Found %s, so this looks like a %s project.\n\nInclude: %s\nExclude: %s\nOutput:  %s\nSelect:  %s"),
+		pt.Manifest, pt.Name,
+		strings.Join(pt.IncludePatterns, ", "),
+		strings.Join(pt.ExcludePatterns, ", "),
+		filepath.Base(pt.OutputPath),
+		strings.Join(selections, ", "))
+	return m.renderDialog(
+		iconInfo+" "+fmt.Sprintf(tr("Apply %s Defaults?"), pt.Name),
+		body,
+		tr("y/enter to apply • e to apply and edit • any other key keeps the defaults"),
+	)
+}
+
+// renderDialog draws a centered modal with a title, a wrapped body and a hint line.
+func (m AppModel) renderDialog(titleText, bodyText, hintText string) string {
+	modalWidth := min(m.Width-10, 64)
+	contentWidth := modalWidth - 4
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(m.Styles.ColorMauve).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		Render(titleText)
+	body := lipgloss.NewStyle().
+		Foreground(m.Styles.ColorText).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		MarginTop(1).
+		Render(bodyText)
+	hints := lipgloss.NewStyle().
+		Foreground(m.Styles.ColorSubtext).
+		Italic(true).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		MarginTop(1).
+		Render(hintText)
+	content := lipgloss.JoinVertical(lipgloss.Left, title, body, hints)
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.Styles.ColorMauve).
+		BorderBackground(m.Styles.ColorBase).
+		Background(m.Styles.ColorBase).
+		Padding(1, 2).
+		Width(modalWidth).
+		Render(content)
+	return lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.Styles.ColorBase),
+		lipgloss.WithWhitespaceChars(" "),
+	)
+}
+
+func (m AppModel) renderTooltipView() string {
+	tip := optionTooltips[m.ActiveTooltip]
+	modalWidth := min(m.Width-10, 64)
+	contentWidth := modalWidth - 4
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(m.Styles.ColorMauve).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		Render(fmt.Sprintf("%s %s (%s)", iconHelp, tip.Title, m.ActiveTooltip))
+	body := lipgloss.NewStyle().
+		Foreground(m.Styles.ColorText).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		MarginTop(1).
+		Render(tip.Body)
+	example := lipgloss.NewStyle().
+		Foreground(m.Styles.ColorSubtext).
+		Background(m.Styles.ColorBase).
+		Italic(true).
+		Width(contentWidth).
+		MarginTop(1).
+		Render("Example: " + tip.Example)
+	hints := lipgloss.NewStyle().
+		Foreground(m.Styles.ColorSubtext).
+		Italic(true).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		MarginTop(1).
+		Render("Press any key to go back")
+	content := lipgloss.JoinVertical(
+		lipgloss.Left,
+		title,
+		body,
+		example,
+		hints,
+	)
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.Styles.ColorMauve).
+		BorderBackground(m.Styles.ColorBase).
+		Background(m.Styles.ColorBase).
+		Padding(1, 2).
+		Width(modalWidth).
+		Render(content)
+	return lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.Styles.ColorBase),
+		lipgloss.WithWhitespaceChars(" "),
+	)
+}
+
 func (m AppModel) renderNewTabView() string {
+	return m.renderInputDialog(iconFolder+" Open New Tab", "Enter the full path to a directory:", m.NewTabInput)
+}
+
+func (m AppModel) renderGroupInputView() string {
+	return m.renderInputDialog(iconFolder+" Move Tab to Group", "Tabs in a group are shown together (G to switch):", m.GroupInput)
+}
+
+func (m AppModel) renderGroupsView() string {
+	rows := []string{fmt.Sprintf("All tabs (%d)", len(m.Session.Spaces))}
+	for _, group := range m.Session.Groups() {
+		count := 0
+		for _, space := range m.Session.Spaces {
+			if space.Group == group {
+				count++
+			}
+		}
+		marker := "  "
+		if group == m.Session.ActiveGroup {
+			marker = "● "
+		}
+		rows = append(rows, fmt.Sprintf("%s%s (%d)", marker, group, count))
+	}
+	return m.renderListDialog(
+		iconFolder+" "+tr("Switch Tab Group"),
+		rows, m.GroupsCursor,
+		"",
+		tr("enter to switch • ctrl+g moves the current tab • Esc to close"),
+	)
+}
+
+func (m AppModel) renderSessionsView() string {
+	var rows []string
+	for _, name := range m.SessionNames {
+		marker := "  "
+		if name == m.Sessions.Name {
+			marker = "● "
+		}
+		rows = append(rows, marker+name)
+	}
+	rows = append(rows, tr("+ New session..."))
+	return m.renderListDialog(
+		iconFolder+" "+tr("Switch Session"),
+		rows, m.SessionsCursor,
+		"",
+		tr("enter to switch • Esc to close"),
+	)
+}
+
+// renderInputDialog draws a centered modal around a single text input.
+func (m AppModel) renderInputDialog(titleText, descText string, input textinput.Model) string {
 	modalWidth := min(m.Width-10, 60)
 	contentWidth := modalWidth - 4
 	title := lipgloss.NewStyle().
@@ -666,14 +1110,14 @@ func (m AppModel) renderNewTabView() string {
 		Background(m.Styles.ColorBase).
 		Width(contentWidth).
 		Align(lipgloss.Center).
-		Render(iconFolder + " Open New Tab")
+		Render(titleText)
 	description := lipgloss.NewStyle().
 		Foreground(m.Styles.ColorSubtext).
 		Background(m.Styles.ColorBase).
 		Width(contentWidth).
 		Align(lipgloss.Center).
 		MarginTop(1).
-		Render("Enter the full path to a directory:")
+		Render(descText)
 	inputBox := lipgloss.NewStyle().
 		Border(lipgloss.RoundedBorder()).
 		BorderForeground(m.Styles.ColorMauve).
@@ -682,7 +1126,7 @@ func (m AppModel) renderNewTabView() string {
 		Padding(0, 1).
 		Width(contentWidth - 2).
 		MarginTop(1).
-		Render(m.NewTabInput.View())
+		Render(input.View())
 	hints := lipgloss.NewStyle().
 		Foreground(m.Styles.ColorSubtext).
 		Italic(true).
@@ -714,3 +1158,329 @@ func (m AppModel) renderNewTabView() string {
 		lipgloss.WithWhitespaceChars(" "),
 	)
 }
+
+func (m AppModel) renderMessageLogView() string {
+	modalWidth := min(m.Width-10, 90)
+	modalHeight := min(m.Height-6, 24)
+	contentWidth := modalWidth - 4
+	listHeight := max(1, modalHeight-8)
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(m.Styles.ColorMauve).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		Render(iconKeyboard + " Message Log")
+
+	var rows []string
+	if len(m.MessageLog) == 0 {
+		rows = append(rows, lipgloss.NewStyle().
+			Foreground(m.Styles.ColorSubtext).
+			Background(m.Styles.ColorBase).
+			Render("No messages yet."))
+	} else {
+		// Newest entries at the bottom; the offset scrolls back in time.
+		end := len(m.MessageLog) - m.MessageLogOffset
+		start := max(0, end-listHeight)
+		for _, entry := range m.MessageLog[start:end] {
+			stamp := lipgloss.NewStyle().
+				Foreground(m.Styles.ColorSubtext).
+				Background(m.Styles.ColorBase).
+				Render(entry.Time.Format("15:04:05") + "  ")
+			textStyle := lipgloss.NewStyle().
+				Foreground(m.severityColor(entry.Severity, m.Styles.ColorText)).
+				Background(m.Styles.ColorBase)
+			text := textStyle.
+				Width(max(0, contentWidth-lipgloss.Width(stamp))).
+				MaxHeight(1).
+				Render(entry.Text)
+			rows = append(rows, lipgloss.JoinHorizontal(lipgloss.Top, stamp, text))
+		}
+	}
+
+	list := lipgloss.NewStyle().
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Height(listHeight).
+		MarginTop(1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, rows...))
+
+	hints := lipgloss.NewStyle().
+		Foreground(m.Styles.ColorSubtext).
+		Italic(true).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		MarginTop(1).
+		Render(fmt.Sprintf("%d messages • ↑/↓ to scroll • Esc to close", len(m.MessageLog)))
+
+	content := lipgloss.JoinVertical(lipgloss.Left, title, list, hints)
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.Styles.ColorMauve).
+		BorderBackground(m.Styles.ColorBase).
+		Background(m.Styles.ColorBase).
+		Padding(1, 2).
+		Width(modalWidth).
+		Render(content)
+	return lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.Styles.ColorBase),
+		lipgloss.WithWhitespaceChars(" "),
+	)
+}
+
+func (m AppModel) renderPreviewView() string {
+	contentWidth := m.Viewport.Width
+	space := m.Session.GetActiveSpace()
+	name := m.PreviewPath
+	if space != nil {
+		if rel, err := filepath.Rel(space.RootPath, m.PreviewPath); err == nil {
+			name = filepath.ToSlash(rel)
+		}
+	}
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(m.Styles.ColorMauve).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		Render(iconFile + " " + name)
+
+	body := lipgloss.NewStyle().
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Height(m.Viewport.Height).
+		MarginTop(1).
+		Render(m.Viewport.View())
+
+	var footer string
+	if m.PreviewSearching {
+		label := lipgloss.NewStyle().
+			Foreground(m.Styles.ColorInk).
+			Background(m.Styles.ColorYellow).
+			Bold(true).
+			Padding(0, 1).
+			Render("SEARCH /")
+		footer = lipgloss.JoinHorizontal(lipgloss.Top, label, " ", m.PreviewSearchInput.View())
+	} else {
+		hint := "/ search • ↑/↓ scroll • Esc to close"
+		if m.PreviewQuery != "" {
+			pos := 0
+			if len(m.PreviewMatches) > 0 {
+				pos = m.PreviewMatchPtr + 1
+			}
+			hint = fmt.Sprintf("%q %d/%d • n/N next/prev • ", m.PreviewQuery, pos, len(m.PreviewMatches)) + hint
+		}
+		footer = lipgloss.NewStyle().
+			Foreground(m.Styles.ColorSubtext).
+			Italic(true).
+			Background(m.Styles.ColorBase).
+			Width(contentWidth).
+			Align(lipgloss.Center).
+			Render(hint)
+	}
+	footer = lipgloss.NewStyle().Background(m.Styles.ColorBase).MarginTop(1).Render(footer)
+
+	content := lipgloss.JoinVertical(lipgloss.Left, title, body, footer)
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.Styles.ColorMauve).
+		BorderBackground(m.Styles.ColorBase).
+		Background(m.Styles.ColorBase).
+		Padding(1, 2).
+		Width(contentWidth + 4).
+		Render(content)
+	return lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.Styles.ColorBase),
+		lipgloss.WithWhitespaceChars(" "),
+	)
+}
+
+func (m AppModel) renderOffendersView() string {
+	var rows []string
+	total := 0
+	for _, f := range m.Offenders {
+		rows = append(rows, fmt.Sprintf("%8s  %s", "~"+core.FormatTokens(f.Tokens), f.Path))
+		total += f.Tokens
+	}
+	return m.renderListDialog(
+		iconWarn+" Largest Selected Files",
+		rows, m.OffendersCursor,
+		"Nothing selected.",
+		fmt.Sprintf("top %d: ~%s tokens • space/d to deselect • Esc to close", len(m.Offenders), core.FormatTokens(total)),
+	)
+}
+
+func (m AppModel) renderCompareView() string {
+	if m.CompareTargetID == "" {
+		var rows []string
+		for _, space := range m.otherSpaces() {
+			rows = append(rows, fmt.Sprintf("%-20s %s", filepath.Base(space.RootPath), space.RootPath))
+		}
+		return m.renderListDialog(
+			iconInfo+" Compare Selections With...",
+			rows, m.CompareCursor,
+			"No other tabs open.",
+			"enter to compare • Esc to close",
+		)
+	}
+
+	active := m.Session.GetActiveSpace()
+	target := m.spaceByID(m.CompareTargetID)
+	if active == nil || target == nil {
+		return m.renderListDialog(iconWarn+" Compare Selections", nil, 0, "That tab is no longer open.", "Esc to close")
+	}
+	diff := core.DiffSelections(active, target)
+	return m.renderListDialog(
+		fmt.Sprintf("%s Selections: %s → %s", iconInfo, filepath.Base(active.RootPath), filepath.Base(target.RootPath)),
+		m.compareRows(), m.CompareCursor,
+		"Both tabs select the same paths.",
+		fmt.Sprintf("+%d added • -%d removed • %d shared • Esc to close", len(diff.Added), len(diff.Removed), diff.Common),
+	)
+}
+
+// renderListDialog draws a centered modal with a scrollable, cursor-driven
+// list. emptyText is shown when rows is empty.
+func (m AppModel) renderListDialog(titleText string, rows []string, cursor int, emptyText, hintText string) string {
+	modalWidth := min(m.Width-10, 90)
+	modalHeight := min(m.Height-6, 28)
+	contentWidth := modalWidth - 4
+	listHeight := max(1, modalHeight-8)
+
+	title := lipgloss.NewStyle().
+		Bold(true).
+		Foreground(m.Styles.ColorMauve).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		Render(titleText)
+
+	var lines []string
+	if len(rows) == 0 {
+		lines = append(lines, lipgloss.NewStyle().
+			Foreground(m.Styles.ColorSubtext).
+			Background(m.Styles.ColorBase).
+			Render(emptyText))
+	} else {
+		// Keep the cursor in view
+		start := max(0, min(cursor-listHeight/2, len(rows)-listHeight))
+		end := min(len(rows), start+listHeight)
+		for i := start; i < end; i++ {
+			style := lipgloss.NewStyle().
+				Foreground(m.Styles.ColorText).
+				Background(m.Styles.ColorBase).
+				Width(contentWidth).
+				MaxHeight(1)
+			if i == cursor {
+				style = style.Foreground(m.Styles.ColorMauve).Background(m.Styles.ColorSurface).Bold(true)
+			}
+			lines = append(lines, style.Render(rows[i]))
+		}
+	}
+
+	list := lipgloss.NewStyle().
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Height(listHeight).
+		MarginTop(1).
+		Render(lipgloss.JoinVertical(lipgloss.Left, lines...))
+
+	hints := lipgloss.NewStyle().
+		Foreground(m.Styles.ColorSubtext).
+		Italic(true).
+		Background(m.Styles.ColorBase).
+		Width(contentWidth).
+		Align(lipgloss.Center).
+		MarginTop(1).
+		Render(hintText)
+
+	content := lipgloss.JoinVertical(lipgloss.Left, title, list, hints)
+	box := lipgloss.NewStyle().
+		Border(lipgloss.RoundedBorder()).
+		BorderForeground(m.Styles.ColorMauve).
+		BorderBackground(m.Styles.ColorBase).
+		Background(m.Styles.ColorBase).
+		Padding(1, 2).
+		Width(modalWidth).
+		Render(content)
+	return lipgloss.Place(
+		m.Width, m.Height,
+		lipgloss.Center, lipgloss.Center,
+		box,
+		lipgloss.WithWhitespaceBackground(m.Styles.ColorBase),
+		lipgloss.WithWhitespaceChars(" "),
+	)
+}
+
+// severityColor maps a severity to its accent, using fallback for plain info.
+func (m AppModel) severityColor(s Severity, fallback lipgloss.Color) lipgloss.Color {
+	switch s {
+	case SeverityError:
+		return m.Styles.ColorRed
+	case SeverityWarn:
+		return m.Styles.ColorPeach
+	default:
+		return fallback
+	}
+}
+
+// renderBanner renders a one-line warning across the full width.
+func (m AppModel) renderBanner(text string) string {
+	return lipgloss.NewStyle().
+		Foreground(m.Styles.ColorInk).
+		Background(m.Styles.ColorPeach).
+		Bold(true).
+		Padding(0, 1).
+		Width(m.Width).
+		MaxWidth(m.Width).
+		MaxHeight(1).
+		Render(iconWarn + " " + text)
+}
+
+// overlayToasts draws the toast stack over the bottom-right corner of body,
+// newest toast at the bottom, without changing the layout height.
+func (m AppModel) overlayToasts(body string) string {
+	if len(m.Toasts) == 0 {
+		return body
+	}
+	lines := strings.Split(body, "\n")
+	maxWidth := max(10, min(60, m.Width/2))
+
+	for i, toast := range m.Toasts {
+		row := len(lines) - len(m.Toasts) + i - 1 // Keep one line of margin above the footer
+		if row < 0 {
+			continue
+		}
+
+		icon := iconInfo
+		switch toast.Severity {
+		case SeverityWarn:
+			icon = iconWarn
+		case SeverityError:
+			icon = iconError
+		}
+		rendered := lipgloss.NewStyle().
+			Foreground(m.Styles.ColorInk).
+			Background(m.severityColor(toast.Severity, m.Styles.ColorBlue)).
+			Bold(toast.Severity == SeverityError).
+			Padding(0, 1).
+			Render(ansi.Truncate(icon+" "+toast.Text, maxWidth-2, "…"))
+
+		toastWidth := lipgloss.Width(rendered)
+		left := ansi.Truncate(lines[row], max(0, m.Width-toastWidth-1), "")
+		gap := max(0, m.Width-toastWidth-1-lipgloss.Width(left))
+		lines[row] = left +
+			lipgloss.NewStyle().Background(m.Styles.ColorBase).Render(strings.Repeat(" ", gap)) +
+			rendered +
+			lipgloss.NewStyle().Background(m.Styles.ColorBase).Render(" ")
+	}
+	return strings.Join(lines, "\n")
+}
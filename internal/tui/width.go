@@ -0,0 +1,51 @@
+// Package tui implements measuring and cutting text by terminal cells.
+package tui
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// maxTabNameCells is the widest a project or branch name gets in a tab.
+const maxTabNameCells = 24
+
+// cellWidth is how many terminal cells the plain text s takes: two for wide
+// CJK characters and most emoji, none for combining marks.
+func cellWidth(s string) int {
+	return runewidth.StringWidth(s)
+}
+
+// truncateCells cuts the plain text s to at most w cells, ending it with an
+// ellipsis when anything was cut. Wide characters are never split.
+func truncateCells(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	return runewidth.Truncate(s, w, "…")
+}
+
+// foldIndex finds substr in s ignoring case and returns its byte range in s,
+// or -1, -1. Unlike indexing a lower-cased copy, the range always falls on
+// rune boundaries of s, even where case folding changes the byte length.
+func foldIndex(s, substr string) (start, end int) {
+	if substr == "" {
+		return -1, -1
+	}
+	for i := range s {
+		j, k := i, 0
+		for k < len(substr) && j < len(s) {
+			r1, n1 := utf8.DecodeRuneInString(s[j:])
+			r2, n2 := utf8.DecodeRuneInString(substr[k:])
+			if !strings.EqualFold(string(r1), string(r2)) {
+				break
+			}
+			j, k = j+n1, k+n2
+		}
+		if k == len(substr) {
+			return i, j
+		}
+	}
+	return -1, -1
+}
@@ -0,0 +1,53 @@
+package tui
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"pandabrew/internal/core"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+func TestWideFileNames(t *testing.T) {
+	if start, end := foldIndex("ÀÉ名前.go", "é名"); start != 2 || end != 7 {
+		t.Errorf("foldIndex = %d, %d, want 2, 7", start, end)
+	}
+	if got := truncateCells("設定ファイル.go", 7); got != "設定フ…" {
+		t.Errorf("truncateCells = %q", got)
+	}
+
+	root := t.TempDir()
+	space := &core.DirectorySpace{ID: "a", RootPath: filepath.Join(root, "プロジェクト🐼とても長い名前のディレクトリ")}
+	m := InitialModel(&core.Session{Spaces: []*core.DirectorySpace{space}, ActiveSpaceID: "a"}, nil)
+	state := m.TabStates["a"]
+	for _, name := range []string{"短い.go", "とても長いファイル名がここに続いていて行に収まらない名前.go", "emoji 🐼☕🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉🎉.md"} {
+		state.VisibleNodes = append(state.VisibleNodes, &TreeNode{Name: name, FullPath: filepath.Join(space.RootPath, name)})
+	}
+	state.SearchQuery = "ファイル"
+	updated, _ := m.Update(tea.WindowSizeMsg{Width: 64, Height: 20})
+	m = updated.(AppModel)
+
+	// Nothing wraps: one row for the tabs, one per node, one for the footer
+	lines := strings.Split(ansi.Strip(m.View()), "\n")
+	if len(lines) != 20 {
+		t.Fatalf("view is %d rows, want 20", len(lines))
+	}
+	if !strings.Contains(lines[0], "PandaBrew") || !strings.Contains(lines[0], "プロジェクト") || strings.Contains(lines[1], "Switch") {
+		t.Errorf("tab bar wrapped:\n%s\n%s", lines[0], lines[1])
+	}
+	if !strings.Contains(lines[len(lines)-1], "Ready") {
+		t.Errorf("footer wrapped: %q", lines[len(lines)-1])
+	}
+	for _, line := range lines {
+		if lipgloss.Width(line) != 64 {
+			t.Errorf("row is %d cells, want 64: %q", lipgloss.Width(line), line)
+		}
+		if strings.Contains(line, "とても長いファイル") && (!strings.Contains(line, iconSquare) || !strings.Contains(line, "…")) {
+			t.Errorf("long name not cut within its row: %q", line)
+		}
+	}
+}